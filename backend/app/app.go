@@ -0,0 +1,462 @@
+// Package app wires the repositories, use cases and controllers together.
+// It is shared by the server binary and the bugtracker admin CLI.
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"bug-tracker/authz"
+	"bug-tracker/config"
+	"bug-tracker/controller"
+	"bug-tracker/health"
+	"bug-tracker/logging"
+	"bug-tracker/metrics"
+	"bug-tracker/oidc"
+	"bug-tracker/repository"
+	"bug-tracker/repository/instrument"
+	"bug-tracker/repository/memory"
+	"bug-tracker/repository/mongodb"
+	"bug-tracker/repository/postgres"
+	"bug-tracker/repository/sqlite"
+	"bug-tracker/router"
+	"bug-tracker/signing"
+	"bug-tracker/tracing"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+)
+
+// bugMetricsInterval is how often the bug count gauges are refreshed
+const bugMetricsInterval = time.Minute
+
+// signingKeyRefreshInterval is how often signing keys are rotated and
+// reloaded, so keys other servers create are known before they sign
+const signingKeyRefreshInterval = time.Minute
+
+// rolesRefreshInterval is how often roles are reloaded, so role changes
+// made on other servers take effect here
+const rolesRefreshInterval = 30 * time.Second
+
+// Connect connects to MongoDB and verifies the connection with a ping
+func Connect(ctx context.Context, cfg *config.Config) (*mongo.Client, error) {
+	// Create client options with proper settings for Atlas
+	clientOptions := options.Client().ApplyURI(cfg.MongoURI).
+		SetServerSelectionTimeout(5 * time.Second).
+		SetConnectTimeout(10 * time.Second).
+		SetMonitor(otelmongo.NewMonitor())
+
+	client, err := mongo.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(ctx)
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// Repositories are the storage backends the API is built on
+type Repositories struct {
+	Users   repository.UserRepositoryInterface
+	Bugs    repository.BugRepositoryInterface
+	Reports repository.ReportRepositoryInterface
+	Tokens  repository.APITokenRepositoryInterface
+	Roles   repository.RoleRepositoryInterface
+	// CustomFields are the custom field definitions of bugs
+	CustomFields repository.CustomFieldRepositoryInterface
+	// Templates are the templates bugs are reported from
+	Templates repository.BugTemplateRepositoryInterface
+	// Milestones are the releases bugs are found in and fixed in
+	Milestones repository.MilestoneRepositoryInterface
+	// SigningKeys are the keys of the key set Serve builds
+	SigningKeys repository.SigningKeyRepositoryInterface
+}
+
+// MongoRepositories returns repositories backed by db
+func MongoRepositories(db *mongo.Database) Repositories {
+	return Repositories{
+		Users:   mongodb.NewUserRepository(db),
+		Bugs:    mongodb.NewBugRepository(db),
+		Reports: mongodb.NewReportRepository(db),
+		Tokens:  mongodb.NewAPITokenRepository(db),
+		Roles:   mongodb.NewRoleRepository(db),
+
+		CustomFields: mongodb.NewCustomFieldRepository(db),
+		Templates:    mongodb.NewBugTemplateRepository(db),
+		Milestones:   mongodb.NewMilestoneRepository(db),
+		SigningKeys:  mongodb.NewSigningKeyRepository(db),
+	}
+}
+
+// PostgresRepositories returns repositories backed by db
+func PostgresRepositories(db *sql.DB) Repositories {
+	return Repositories{
+		Users:   postgres.NewUserRepository(db),
+		Bugs:    postgres.NewBugRepository(db),
+		Reports: postgres.NewReportRepository(db),
+		Tokens:  postgres.NewAPITokenRepository(db),
+		Roles:   postgres.NewRoleRepository(db),
+
+		CustomFields: postgres.NewCustomFieldRepository(db),
+		Templates:    postgres.NewBugTemplateRepository(db),
+		Milestones:   postgres.NewMilestoneRepository(db),
+		SigningKeys:  postgres.NewSigningKeyRepository(db),
+	}
+}
+
+// SQLiteRepositories returns repositories backed by db
+func SQLiteRepositories(db *sql.DB) Repositories {
+	return Repositories{
+		Users:   sqlite.NewUserRepository(db),
+		Bugs:    sqlite.NewBugRepository(db),
+		Reports: sqlite.NewReportRepository(db),
+		Tokens:  sqlite.NewAPITokenRepository(db),
+		Roles:   sqlite.NewRoleRepository(db),
+
+		CustomFields: sqlite.NewCustomFieldRepository(db),
+		Templates:    sqlite.NewBugTemplateRepository(db),
+		Milestones:   sqlite.NewMilestoneRepository(db),
+		SigningKeys:  sqlite.NewSigningKeyRepository(db),
+	}
+}
+
+// MemoryRepositories returns empty in-memory repositories
+func MemoryRepositories() Repositories {
+	bugs := memory.NewBugRepository()
+	return Repositories{
+		Users:   memory.NewUserRepository(),
+		Bugs:    bugs,
+		Reports: memory.NewReportRepository(bugs),
+		Tokens:  memory.NewAPITokenRepository(),
+		Roles:   memory.NewRoleRepository(),
+
+		CustomFields: memory.NewCustomFieldRepository(),
+		Templates:    memory.NewBugTemplateRepository(),
+		Milestones:   memory.NewMilestoneRepository(),
+		SigningKeys:  memory.NewSigningKeyRepository(),
+	}
+}
+
+// Instrumented returns repositories that report every call to hook
+func (r Repositories) Instrumented(hook instrument.Hook) Repositories {
+	return Repositories{
+		Users:   instrument.Users(r.Users, hook),
+		Bugs:    instrument.Bugs(r.Bugs, hook),
+		Reports: instrument.Reports(r.Reports, hook),
+		Tokens:  instrument.APITokens(r.Tokens, hook),
+		Roles:   instrument.Roles(r.Roles, hook),
+
+		CustomFields: instrument.CustomFields(r.CustomFields, hook),
+		Templates:    instrument.BugTemplates(r.Templates, hook),
+		Milestones:   instrument.Milestones(r.Milestones, hook),
+		SigningKeys:  instrument.SigningKeys(r.SigningKeys, hook),
+	}
+}
+
+// NewHandler builds the HTTP handler for the API backed by repos, signing
+// login tokens with keys and granting permissions by roles. Storage calls
+// are traced, metrics are served and recorded when m is not nil, and
+// checker answers the readiness probe.
+func NewHandler(cfg *config.Config, repos Repositories, keys *signing.KeySet, roles *authz.Roles, m *metrics.Metrics, checker *health.Checker) *gin.Engine {
+	hook := tracing.StorageHook(cfg.Storage)
+	if m != nil {
+		// Tracing first, so the span covers the time metrics record
+		hook = instrument.Chain(hook, m.StorageHook(cfg.Storage))
+	}
+	repos = repos.Instrumented(hook)
+
+	// Initialize use cases
+	var authUseCase usecase.AuthUseCaseInterface = NewAuthUseCase(cfg, repos.Users, roles, keys)
+	if m != nil {
+		authUseCase = m.AuthUseCase(authUseCase)
+	}
+	bugUseCase := usecase.NewBugUseCase(repos.Bugs, repos.Users, repos.CustomFields, repos.Templates, repos.Milestones, roles)
+	reportUseCase := usecase.NewReportUseCase(repos.Reports, repos.Users)
+	importUseCase := usecase.NewImportUseCase(repos.Bugs, repos.Users, roles)
+	tokenUseCase := usecase.NewTokenUseCase(repos.Tokens, repos.Users, roles)
+	roleUseCase := usecase.NewRoleUseCase(repos.Roles, repos.Users, roles)
+	fieldUseCase := usecase.NewCustomFieldUseCase(repos.CustomFields, repos.Bugs)
+	templateUseCase := usecase.NewBugTemplateUseCase(repos.Templates)
+	milestoneUseCase := usecase.NewMilestoneUseCase(repos.Milestones, repos.Bugs)
+
+	// Initialize controllers
+	authController := controller.NewAuthController(authUseCase, roles)
+	bugController := controller.NewBugController(bugUseCase, roles)
+	reportController := controller.NewReportController(reportUseCase, roles)
+	importController := controller.NewImportController(importUseCase, cfg.MaxImportSize, roles)
+	healthController := controller.NewHealthController(checker)
+	tokenController := controller.NewTokenController(tokenUseCase)
+	roleController := controller.NewRoleController(roleUseCase, roles)
+	fieldController := controller.NewCustomFieldController(fieldUseCase, roles)
+	templateController := controller.NewBugTemplateController(templateUseCase, roles)
+	milestoneController := controller.NewMilestoneController(milestoneUseCase, roles)
+
+	// Initialize router
+	r := router.NewRouter(authController, bugController, reportController, importController, healthController, tokenController, roleController, fieldController, templateController, milestoneController, authUseCase, tokenUseCase, router.Options{
+		Metrics:        m,
+		MetricsToken:   cfg.MetricsToken,
+		CORSOrigins:    cfg.CORSOrigins,
+		TrustedProxies: cfg.TrustedProxies,
+		RateLimit:      cfg.RateLimit,
+		AuthRateLimit:  cfg.AuthRateLimit,
+	})
+	return r.Setup()
+}
+
+// NewKeySet creates the key set that signs login tokens, or returns nil
+// when they are signed with the JWT secret. Refresh it before use.
+func NewKeySet(cfg *config.Config, repo repository.SigningKeyRepositoryInterface) *signing.KeySet {
+	if cfg.JWTAlgorithm == "HS256" {
+		return nil
+	}
+	return signing.New(repo, signing.Options{
+		Algorithm:        cfg.JWTAlgorithm,
+		RotationInterval: cfg.JWTKeyRotation,
+		PublishDelay:     cfg.JWTKeyPublishDelay,
+		// Keys must outlive two-factor challenges as well as logins
+		TokenTTL: max(cfg.TokenTTL, usecase.ChallengeTTL),
+	})
+}
+
+// NewAuthUseCase creates the auth use case with the token, lockout,
+// two-factor and single sign-on settings in cfg. keys signs login tokens;
+// without it they are signed with the JWT secret.
+func NewAuthUseCase(cfg *config.Config, users repository.UserRepositoryInterface, roles *authz.Roles, keys *signing.KeySet) *usecase.AuthUseCase {
+	lockout := usecase.LockoutPolicy{
+		Threshold:   cfg.LockoutThreshold,
+		Duration:    cfg.LockoutDuration,
+		MaxDuration: cfg.LockoutMaxDuration,
+	}
+	twoFactor := usecase.TwoFactorPolicy{
+		Issuer:        cfg.TwoFactorIssuer,
+		RequiredRoles: cfg.TwoFactorRequiredRoles,
+	}
+	var sso usecase.SSOPolicy
+	if cfg.OIDCIssuer != "" {
+		sso = usecase.SSOPolicy{
+			Provider: oidc.NewProvider(oidc.Config{
+				Issuer:       cfg.OIDCIssuer,
+				ClientID:     cfg.OIDCClientID,
+				ClientSecret: cfg.OIDCClientSecret,
+				RedirectURL:  cfg.OIDCRedirectURL,
+				Scopes:       cfg.OIDCScopes,
+			}),
+			RoleClaim:   cfg.OIDCRoleClaim,
+			Roles:       cfg.OIDCRoles(),
+			DefaultRole: cfg.OIDCDefaultRole,
+		}
+		if sso.DefaultRole == "none" {
+			sso.DefaultRole = ""
+		}
+	}
+	return usecase.NewAuthUseCase(users, roles, cfg.JWTSecret, keys, cfg.TokenTTL, cfg.AuthCacheTTL, lockout, twoFactor, sso)
+}
+
+// checkRoles fails when settings name roles that don't exist. Config only
+// checks role names are well-formed, since roles are kept in storage.
+func checkRoles(cfg *config.Config, roles *authz.Roles) error {
+	var errs []error
+	check := func(env, role string) {
+		if !roles.Exists(role) {
+			errs = append(errs, fmt.Errorf("%s: role %q does not exist", env, role))
+		}
+	}
+
+	for _, role := range cfg.TwoFactorRequiredRoles {
+		check("TWO_FACTOR_REQUIRED_ROLES", role)
+	}
+	if cfg.OIDCIssuer != "" {
+		for _, pair := range cfg.OIDCRoleMapping {
+			_, role, _ := strings.Cut(pair, "=")
+			check("OIDC_ROLE_MAPPING", strings.TrimSpace(role))
+		}
+		if cfg.OIDCDefaultRole != "none" {
+			check("OIDC_DEFAULT_ROLE", cfg.OIDCDefaultRole)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Serve connects to the configured storage and serves the API until it
+// fails or receives SIGINT or SIGTERM
+func Serve(cfg *config.Config) error {
+	level, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return err
+	}
+	// Also routes the standard log package through the JSON handler
+	slog.SetDefault(logging.New(os.Stdout, level))
+
+	gin.SetMode(cfg.Mode)
+	if cfg.JWTSecret == config.DefaultJWTSecret {
+		slog.Warn("using the default JWT secret; set JWT_SECRET before deploying")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.TracesExporter)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Warn("failed to flush traces", "error", err)
+		}
+	}()
+
+	storage, err := Open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	switch cfg.Storage {
+	case "memory":
+		slog.Warn("using in-memory storage; data is lost when the server stops")
+	case "mongo":
+		slog.Info("connected to MongoDB", "database", cfg.DBName)
+	case "postgres":
+		slog.Info("connected to PostgreSQL")
+	case "sqlite":
+		slog.Info("opened SQLite database", "path", cfg.SQLitePath)
+	}
+
+	if err := storage.Migrate(ctx, cfg.AutoMigrate); err != nil {
+		return err
+	}
+
+	checker := health.New()
+	storage.AddHealthChecks(checker)
+
+	// Background workers are stopped during shutdown, before storage closes
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+
+	keys := NewKeySet(cfg, storage.SigningKeys)
+	if keys != nil {
+		if err := keys.Refresh(ctx); err != nil {
+			return fmt.Errorf("failed to load signing keys: %w", err)
+		}
+		worker := checker.Worker("signing-keys")
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			defer worker.Done()
+			keys.Run(workerCtx, signingKeyRefreshInterval)
+		}()
+	}
+
+	roles := authz.New(storage.Roles)
+	if err := roles.Refresh(ctx); err != nil {
+		return fmt.Errorf("failed to load roles: %w", err)
+	}
+	if err := checkRoles(cfg, roles); err != nil {
+		return err
+	}
+	rolesWorker := checker.Worker("roles")
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		defer rolesWorker.Done()
+		roles.Run(workerCtx, rolesRefreshInterval)
+	}()
+
+	var m *metrics.Metrics
+	if cfg.Metrics {
+		m = metrics.New()
+		worker := checker.Worker("bug-metrics")
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			defer worker.Done()
+			m.RefreshBugCounts(workerCtx, storage.Bugs, bugMetricsInterval)
+		}()
+	}
+
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           NewHandler(cfg, storage.Repositories, keys, roles, m, checker),
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("server starting", "port", cfg.Port)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-serveErr:
+		return err
+	case sig := <-signals:
+		slog.Info("draining before shutdown", "signal", sig.String(), "delay", cfg.DrainDelay.String())
+	}
+
+	checker.Drain()
+	// Keep serving while load balancers notice readiness failing; a second
+	// signal skips the wait
+	select {
+	case <-time.After(cfg.DrainDelay):
+	case <-signals:
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancelShutdown()
+	return shutdown(shutdownCtx, server, stopWorkers, &workers)
+}
+
+// shutdown stops accepting connections, waits for in-flight requests and
+// then for background workers, until ctx expires. Requests still running
+// at the deadline are cut off.
+func shutdown(ctx context.Context, server *http.Server, stopWorkers func(), workers *sync.WaitGroup) error {
+	slog.Info("shutting down")
+
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("in-flight requests did not finish in time", "error", err)
+		_ = server.Close()
+	}
+
+	stopWorkers()
+	stopped := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		slog.Warn("background workers did not stop in time")
+	}
+
+	slog.Info("server stopped")
+	return nil
+}
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"bug-tracker/authz"
+	"bug-tracker/config"
+	"bug-tracker/health"
+	"bug-tracker/models"
+	"bug-tracker/repository/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdownWaitsForInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = io.WriteString(w, "done")
+	})}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+	<-started
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workerStopped := false
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		<-workerCtx.Done()
+		workerStopped = true
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, shutdown(ctx, server, stopWorkers, &workers))
+
+	assert.Equal(t, "done", <-body)
+	assert.True(t, workerStopped)
+
+	_, err = http.Get("http://" + listener.Addr().String())
+	assert.Error(t, err, "server should stop accepting connections")
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+	go func() {
+		if resp, err := http.Get("http://" + listener.Addr().String()); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	// A worker that ignores cancellation must not block shutdown either
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		<-release
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	require.NoError(t, shutdown(ctx, server, func() {}, &workers))
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestCheckRoles(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRoleRepository()
+	require.NoError(t, repo.Save(ctx, &models.Role{Name: "triage", Permissions: []string{models.PermBugView}}))
+	roles := authz.New(repo)
+	require.NoError(t, roles.Refresh(ctx))
+
+	cfg := &config.Config{
+		TwoFactorRequiredRoles: []string{"admin", "triage"},
+		OIDCIssuer:             "https://login.example.com",
+		OIDCRoleMapping:        []string{"bt-admins=admin", "qa = triage"},
+		OIDCDefaultRole:        "none",
+	}
+	assert.NoError(t, checkRoles(cfg, roles))
+
+	cfg.TwoFactorRequiredRoles = []string{"owner"}
+	cfg.OIDCRoleMapping = []string{"qa=testers"}
+	cfg.OIDCDefaultRole = "guest"
+	err := checkRoles(cfg, roles)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `TWO_FACTOR_REQUIRED_ROLES: role "owner" does not exist`)
+	assert.Contains(t, err.Error(), `OIDC_ROLE_MAPPING: role "testers" does not exist`)
+	assert.Contains(t, err.Error(), `OIDC_DEFAULT_ROLE: role "guest" does not exist`)
+
+	// Single sign-on settings are ignored while it is off
+	cfg.TwoFactorRequiredRoles = nil
+	cfg.OIDCIssuer = ""
+	assert.NoError(t, checkRoles(cfg, roles))
+}
+
+// countingMigrator has pending migrations until Up runs, counting how often
+// Pending is asked
+type countingMigrator struct {
+	pending      []Migration
+	pendingCalls int
+}
+
+func (m *countingMigrator) Applied(context.Context) ([]Migration, error) {
+	return nil, nil
+}
+
+func (m *countingMigrator) Pending(context.Context) ([]Migration, error) {
+	m.pendingCalls++
+	return m.pending, nil
+}
+
+func (m *countingMigrator) Up(context.Context) ([]Migration, error) {
+	applied := m.pending
+	m.pending = nil
+	return applied, nil
+}
+
+func TestMigrationsCheck(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stops probing once migrated at startup", func(t *testing.T) {
+		migrator := &countingMigrator{pending: []Migration{{Version: 1, Name: "init"}}}
+		storage := &Storage{Migrator: migrator}
+		require.NoError(t, storage.Migrate(ctx, true))
+
+		checker := health.New()
+		storage.AddHealthChecks(checker)
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, health.StatusOK, checker.Ready(ctx).Status)
+		}
+		assert.Zero(t, migrator.pendingCalls)
+	})
+
+	t.Run("probes until migrated elsewhere", func(t *testing.T) {
+		migrator := &countingMigrator{pending: []Migration{{Version: 1, Name: "init"}}}
+		storage := &Storage{Migrator: migrator}
+		require.NoError(t, storage.Migrate(ctx, false))
+
+		checker := health.New()
+		storage.AddHealthChecks(checker)
+		assert.Equal(t, health.StatusFailing, checker.Ready(ctx).Status)
+
+		_, err := migrator.Up(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, health.StatusOK, checker.Ready(ctx).Status)
+		assert.Equal(t, health.StatusOK, checker.Ready(ctx).Status)
+		assert.Equal(t, 3, migrator.pendingCalls)
+	})
+}
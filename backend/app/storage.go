@@ -0,0 +1,260 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"bug-tracker/config"
+	"bug-tracker/health"
+	"bug-tracker/migrations"
+	"bug-tracker/repository/postgres"
+	"bug-tracker/repository/sqlite"
+	"bug-tracker/repository/sqlutil"
+)
+
+// ErrMigrationsLocked is returned by Migrator.Up when another process is
+// applying migrations
+var ErrMigrationsLocked = errors.New("migrations are being applied by another process")
+
+// Migration describes a schema migration of any backend. AppliedAt is zero
+// for pending migrations.
+type Migration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migrator manages the schema of a storage backend
+type Migrator interface {
+	Applied(ctx context.Context) ([]Migration, error)
+	Pending(ctx context.Context) ([]Migration, error)
+	Up(ctx context.Context) ([]Migration, error)
+}
+
+// disconnectTimeout bounds closing MongoDB connections so a dead cluster
+// can't hang shutdown
+const disconnectTimeout = 10 * time.Second
+
+// Storage is an open storage backend
+type Storage struct {
+	Repositories
+	// Migrator is nil for backends without a schema
+	Migrator Migrator
+	// migrated is set once the schema is known to be up to date, which it
+	// then stays for the life of the process
+	migrated atomic.Bool
+	ping     func(ctx context.Context) error
+	close    func()
+}
+
+// Ping checks that the backend is reachable
+func (s *Storage) Ping(ctx context.Context) error {
+	if s.ping == nil {
+		return nil
+	}
+	return s.ping(ctx)
+}
+
+// Close releases the backend's connections
+func (s *Storage) Close() {
+	if s.close != nil {
+		s.close()
+	}
+}
+
+// Open connects to the storage backend selected by cfg.Storage
+func Open(ctx context.Context, cfg *config.Config) (*Storage, error) {
+	switch cfg.Storage {
+	case "memory":
+		return &Storage{Repositories: MemoryRepositories()}, nil
+	case "mongo":
+		client, err := Connect(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+		}
+		db := client.Database(cfg.DBName)
+		return &Storage{
+			Repositories: MongoRepositories(db),
+			Migrator:     mongoMigrator{migrations.NewRunner(db)},
+			ping:         func(ctx context.Context) error { return client.Ping(ctx, nil) },
+			close: func() {
+				ctx, cancel := context.WithTimeout(context.Background(), disconnectTimeout)
+				defer cancel()
+				if err := client.Disconnect(ctx); err != nil {
+					slog.Warn("failed to disconnect from MongoDB", "error", err)
+				}
+			},
+		}, nil
+	case "postgres":
+		db, err := postgres.Open(ctx, cfg.PostgresURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+		}
+		return &Storage{
+			Repositories: PostgresRepositories(db),
+			Migrator:     sqlMigrator{migrator: postgres.NewMigrator(db), errLocked: postgres.ErrLocked},
+			ping:         db.PingContext,
+			close:        func() { _ = db.Close() },
+		}, nil
+	case "sqlite":
+		db, err := sqlite.Open(ctx, cfg.SQLitePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+		}
+		return &Storage{
+			Repositories: SQLiteRepositories(db),
+			Migrator:     sqlMigrator{migrator: sqlite.NewMigrator(db)},
+			ping:         db.PingContext,
+			close:        func() { _ = db.Close() },
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown STORAGE %q; use mongo, postgres, sqlite or memory", cfg.Storage)
+	}
+}
+
+// AddHealthChecks registers readiness checks for the backend's connection
+// and schema with checker
+func (s *Storage) AddHealthChecks(checker *health.Checker) {
+	checker.Add("storage", s.Ping)
+	if s.Migrator == nil {
+		return
+	}
+	// Checking for pending migrations may create the migrations table, so
+	// probes only check until the schema is up to date
+	checker.Add("migrations", func(ctx context.Context) error {
+		if s.migrated.Load() {
+			return nil
+		}
+		pending, err := s.Migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d migrations pending", len(pending))
+		}
+		s.migrated.Store(true)
+		return nil
+	})
+}
+
+// Migrate applies pending migrations, or only warns about them when
+// automatic migration is disabled.
+func (s *Storage) Migrate(ctx context.Context, auto bool) error {
+	if s.Migrator == nil {
+		return nil
+	}
+
+	if !auto {
+		pending, err := s.Migrator.Pending(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to check migrations: %w", err)
+		}
+		if len(pending) > 0 {
+			slog.Warn("database migrations pending; run 'bugtracker migrate'", "count", len(pending))
+			return nil
+		}
+		s.migrated.Store(true)
+		return nil
+	}
+
+	applied, err := s.Migrator.Up(ctx)
+	if errors.Is(err, ErrMigrationsLocked) {
+		// Another instance is migrating; it will finish without us
+		slog.Info("migrations are being applied by another instance")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	for _, m := range applied {
+		slog.Info("applied migration", "version", m.Version, "name", m.Name)
+	}
+	s.migrated.Store(true)
+	return nil
+}
+
+// mongoMigrator adapts migrations.Runner to Migrator
+type mongoMigrator struct {
+	runner *migrations.Runner
+}
+
+func (m mongoMigrator) Applied(ctx context.Context) ([]Migration, error) {
+	applied, err := m.runner.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Migration, len(applied))
+	for i, a := range applied {
+		result[i] = Migration{Version: a.Version, Name: a.Name, AppliedAt: a.AppliedAt}
+	}
+	return result, nil
+}
+
+func (m mongoMigrator) Pending(ctx context.Context) ([]Migration, error) {
+	pending, err := m.runner.Pending(ctx)
+	return fromMongoMigrations(pending), err
+}
+
+func (m mongoMigrator) Up(ctx context.Context) ([]Migration, error) {
+	applied, err := m.runner.Up(ctx)
+	if errors.Is(err, migrations.ErrLocked) {
+		err = ErrMigrationsLocked
+	}
+	return fromMongoMigrations(applied), err
+}
+
+func fromMongoMigrations(list []migrations.Migration) []Migration {
+	result := make([]Migration, len(list))
+	for i, m := range list {
+		result[i] = Migration{Version: m.Version, Name: m.Name}
+	}
+	return result
+}
+
+// sqlMigrator adapts the migrators of the SQL backends to Migrator
+type sqlMigrator struct {
+	migrator interface {
+		Applied(ctx context.Context) ([]sqlutil.AppliedMigration, error)
+		Pending(ctx context.Context) ([]sqlutil.Migration, error)
+		Up(ctx context.Context) ([]sqlutil.Migration, error)
+	}
+	// errLocked is the backend's error for a concurrent migration, if any
+	errLocked error
+}
+
+func (m sqlMigrator) Applied(ctx context.Context) ([]Migration, error) {
+	applied, err := m.migrator.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Migration, len(applied))
+	for i, a := range applied {
+		result[i] = Migration{Version: a.Version, Name: a.Name, AppliedAt: a.AppliedAt}
+	}
+	return result, nil
+}
+
+func (m sqlMigrator) Pending(ctx context.Context) ([]Migration, error) {
+	pending, err := m.migrator.Pending(ctx)
+	return fromSQLMigrations(pending), err
+}
+
+func (m sqlMigrator) Up(ctx context.Context) ([]Migration, error) {
+	applied, err := m.migrator.Up(ctx)
+	if m.errLocked != nil && errors.Is(err, m.errLocked) {
+		err = ErrMigrationsLocked
+	}
+	return fromSQLMigrations(applied), err
+}
+
+func fromSQLMigrations(list []sqlutil.Migration) []Migration {
+	result := make([]Migration, len(list))
+	for i, m := range list {
+		result[i] = Migration{Version: m.Version, Name: m.Name}
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+// Package authz decides what users may do from the permissions their role
+// grants.
+//
+// Roles live in storage so admins can change them and every server agrees.
+// Each server keeps a copy it refreshes periodically. A nil *Roles uses
+// the built-in roles, which suits tests and tools that never store roles.
+package authz
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"bug-tracker/logging"
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// Roles holds the permissions of each role
+type Roles struct {
+	repo repository.RoleRepositoryInterface
+
+	mu    sync.RWMutex
+	roles map[string]*models.Role
+}
+
+// New creates roles backed by repo. Call Refresh before use.
+func New(repo repository.RoleRepositoryInterface) *Roles {
+	return &Roles{repo: repo, roles: defaults()}
+}
+
+func defaults() map[string]*models.Role {
+	roles := make(map[string]*models.Role)
+	for _, role := range models.DefaultRoles() {
+		roles[role.Name] = role
+	}
+	return roles
+}
+
+// Run refreshes the roles every interval until ctx is done, so changes
+// made on other servers are picked up. Failures are logged and retried
+// next time.
+func (r *Roles) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.Refresh(ctx); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Warn("failed to refresh roles", "error", err)
+		}
+	}
+}
+
+// Refresh loads the stored roles, first storing any built-in role that is
+// missing
+func (r *Roles) Refresh(ctx context.Context) error {
+	stored, err := r.repo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	roles := make(map[string]*models.Role, len(stored))
+	for _, role := range stored {
+		roles[role.Name] = role
+	}
+	for _, role := range models.DefaultRoles() {
+		if _, ok := roles[role.Name]; ok {
+			continue
+		}
+		if err := r.repo.Save(ctx, role); err != nil {
+			return err
+		}
+		roles[role.Name] = role
+		logging.FromContext(ctx).Info("role created", "role", role.Name)
+	}
+
+	r.mu.Lock()
+	r.roles = roles
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Roles) find(name string) *models.Role {
+	if r == nil {
+		return defaults()[name]
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.roles[name]
+}
+
+// Can reports whether role grants permission. Unknown roles grant nothing.
+func (r *Roles) Can(role, permission string) bool {
+	found := r.find(role)
+	return found != nil && found.Has(permission)
+}
+
+// Exists reports whether role is defined
+func (r *Roles) Exists(role string) bool {
+	return r.find(role) != nil
+}
+
+// Permissions returns the permissions role grants, or none for unknown roles
+func (r *Roles) Permissions(role string) []string {
+	found := r.find(role)
+	if found == nil {
+		return nil
+	}
+	return append([]string{}, found.Permissions...)
+}
+
+// List returns copies of the roles ordered by name
+func (r *Roles) List() []models.Role {
+	roles := defaults()
+	if r != nil {
+		r.mu.RLock()
+		roles = r.roles
+		defer r.mu.RUnlock()
+	}
+
+	list := make([]models.Role, 0, len(roles))
+	for _, role := range roles {
+		clone := *role
+		clone.Permissions = append([]string{}, role.Permissions...)
+		list = append(list, clone)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Granting returns the names of the roles that grant permission
+func (r *Roles) Granting(permission string) []string {
+	var names []string
+	for _, role := range r.List() {
+		if role.Has(permission) {
+			names = append(names, role.Name)
+		}
+	}
+	return names
+}
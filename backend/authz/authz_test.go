@@ -0,0 +1,74 @@
+package authz
+
+import (
+	"context"
+	"testing"
+
+	"bug-tracker/models"
+	"bug-tracker/repository/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNilRolesUseDefaults(t *testing.T) {
+	var roles *Roles
+
+	assert.True(t, roles.Can(models.RoleAdmin, models.PermRoleManage))
+	assert.True(t, roles.Can(models.RoleManager, models.PermBugAssign))
+	assert.False(t, roles.Can(models.RoleDeveloper, models.PermBugDelete))
+	assert.False(t, roles.Can("owner", models.PermBugView))
+	assert.True(t, roles.Exists(models.RoleDeveloper))
+	assert.False(t, roles.Exists("owner"))
+	assert.Equal(t, []string{models.RoleDeveloper}, roles.Granting(models.PermBugAssignable))
+}
+
+func TestRefreshStoresBuiltinRoles(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRoleRepository()
+	roles := New(repo)
+
+	require.NoError(t, roles.Refresh(ctx))
+
+	stored, err := repo.FindAll(ctx)
+	require.NoError(t, err)
+	var names []string
+	for _, role := range stored {
+		names = append(names, role.Name)
+	}
+	assert.Equal(t, []string{models.RoleAdmin, models.RoleDeveloper, models.RoleManager}, names)
+}
+
+func TestRefreshLoadsStoredRoles(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewRoleRepository()
+	roles := New(repo)
+	require.NoError(t, roles.Refresh(ctx))
+
+	// Edits made elsewhere, as by another server
+	require.NoError(t, repo.Save(ctx, &models.Role{Name: "triager", Permissions: []string{models.PermBugView, models.PermBugAssign}}))
+	require.NoError(t, repo.Save(ctx, &models.Role{Name: models.RoleDeveloper, Permissions: []string{models.PermBugCreate}}))
+	assert.False(t, roles.Exists("triager"), "changes wait for a refresh")
+
+	require.NoError(t, roles.Refresh(ctx))
+	assert.True(t, roles.Can("triager", models.PermBugAssign))
+	assert.False(t, roles.Can("triager", models.PermBugDelete))
+	assert.False(t, roles.Can(models.RoleDeveloper, models.PermBugAssignable))
+	assert.Empty(t, roles.Granting(models.PermBugAssignable))
+
+	var names []string
+	for _, role := range roles.List() {
+		names = append(names, role.Name)
+	}
+	assert.Equal(t, []string{models.RoleAdmin, models.RoleDeveloper, models.RoleManager, "triager"}, names)
+}
+
+func TestListReturnsCopies(t *testing.T) {
+	roles := New(memory.NewRoleRepository())
+	require.NoError(t, roles.Refresh(context.Background()))
+
+	list := roles.List()
+	list[0].Permissions[0] = "changed"
+
+	assert.NotEqual(t, "changed", roles.List()[0].Permissions[0])
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bug-tracker/config"
+	"bug-tracker/repository/mongodb"
+	"bug-tracker/repository/sqlite"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func runMigrate(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	status := fs.Bool("status", false, "list applied and pending migrations without applying any")
+	_ = fs.Parse(args)
+
+	ctx := context.Background()
+	storage, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	runner := storage.Migrator
+
+	if *status {
+		applied, err := runner.Applied(ctx)
+		if err != nil {
+			return err
+		}
+		pending, err := runner.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		for _, m := range applied {
+			fmt.Printf("applied  %3d  %-24s %s\n", m.Version, m.Name, m.AppliedAt.Format("2006-01-02 15:04:05"))
+		}
+		for _, m := range pending {
+			fmt.Printf("pending  %3d  %s\n", m.Version, m.Name)
+		}
+		return nil
+	}
+
+	applied, err := runner.Up(ctx)
+	for _, m := range applied {
+		fmt.Printf("Applied migration %d (%s)\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		fmt.Println("Database is up to date")
+	}
+	return nil
+}
+
+func runReindex(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("reindex", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	ctx := context.Background()
+	db, disconnect, err := openDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	if err := mongodb.RebuildIndexes(ctx, db); err != nil {
+		return err
+	}
+
+	fmt.Println("Indexes rebuilt")
+	return nil
+}
+
+func runCheckDB(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("check-db", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	ctx := context.Background()
+	db, disconnect, err := openDB(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	var buildInfo struct {
+		Version string `bson:"version"`
+	}
+	if err := db.RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&buildInfo); err != nil {
+		return err
+	}
+
+	fmt.Printf("Connected to MongoDB %s, database %q\n", buildInfo.Version, cfg.DBName)
+	for _, name := range []string{"users", "bugs"} {
+		count, err := db.Collection(name).EstimatedDocumentCount(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  %-6s %d documents\n", name, count)
+	}
+	return nil
+}
+
+func runBackup(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	output := fs.String("o", "", "file to write; defaults to a timestamped copy next to the database")
+	_ = fs.Parse(args)
+
+	if cfg.Storage != "sqlite" {
+		return fmt.Errorf("backup needs SQLite storage, not %q; use your database's own tools", cfg.Storage)
+	}
+
+	path := *output
+	if path == "" {
+		base := strings.TrimSuffix(cfg.SQLitePath, filepath.Ext(cfg.SQLitePath))
+		path = base + "-" + time.Now().Format("20060102-150405") + ".db"
+	}
+
+	// Opening a missing file would create an empty database
+	if _, err := os.Stat(cfg.SQLitePath); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	db, err := sqlite.Open(ctx, cfg.SQLitePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := sqlite.Backup(ctx, db, path); err != nil {
+		return err
+	}
+
+	fmt.Printf("Backed up %s to %s\n", cfg.SQLitePath, path)
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"strings"
+
+	"bug-tracker/config"
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+)
+
+func runExport(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "csv", "output format: csv or ndjson")
+	columns := fs.String("columns", "", "comma-separated columns (default: "+strings.Join(models.DefaultExportColumns, ",")+")")
+	status := fs.String("status", "", "only export bugs with this status")
+	priority := fs.String("priority", "", "only export bugs with this priority")
+	bom := fs.Bool("bom", false, "start CSV output with a UTF-8 byte order mark for Excel")
+	output := fs.String("o", "", "write to this file instead of stdout")
+	_ = fs.Parse(args)
+
+	opts := models.ExportOptions{Format: *format, BOM: *bom}
+	if *columns != "" {
+		opts.Columns = strings.Split(*columns, ",")
+	}
+	if err := usecase.NormalizeExportOptions(&opts); err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	ctx := context.Background()
+	storage, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	bugUseCase := usecase.NewBugUseCase(storage.Bugs, storage.Users, storage.CustomFields, storage.Templates, storage.Milestones, nil)
+	filter := models.BugFilter{Status: *status, Priority: *priority}
+	return bugUseCase.ExportBugs(ctx, filter, opts, w)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"bug-tracker/authz"
+	"bug-tracker/config"
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+)
+
+func runImport(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "csv", "source format: csv, github or jira")
+	mapping := fs.String("mapping", "", `CSV column mapping as JSON, e.g. '{"title":"Summary"}'`)
+	userMap := fs.String("user-map", "", `source user to email mapping as JSON, e.g. '{"octocat":"cat@example.com"}'`)
+	as := fs.String("as", "", "email of the user performing the import (required)")
+	commit := fs.Bool("commit", false, "write the bugs; without it the import is a dry run")
+	asJSON := fs.Bool("json", false, "print the full report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bugtracker import [flags] <file>")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 || *as == "" {
+		fs.Usage()
+		return errors.New("a file and -as are required")
+	}
+
+	opts := models.ImportOptions{Format: *format, DryRun: !*commit}
+	if *mapping != "" {
+		if err := json.Unmarshal([]byte(*mapping), &opts.Mapping); err != nil {
+			return fmt.Errorf("invalid -mapping: %w", err)
+		}
+	}
+	if *userMap != "" {
+		if err := json.Unmarshal([]byte(*userMap), &opts.UserMap); err != nil {
+			return fmt.Errorf("invalid -user-map: %w", err)
+		}
+	}
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	ctx := context.Background()
+	storage, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	userRepo := storage.Users
+	importer, err := userRepo.FindByEmail(ctx, *as)
+	if err != nil {
+		return err
+	}
+	if importer == nil {
+		return fmt.Errorf("no user with email %s", *as)
+	}
+
+	// Assignees are checked against the roles admins have set up
+	roles := authz.New(storage.Roles)
+	if err := roles.Refresh(ctx); err != nil {
+		return fmt.Errorf("failed to load roles: %w", err)
+	}
+
+	importUseCase := usecase.NewImportUseCase(storage.Bugs, userRepo, roles)
+	report, err := importUseCase.ImportBugs(ctx, file, opts, importer)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	printImportReport(report)
+	return nil
+}
+
+func printImportReport(report *models.ImportReport) {
+	for _, row := range report.Rows {
+		if row.Action == "create" && len(row.Warnings) == 0 {
+			continue
+		}
+		fmt.Printf("line %d (%s): %s\n", row.Line, row.ExternalID, row.Action)
+		for _, msg := range row.Errors {
+			fmt.Printf("  error: %s\n", msg)
+		}
+		for _, msg := range row.Warnings {
+			fmt.Printf("  warning: %s\n", msg)
+		}
+	}
+
+	verb := "Imported"
+	if report.DryRun {
+		verb = "Would import"
+	}
+	fmt.Printf("%s %d of %d bugs (%d already imported, %d failed)\n",
+		verb, report.Created, report.Total, report.Skipped, report.Failed)
+	if report.DryRun {
+		fmt.Println("Dry run only; re-run with -commit to write the bugs.")
+	}
+}
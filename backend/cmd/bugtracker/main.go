@@ -0,0 +1,124 @@
+// Command bugtracker runs and administers the bug tracker server.
+//
+// Usage:
+//
+//	bugtracker <command> [flags]
+//
+// Run "bugtracker" without arguments to list the commands. Every command
+// reads the same configuration as the server (environment, .env and
+// CONFIG_FILE).
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"bug-tracker/app"
+	"bug-tracker/config"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(cfg *config.Config, args []string) error
+}
+
+var commands = []command{
+	{"serve", "Run the API server", runServe},
+	{"migrate", "Apply database schema changes", runMigrate},
+	{"create-admin", "Create an admin user", runCreateAdmin},
+	{"reset-password", "Set a new password for a user", runResetPassword},
+	{"list-users", "List users, optionally by role", runListUsers},
+	{"unlock-user", "Clear a user's failed logins and lockout", runUnlockUser},
+	{"reset-2fa", "Remove a user's two-factor authentication", runResetTwoFactor},
+	{"export", "Export bugs as CSV or NDJSON", runExport},
+	{"import", "Import bugs from CSV, GitHub Issues JSON or Jira CSV", runImport},
+	{"check-db", "Check the database connection", runCheckDB},
+	{"reindex", "Drop and rebuild all database indexes", runReindex},
+	{"backup", "Snapshot the SQLite database to a file", runBackup},
+}
+
+func main() {
+	log.SetFlags(0)
+	// Admin commands report their own results; only surface problems.
+	// serve replaces this with the configured JSON logger.
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			cfg, err := config.Load()
+			if err != nil {
+				fatalf("invalid configuration: %v", err)
+			}
+			if err := cmd.run(cfg, os.Args[2:]); err != nil {
+				fatalf("%s: %v", cmd.name, err)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
+
+// fatalf reports a failure and exits. It writes to stderr directly since
+// the log package is routed through slog, which may filter it out.
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: bugtracker <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-15s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// openStorage connects to the configured storage backend. The in-memory
+// backend is refused since anything a command did would be lost on exit.
+func openStorage(ctx context.Context, cfg *config.Config) (*app.Storage, error) {
+	if cfg.Storage == "memory" {
+		return nil, errors.New("this command needs a database; set STORAGE to mongo, postgres or sqlite")
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return app.Open(connectCtx, cfg)
+}
+
+// openDB connects to the configured MongoDB database and returns it with a function
+// that disconnects the client.
+func openDB(ctx context.Context, cfg *config.Config) (*mongo.Database, func(), error) {
+	if cfg.Storage != "mongo" {
+		return nil, nil, fmt.Errorf("this command needs MongoDB storage, not %q", cfg.Storage)
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	client, err := app.Connect(connectCtx, cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
+	}
+
+	disconnect := func() {
+		_ = client.Disconnect(context.Background())
+	}
+	return client.Database(cfg.DBName), disconnect, nil
+}
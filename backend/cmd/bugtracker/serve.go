@@ -0,0 +1,17 @@
+package main
+
+import (
+	"flag"
+
+	"bug-tracker/app"
+	"bug-tracker/config"
+)
+
+func runServe(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	port := fs.String("port", cfg.Port, "port to listen on")
+	_ = fs.Parse(args)
+
+	cfg.Port = *port
+	return app.Serve(cfg)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"bug-tracker/app"
+	"bug-tracker/config"
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+)
+
+// newAuthUseCase returns the auth use case for user commands, which issue
+// no login tokens and check no permissions, so need no signing keys or
+// roles
+func newAuthUseCase(cfg *config.Config, storage *app.Storage) *usecase.AuthUseCase {
+	return app.NewAuthUseCase(cfg, storage.Users, nil, nil)
+}
+
+func runCreateAdmin(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	name := fs.String("name", "Administrator", "display name")
+	email := fs.String("email", "", "email address (required)")
+	password := fs.String("password", "", "password; read from BUGTRACKER_PASSWORD or stdin if empty")
+	_ = fs.Parse(args)
+
+	if *email == "" {
+		fs.Usage()
+		return errors.New("-email is required")
+	}
+	pw, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	storage, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	authUseCase := newAuthUseCase(cfg, storage)
+	user, err := authUseCase.Register(ctx, models.RegisterRequest{
+		Name:     *name,
+		Email:    *email,
+		Password: pw,
+		Role:     "admin",
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created admin %s (%s)\n", user.Email, user.ID.Hex())
+	return nil
+}
+
+func runResetPassword(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	email := fs.String("email", "", "email of the user (required)")
+	password := fs.String("password", "", "new password; read from BUGTRACKER_PASSWORD or stdin if empty")
+	_ = fs.Parse(args)
+
+	if *email == "" {
+		fs.Usage()
+		return errors.New("-email is required")
+	}
+	pw, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	storage, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	authUseCase := newAuthUseCase(cfg, storage)
+	if err := authUseCase.ResetPassword(ctx, *email, pw); err != nil {
+		return err
+	}
+
+	fmt.Printf("Password updated for %s\n", *email)
+	return nil
+}
+
+func runUnlockUser(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("unlock-user", flag.ExitOnError)
+	email := fs.String("email", "", "email of the user (required)")
+	_ = fs.Parse(args)
+
+	if *email == "" {
+		fs.Usage()
+		return errors.New("-email is required")
+	}
+
+	ctx := context.Background()
+	storage, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	user, err := storage.Users.FindByEmail(ctx, *email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("no user with email %s", *email)
+	}
+	if err := newAuthUseCase(cfg, storage).Unlock(ctx, user.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("Unlocked %s\n", *email)
+	return nil
+}
+
+func runResetTwoFactor(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("reset-2fa", flag.ExitOnError)
+	email := fs.String("email", "", "email of the user (required)")
+	_ = fs.Parse(args)
+
+	if *email == "" {
+		fs.Usage()
+		return errors.New("-email is required")
+	}
+
+	ctx := context.Background()
+	storage, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	user, err := storage.Users.FindByEmail(ctx, *email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("no user with email %s", *email)
+	}
+	if err := newAuthUseCase(cfg, storage).ResetTwoFactor(ctx, user.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed two-factor authentication for %s\n", *email)
+	return nil
+}
+
+func runListUsers(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("list-users", flag.ExitOnError)
+	role := fs.String("role", "", "only list users with this role")
+	_ = fs.Parse(args)
+
+	ctx := context.Background()
+	storage, err := openStorage(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	userRepo := storage.Users
+	var users []*models.User
+	if *role != "" {
+		users, err = userRepo.FindByRole(ctx, *role)
+	} else {
+		users, err = userRepo.FindAll(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	now := time.Now()
+	fmt.Fprintln(w, "ID\tNAME\tEMAIL\tROLE\tCREATED\t2FA\tLOCKED")
+	for _, user := range users {
+		twoFactor := "-"
+		if user.TwoFactorEnabled() {
+			twoFactor = "on"
+		} else if user.TwoFactor != nil {
+			twoFactor = "pending"
+		}
+		locked := "-"
+		if user.Locked(now) {
+			locked = "until " + user.LockedUntil.Local().Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			user.ID.Hex(), user.Name, user.Email, user.Role, user.CreatedAt.Format("2006-01-02"), twoFactor, locked)
+	}
+	return w.Flush()
+}
+
+// readPassword returns the flag value, falling back to the
+// BUGTRACKER_PASSWORD environment variable and then a line from stdin so
+// passwords needn't appear in shell history.
+func readPassword(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if env := os.Getenv("BUGTRACKER_PASSWORD"); env != "" {
+		return env, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("no password given")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,406 @@
+// Package config loads the settings shared by the server and the admin CLI.
+//
+// Each setting has an environment variable, a default and a key in the
+// optional config file, which is the variable's name in lower case. Later
+// sources override earlier ones:
+//
+//  1. the defaults below
+//  2. the YAML or TOML file named by CONFIG_FILE
+//  3. a .env file in the working directory
+//  4. the process environment
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/ratelimit"
+
+	"github.com/joho/godotenv"
+)
+
+// DefaultJWTSecret is the development JWT secret. Release mode refuses it.
+const DefaultJWTSecret = "your-secret-key"
+
+// minJWTSecretLength is the shortest JWT secret release mode accepts; HS256
+// keys shorter than the hash give away security
+const minJWTSecretLength = 32
+
+// minJWTKeyPublishDelay covers the five minutes verifiers may cache the
+// key set and the minute servers take to load new keys
+const minJWTKeyPublishDelay = 10 * time.Minute
+
+// Gin modes
+const (
+	ModeDebug   = "debug"
+	ModeRelease = "release"
+	ModeTest    = "test"
+)
+
+type Config struct {
+	// Storage selects the backend: "mongo", "postgres", "sqlite", or
+	// "memory" for demos without a database
+	Storage     string `env:"STORAGE" default:"mongo"`
+	MongoURI    string `env:"MONGO_URI" default:"mongodb://localhost:27017"`
+	DBName      string `env:"DB_NAME" default:"bug_tracker"`
+	PostgresURL string `env:"POSTGRES_URL" default:"postgres://localhost:5432/bug_tracker?sslmode=disable"`
+	SQLitePath  string `env:"SQLITE_PATH" default:"bug_tracker.db"`
+	Port        string `env:"PORT" default:"8080"`
+	// Mode is gin's mode. Release mode enforces production settings such
+	// as a strong JWT secret and an exact CORS origin list.
+	Mode string `env:"GIN_MODE" default:"debug"`
+	// AutoMigrate applies pending database migrations when the server starts
+	AutoMigrate bool `env:"AUTO_MIGRATE" default:"true"`
+
+	// JWTSecret signs login tokens when JWTAlgorithm is HS256, and
+	// single sign-on state always
+	JWTSecret string `env:"JWT_SECRET" default:"your-secret-key"`
+	// JWTAlgorithm signs login tokens: RS256 or EdDSA with keys published
+	// at /.well-known/jwks.json, or HS256 with JWTSecret
+	JWTAlgorithm string `env:"JWT_ALGORITHM" default:"RS256"`
+	// JWTKeyRotation is how long each signing key signs before the next
+	// takes over. JWTKeyPublishDelay is how long new keys are published
+	// first, which must outlast how long verifiers cache the key set.
+	JWTKeyRotation     time.Duration `env:"JWT_KEY_ROTATION" default:"720h"`
+	JWTKeyPublishDelay time.Duration `env:"JWT_KEY_PUBLISH_DELAY" default:"1h"`
+	// TokenTTL is how long a login token stays valid
+	TokenTTL time.Duration `env:"JWT_TTL" default:"24h"`
+	// AuthCacheTTL is how long a validated login token's user is cached.
+	// Role changes and deactivations reach other servers' caches within
+	// it; 0 looks the user up on every request.
+	AuthCacheTTL time.Duration `env:"AUTH_CACHE_TTL" default:"30s"`
+
+	// CORSOrigins may call the API from a browser. Outside release mode
+	// any origin is allowed.
+	CORSOrigins []string `env:"CORS_ALLOWED_ORIGINS" default:"https://bug-tracker-frontend.vercel.app,https://bug-tracker-frontend-kenean-r.vercel.app,http://localhost:5174,http://localhost:5173,http://localhost:3000"`
+	// MaxImportSize caps an uploaded import file, in bytes
+	MaxImportSize int64 `env:"MAX_IMPORT_SIZE" default:"20971520"`
+	// TrustedProxies may set X-Forwarded-For, given as IPs or CIDRs. With
+	// none, the client address is the connection's, so clients can't dodge
+	// rate limits by forging the header.
+	TrustedProxies []string `env:"TRUSTED_PROXIES"`
+
+	// RateLimit caps requests per client IP to each API route, and
+	// AuthRateLimit to login and registration, as "requests/period" or
+	// "off"
+	RateLimit     ratelimit.Limit `env:"RATE_LIMIT" default:"300/1m"`
+	AuthRateLimit ratelimit.Limit `env:"AUTH_RATE_LIMIT" default:"10/1m"`
+	// LockoutThreshold consecutive wrong passwords lock an account for
+	// LockoutDuration, doubling with each further failure up to
+	// LockoutMaxDuration. Zero disables lockout.
+	LockoutThreshold   int           `env:"LOGIN_LOCKOUT_THRESHOLD" default:"5"`
+	LockoutDuration    time.Duration `env:"LOGIN_LOCKOUT_DURATION" default:"1m"`
+	LockoutMaxDuration time.Duration `env:"LOGIN_LOCKOUT_MAX_DURATION" default:"1h"`
+	// TwoFactorRequiredRoles must set up TOTP two-factor authentication
+	// before they can log in, e.g. "admin,manager"
+	TwoFactorRequiredRoles []string `env:"TWO_FACTOR_REQUIRED_ROLES"`
+	// TwoFactorIssuer labels accounts in authenticator apps
+	TwoFactorIssuer string `env:"TWO_FACTOR_ISSUER" default:"Bug Tracker"`
+
+	// OIDCIssuer enables OpenID Connect single sign-on with the provider
+	// at this URL. OIDCRedirectURL is the frontend page the provider sends
+	// users back to; it must be registered with the provider.
+	OIDCIssuer       string   `env:"OIDC_ISSUER"`
+	OIDCClientID     string   `env:"OIDC_CLIENT_ID"`
+	OIDCClientSecret string   `env:"OIDC_CLIENT_SECRET"`
+	OIDCRedirectURL  string   `env:"OIDC_REDIRECT_URL"`
+	OIDCScopes       []string `env:"OIDC_SCOPES" default:"openid,email,profile"`
+	// OIDCRoleMapping maps values of the OIDCRoleClaim ID token claim to
+	// roles, e.g. "bt-admins=admin,qa=developer". New users no value maps
+	// to get OIDCDefaultRole, or are refused when it is "none".
+	OIDCRoleClaim   string   `env:"OIDC_ROLE_CLAIM" default:"groups"`
+	OIDCRoleMapping []string `env:"OIDC_ROLE_MAPPING"`
+	OIDCDefaultRole string   `env:"OIDC_DEFAULT_ROLE" default:"developer"`
+
+	// LogLevel is the minimum level of server logs: debug, info, warn or error
+	LogLevel string `env:"LOG_LEVEL" default:"info"`
+	// Metrics serves Prometheus metrics at /metrics, protected by
+	// MetricsToken when it is set
+	Metrics      bool   `env:"METRICS_ENABLED" default:"true"`
+	MetricsToken string `env:"METRICS_TOKEN"`
+	// TracesExporter sends OpenTelemetry traces to "otlp", "stdout" or
+	// "none"
+	TracesExporter string `env:"OTEL_TRACES_EXPORTER" default:"none"`
+
+	// DrainDelay is how long the server keeps serving with readiness
+	// failing after a shutdown signal, so load balancers can stop routing
+	// traffic to it
+	DrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY" default:"5s"`
+	// ShutdownTimeout bounds the wait for in-flight requests and
+	// background workers once draining is over
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" default:"30s"`
+	// HTTP server timeouts; zero disables one
+	ReadHeaderTimeout time.Duration `env:"HTTP_READ_HEADER_TIMEOUT" default:"10s"`
+	ReadTimeout       time.Duration `env:"HTTP_READ_TIMEOUT" default:"1m"`
+	WriteTimeout      time.Duration `env:"HTTP_WRITE_TIMEOUT" default:"1m"`
+	IdleTimeout       time.Duration `env:"HTTP_IDLE_TIMEOUT" default:"2m"`
+}
+
+// Default returns the configuration used when nothing is set
+func Default() *Config {
+	cfg := &Config{}
+	for _, s := range settings(cfg) {
+		if err := s.set(s.def); err != nil {
+			panic(fmt.Sprintf("config: bad default for %s: %v", s.env, err))
+		}
+	}
+	return cfg
+}
+
+// Load reads and validates the configuration. A .env file in the working
+// directory is loaded into the environment first if one exists.
+func Load() (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read .env: %w", err)
+	}
+
+	// Report every problem at once rather than one per restart
+	var errs []error
+	cfg := Default()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		errs = append(errs, cfg.loadFile(path))
+	}
+	errs = append(errs, cfg.loadEnv(os.LookupEnv), cfg.Validate())
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadEnv applies every setting lookup finds. Empty values are ignored so
+// "FOO=" in .env doesn't wipe a default.
+func (c *Config) loadEnv(lookup func(key string) (string, bool)) error {
+	var errs []error
+	for _, s := range settings(c) {
+		value, ok := lookup(s.env)
+		if !ok || value == "" {
+			continue
+		}
+		if err := s.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate reports every setting that can't work, so a misconfigured
+// server fails at startup rather than on the first request
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch c.Storage {
+	case "mongo", "postgres", "sqlite", "memory":
+	default:
+		fail("STORAGE must be mongo, postgres, sqlite or memory, not %q", c.Storage)
+	}
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 0 || port > 65535 {
+		fail("PORT must be a port number, not %q", c.Port)
+	}
+	switch c.Mode {
+	case ModeDebug, ModeTest:
+	case ModeRelease:
+		if c.JWTSecret == DefaultJWTSecret {
+			fail("JWT_SECRET must be set in release mode")
+		} else if len(c.JWTSecret) < minJWTSecretLength {
+			fail("JWT_SECRET must be at least %d characters in release mode", minJWTSecretLength)
+		}
+	default:
+		fail("GIN_MODE must be debug, release or test, not %q", c.Mode)
+	}
+	if c.JWTSecret == "" {
+		fail("JWT_SECRET must not be empty")
+	}
+	if c.TokenTTL == 0 {
+		fail("JWT_TTL must not be zero")
+	}
+	switch c.JWTAlgorithm {
+	case "HS256":
+	case "RS256", "EdDSA":
+		if c.JWTKeyPublishDelay < minJWTKeyPublishDelay {
+			fail("JWT_KEY_PUBLISH_DELAY must be at least %s", minJWTKeyPublishDelay)
+		}
+		if c.JWTKeyRotation <= c.JWTKeyPublishDelay {
+			fail("JWT_KEY_ROTATION must be longer than JWT_KEY_PUBLISH_DELAY")
+		}
+	default:
+		fail("JWT_ALGORITHM must be RS256, EdDSA or HS256, not %q", c.JWTAlgorithm)
+	}
+
+	for _, origin := range c.CORSOrigins {
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			fail("CORS_ALLOWED_ORIGINS: %q is not an origin such as https://example.com", origin)
+		}
+	}
+	if c.MaxImportSize <= 0 {
+		fail("MAX_IMPORT_SIZE must be positive")
+	}
+	for _, proxy := range c.TrustedProxies {
+		if _, err := netip.ParsePrefix(proxy); err != nil {
+			if _, err := netip.ParseAddr(proxy); err != nil {
+				fail("TRUSTED_PROXIES: %q is not an IP address or CIDR range", proxy)
+			}
+		}
+	}
+	if c.LockoutThreshold < 0 {
+		fail("LOGIN_LOCKOUT_THRESHOLD must not be negative")
+	}
+	if c.LockoutThreshold > 0 && c.LockoutDuration == 0 {
+		fail("LOGIN_LOCKOUT_DURATION must be set when lockout is enabled")
+	}
+	for _, role := range c.TwoFactorRequiredRoles {
+		if !validRole(role) {
+			fail("TWO_FACTOR_REQUIRED_ROLES: %q is not a role name", role)
+		}
+	}
+	if c.TwoFactorIssuer == "" {
+		fail("TWO_FACTOR_ISSUER must not be empty")
+	}
+	if c.OIDCIssuer != "" {
+		if u, err := url.Parse(c.OIDCIssuer); err != nil || u.Scheme == "" || u.Host == "" {
+			fail("OIDC_ISSUER: %q is not a URL such as https://login.example.com", c.OIDCIssuer)
+		}
+		if c.OIDCClientID == "" {
+			fail("OIDC_CLIENT_ID must be set when OIDC_ISSUER is")
+		}
+		if u, err := url.Parse(c.OIDCRedirectURL); err != nil || u.Scheme == "" || u.Host == "" {
+			fail("OIDC_REDIRECT_URL must be the URL of the frontend's sign-in callback, not %q", c.OIDCRedirectURL)
+		}
+		if !slices.Contains(c.OIDCScopes, "openid") {
+			fail("OIDC_SCOPES must include openid")
+		}
+		if c.OIDCRoleClaim == "" && len(c.OIDCRoleMapping) > 0 {
+			fail("OIDC_ROLE_CLAIM must be set when OIDC_ROLE_MAPPING is")
+		}
+		for _, pair := range c.OIDCRoleMapping {
+			value, role, ok := strings.Cut(pair, "=")
+			if !ok || strings.TrimSpace(value) == "" || !validRole(role) {
+				fail("OIDC_ROLE_MAPPING: %q is not value=role", pair)
+			}
+		}
+		if c.OIDCDefaultRole != "none" && !validRole(c.OIDCDefaultRole) {
+			fail("OIDC_DEFAULT_ROLE must be a role name or none, not %q", c.OIDCDefaultRole)
+		}
+	}
+
+	switch strings.ToLower(c.LogLevel) {
+	case "debug", "info", "warn", "error":
+	default:
+		fail("LOG_LEVEL must be debug, info, warn or error, not %q", c.LogLevel)
+	}
+	switch c.TracesExporter {
+	case "", "none", "otlp", "stdout", "console":
+	default:
+		fail("OTEL_TRACES_EXPORTER must be otlp, stdout or none, not %q", c.TracesExporter)
+	}
+
+	for _, s := range settings(c) {
+		if d, ok := s.field.Interface().(time.Duration); ok && d < 0 {
+			fail("%s must not be negative", s.env)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// OIDCRoles returns OIDCRoleMapping as a map from claim values to roles
+func (c *Config) OIDCRoles() map[string]string {
+	roles := make(map[string]string, len(c.OIDCRoleMapping))
+	for _, pair := range c.OIDCRoleMapping {
+		if value, role, ok := strings.Cut(pair, "="); ok {
+			roles[strings.TrimSpace(value)] = strings.TrimSpace(role)
+		}
+	}
+	return roles
+}
+
+// validRole reports whether role is well-formed. Roles are defined in the
+// database, so whether it exists is checked once storage is open.
+func validRole(role string) bool {
+	return models.ValidRoleName(strings.TrimSpace(role))
+}
+
+// setting is one configurable field of a Config
+type setting struct {
+	env   string
+	def   string
+	field reflect.Value
+}
+
+// key is the setting's name in a config file
+func (s setting) key() string {
+	return strings.ToLower(s.env)
+}
+
+// settings lists c's fields in declaration order
+func settings(c *Config) []setting {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	list := make([]setting, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		list = append(list, setting{env: f.Tag.Get("env"), def: f.Tag.Get("default"), field: v.Field(i)})
+	}
+	return list
+}
+
+// set parses value into the setting's field. Lists are comma-separated.
+func (s setting) set(value string) error {
+	if u, ok := s.field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	switch s.field.Interface().(type) {
+	case string:
+		s.field.SetString(value)
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		s.field.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		s.field.SetInt(int64(n))
+	case int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		s.field.SetInt(n)
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s or 5m", value)
+		}
+		s.field.SetInt(int64(d))
+	case []string:
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		s.field.Set(reflect.ValueOf(list))
+	default:
+		panic("config: unsupported setting type " + s.field.Type().String())
+	}
+	return nil
+}
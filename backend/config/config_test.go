@@ -0,0 +1,235 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bug-tracker/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearEnv unsets every setting for the duration of the test so the
+// developer's environment doesn't leak in
+func clearEnv(t *testing.T) {
+	for _, s := range settings(&Config{}) {
+		t.Setenv(s.env, "")
+	}
+	t.Setenv("CONFIG_FILE", "")
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestDefault(t *testing.T) {
+	cfg := Default()
+
+	assert.Equal(t, "mongo", cfg.Storage)
+	assert.Equal(t, "8080", cfg.Port)
+	assert.True(t, cfg.AutoMigrate)
+	assert.Equal(t, 24*time.Hour, cfg.TokenTTL)
+	assert.Equal(t, 30*time.Second, cfg.AuthCacheTTL)
+	assert.Equal(t, int64(20<<20), cfg.MaxImportSize)
+	assert.Contains(t, cfg.CORSOrigins, "http://localhost:5173")
+	assert.Equal(t, time.Minute, cfg.WriteTimeout)
+	assert.Equal(t, ratelimit.Limit{Requests: 10, Per: time.Minute}, cfg.AuthRateLimit)
+	assert.Equal(t, 5, cfg.LockoutThreshold)
+	assert.Empty(t, cfg.TrustedProxies)
+	assert.Empty(t, cfg.TwoFactorRequiredRoles)
+	assert.Equal(t, "Bug Tracker", cfg.TwoFactorIssuer)
+	assert.Equal(t, "RS256", cfg.JWTAlgorithm)
+	assert.Equal(t, 30*24*time.Hour, cfg.JWTKeyRotation)
+	assert.Empty(t, cfg.OIDCIssuer)
+	assert.Equal(t, []string{"openid", "email", "profile"}, cfg.OIDCScopes)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{name: "yaml", file: "bugtracker.yaml"},
+		{name: "toml", file: "bugtracker.toml"},
+	}
+	contents := map[string]string{
+		"bugtracker.yaml": `
+storage: sqlite
+port: 9000
+jwt_ttl: 2h
+metrics_enabled: false
+auth_rate_limit: off
+cors_allowed_origins:
+  - https://bugs.example.com
+  - https://admin.example.com
+`,
+		"bugtracker.toml": `
+storage = "sqlite"
+port = 9000
+jwt_ttl = "2h"
+metrics_enabled = false
+auth_rate_limit = "off"
+cors_allowed_origins = ["https://bugs.example.com", "https://admin.example.com"]
+`,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("CONFIG_FILE", writeFile(t, tt.file, contents[tt.file]))
+			// The environment beats the file
+			t.Setenv("PORT", "9100")
+
+			cfg, err := Load()
+			require.NoError(t, err)
+
+			assert.Equal(t, "sqlite", cfg.Storage)
+			assert.Equal(t, "9100", cfg.Port)
+			assert.Equal(t, 2*time.Hour, cfg.TokenTTL)
+			assert.False(t, cfg.Metrics)
+			assert.False(t, cfg.AuthRateLimit.Enabled())
+			assert.Equal(t, []string{"https://bugs.example.com", "https://admin.example.com"}, cfg.CORSOrigins)
+			// Untouched settings keep their defaults
+			assert.Equal(t, "bug_tracker", cfg.DBName)
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		file     string
+		expected []string
+	}{
+		{
+			name:     "unparseable values",
+			env:      map[string]string{"HTTP_READ_TIMEOUT": "soon", "AUTO_MIGRATE": "maybe"},
+			expected: []string{"HTTP_READ_TIMEOUT", "AUTO_MIGRATE"},
+		},
+		{
+			name:     "unknown file keys",
+			file:     "jwt_secrte: oops\n",
+			expected: []string{`unknown setting "jwt_secrte"`},
+		},
+		{
+			name:     "unknown storage",
+			env:      map[string]string{"STORAGE": "redis"},
+			expected: []string{"STORAGE"},
+		},
+		{
+			name:     "default secret in release mode",
+			env:      map[string]string{"GIN_MODE": "release"},
+			expected: []string{"JWT_SECRET must be set in release mode"},
+		},
+		{
+			name:     "short secret in release mode",
+			env:      map[string]string{"GIN_MODE": "release", "JWT_SECRET": "hunter2"},
+			expected: []string{"JWT_SECRET must be at least 32 characters"},
+		},
+		{
+			name:     "unknown JWT algorithm",
+			env:      map[string]string{"JWT_ALGORITHM": "none"},
+			expected: []string{"JWT_ALGORITHM"},
+		},
+		{
+			name:     "key rotation shorter than publishing",
+			env:      map[string]string{"JWT_ALGORITHM": "EdDSA", "JWT_KEY_ROTATION": "30m", "JWT_KEY_PUBLISH_DELAY": "1h"},
+			expected: []string{"JWT_KEY_ROTATION must be longer than JWT_KEY_PUBLISH_DELAY"},
+		},
+		{
+			name:     "keys published too briefly",
+			env:      map[string]string{"JWT_KEY_PUBLISH_DELAY": "1m"},
+			expected: []string{"JWT_KEY_PUBLISH_DELAY must be at least 10m0s"},
+		},
+		{
+			name:     "malformed CORS origin",
+			env:      map[string]string{"CORS_ALLOWED_ORIGINS": "bugs.example.com"},
+			expected: []string{"CORS_ALLOWED_ORIGINS"},
+		},
+		{
+			name:     "malformed two-factor role",
+			env:      map[string]string{"TWO_FACTOR_REQUIRED_ROLES": "admin,Owner!"},
+			expected: []string{`TWO_FACTOR_REQUIRED_ROLES: "Owner!"`},
+		},
+		{
+			name:     "single sign-on without a client",
+			env:      map[string]string{"OIDC_ISSUER": "https://login.example.com", "OIDC_SCOPES": "email"},
+			expected: []string{"OIDC_CLIENT_ID must be set", "OIDC_REDIRECT_URL", "OIDC_SCOPES must include openid"},
+		},
+		{
+			name: "malformed role mapping",
+			env: map[string]string{
+				"OIDC_ISSUER":       "https://login.example.com",
+				"OIDC_CLIENT_ID":    "bug-tracker",
+				"OIDC_REDIRECT_URL": "https://bugs.example.com/sso/callback",
+				"OIDC_ROLE_MAPPING": "bt-admins=admin,owners=Owners,qa",
+				"OIDC_DEFAULT_ROLE": "new guest",
+			},
+			expected: []string{`OIDC_ROLE_MAPPING: "owners=Owners"`, `OIDC_ROLE_MAPPING: "qa"`, "OIDC_DEFAULT_ROLE"},
+		},
+		{
+			name:     "malformed rate limit",
+			env:      map[string]string{"RATE_LIMIT": "lots"},
+			expected: []string{"RATE_LIMIT"},
+		},
+		{
+			name:     "malformed trusted proxy",
+			env:      map[string]string{"TRUSTED_PROXIES": "10.0.0.0/8,proxy.local"},
+			expected: []string{`"proxy.local" is not an IP address or CIDR range`},
+		},
+		{
+			name:     "negative timeout",
+			env:      map[string]string{"SHUTDOWN_TIMEOUT": "-1s"},
+			expected: []string{"SHUTDOWN_TIMEOUT must not be negative"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			if tt.file != "" {
+				t.Setenv("CONFIG_FILE", writeFile(t, "bugtracker.yaml", tt.file))
+			}
+
+			_, err := Load()
+			require.Error(t, err)
+			for _, expected := range tt.expected {
+				assert.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+}
+
+func TestOIDCRoles(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("OIDC_ISSUER", "https://login.example.com")
+	t.Setenv("OIDC_CLIENT_ID", "bug-tracker")
+	t.Setenv("OIDC_REDIRECT_URL", "https://bugs.example.com/sso/callback")
+	t.Setenv("OIDC_ROLE_MAPPING", "bt-admins = admin, qa=developer")
+	t.Setenv("OIDC_DEFAULT_ROLE", "none")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"bt-admins": "admin", "qa": "developer"}, cfg.OIDCRoles())
+	assert.Equal(t, "none", cfg.OIDCDefaultRole)
+}
+
+func TestLoadReleaseMode(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("GIN_MODE", "release")
+	t.Setenv("JWT_SECRET", "0123456789abcdef0123456789abcdef")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, ModeRelease, cfg.Mode)
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// loadFile applies the settings in a YAML or TOML file, chosen by its
+// extension. Unknown keys are errors so a typo doesn't go unnoticed.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	known := make(map[string]setting)
+	for _, s := range settings(c) {
+		known[s.key()] = s
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		raw := values[key]
+		s, ok := known[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown setting %q", path, key))
+			continue
+		}
+		value, err := fileValue(raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", path, key, err))
+			continue
+		}
+		if err := s.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", path, key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fileValue turns a decoded file value into the text form settings parse,
+// joining lists with commas
+func fileValue(raw interface{}) (string, error) {
+	switch v := raw.(type) {
+	case string:
+		return v, nil
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, err := fileValue(item)
+			if err != nil {
+				return "", err
+			}
+			if strings.Contains(s, ",") {
+				return "", fmt.Errorf("list item %q must not contain a comma", s)
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ","), nil
+	case map[string]interface{}:
+		return "", errors.New("expected a value, not a table")
+	case nil:
+		return "", errors.New("missing value")
+	default:
+		// Numbers and booleans
+		return fmt.Sprint(v), nil
+	}
+}
@@ -1,8 +1,13 @@
 package controller
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"bug-tracker/authz"
 	"bug-tracker/models"
 	"bug-tracker/usecase"
 
@@ -11,11 +16,13 @@ import (
 
 type AuthController struct {
 	authUseCase usecase.AuthUseCaseInterface
+	roles       *authz.Roles
 }
 
-func NewAuthController(authUseCase usecase.AuthUseCaseInterface) *AuthController {
+func NewAuthController(authUseCase usecase.AuthUseCaseInterface, roles *authz.Roles) *AuthController {
 	return &AuthController{
 		authUseCase: authUseCase,
+		roles:       roles,
 	}
 }
 
@@ -41,7 +48,7 @@ func (c *AuthController) Register(ctx *gin.Context) {
 		case usecase.ErrEmailAlreadyExists:
 			ctx.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
 		default:
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
+			internalError(ctx, "Failed to register user", err)
 		}
 		return
 	}
@@ -56,31 +63,98 @@ func (c *AuthController) Login(ctx *gin.Context) {
 		return
 	}
 
-	token, user, err := c.authUseCase.Login(ctx, req)
+	result, err := c.authUseCase.Login(ctx, req)
+	if lockedOut(ctx, err) {
+		return
+	}
 	if err != nil {
 		switch err {
 		case usecase.ErrUserNotFound:
 			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		case usecase.ErrInvalidPassword:
 			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		case usecase.ErrUserDeactivated:
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Account is deactivated"})
 		default:
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login"})
+			internalError(ctx, "Failed to login", err)
 		}
 		return
 	}
 
+	// The password was right but a second factor is needed first
+	if result.Token == "" {
+		ctx.JSON(http.StatusOK, result)
+		return
+	}
+
 	ctx.JSON(http.StatusOK, LoginResponse{
-		Token: token,
-		User:  *user,
+		Token: result.Token,
+		User:  *result.User,
 	})
 }
 
 func (c *AuthController) GetDevelopers(ctx *gin.Context) {
 	developers, err := c.authUseCase.GetDevelopers(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch developers"})
+		internalError(ctx, "Failed to fetch developers", err)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, developers)
 }
+
+// ListLockouts lists accounts with recent failed logins, for user managers
+func (c *AuthController) ListLockouts(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermUserManage) {
+		return
+	}
+
+	lockouts, err := c.authUseCase.ListLockouts(ctx)
+	if err != nil {
+		internalError(ctx, "Failed to fetch lockouts", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lockouts)
+}
+
+// Unlock clears an account's failed logins and lock, for user managers
+func (c *AuthController) Unlock(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermUserManage) {
+		return
+	}
+
+	userID, err := models.ParseID(ctx.Param("id"))
+	if err != nil || userID.IsZero() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := c.authUseCase.Unlock(ctx, userID); err != nil {
+		switch err {
+		case usecase.ErrUserNotFound:
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			internalError(ctx, "Failed to unlock account", err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// lockedOut responds 429 and reports true when err is a locked account
+func lockedOut(ctx *gin.Context, err error) bool {
+	var locked *usecase.AccountLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	ctx.Header("Retry-After", retryAfter(time.Until(locked.Until)))
+	ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed logins; try again later"})
+	return true
+}
+
+// retryAfter formats d as a Retry-After header value, in whole seconds
+func retryAfter(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(math.Max(d.Seconds(), 1))))
+}
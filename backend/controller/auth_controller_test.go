@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"bug-tracker/models"
 	"bug-tracker/usecase"
@@ -14,7 +15,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // MockAuthUseCase is a mock implementation of the AuthUseCaseInterface
@@ -25,11 +25,10 @@ type MockAuthUseCase struct {
 // Ensure MockAuthUseCase implements the interface
 var _ usecase.AuthUseCaseInterface = (*MockAuthUseCase)(nil)
 
-func (m *MockAuthUseCase) Login(ctx context.Context, req models.LoginRequest) (string, *models.UserResponse, error) {
+func (m *MockAuthUseCase) Login(ctx context.Context, req models.LoginRequest) (*models.LoginResult, error) {
 	args := m.Called(ctx, req)
-	token, _ := args.Get(0).(string)
-	user, _ := args.Get(1).(*models.UserResponse)
-	return token, user, args.Error(2)
+	result, _ := args.Get(0).(*models.LoginResult)
+	return result, args.Error(1)
 }
 
 func (m *MockAuthUseCase) Register(ctx context.Context, req models.RegisterRequest) (*models.UserResponse, error) {
@@ -45,14 +44,109 @@ func (m *MockAuthUseCase) GetDevelopers(ctx context.Context) ([]models.UserRespo
 	return args.Get(0).([]models.UserResponse), args.Error(1)
 }
 
-func (m *MockAuthUseCase) ValidateToken(tokenString string) (*models.User, error) {
-	args := m.Called(tokenString)
+func (m *MockAuthUseCase) ValidateToken(ctx context.Context, tokenString string) (*models.User, error) {
+	args := m.Called(ctx, tokenString)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockAuthUseCase) ResetPassword(ctx context.Context, email, password string) error {
+	args := m.Called(ctx, email, password)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) ListLockouts(ctx context.Context) ([]models.LockoutStatus, error) {
+	args := m.Called(ctx)
+	lockouts, _ := args.Get(0).([]models.LockoutStatus)
+	return lockouts, args.Error(1)
+}
+
+func (m *MockAuthUseCase) Unlock(ctx context.Context, id models.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) ChangeRole(ctx context.Context, id models.ID, role string) (*models.UserResponse, error) {
+	args := m.Called(ctx, id, role)
+	user, _ := args.Get(0).(*models.UserResponse)
+	return user, args.Error(1)
+}
+
+func (m *MockAuthUseCase) Deactivate(ctx context.Context, id models.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) Reactivate(ctx context.Context, id models.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) VerifyTwoFactor(ctx context.Context, challenge, code string) (*models.LoginResult, error) {
+	args := m.Called(ctx, challenge, code)
+	result, _ := args.Get(0).(*models.LoginResult)
+	return result, args.Error(1)
+}
+
+func (m *MockAuthUseCase) ValidateEnrollmentToken(ctx context.Context, tokenString string) (*models.User, error) {
+	args := m.Called(ctx, tokenString)
+	user, _ := args.Get(0).(*models.User)
+	return user, args.Error(1)
+}
+
+func (m *MockAuthUseCase) TwoFactorStatus(ctx context.Context, id models.ID) (*models.TwoFactorStatus, error) {
+	args := m.Called(ctx, id)
+	status, _ := args.Get(0).(*models.TwoFactorStatus)
+	return status, args.Error(1)
+}
+
+func (m *MockAuthUseCase) BeginTwoFactor(ctx context.Context, id models.ID) (*models.TwoFactorEnrollment, error) {
+	args := m.Called(ctx, id)
+	enrollment, _ := args.Get(0).(*models.TwoFactorEnrollment)
+	return enrollment, args.Error(1)
+}
+
+func (m *MockAuthUseCase) ConfirmTwoFactor(ctx context.Context, id models.ID, code string) (*models.TwoFactorConfirmation, error) {
+	args := m.Called(ctx, id, code)
+	confirmation, _ := args.Get(0).(*models.TwoFactorConfirmation)
+	return confirmation, args.Error(1)
+}
+
+func (m *MockAuthUseCase) RegenerateRecoveryCodes(ctx context.Context, id models.ID, code string) ([]string, error) {
+	args := m.Called(ctx, id, code)
+	codes, _ := args.Get(0).([]string)
+	return codes, args.Error(1)
+}
+
+func (m *MockAuthUseCase) DisableTwoFactor(ctx context.Context, id models.ID, code string) error {
+	args := m.Called(ctx, id, code)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) ResetTwoFactor(ctx context.Context, id models.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) BeginSSO(ctx context.Context) (*models.SSOAuthorization, error) {
+	args := m.Called(ctx)
+	authorization, _ := args.Get(0).(*models.SSOAuthorization)
+	return authorization, args.Error(1)
+}
+
+func (m *MockAuthUseCase) CompleteSSO(ctx context.Context, code, state, binding string) (*models.LoginResult, error) {
+	args := m.Called(ctx, code, state, binding)
+	result, _ := args.Get(0).(*models.LoginResult)
+	return result, args.Error(1)
+}
+
+func (m *MockAuthUseCase) PublicKeys() models.JSONWebKeySet {
+	args := m.Called()
+	return args.Get(0).(models.JSONWebKeySet)
+}
+
 func TestLogin(t *testing.T) {
 	// Set Gin to Test Mode
 	gin.SetMode(gin.TestMode)
@@ -71,19 +165,19 @@ func TestLogin(t *testing.T) {
 				Password: "password123",
 			},
 			mockResponse: func(m *MockAuthUseCase) {
-				userID, err := primitive.ObjectIDFromHex("680f741010571194baf681b1")
+				userID, err := models.ParseID("680f741010571194baf681b1")
 				if err != nil {
 					t.Fatal(err)
 				}
 				m.On("Login", mock.Anything, models.LoginRequest{
 					Email:    "test@example.com",
 					Password: "password123",
-				}).Return("token123", &models.UserResponse{
+				}).Return(&models.LoginResult{Token: "token123", User: &models.UserResponse{
 					ID:    userID,
 					Name:  "Test User",
 					Email: "test@example.com",
 					Role:  "developer",
-				}, nil)
+				}}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: map[string]interface{}{
@@ -106,13 +200,58 @@ func TestLogin(t *testing.T) {
 				m.On("Login", mock.Anything, models.LoginRequest{
 					Email:    "test@example.com",
 					Password: "wrongpassword",
-				}).Return("", nil, usecase.ErrInvalidPassword)
+				}).Return(nil, usecase.ErrInvalidPassword)
 			},
 			expectedStatus: http.StatusUnauthorized,
 			expectedBody: map[string]interface{}{
 				"error": "Invalid email or password",
 			},
 		},
+		{
+			name: "Deactivated Account",
+			payload: models.LoginRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("Login", mock.Anything, mock.Anything).Return(nil, usecase.ErrUserDeactivated)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody: map[string]interface{}{
+				"error": "Account is deactivated",
+			},
+		},
+		{
+			name: "Locked Account",
+			payload: models.LoginRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("Login", mock.Anything, mock.Anything).
+					Return(nil, &usecase.AccountLockedError{Until: time.Now().Add(time.Minute)})
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody: map[string]interface{}{
+				"error": "Too many failed logins; try again later",
+			},
+		},
+		{
+			name: "Two-Factor Challenge",
+			payload: models.LoginRequest{
+				Email:    "admin@example.com",
+				Password: "password123",
+			},
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("Login", mock.Anything, mock.Anything).
+					Return(&models.LoginResult{ChallengeToken: "challenge123", TwoFactorRequired: true}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"challenge_token":     "challenge123",
+				"two_factor_required": true,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -123,7 +262,7 @@ func TestLogin(t *testing.T) {
 			tt.mockResponse(mockAuth)
 
 			// Create the controller with the mock
-			controller := NewAuthController(mockAuth)
+			controller := NewAuthController(mockAuth, nil)
 
 			// Register the route
 			router.POST("/login", controller.Login)
@@ -173,7 +312,7 @@ func TestRegister(t *testing.T) {
 				Role:     "developer",
 			},
 			mockResponse: func(m *MockAuthUseCase) {
-				userID, err := primitive.ObjectIDFromHex("680f741010571194baf681b1")
+				userID, err := models.ParseID("680f741010571194baf681b1")
 				if err != nil {
 					t.Fatal(err)
 				}
@@ -230,7 +369,7 @@ func TestRegister(t *testing.T) {
 			tt.mockResponse(mockAuth)
 
 			// Create the controller with the mock
-			controller := NewAuthController(mockAuth)
+			controller := NewAuthController(mockAuth, nil)
 
 			// Register the route
 			router.POST("/register", controller.Register)
@@ -259,3 +398,71 @@ func TestRegister(t *testing.T) {
 		})
 	}
 }
+
+func TestUnlock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := models.NewID()
+	tests := []struct {
+		name           string
+		userRole       string
+		id             string
+		mockResponse   func(*MockAuthUseCase)
+		expectedStatus int
+	}{
+		{
+			name:     "Admin unlocks account",
+			userRole: "admin",
+			id:       userID.Hex(),
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("Unlock", mock.Anything, userID).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:     "Unknown user",
+			userRole: "admin",
+			id:       userID.Hex(),
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("Unlock", mock.Anything, userID).Return(usecase.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid ID",
+			userRole:       "admin",
+			id:             "not-an-id",
+			mockResponse:   func(m *MockAuthUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Managers may not unlock",
+			userRole:       "manager",
+			id:             userID.Hex(),
+			mockResponse:   func(m *MockAuthUseCase) {},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuth := new(MockAuthUseCase)
+			tt.mockResponse(mockAuth)
+			controller := NewAuthController(mockAuth, nil)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user", &models.User{Role: tt.userRole})
+				c.Next()
+			})
+			router.DELETE("/users/:id/lockout", controller.Unlock)
+
+			req, _ := http.NewRequest(http.MethodDelete, "/users/"+tt.id+"/lockout", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockAuth.AssertExpectations(t)
+		})
+	}
+}
@@ -1,25 +1,37 @@
 package controller
 
 import (
+	"bug-tracker/authz"
+	"bug-tracker/logging"
 	"bug-tracker/models"
 	"bug-tracker/usecase"
+	"errors"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type BugController struct {
 	bugUseCase usecase.BugUseCaseInterface
+	roles      *authz.Roles
 }
 
-func NewBugController(bugUseCase usecase.BugUseCaseInterface) *BugController {
+func NewBugController(bugUseCase usecase.BugUseCaseInterface, roles *authz.Roles) *BugController {
 	return &BugController{
 		bugUseCase: bugUseCase,
+		roles:      roles,
 	}
 }
 
 func (c *BugController) CreateBug(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermBugCreate) {
+		return
+	}
+
 	var req models.CreateBugRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -30,29 +42,43 @@ func (c *BugController) CreateBug(ctx *gin.Context) {
 
 	bug, err := c.bugUseCase.CreateBug(ctx, req, user.ID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bug"})
+		switch {
+		case errors.Is(err, usecase.ErrTemplateNotFound):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Template not found"})
+		case errors.Is(err, usecase.ErrMilestoneNotFound):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Milestone not found"})
+		case errors.Is(err, usecase.ErrInvalidFieldValue),
+			errors.Is(err, usecase.ErrMissingSections),
+			errors.Is(err, usecase.ErrIncompleteBug),
+			errors.Is(err, usecase.ErrInvalidLabel):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			internalError(ctx, "Failed to create bug", err)
+		}
 		return
 	}
 
 	ctx.JSON(http.StatusCreated, bug)
 }
 
+// GetBugs lists the bugs matching the filter query parameters, which
+// ExportBugs shares
 func (c *BugController) GetBugs(ctx *gin.Context) {
 	user := ctx.MustGet("user").(*models.User)
 
-	var bugs []*models.BugResponse
-	var err error
-
-	if user.Role == "developer" {
-		// Developers only see bugs assigned to them
-		bugs, err = c.bugUseCase.GetBugsByDeveloper(ctx, user.ID)
-	} else {
-		// Managers and admins see all bugs
-		bugs, err = c.bugUseCase.GetAllBugs(ctx)
+	filter, ok := parseBugFilter(ctx)
+	if !ok {
+		return
 	}
+	filter.InvolvedUser = c.visibleTo(user)
 
+	bugs, err := c.bugUseCase.ListBugs(ctx, filter)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bugs"})
+		if errors.Is(err, usecase.ErrInvalidFilter) || errors.Is(err, usecase.ErrInvalidSort) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		internalError(ctx, "Failed to fetch bugs", err)
 		return
 	}
 
@@ -66,7 +92,7 @@ func (c *BugController) UpdateBugStatus(ctx *gin.Context) {
 		return
 	}
 
-	bugID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	bugID, err := models.ParseID(ctx.Param("id"))
 	if err != nil || bugID.IsZero() {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bug ID"})
 		return
@@ -74,7 +100,7 @@ func (c *BugController) UpdateBugStatus(ctx *gin.Context) {
 
 	user := ctx.MustGet("user").(*models.User)
 
-	bug, err := c.bugUseCase.UpdateBugStatus(ctx, bugID, req.Status, user.ID)
+	bug, err := c.bugUseCase.UpdateBugStatus(ctx, bugID, req.Status, user)
 	if err != nil {
 		switch err {
 		case usecase.ErrBugNotFound:
@@ -82,7 +108,7 @@ func (c *BugController) UpdateBugStatus(ctx *gin.Context) {
 		case usecase.ErrUnauthorized:
 			ctx.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to update this bug"})
 		default:
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug status"})
+			internalError(ctx, "Failed to update bug status", err)
 		}
 		return
 	}
@@ -97,15 +123,13 @@ func (c *BugController) AssignBug(ctx *gin.Context) {
 		return
 	}
 
-	bugID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	bugID, err := models.ParseID(ctx.Param("id"))
 	if err != nil || bugID.IsZero() {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bug ID"})
 		return
 	}
 
-	user := ctx.MustGet("user").(*models.User)
-	if user.Role != "manager" && user.Role != "admin" {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only managers and admins can assign bugs"})
+	if !authorize(ctx, c.roles, models.PermBugAssign) {
 		return
 	}
 
@@ -114,8 +138,10 @@ func (c *BugController) AssignBug(ctx *gin.Context) {
 		switch err {
 		case usecase.ErrBugNotFound:
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "Bug not found"})
+		case usecase.ErrNotAssignable:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "This user can't be assigned bugs"})
 		default:
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign bug"})
+			internalError(ctx, "Failed to assign bug", err)
 		}
 		return
 	}
@@ -124,23 +150,30 @@ func (c *BugController) AssignBug(ctx *gin.Context) {
 }
 
 func (c *BugController) GetBugByID(ctx *gin.Context) {
-	bugID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	bugID, err := models.ParseID(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bug ID"})
 		return
 	}
 
+	user := ctx.MustGet("user").(*models.User)
+
 	bug, err := c.bugUseCase.GetBugByID(ctx, bugID)
 	if err != nil {
 		switch err {
 		case usecase.ErrBugNotFound:
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "Bug not found"})
 		default:
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bug"})
+			internalError(ctx, "Failed to fetch bug", err)
 		}
 		return
 	}
 
+	if involved := c.visibleTo(user); involved != nil && !bug.Involves(*involved) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to view this bug"})
+		return
+	}
+
 	ctx.JSON(http.StatusOK, bug)
 }
 
@@ -151,7 +184,7 @@ func (c *BugController) UpdateBug(ctx *gin.Context) {
 		return
 	}
 
-	bugID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	bugID, err := models.ParseID(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bug ID"})
 		return
@@ -161,13 +194,19 @@ func (c *BugController) UpdateBug(ctx *gin.Context) {
 
 	bug, err := c.bugUseCase.UpdateBug(ctx, bugID, req, user)
 	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidFieldValue) || errors.Is(err, usecase.ErrInvalidLabel) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		switch err {
 		case usecase.ErrBugNotFound:
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "Bug not found"})
+		case usecase.ErrMilestoneNotFound:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Milestone not found"})
 		case usecase.ErrUnauthorized:
 			ctx.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to update this bug"})
 		default:
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bug"})
+			internalError(ctx, "Failed to update bug", err)
 		}
 		return
 	}
@@ -176,17 +215,13 @@ func (c *BugController) UpdateBug(ctx *gin.Context) {
 }
 
 func (c *BugController) DeleteBug(ctx *gin.Context) {
-	bugID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	bugID, err := models.ParseID(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bug ID"})
 		return
 	}
 
-	user := ctx.MustGet("user").(*models.User)
-
-	// Only allow deletion by managers or admins
-	if user.Role != "manager" && user.Role != "admin" {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only managers and admins can delete bugs"})
+	if !authorize(ctx, c.roles, models.PermBugDelete) {
 		return
 	}
 
@@ -196,10 +231,180 @@ func (c *BugController) DeleteBug(ctx *gin.Context) {
 		case usecase.ErrBugNotFound:
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "Bug not found"})
 		default:
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bug"})
+			internalError(ctx, "Failed to delete bug", err)
 		}
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Bug deleted successfully"})
 }
+
+// SearchBugs runs a full-text search over bug titles and descriptions
+func (c *BugController) SearchBugs(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*models.User)
+
+	search := models.BugSearch{Query: strings.TrimSpace(ctx.Query("q"))}
+	if search.Query == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' is required"})
+		return
+	}
+	if limit := ctx.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		search.Limit = n
+	}
+
+	search.InvolvedUser = c.visibleTo(user)
+
+	bugs, err := c.bugUseCase.SearchBugs(ctx, search)
+	if err != nil {
+		internalError(ctx, "Failed to search bugs", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, bugs)
+}
+
+func (c *BugController) ExportBugs(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*models.User)
+
+	opts := models.ExportOptions{
+		Format: ctx.DefaultQuery("format", "csv"),
+		BOM:    ctx.Query("bom") == "true",
+	}
+	if columns := ctx.Query("columns"); columns != "" {
+		opts.Columns = strings.Split(columns, ",")
+	}
+	if err := usecase.NormalizeExportOptions(&opts); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, ok := parseBugFilter(ctx)
+	if !ok {
+		return
+	}
+	filter.InvolvedUser = c.visibleTo(user)
+	// Bad field filters and columns must be reported before the headers go
+	// out
+	if err := c.bugUseCase.NormalizeFilter(ctx, &filter); err != nil {
+		if errors.Is(err, usecase.ErrInvalidFilter) || errors.Is(err, usecase.ErrInvalidSort) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		internalError(ctx, "Failed to export bugs", err)
+		return
+	}
+	if err := c.bugUseCase.CheckExportColumns(ctx, opts.Columns); err != nil {
+		if errors.Is(err, usecase.ErrInvalidExportColumn) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		internalError(ctx, "Failed to export bugs", err)
+		return
+	}
+
+	filename := "bugs-" + time.Now().UTC().Format("20060102-150405")
+	if opts.Format == "csv" {
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		filename += ".csv"
+	} else {
+		ctx.Header("Content-Type", "application/x-ndjson")
+		filename += ".ndjson"
+	}
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	ctx.Status(http.StatusOK)
+
+	// Large exports legitimately outlast the server's write timeout
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		logging.FromContext(ctx).Warn("failed to lift write deadline for export", "error", err)
+	}
+
+	if err := c.bugUseCase.ExportBugs(ctx, filter, opts, ctx.Writer); err != nil {
+		// Headers are already sent, so the client just sees a truncated file
+		logging.FromContext(ctx).Error("bug export failed", "error", err)
+		ctx.Abort()
+	}
+}
+
+// visibleTo returns nil when the user may see every bug. Without the
+// bug.view permission users only see bugs they reported or are assigned, so
+// it returns their own ID to restrict listings, searches and exports to those.
+func (c *BugController) visibleTo(user *models.User) *models.ID {
+	if c.roles.Can(user.Role, models.PermBugView) {
+		return nil
+	}
+	return &user.ID
+}
+
+// parseBugFilter reads the filter query parameters of bug listings: status,
+// priority, assigned_to, reported_by, from, to, "fields.<key>" for custom
+// fields and sort, a sort key prefixed with "-" for descending order. It
+// responds 400 and returns false when one is malformed.
+func parseBugFilter(ctx *gin.Context) (models.BugFilter, bool) {
+	filter := models.BugFilter{
+		Status:   ctx.Query("status"),
+		Priority: ctx.Query("priority"),
+	}
+	var err error
+	if filter.AssignedTo, err = parseIDParam(ctx.Query("assigned_to")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assigned_to"})
+		return filter, false
+	}
+	if filter.ReportedBy, err = parseIDParam(ctx.Query("reported_by")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reported_by"})
+		return filter, false
+	}
+	if filter.AffectedVersion, err = parseIDParam(ctx.Query("affected_version")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid affected_version"})
+		return filter, false
+	}
+	if filter.FixVersion, err = parseIDParam(ctx.Query("fix_version")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fix_version"})
+		return filter, false
+	}
+	if filter.From, err = parseDateParam(ctx.Query("from"), false); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' date"})
+		return filter, false
+	}
+	if filter.To, err = parseDateParam(ctx.Query("to"), true); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' date"})
+		return filter, false
+	}
+
+	query := ctx.Request.URL.Query()
+	keys := make([]string, 0, len(query))
+	for param := range query {
+		if key, ok := strings.CutPrefix(param, models.FieldPrefix); ok {
+			keys = append(keys, key)
+		}
+	}
+	// Map order is random; keep the filter stable for the same query
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range query[models.FieldPrefix+key] {
+			filter.Fields = append(filter.Fields, models.FieldFilter{Key: key, Value: value})
+		}
+	}
+
+	if key := ctx.Query("sort"); key != "" {
+		filter.Sort.Key, filter.Sort.Desc = strings.CutPrefix(key, "-")
+	}
+	return filter, true
+}
+
+// parseIDParam parses an optional ID query parameter, returning nil when
+// it is empty
+func parseIDParam(value string) (*models.ID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := models.ParseID(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
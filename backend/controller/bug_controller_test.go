@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -13,7 +15,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"bug-tracker/models"
 	"bug-tracker/usecase"
@@ -27,7 +28,7 @@ type MockBugUseCase struct {
 // Ensure MockBugUseCase implements BugUseCaseInterface
 var _ usecase.BugUseCaseInterface = (*MockBugUseCase)(nil)
 
-func (m *MockBugUseCase) CreateBug(ctx context.Context, req models.CreateBugRequest, reporterID primitive.ObjectID) (*models.BugResponse, error) {
+func (m *MockBugUseCase) CreateBug(ctx context.Context, req models.CreateBugRequest, reporterID models.ID) (*models.BugResponse, error) {
 	args := m.Called(ctx, req, reporterID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -35,7 +36,7 @@ func (m *MockBugUseCase) CreateBug(ctx context.Context, req models.CreateBugRequ
 	return args.Get(0).(*models.BugResponse), args.Error(1)
 }
 
-func (m *MockBugUseCase) GetBugByID(ctx context.Context, id primitive.ObjectID) (*models.BugResponse, error) {
+func (m *MockBugUseCase) GetBugByID(ctx context.Context, id models.ID) (*models.BugResponse, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -51,7 +52,20 @@ func (m *MockBugUseCase) GetAllBugs(ctx context.Context) ([]*models.BugResponse,
 	return args.Get(0).([]*models.BugResponse), args.Error(1)
 }
 
-func (m *MockBugUseCase) GetBugsByDeveloper(ctx context.Context, developerID primitive.ObjectID) ([]*models.BugResponse, error) {
+func (m *MockBugUseCase) ListBugs(ctx context.Context, filter models.BugFilter) ([]*models.BugResponse, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.BugResponse), args.Error(1)
+}
+
+func (m *MockBugUseCase) NormalizeFilter(ctx context.Context, filter *models.BugFilter) error {
+	args := m.Called(ctx, *filter)
+	return args.Error(0)
+}
+
+func (m *MockBugUseCase) GetBugsByDeveloper(ctx context.Context, developerID models.ID) ([]*models.BugResponse, error) {
 	args := m.Called(ctx, developerID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -59,15 +73,15 @@ func (m *MockBugUseCase) GetBugsByDeveloper(ctx context.Context, developerID pri
 	return args.Get(0).([]*models.BugResponse), args.Error(1)
 }
 
-func (m *MockBugUseCase) UpdateBugStatus(ctx context.Context, bugID primitive.ObjectID, status string, userID primitive.ObjectID) (*models.BugResponse, error) {
-	args := m.Called(ctx, bugID, status, userID)
+func (m *MockBugUseCase) UpdateBugStatus(ctx context.Context, bugID models.ID, status string, user *models.User) (*models.BugResponse, error) {
+	args := m.Called(ctx, bugID, status, user.ID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.BugResponse), args.Error(1)
 }
 
-func (m *MockBugUseCase) AssignBug(ctx context.Context, bugID, developerID primitive.ObjectID) (*models.BugResponse, error) {
+func (m *MockBugUseCase) AssignBug(ctx context.Context, bugID, developerID models.ID) (*models.BugResponse, error) {
 	args := m.Called(ctx, bugID, developerID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -75,7 +89,7 @@ func (m *MockBugUseCase) AssignBug(ctx context.Context, bugID, developerID primi
 	return args.Get(0).(*models.BugResponse), args.Error(1)
 }
 
-func (m *MockBugUseCase) UpdateBug(ctx context.Context, id primitive.ObjectID, req models.UpdateBugRequest, user *models.User) (*models.BugResponse, error) {
+func (m *MockBugUseCase) UpdateBug(ctx context.Context, id models.ID, req models.UpdateBugRequest, user *models.User) (*models.BugResponse, error) {
 	args := m.Called(ctx, id, req, user)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -83,21 +97,39 @@ func (m *MockBugUseCase) UpdateBug(ctx context.Context, id primitive.ObjectID, r
 	return args.Get(0).(*models.BugResponse), args.Error(1)
 }
 
-func (m *MockBugUseCase) DeleteBug(ctx context.Context, id primitive.ObjectID) error {
+func (m *MockBugUseCase) DeleteBug(ctx context.Context, id models.ID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockBugUseCase) CheckExportColumns(ctx context.Context, columns []string) error {
+	args := m.Called(ctx, columns)
+	return args.Error(0)
+}
+
+func (m *MockBugUseCase) ExportBugs(ctx context.Context, filter models.BugFilter, opts models.ExportOptions, w io.Writer) error {
+	args := m.Called(ctx, filter, opts, w)
+	return args.Error(0)
+}
+
+func (m *MockBugUseCase) SearchBugs(ctx context.Context, search models.BugSearch) ([]*models.BugResponse, error) {
+	args := m.Called(ctx, search)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.BugResponse), args.Error(1)
+}
+
 func TestCreateBug(t *testing.T) {
 	// Set Gin to Test Mode
 	gin.SetMode(gin.TestMode)
 
 	// Create fixed ObjectIDs for testing
-	fixedBugID, err := primitive.ObjectIDFromHex("680f74774848325f4e61925c")
+	fixedBugID, err := models.ParseID("680f74774848325f4e61925c")
 	if err != nil {
 		t.Fatal(err)
 	}
-	fixedUserID, err := primitive.ObjectIDFromHex("680f74774848325f4e61925d")
+	fixedUserID, err := models.ParseID("680f74774848325f4e61925d")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -154,6 +186,38 @@ func TestCreateBug(t *testing.T) {
 				"updated_at": "0001-01-01T00:00:00Z",
 			},
 		},
+		{
+			name: "Invalid Custom Field Value",
+			payload: models.CreateBugRequest{
+				Title:       "Test Bug",
+				Description: "This is a test bug",
+				Priority:    "high",
+				Fields:      map[string]interface{}{"environment": "moon"},
+			},
+			mockResponse: func(m *MockBugUseCase) {
+				m.On("CreateBug", mock.Anything, mock.Anything, fixedUserID).
+					Return(nil, fmt.Errorf("%w: %q must be one of prod, staging", usecase.ErrInvalidFieldValue, "environment"))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"error": `invalid custom field value: "environment" must be one of prod, staging`,
+			},
+		},
+		{
+			name: "Template Sections Left Empty",
+			payload: models.CreateBugRequest{
+				TemplateID: fixedBugID,
+				Title:      "Test Bug",
+			},
+			mockResponse: func(m *MockBugUseCase) {
+				m.On("CreateBug", mock.Anything, mock.Anything, fixedUserID).
+					Return(nil, fmt.Errorf("%w: %s", usecase.ErrMissingSections, "Steps to reproduce"))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"error": "required sections are empty: Steps to reproduce",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -163,14 +227,14 @@ func TestCreateBug(t *testing.T) {
 			tt.mockResponse(mockBugUseCase)
 
 			// Create a new controller with the mock
-			bugController := NewBugController(mockBugUseCase)
+			bugController := NewBugController(mockBugUseCase, nil)
 
 			// Create a new Gin router
 			router := gin.New()
 
 			// Add middleware to set user in context
 			router.Use(func(c *gin.Context) {
-				c.Set("user", &models.User{ID: fixedUserID})
+				c.Set("user", &models.User{ID: fixedUserID, Role: "developer"})
 				c.Next()
 			})
 
@@ -209,18 +273,29 @@ func TestGetBugByID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	// Create fixed ObjectIDs for testing
-	fixedBugID, err := primitive.ObjectIDFromHex("680f74774848325f4e61925c")
+	fixedBugID, err := models.ParseID("680f74774848325f4e61925c")
 	if err != nil {
 		t.Fatal(err)
 	}
-	fixedUserID, err := primitive.ObjectIDFromHex("680f74774848325f4e61925d")
+	fixedUserID, err := models.ParseID("680f74774848325f4e61925d")
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	fixedAssigneeID := models.NewID()
+	assignedBug := func() *models.BugResponse {
+		return &models.BugResponse{
+			ID:         fixedBugID,
+			Title:      "Test Bug",
+			ReportedBy: models.UserResponse{ID: fixedUserID},
+			AssignedTo: &models.UserResponse{ID: fixedAssigneeID},
+		}
+	}
+
 	tests := []struct {
 		name           string
-		bugID          primitive.ObjectID
+		bugID          models.ID
+		user           *models.User
 		mockResponse   func(*MockBugUseCase)
 		expectedStatus int
 		expectedBody   map[string]interface{}
@@ -228,6 +303,7 @@ func TestGetBugByID(t *testing.T) {
 		{
 			name:  "Successful Bug Retrieval",
 			bugID: fixedBugID,
+			user:  &models.User{ID: models.NewID(), Role: "manager"},
 			mockResponse: func(m *MockBugUseCase) {
 				m.On("GetBugByID", mock.Anything, fixedBugID).Return(&models.BugResponse{
 					ID:          fixedBugID,
@@ -265,6 +341,7 @@ func TestGetBugByID(t *testing.T) {
 		{
 			name:  "Bug Not Found",
 			bugID: fixedBugID,
+			user:  &models.User{ID: models.NewID(), Role: "manager"},
 			mockResponse: func(m *MockBugUseCase) {
 				m.On("GetBugByID", mock.Anything, fixedBugID).Return(nil, usecase.ErrBugNotFound)
 			},
@@ -273,6 +350,36 @@ func TestGetBugByID(t *testing.T) {
 				"error": "Bug not found",
 			},
 		},
+		{
+			name:  "Reporter Without bug.view",
+			bugID: fixedBugID,
+			user:  &models.User{ID: fixedUserID, Role: "developer"},
+			mockResponse: func(m *MockBugUseCase) {
+				m.On("GetBugByID", mock.Anything, fixedBugID).Return(assignedBug(), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "Assignee Without bug.view",
+			bugID: fixedBugID,
+			user:  &models.User{ID: fixedAssigneeID, Role: "developer"},
+			mockResponse: func(m *MockBugUseCase) {
+				m.On("GetBugByID", mock.Anything, fixedBugID).Return(assignedBug(), nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "Other Developer",
+			bugID: fixedBugID,
+			user:  &models.User{ID: models.NewID(), Role: "developer"},
+			mockResponse: func(m *MockBugUseCase) {
+				m.On("GetBugByID", mock.Anything, fixedBugID).Return(assignedBug(), nil)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody: map[string]interface{}{
+				"error": "Not authorized to view this bug",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -282,11 +389,17 @@ func TestGetBugByID(t *testing.T) {
 			tt.mockResponse(mockBugUseCase)
 
 			// Create a new controller with the mock
-			bugController := NewBugController(mockBugUseCase)
+			bugController := NewBugController(mockBugUseCase, nil)
 
 			// Create a new Gin router
 			router := gin.New()
 
+			// Add middleware to set user in context
+			router.Use(func(c *gin.Context) {
+				c.Set("user", tt.user)
+				c.Next()
+			})
+
 			router.GET("/bugs/:id", bugController.GetBugByID)
 
 			// Create a request
@@ -307,7 +420,9 @@ func TestGetBugByID(t *testing.T) {
 			assert.NoError(t, err)
 
 			// Assert the response body
-			assert.Equal(t, tt.expectedBody, response)
+			if tt.expectedBody != nil {
+				assert.Equal(t, tt.expectedBody, response)
+			}
 
 			// Verify that the mock was called as expected
 			mockBugUseCase.AssertExpectations(t)
@@ -320,15 +435,15 @@ func TestGetBugs(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	// Create fixed ObjectIDs for testing
-	fixedBugID1, err := primitive.ObjectIDFromHex("680f74774848325f4e61925c")
+	fixedBugID1, err := models.ParseID("680f74774848325f4e61925c")
 	if err != nil {
 		t.Fatal(err)
 	}
-	fixedBugID2, err := primitive.ObjectIDFromHex("680f74774848325f4e61925d")
+	fixedBugID2, err := models.ParseID("680f74774848325f4e61925d")
 	if err != nil {
 		t.Fatal(err)
 	}
-	fixedUserID, err := primitive.ObjectIDFromHex("680f74774848325f4e61925e")
+	fixedUserID, err := models.ParseID("680f74774848325f4e61925e")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -336,6 +451,7 @@ func TestGetBugs(t *testing.T) {
 	tests := []struct {
 		name           string
 		userRole       string
+		query          string
 		mockResponse   func(*MockBugUseCase)
 		expectedStatus int
 		expectedBody   interface{}
@@ -344,7 +460,7 @@ func TestGetBugs(t *testing.T) {
 			name:     "Get All Bugs as Manager",
 			userRole: "manager",
 			mockResponse: func(m *MockBugUseCase) {
-				m.On("GetAllBugs", mock.Anything).Return([]*models.BugResponse{
+				m.On("ListBugs", mock.Anything, models.BugFilter{}).Return([]*models.BugResponse{
 					{
 						ID:          fixedBugID1,
 						Title:       "Test Bug 1",
@@ -415,7 +531,7 @@ func TestGetBugs(t *testing.T) {
 			name:     "Get Developer's Bugs",
 			userRole: "developer",
 			mockResponse: func(m *MockBugUseCase) {
-				m.On("GetBugsByDeveloper", mock.Anything, fixedUserID).Return([]*models.BugResponse{
+				m.On("ListBugs", mock.Anything, models.BugFilter{InvolvedUser: &fixedUserID}).Return([]*models.BugResponse{
 					{
 						ID:          fixedBugID1,
 						Title:       "Test Bug 1",
@@ -456,13 +572,43 @@ func TestGetBugs(t *testing.T) {
 			name:     "Error Getting Bugs",
 			userRole: "manager",
 			mockResponse: func(m *MockBugUseCase) {
-				m.On("GetAllBugs", mock.Anything).Return(nil, errors.New("database error"))
+				m.On("ListBugs", mock.Anything, models.BugFilter{}).Return(nil, errors.New("database error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody: map[string]interface{}{
 				"error": "Failed to fetch bugs",
 			},
 		},
+		{
+			name:     "Filter and Sort by Custom Fields",
+			userRole: "manager",
+			query:    "?status=open&fields.environment=prod&fields.browsers=firefox&sort=-fields.severity",
+			mockResponse: func(m *MockBugUseCase) {
+				m.On("ListBugs", mock.Anything, models.BugFilter{
+					Status: "open",
+					Fields: []models.FieldFilter{
+						{Key: "browsers", Value: "firefox"},
+						{Key: "environment", Value: "prod"},
+					},
+					Sort: models.BugSort{Key: "fields.severity", Desc: true},
+				}).Return([]*models.BugResponse{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []interface{}{},
+		},
+		{
+			name:     "Invalid Sort",
+			userRole: "manager",
+			query:    "?sort=priority",
+			mockResponse: func(m *MockBugUseCase) {
+				m.On("ListBugs", mock.Anything, models.BugFilter{Sort: models.BugSort{Key: "priority"}}).
+					Return(nil, fmt.Errorf("%w %q", usecase.ErrInvalidSort, "priority"))
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"error": `invalid sort "priority"`,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -472,7 +618,7 @@ func TestGetBugs(t *testing.T) {
 			tt.mockResponse(mockBugUseCase)
 
 			// Create a new controller with the mock
-			bugController := NewBugController(mockBugUseCase)
+			bugController := NewBugController(mockBugUseCase, nil)
 
 			// Create a new Gin router
 			router := gin.New()
@@ -489,7 +635,7 @@ func TestGetBugs(t *testing.T) {
 			router.GET("/bugs", bugController.GetBugs)
 
 			// Create a request
-			req, _ := http.NewRequest("GET", "/bugs", nil)
+			req, _ := http.NewRequest("GET", "/bugs"+tt.query, nil)
 
 			// Create a response recorder
 			w := httptest.NewRecorder()
@@ -519,18 +665,18 @@ func TestUpdateBugStatus(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	// Create fixed ObjectIDs for testing
-	fixedBugID, err := primitive.ObjectIDFromHex("680f74774848325f4e61925c")
+	fixedBugID, err := models.ParseID("680f74774848325f4e61925c")
 	if err != nil {
 		t.Fatal(err)
 	}
-	fixedUserID, err := primitive.ObjectIDFromHex("680f74774848325f4e61925d")
+	fixedUserID, err := models.ParseID("680f74774848325f4e61925d")
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	tests := []struct {
 		name           string
-		bugID          primitive.ObjectID
+		bugID          models.ID
 		payload        models.UpdateBugStatusRequest
 		mockResponse   func(*MockBugUseCase)
 		expectedStatus int
@@ -606,7 +752,7 @@ func TestUpdateBugStatus(t *testing.T) {
 		},
 		{
 			name:  "Invalid Bug ID",
-			bugID: primitive.ObjectID{},
+			bugID: models.ID{},
 			payload: models.UpdateBugStatusRequest{
 				Status: "in-progress",
 			},
@@ -627,7 +773,7 @@ func TestUpdateBugStatus(t *testing.T) {
 			tt.mockResponse(mockBugUseCase)
 
 			// Create a new controller with the mock
-			bugController := NewBugController(mockBugUseCase)
+			bugController := NewBugController(mockBugUseCase, nil)
 
 			// Create a new Gin router
 			router := gin.New()
@@ -673,23 +819,23 @@ func TestAssignBug(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	// Create fixed ObjectIDs for testing
-	fixedBugID, err := primitive.ObjectIDFromHex("680f74774848325f4e61925c")
+	fixedBugID, err := models.ParseID("680f74774848325f4e61925c")
 	if err != nil {
 		t.Fatal(err)
 	}
-	fixedUserID, err := primitive.ObjectIDFromHex("680f74774848325f4e61925d")
+	fixedUserID, err := models.ParseID("680f74774848325f4e61925d")
 	if err != nil {
 		t.Fatal(err)
 	}
-	fixedDeveloperID, err := primitive.ObjectIDFromHex("680f74774848325f4e61925e")
+	fixedDeveloperID, err := models.ParseID("680f74774848325f4e61925e")
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	tests := []struct {
 		name           string
-		bugID          primitive.ObjectID
-		developerID    primitive.ObjectID
+		bugID          models.ID
+		developerID    models.ID
 		userRole       string
 		mockResponse   func(*MockBugUseCase)
 		expectedStatus int
@@ -756,7 +902,7 @@ func TestAssignBug(t *testing.T) {
 			},
 			expectedStatus: http.StatusForbidden,
 			expectedBody: map[string]interface{}{
-				"error": "Only managers and admins can assign bugs",
+				"error": "Your role lacks the bug.assign permission",
 			},
 		},
 		{
@@ -781,7 +927,7 @@ func TestAssignBug(t *testing.T) {
 			tt.mockResponse(mockBugUseCase)
 
 			// Create a new controller with the mock
-			bugController := NewBugController(mockBugUseCase)
+			bugController := NewBugController(mockBugUseCase, nil)
 
 			// Create a new Gin router
 			router := gin.New()
@@ -832,18 +978,18 @@ func TestUpdateBug(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	// Create fixed ObjectIDs for testing
-	fixedBugID, err := primitive.ObjectIDFromHex("680f74774848325f4e61925c")
+	fixedBugID, err := models.ParseID("680f74774848325f4e61925c")
 	if err != nil {
 		t.Fatal(err)
 	}
-	fixedUserID, err := primitive.ObjectIDFromHex("680f74774848325f4e61925d")
+	fixedUserID, err := models.ParseID("680f74774848325f4e61925d")
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	tests := []struct {
 		name           string
-		bugID          primitive.ObjectID
+		bugID          models.ID
 		payload        models.UpdateBugRequest
 		userRole       string
 		mockResponse   func(*MockBugUseCase)
@@ -940,7 +1086,7 @@ func TestUpdateBug(t *testing.T) {
 			tt.mockResponse(mockBugUseCase)
 
 			// Create a new controller with the mock
-			bugController := NewBugController(mockBugUseCase)
+			bugController := NewBugController(mockBugUseCase, nil)
 
 			// Create a new Gin router
 			router := gin.New()
@@ -989,18 +1135,18 @@ func TestDeleteBug(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	// Create fixed ObjectIDs for testing
-	fixedBugID, err := primitive.ObjectIDFromHex("680f74774848325f4e61925c")
+	fixedBugID, err := models.ParseID("680f74774848325f4e61925c")
 	if err != nil {
 		t.Fatal(err)
 	}
-	fixedUserID, err := primitive.ObjectIDFromHex("680f74774848325f4e61925d")
+	fixedUserID, err := models.ParseID("680f74774848325f4e61925d")
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	tests := []struct {
 		name           string
-		bugID          primitive.ObjectID
+		bugID          models.ID
 		userRole       string
 		mockResponse   func(*MockBugUseCase)
 		expectedStatus int
@@ -1027,7 +1173,7 @@ func TestDeleteBug(t *testing.T) {
 			},
 			expectedStatus: http.StatusForbidden,
 			expectedBody: map[string]interface{}{
-				"error": "Only managers and admins can delete bugs",
+				"error": "Your role lacks the bug.delete permission",
 			},
 		},
 		{
@@ -1051,7 +1197,7 @@ func TestDeleteBug(t *testing.T) {
 			tt.mockResponse(mockBugUseCase)
 
 			// Create a new controller with the mock
-			bugController := NewBugController(mockBugUseCase)
+			bugController := NewBugController(mockBugUseCase, nil)
 
 			// Create a new Gin router
 			router := gin.New()
@@ -1092,3 +1238,231 @@ func TestDeleteBug(t *testing.T) {
 		})
 	}
 }
+
+func TestExportBugs(t *testing.T) {
+	// Set Gin to Test Mode
+	gin.SetMode(gin.TestMode)
+
+	fixedUserID, err := models.ParseID("680f74774848325f4e61925e")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		userRole        string
+		query           string
+		mockResponse    func(*MockBugUseCase)
+		expectedStatus  int
+		expectedType    string
+		expectedContent string
+	}{
+		{
+			name:     "CSV Export as Manager",
+			userRole: "manager",
+			query:    "?status=open&columns=id,title",
+			mockResponse: func(m *MockBugUseCase) {
+				m.On("NormalizeFilter", mock.Anything, models.BugFilter{Status: "open"}).Return(nil)
+				m.On("CheckExportColumns", mock.Anything, []string{"id", "title"}).Return(nil)
+				m.On("ExportBugs", mock.Anything, models.BugFilter{Status: "open"}, models.ExportOptions{
+					Format:  "csv",
+					Columns: []string{"id", "title"},
+				}, mock.Anything).Run(func(args mock.Arguments) {
+					_, _ = args.Get(3).(io.Writer).Write([]byte("id,title\n"))
+				}).Return(nil)
+			},
+			expectedStatus:  http.StatusOK,
+			expectedType:    "text/csv; charset=utf-8",
+			expectedContent: "id,title\n",
+		},
+		{
+			name:     "Developer Export Limited to Assigned Bugs",
+			userRole: "developer",
+			query:    "?format=ndjson",
+			mockResponse: func(m *MockBugUseCase) {
+				m.On("NormalizeFilter", mock.Anything, models.BugFilter{InvolvedUser: &fixedUserID}).Return(nil)
+				m.On("CheckExportColumns", mock.Anything, models.DefaultExportColumns).Return(nil)
+				m.On("ExportBugs", mock.Anything, models.BugFilter{InvolvedUser: &fixedUserID}, models.ExportOptions{
+					Format:  "ndjson",
+					Columns: models.DefaultExportColumns,
+				}, mock.Anything).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedType:   "application/x-ndjson",
+		},
+		{
+			name:            "Invalid Format",
+			userRole:        "manager",
+			query:           "?format=xlsx",
+			mockResponse:    func(m *MockBugUseCase) {},
+			expectedStatus:  http.StatusBadRequest,
+			expectedType:    "application/json; charset=utf-8",
+			expectedContent: `{"error":"export format must be csv or ndjson"}`,
+		},
+		{
+			name:            "Invalid Assignee",
+			userRole:        "manager",
+			query:           "?assigned_to=nope",
+			mockResponse:    func(m *MockBugUseCase) {},
+			expectedStatus:  http.StatusBadRequest,
+			expectedType:    "application/json; charset=utf-8",
+			expectedContent: `{"error":"Invalid assigned_to"}`,
+		},
+		{
+			name:     "Unknown Custom Field",
+			userRole: "manager",
+			query:    "?fields.nope=1",
+			mockResponse: func(m *MockBugUseCase) {
+				m.On("NormalizeFilter", mock.Anything, models.BugFilter{Fields: []models.FieldFilter{{Key: "nope", Value: "1"}}}).
+					Return(fmt.Errorf("%w: unknown field %q", usecase.ErrInvalidFilter, "nope"))
+			},
+			expectedStatus:  http.StatusBadRequest,
+			expectedType:    "application/json; charset=utf-8",
+			expectedContent: `{"error":"invalid filter: unknown field \"nope\""}`,
+		},
+		{
+			name:     "Unknown Custom Field Column",
+			userRole: "manager",
+			query:    "?columns=id,fields.nope",
+			mockResponse: func(m *MockBugUseCase) {
+				m.On("NormalizeFilter", mock.Anything, models.BugFilter{}).Return(nil)
+				m.On("CheckExportColumns", mock.Anything, []string{"id", "fields.nope"}).
+					Return(fmt.Errorf("%w %q", usecase.ErrInvalidExportColumn, "fields.nope"))
+			},
+			expectedStatus:  http.StatusBadRequest,
+			expectedType:    "application/json; charset=utf-8",
+			expectedContent: `{"error":"unknown export column \"fields.nope\""}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create a new mock
+			mockBugUseCase := new(MockBugUseCase)
+			tt.mockResponse(mockBugUseCase)
+
+			// Create a new controller with the mock
+			bugController := NewBugController(mockBugUseCase, nil)
+
+			// Create a new Gin router
+			router := gin.New()
+
+			// Add middleware to set user in context
+			router.Use(func(c *gin.Context) {
+				c.Set("user", &models.User{
+					ID:   fixedUserID,
+					Role: tt.userRole,
+				})
+				c.Next()
+			})
+
+			router.GET("/bugs/export", bugController.ExportBugs)
+
+			req, _ := http.NewRequest("GET", "/bugs/export"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+			if tt.expectedContent != "" {
+				assert.Equal(t, tt.expectedContent, w.Body.String())
+			}
+
+			mockBugUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSearchBugs(t *testing.T) {
+	// Set Gin to Test Mode
+	gin.SetMode(gin.TestMode)
+
+	fixedUserID, err := models.ParseID("680f74774848325f4e61925e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []*models.BugResponse{{Title: "Login crash"}}
+
+	tests := []struct {
+		name           string
+		userRole       string
+		query          string
+		mockResponse   func(*MockBugUseCase)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:     "Manager Searches All Bugs",
+			userRole: "manager",
+			query:    "?q=login+crash&limit=5",
+			mockResponse: func(m *MockBugUseCase) {
+				m.On("SearchBugs", mock.Anything, models.BugSearch{Query: "login crash", Limit: 5}).Return(results, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:     "Developer Search Limited to Assigned Bugs",
+			userRole: "developer",
+			query:    "?q=crash",
+			mockResponse: func(m *MockBugUseCase) {
+				m.On("SearchBugs", mock.Anything, models.BugSearch{Query: "crash", InvolvedUser: &fixedUserID}).Return(results, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Missing Query",
+			userRole:       "manager",
+			query:          "?q=+",
+			mockResponse:   func(m *MockBugUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"Query parameter 'q' is required"}`,
+		},
+		{
+			name:           "Invalid Limit",
+			userRole:       "manager",
+			query:          "?q=crash&limit=0",
+			mockResponse:   func(m *MockBugUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"Invalid limit"}`,
+		},
+		{
+			name:     "Search Failure",
+			userRole: "manager",
+			query:    "?q=crash",
+			mockResponse: func(m *MockBugUseCase) {
+				m.On("SearchBugs", mock.Anything, models.BugSearch{Query: "crash"}).Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"Failed to search bugs"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBugUseCase := new(MockBugUseCase)
+			tt.mockResponse(mockBugUseCase)
+			bugController := NewBugController(mockBugUseCase, nil)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user", &models.User{
+					ID:   fixedUserID,
+					Role: tt.userRole,
+				})
+				c.Next()
+			})
+			router.GET("/bugs/search", bugController.SearchBugs)
+
+			req, _ := http.NewRequest("GET", "/bugs/search"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+
+			mockBugUseCase.AssertExpectations(t)
+		})
+	}
+}
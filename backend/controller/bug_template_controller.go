@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"bug-tracker/authz"
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BugTemplateController serves the templates bugs are reported from, which
+// managers edit
+type BugTemplateController struct {
+	templateUseCase usecase.BugTemplateUseCaseInterface
+	roles           *authz.Roles
+}
+
+func NewBugTemplateController(templateUseCase usecase.BugTemplateUseCaseInterface, roles *authz.Roles) *BugTemplateController {
+	return &BugTemplateController{
+		templateUseCase: templateUseCase,
+		roles:           roles,
+	}
+}
+
+// ListTemplates lists the templates anyone reporting bugs may use
+func (c *BugTemplateController) ListTemplates(ctx *gin.Context) {
+	templates, err := c.templateUseCase.ListTemplates(ctx)
+	if err != nil {
+		internalError(ctx, "Failed to fetch templates", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, templates)
+}
+
+func (c *BugTemplateController) GetTemplate(ctx *gin.Context) {
+	id, err := models.ParseID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	template, err := c.templateUseCase.GetTemplate(ctx, id)
+	if err != nil {
+		switch err {
+		case usecase.ErrTemplateNotFound:
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		default:
+			internalError(ctx, "Failed to fetch template", err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, template)
+}
+
+func (c *BugTemplateController) CreateTemplate(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermTemplateManage) {
+		return
+	}
+
+	var req models.SaveBugTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := c.templateUseCase.CreateTemplate(ctx, req)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidTemplate) || errors.Is(err, usecase.ErrInvalidLabel) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		internalError(ctx, "Failed to create template", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, template)
+}
+
+func (c *BugTemplateController) UpdateTemplate(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermTemplateManage) {
+		return
+	}
+
+	id, err := models.ParseID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	var req models.SaveBugTemplateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := c.templateUseCase.UpdateTemplate(ctx, id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrTemplateNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		case errors.Is(err, usecase.ErrInvalidTemplate), errors.Is(err, usecase.ErrInvalidLabel):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			internalError(ctx, "Failed to update template", err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, template)
+}
+
+func (c *BugTemplateController) DeleteTemplate(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermTemplateManage) {
+		return
+	}
+
+	id, err := models.ParseID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	if err := c.templateUseCase.DeleteTemplate(ctx, id); err != nil {
+		switch err {
+		case usecase.ErrTemplateNotFound:
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		default:
+			internalError(ctx, "Failed to delete template", err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockBugTemplateUseCase is a mock implementation of the BugTemplateUseCaseInterface
+type MockBugTemplateUseCase struct {
+	mock.Mock
+}
+
+// Ensure MockBugTemplateUseCase implements the interface
+var _ usecase.BugTemplateUseCaseInterface = (*MockBugTemplateUseCase)(nil)
+
+func (m *MockBugTemplateUseCase) ListTemplates(ctx context.Context) ([]*models.BugTemplate, error) {
+	args := m.Called(ctx)
+	templates, _ := args.Get(0).([]*models.BugTemplate)
+	return templates, args.Error(1)
+}
+
+func (m *MockBugTemplateUseCase) GetTemplate(ctx context.Context, id models.ID) (*models.BugTemplate, error) {
+	args := m.Called(ctx, id)
+	template, _ := args.Get(0).(*models.BugTemplate)
+	return template, args.Error(1)
+}
+
+func (m *MockBugTemplateUseCase) CreateTemplate(ctx context.Context, req models.SaveBugTemplateRequest) (*models.BugTemplate, error) {
+	args := m.Called(ctx, req)
+	template, _ := args.Get(0).(*models.BugTemplate)
+	return template, args.Error(1)
+}
+
+func (m *MockBugTemplateUseCase) UpdateTemplate(ctx context.Context, id models.ID, req models.SaveBugTemplateRequest) (*models.BugTemplate, error) {
+	args := m.Called(ctx, id, req)
+	template, _ := args.Get(0).(*models.BugTemplate)
+	return template, args.Error(1)
+}
+
+func (m *MockBugTemplateUseCase) DeleteTemplate(ctx context.Context, id models.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func newTemplateRouter(mockTemplate *MockBugTemplateUseCase, role string) *gin.Engine {
+	controller := NewBugTemplateController(mockTemplate, nil)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{ID: models.NewID(), Role: role})
+		c.Next()
+	})
+	router.GET("/templates", controller.ListTemplates)
+	router.POST("/templates", controller.CreateTemplate)
+	router.GET("/templates/:id", controller.GetTemplate)
+	router.PUT("/templates/:id", controller.UpdateTemplate)
+	router.DELETE("/templates/:id", controller.DeleteTemplate)
+	return router
+}
+
+func TestListTemplates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	template := &models.BugTemplate{ID: models.NewID(), Name: "Crash", Description: "## Steps to reproduce\n"}
+	mockTemplate := new(MockBugTemplateUseCase)
+	mockTemplate.On("ListTemplates", mock.Anything).Return([]*models.BugTemplate{template}, nil)
+	mockTemplate.On("GetTemplate", mock.Anything, template.ID).Return(template, nil)
+	mockTemplate.On("GetTemplate", mock.Anything, mock.Anything).Return(nil, usecase.ErrTemplateNotFound)
+	// Reporters need the templates too
+	router := newTemplateRouter(mockTemplate, "developer")
+
+	req, _ := http.NewRequest(http.MethodGet, "/templates", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var templates []models.BugTemplate
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &templates))
+	assert.Equal(t, "Crash", templates[0].Name)
+
+	req, _ = http.NewRequest(http.MethodGet, "/templates/"+template.ID.Hex(), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest(http.MethodGet, "/templates/"+models.NewID().Hex(), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockTemplate.AssertExpectations(t)
+}
+
+func TestCreateTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		userRole       string
+		payload        string
+		mockResponse   func(*MockBugTemplateUseCase)
+		expectedStatus int
+	}{
+		{
+			name:     "Created",
+			userRole: "manager",
+			payload:  `{"name":"Crash","description":"## Steps to reproduce\n","required_sections":["Steps to reproduce"],"priority":"high"}`,
+			mockResponse: func(m *MockBugTemplateUseCase) {
+				m.On("CreateTemplate", mock.Anything, models.SaveBugTemplateRequest{
+					Name: "Crash", Description: "## Steps to reproduce\n", RequiredSections: []string{"Steps to reproduce"}, Priority: "high",
+				}).Return(&models.BugTemplate{ID: models.NewID(), Name: "Crash"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:     "Required section missing from description",
+			userRole: "manager",
+			payload:  `{"name":"Crash","description":"## Steps\n","required_sections":["Expected"]}`,
+			mockResponse: func(m *MockBugTemplateUseCase) {
+				m.On("CreateTemplate", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("%w: the description has no %q section", usecase.ErrInvalidTemplate, "Expected"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid priority",
+			userRole:       "manager",
+			payload:        `{"name":"Crash","description":"## Steps\n","priority":"urgent"}`,
+			mockResponse:   func(m *MockBugTemplateUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Developers may not edit templates",
+			userRole:       "developer",
+			payload:        `{"name":"Crash","description":"## Steps\n"}`,
+			mockResponse:   func(m *MockBugTemplateUseCase) {},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemplate := new(MockBugTemplateUseCase)
+			tt.mockResponse(mockTemplate)
+			router := newTemplateRouter(mockTemplate, tt.userRole)
+
+			req, _ := http.NewRequest(http.MethodPost, "/templates", bytes.NewBufferString(tt.payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockTemplate.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateAndDeleteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	id := models.NewID()
+	mockTemplate := new(MockBugTemplateUseCase)
+	mockTemplate.On("UpdateTemplate", mock.Anything, id, models.SaveBugTemplateRequest{Name: "Crash", Description: "## Steps\n"}).
+		Return(nil, usecase.ErrTemplateNotFound)
+	mockTemplate.On("DeleteTemplate", mock.Anything, id).Return(nil)
+	router := newTemplateRouter(mockTemplate, "manager")
+
+	req, _ := http.NewRequest(http.MethodPut, "/templates/"+id.Hex(), bytes.NewBufferString(`{"name":"Crash","description":"## Steps\n"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req, _ = http.NewRequest(http.MethodDelete, "/templates/"+id.Hex(), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	req, _ = http.NewRequest(http.MethodDelete, "/templates/nope", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockTemplate.AssertExpectations(t)
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"net/http"
+
+	"bug-tracker/authz"
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CustomFieldController serves the custom field definitions of bugs, which
+// admins edit
+type CustomFieldController struct {
+	fieldUseCase usecase.CustomFieldUseCaseInterface
+	roles        *authz.Roles
+}
+
+func NewCustomFieldController(fieldUseCase usecase.CustomFieldUseCaseInterface, roles *authz.Roles) *CustomFieldController {
+	return &CustomFieldController{
+		fieldUseCase: fieldUseCase,
+		roles:        roles,
+	}
+}
+
+// ListFields lists the custom fields, which anyone reporting bugs needs
+func (c *CustomFieldController) ListFields(ctx *gin.Context) {
+	fields, err := c.fieldUseCase.ListFields(ctx)
+	if err != nil {
+		internalError(ctx, "Failed to fetch custom fields", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, fields)
+}
+
+// SaveField defines a custom field, or changes its definition
+func (c *CustomFieldController) SaveField(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermFieldManage) {
+		return
+	}
+
+	var req models.SaveCustomFieldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	field, err := c.fieldUseCase.SaveField(ctx, ctx.Param("key"), req)
+	if err != nil {
+		switch err {
+		case usecase.ErrInvalidFieldKey, usecase.ErrBlankFieldName, usecase.ErrInvalidFieldOptions, usecase.ErrFieldTypeChange:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			internalError(ctx, "Failed to save custom field", err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, field)
+}
+
+// DeleteField deletes a custom field and its values on every bug
+func (c *CustomFieldController) DeleteField(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermFieldManage) {
+		return
+	}
+
+	if err := c.fieldUseCase.DeleteField(ctx, ctx.Param("key")); err != nil {
+		switch err {
+		case usecase.ErrFieldNotFound:
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Custom field not found"})
+		default:
+			internalError(ctx, "Failed to delete custom field", err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCustomFieldUseCase is a mock implementation of the CustomFieldUseCaseInterface
+type MockCustomFieldUseCase struct {
+	mock.Mock
+}
+
+// Ensure MockCustomFieldUseCase implements the interface
+var _ usecase.CustomFieldUseCaseInterface = (*MockCustomFieldUseCase)(nil)
+
+func (m *MockCustomFieldUseCase) ListFields(ctx context.Context) ([]*models.CustomField, error) {
+	args := m.Called(ctx)
+	fields, _ := args.Get(0).([]*models.CustomField)
+	return fields, args.Error(1)
+}
+
+func (m *MockCustomFieldUseCase) SaveField(ctx context.Context, key string, req models.SaveCustomFieldRequest) (*models.CustomField, error) {
+	args := m.Called(ctx, key, req)
+	field, _ := args.Get(0).(*models.CustomField)
+	return field, args.Error(1)
+}
+
+func (m *MockCustomFieldUseCase) DeleteField(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func newCustomFieldRouter(mockField *MockCustomFieldUseCase, role string) *gin.Engine {
+	controller := NewCustomFieldController(mockField, nil)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{ID: models.NewID(), Role: role})
+		c.Next()
+	})
+	router.GET("/fields", controller.ListFields)
+	router.PUT("/fields/:key", controller.SaveField)
+	router.DELETE("/fields/:key", controller.DeleteField)
+	return router
+}
+
+func TestListFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockField := new(MockCustomFieldUseCase)
+	mockField.On("ListFields", mock.Anything).
+		Return([]*models.CustomField{{Key: "environment", Name: "Environment", Type: models.FieldEnum, Options: []string{"prod"}}}, nil)
+	// Reporters need the fields too
+	router := newCustomFieldRouter(mockField, "developer")
+
+	req, _ := http.NewRequest(http.MethodGet, "/fields", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var fields []models.CustomField
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &fields))
+	assert.Equal(t, "environment", fields[0].Key)
+	mockField.AssertExpectations(t)
+}
+
+func TestSaveField(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		userRole       string
+		payload        string
+		mockResponse   func(*MockCustomFieldUseCase)
+		expectedStatus int
+	}{
+		{
+			name:     "Saved",
+			userRole: "admin",
+			payload:  `{"name":"Environment","type":"enum","options":["prod","staging"],"required":true}`,
+			mockResponse: func(m *MockCustomFieldUseCase) {
+				m.On("SaveField", mock.Anything, "environment", models.SaveCustomFieldRequest{
+					Name: "Environment", Type: models.FieldEnum, Options: []string{"prod", "staging"}, Required: true,
+				}).Return(&models.CustomField{Key: "environment", Name: "Environment", Type: models.FieldEnum}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:     "Type change",
+			userRole: "admin",
+			payload:  `{"name":"Environment","type":"text"}`,
+			mockResponse: func(m *MockCustomFieldUseCase) {
+				m.On("SaveField", mock.Anything, "environment", mock.Anything).Return(nil, usecase.ErrFieldTypeChange)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unknown type",
+			userRole:       "admin",
+			payload:        `{"name":"Environment","type":"color"}`,
+			mockResponse:   func(m *MockCustomFieldUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Managers may not define fields",
+			userRole:       "manager",
+			payload:        `{"name":"Environment","type":"text"}`,
+			mockResponse:   func(m *MockCustomFieldUseCase) {},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockField := new(MockCustomFieldUseCase)
+			tt.mockResponse(mockField)
+			router := newCustomFieldRouter(mockField, tt.userRole)
+
+			req, _ := http.NewRequest(http.MethodPut, "/fields/environment", bytes.NewBufferString(tt.payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockField.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteField(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "Deleted", expectedStatus: http.StatusNoContent},
+		{name: "Unknown field", err: usecase.ErrFieldNotFound, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockField := new(MockCustomFieldUseCase)
+			mockField.On("DeleteField", mock.Anything, "environment").Return(tt.err)
+			router := newCustomFieldRouter(mockField, "admin")
+
+			req, _ := http.NewRequest(http.MethodDelete, "/fields/environment", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockField.AssertExpectations(t)
+		})
+	}
+}
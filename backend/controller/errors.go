@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"net/http"
+
+	"bug-tracker/authz"
+	"bug-tracker/logging"
+	"bug-tracker/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// internalError logs the cause of a failed request and responds with a
+// generic 500 message, so storage errors never reach clients
+func internalError(ctx *gin.Context, message string, err error) {
+	logging.FromContext(ctx).Error(message, "error", err)
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
+// authorize reports whether the current user's role grants permission,
+// responding 403 when it doesn't
+func authorize(ctx *gin.Context, roles *authz.Roles, permission string) bool {
+	user := ctx.MustGet("user").(*models.User)
+	if roles.Can(user.Role, permission) {
+		return true
+	}
+	ctx.JSON(http.StatusForbidden, gin.H{"error": "Your role lacks the " + permission + " permission"})
+	return false
+}
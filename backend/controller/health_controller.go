@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"net/http"
+
+	"bug-tracker/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	checker *health.Checker
+}
+
+func NewHealthController(checker *health.Checker) *HealthController {
+	return &HealthController{
+		checker: checker,
+	}
+}
+
+// Live reports that the process is up. It stays healthy while draining so
+// the orchestrator doesn't restart a server that is shutting down.
+func (c *HealthController) Live(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// Ready runs the readiness checks and answers 503 unless all of them pass
+func (c *HealthController) Ready(ctx *gin.Context) {
+	report := c.checker.Ready(ctx)
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, report)
+}
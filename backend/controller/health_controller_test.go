@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"bug-tracker/health"
+)
+
+func TestHealthProbes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		setup          func(*health.Checker)
+		path           string
+		expectedStatus int
+		expectedState  string
+	}{
+		{
+			name:           "Live",
+			setup:          func(c *health.Checker) {},
+			path:           "/healthz",
+			expectedStatus: http.StatusOK,
+			expectedState:  health.StatusOK,
+		},
+		{
+			name:           "Live While Draining",
+			setup:          func(c *health.Checker) { c.Drain() },
+			path:           "/healthz",
+			expectedStatus: http.StatusOK,
+			expectedState:  health.StatusOK,
+		},
+		{
+			name: "Ready",
+			setup: func(c *health.Checker) {
+				c.Add("storage", func(context.Context) error { return nil })
+			},
+			path:           "/readyz",
+			expectedStatus: http.StatusOK,
+			expectedState:  health.StatusOK,
+		},
+		{
+			name: "Not Ready When a Check Fails",
+			setup: func(c *health.Checker) {
+				c.Add("storage", func(context.Context) error { return errors.New("server selection timeout") })
+			},
+			path:           "/readyz",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedState:  health.StatusFailing,
+		},
+		{
+			name: "Not Ready While Draining",
+			setup: func(c *health.Checker) {
+				c.Add("storage", func(context.Context) error { return nil })
+				c.Drain()
+			},
+			path:           "/readyz",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedState:  health.StatusDraining,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := health.New()
+			tt.setup(checker)
+			controller := NewHealthController(checker)
+
+			router := gin.New()
+			router.GET("/healthz", controller.Live)
+			router.GET("/readyz", controller.Ready)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			var body map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.expectedState, body["status"])
+		})
+	}
+}
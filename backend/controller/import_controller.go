@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"bug-tracker/authz"
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImportController struct {
+	importUseCase usecase.ImportUseCaseInterface
+	// maxSize caps the size of an uploaded import file, in bytes
+	maxSize int64
+	roles   *authz.Roles
+}
+
+func NewImportController(importUseCase usecase.ImportUseCaseInterface, maxSize int64, roles *authz.Roles) *ImportController {
+	return &ImportController{
+		importUseCase: importUseCase,
+		maxSize:       maxSize,
+		roles:         roles,
+	}
+}
+
+// ImportBugs accepts a multipart upload with the export in the "file" field.
+// Imports are dry runs unless dry_run=false is sent explicitly, so clients
+// review the report before committing to it.
+func (c *ImportController) ImportBugs(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermBugImport) {
+		return
+	}
+	user := ctx.MustGet("user").(*models.User)
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.maxSize)
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "An import file is required"})
+		return
+	}
+
+	opts := models.ImportOptions{
+		Format: ctx.PostForm("format"),
+		DryRun: ctx.DefaultPostForm("dry_run", "true") != "false",
+	}
+	if mapping := ctx.PostForm("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &opts.Mapping); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "mapping must be a JSON object"})
+			return
+		}
+	}
+	if userMap := ctx.PostForm("user_map"); userMap != "" {
+		if err := json.Unmarshal([]byte(userMap), &opts.UserMap); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "user_map must be a JSON object"})
+			return
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read import file"})
+		return
+	}
+	defer file.Close()
+
+	report, err := c.importUseCase.ImportBugs(ctx, file, opts, user)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidImportFormat),
+			errors.Is(err, usecase.ErrInvalidImportFile),
+			errors.Is(err, usecase.ErrInvalidImportField):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			internalError(ctx, "Failed to import bugs", err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
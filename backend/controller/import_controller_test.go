@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+)
+
+// MockImportUseCase is a mock implementation of the ImportUseCaseInterface
+type MockImportUseCase struct {
+	mock.Mock
+}
+
+// Ensure MockImportUseCase implements ImportUseCaseInterface
+var _ usecase.ImportUseCaseInterface = (*MockImportUseCase)(nil)
+
+func (m *MockImportUseCase) ImportBugs(ctx context.Context, r io.Reader, opts models.ImportOptions, importer *models.User) (*models.ImportReport, error) {
+	args := m.Called(ctx, r, opts, importer)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ImportReport), args.Error(1)
+}
+
+func newImportRequest(t *testing.T, fields map[string]string, file string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		assert.NoError(t, writer.WriteField(name, value))
+	}
+	if file != "" {
+		part, err := writer.CreateFormFile("file", "bugs.csv")
+		assert.NoError(t, err)
+		_, _ = part.Write([]byte(file))
+	}
+	assert.NoError(t, writer.Close())
+
+	req, _ := http.NewRequest("POST", "/bugs/import", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestImportBugs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		userRole       string
+		fields         map[string]string
+		file           string
+		mockResponse   func(*MockImportUseCase)
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:     "Dry Run by Default",
+			userRole: "manager",
+			fields:   map[string]string{"format": "csv", "mapping": `{"title":"Name"}`},
+			file:     "Name\nBug\n",
+			mockResponse: func(m *MockImportUseCase) {
+				m.On("ImportBugs", mock.Anything, mock.Anything, models.ImportOptions{
+					Format:  "csv",
+					Mapping: map[string]string{"title": "Name"},
+					DryRun:  true,
+				}, mock.Anything).Return(&models.ImportReport{DryRun: true, Total: 1, Created: 1, Rows: []models.ImportRowResult{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"dry_run": true,
+				"total":   float64(1),
+				"created": float64(1),
+				"skipped": float64(0),
+				"failed":  float64(0),
+				"rows":    []interface{}{},
+			},
+		},
+		{
+			name:     "Committed Import",
+			userRole: "admin",
+			fields:   map[string]string{"format": "jira", "dry_run": "false"},
+			file:     "Summary\nBug\n",
+			mockResponse: func(m *MockImportUseCase) {
+				m.On("ImportBugs", mock.Anything, mock.Anything, models.ImportOptions{Format: "jira"}, mock.Anything).
+					Return(&models.ImportReport{Total: 1, Created: 1, Rows: []models.ImportRowResult{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"dry_run": false,
+				"total":   float64(1),
+				"created": float64(1),
+				"skipped": float64(0),
+				"failed":  float64(0),
+				"rows":    []interface{}{},
+			},
+		},
+		{
+			name:           "Forbidden for Developer",
+			userRole:       "developer",
+			fields:         map[string]string{"format": "csv"},
+			file:           "title\nBug\n",
+			mockResponse:   func(m *MockImportUseCase) {},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   map[string]interface{}{"error": "Your role lacks the bug.import permission"},
+		},
+		{
+			name:           "Missing File",
+			userRole:       "admin",
+			fields:         map[string]string{"format": "csv"},
+			mockResponse:   func(m *MockImportUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"error": "An import file is required"},
+		},
+		{
+			name:           "Invalid Mapping",
+			userRole:       "admin",
+			fields:         map[string]string{"format": "csv", "mapping": "title=Name"},
+			file:           "Name\nBug\n",
+			mockResponse:   func(m *MockImportUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"error": "mapping must be a JSON object"},
+		},
+		{
+			name:     "Invalid Format",
+			userRole: "admin",
+			fields:   map[string]string{"format": "trello"},
+			file:     "{}",
+			mockResponse: func(m *MockImportUseCase) {
+				m.On("ImportBugs", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, usecase.ErrInvalidImportFormat)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"error": "import format must be csv, github or jira"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockImportUseCase := new(MockImportUseCase)
+			tt.mockResponse(mockImportUseCase)
+
+			importController := NewImportController(mockImportUseCase, 20<<20, nil)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user", &models.User{Role: tt.userRole})
+				c.Next()
+			})
+			router.POST("/bugs/import", importController.ImportBugs)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, newImportRequest(t, tt.fields, tt.file))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response map[string]interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedBody, response)
+
+			mockImportUseCase.AssertExpectations(t)
+		})
+	}
+}
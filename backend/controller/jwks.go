@@ -0,0 +1,18 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jwksCacheControl lets verifiers cache the key set for five minutes, well
+// within the time new keys are published before they sign
+const jwksCacheControl = "public, max-age=300"
+
+// JWKS serves the public keys that verify login tokens, so other services
+// can check them
+func (c *AuthController) JWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", jwksCacheControl)
+	ctx.JSON(http.StatusOK, c.authUseCase.PublicKeys())
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bug-tracker/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWKS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys := models.JSONWebKeySet{Keys: []models.JSONWebKey{
+		{KeyType: "OKP", Use: "sig", Algorithm: "EdDSA", KeyID: "key-1", Curve: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+	}}
+	mockAuth := new(MockAuthUseCase)
+	mockAuth.On("PublicKeys").Return(keys)
+	controller := NewAuthController(mockAuth, nil)
+
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", controller.JWKS)
+
+	req, _ := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, map[string]interface{}{
+		"keys": []interface{}{map[string]interface{}{
+			"kty": "OKP",
+			"use": "sig",
+			"alg": "EdDSA",
+			"kid": "key-1",
+			"crv": "Ed25519",
+			"x":   "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",
+		}},
+	}, response)
+	mockAuth.AssertExpectations(t)
+}
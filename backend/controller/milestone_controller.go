@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"bug-tracker/authz"
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MilestoneController serves milestones, their progress and their release
+// notes
+type MilestoneController struct {
+	milestoneUseCase usecase.MilestoneUseCaseInterface
+	roles            *authz.Roles
+}
+
+func NewMilestoneController(milestoneUseCase usecase.MilestoneUseCaseInterface, roles *authz.Roles) *MilestoneController {
+	return &MilestoneController{
+		milestoneUseCase: milestoneUseCase,
+		roles:            roles,
+	}
+}
+
+// ListMilestones lists milestones by due date, optionally only those in
+// the state given by the state query parameter
+func (c *MilestoneController) ListMilestones(ctx *gin.Context) {
+	milestones, err := c.milestoneUseCase.ListMilestones(ctx, ctx.Query("state"))
+	if err != nil {
+		if err == usecase.ErrInvalidState {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		internalError(ctx, "Failed to fetch milestones", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, milestones)
+}
+
+func (c *MilestoneController) GetMilestone(ctx *gin.Context) {
+	id, ok := parseMilestoneID(ctx)
+	if !ok {
+		return
+	}
+
+	milestone, err := c.milestoneUseCase.GetMilestone(ctx, id)
+	if err != nil {
+		milestoneError(ctx, "Failed to fetch milestone", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, milestone)
+}
+
+func (c *MilestoneController) CreateMilestone(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermMilestoneManage) {
+		return
+	}
+
+	var req models.SaveMilestoneRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	milestone, err := c.milestoneUseCase.CreateMilestone(ctx, req)
+	if err != nil {
+		milestoneError(ctx, "Failed to create milestone", err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, milestone)
+}
+
+func (c *MilestoneController) UpdateMilestone(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermMilestoneManage) {
+		return
+	}
+
+	id, ok := parseMilestoneID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.SaveMilestoneRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	milestone, err := c.milestoneUseCase.UpdateMilestone(ctx, id, req)
+	if err != nil {
+		milestoneError(ctx, "Failed to update milestone", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, milestone)
+}
+
+func (c *MilestoneController) DeleteMilestone(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermMilestoneManage) {
+		return
+	}
+
+	id, ok := parseMilestoneID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.milestoneUseCase.DeleteMilestone(ctx, id); err != nil {
+		milestoneError(ctx, "Failed to delete milestone", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetProgress returns the open and closed bug counts of a milestone and
+// its burndown
+func (c *MilestoneController) GetProgress(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermReportView) {
+		return
+	}
+
+	id, ok := parseMilestoneID(ctx)
+	if !ok {
+		return
+	}
+
+	progress, err := c.milestoneUseCase.GetProgress(ctx, id)
+	if err != nil {
+		milestoneError(ctx, "Failed to fetch milestone progress", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, progress)
+}
+
+// GetReleaseNotes returns the bugs fixed in a milestone as Markdown
+func (c *MilestoneController) GetReleaseNotes(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermBugView) {
+		return
+	}
+
+	id, ok := parseMilestoneID(ctx)
+	if !ok {
+		return
+	}
+
+	notes, err := c.milestoneUseCase.ReleaseNotes(ctx, id)
+	if err != nil {
+		milestoneError(ctx, "Failed to generate release notes", err)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(notes))
+}
+
+// parseMilestoneID parses the id path parameter, answering 400 when it is
+// malformed
+func parseMilestoneID(ctx *gin.Context) (models.ID, bool) {
+	id, err := models.ParseID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid milestone ID"})
+		return id, false
+	}
+	return id, true
+}
+
+// milestoneError answers with the status for a milestone use case error
+func milestoneError(ctx *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrMilestoneNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Milestone not found"})
+	case errors.Is(err, usecase.ErrDuplicateMilestone):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrInvalidDueDate), errors.Is(err, usecase.ErrInvalidState):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		internalError(ctx, message, err)
+	}
+}
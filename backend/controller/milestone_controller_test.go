@@ -0,0 +1,255 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMilestoneUseCase is a mock implementation of the MilestoneUseCaseInterface
+type MockMilestoneUseCase struct {
+	mock.Mock
+}
+
+// Ensure MockMilestoneUseCase implements the interface
+var _ usecase.MilestoneUseCaseInterface = (*MockMilestoneUseCase)(nil)
+
+func (m *MockMilestoneUseCase) ListMilestones(ctx context.Context, state string) ([]*models.Milestone, error) {
+	args := m.Called(ctx, state)
+	milestones, _ := args.Get(0).([]*models.Milestone)
+	return milestones, args.Error(1)
+}
+
+func (m *MockMilestoneUseCase) GetMilestone(ctx context.Context, id models.ID) (*models.Milestone, error) {
+	args := m.Called(ctx, id)
+	milestone, _ := args.Get(0).(*models.Milestone)
+	return milestone, args.Error(1)
+}
+
+func (m *MockMilestoneUseCase) CreateMilestone(ctx context.Context, req models.SaveMilestoneRequest) (*models.Milestone, error) {
+	args := m.Called(ctx, req)
+	milestone, _ := args.Get(0).(*models.Milestone)
+	return milestone, args.Error(1)
+}
+
+func (m *MockMilestoneUseCase) UpdateMilestone(ctx context.Context, id models.ID, req models.SaveMilestoneRequest) (*models.Milestone, error) {
+	args := m.Called(ctx, id, req)
+	milestone, _ := args.Get(0).(*models.Milestone)
+	return milestone, args.Error(1)
+}
+
+func (m *MockMilestoneUseCase) DeleteMilestone(ctx context.Context, id models.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockMilestoneUseCase) GetProgress(ctx context.Context, id models.ID) (*models.MilestoneProgress, error) {
+	args := m.Called(ctx, id)
+	progress, _ := args.Get(0).(*models.MilestoneProgress)
+	return progress, args.Error(1)
+}
+
+func (m *MockMilestoneUseCase) ReleaseNotes(ctx context.Context, id models.ID) (string, error) {
+	args := m.Called(ctx, id)
+	return args.String(0), args.Error(1)
+}
+
+func newMilestoneRouter(mockMilestone *MockMilestoneUseCase, role string) *gin.Engine {
+	controller := NewMilestoneController(mockMilestone, nil)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{ID: models.NewID(), Role: role})
+		c.Next()
+	})
+	router.GET("/milestones", controller.ListMilestones)
+	router.POST("/milestones", controller.CreateMilestone)
+	router.GET("/milestones/:id", controller.GetMilestone)
+	router.PUT("/milestones/:id", controller.UpdateMilestone)
+	router.DELETE("/milestones/:id", controller.DeleteMilestone)
+	router.GET("/milestones/:id/progress", controller.GetProgress)
+	router.GET("/milestones/:id/release-notes", controller.GetReleaseNotes)
+	return router
+}
+
+func TestListMilestones(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockMilestone := new(MockMilestoneUseCase)
+	mockMilestone.On("ListMilestones", mock.Anything, "open").Return([]*models.Milestone{{ID: models.NewID(), Name: "2.0"}}, nil)
+	mockMilestone.On("ListMilestones", mock.Anything, "released").Return(nil, usecase.ErrInvalidState)
+	router := newMilestoneRouter(mockMilestone, "developer")
+
+	req, _ := http.NewRequest(http.MethodGet, "/milestones?state=open", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"2.0"`)
+
+	req, _ = http.NewRequest(http.MethodGet, "/milestones?state=released", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockMilestone.AssertExpectations(t)
+}
+
+func TestSaveMilestone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	id := models.NewID()
+
+	tests := []struct {
+		name           string
+		userRole       string
+		method         string
+		path           string
+		payload        string
+		mockResponse   func(*MockMilestoneUseCase)
+		expectedStatus int
+	}{
+		{
+			name:     "Created",
+			userRole: "manager",
+			method:   http.MethodPost,
+			path:     "/milestones",
+			payload:  `{"name":"2.0","due_date":"2024-06-01"}`,
+			mockResponse: func(m *MockMilestoneUseCase) {
+				m.On("CreateMilestone", mock.Anything, models.SaveMilestoneRequest{Name: "2.0", DueDate: "2024-06-01"}).
+					Return(&models.Milestone{ID: id, Name: "2.0"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Invalid due date",
+			userRole:       "manager",
+			method:         http.MethodPost,
+			path:           "/milestones",
+			payload:        `{"name":"2.0","due_date":"June 1st"}`,
+			mockResponse:   func(m *MockMilestoneUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "Duplicate name",
+			userRole: "manager",
+			method:   http.MethodPost,
+			path:     "/milestones",
+			payload:  `{"name":"2.0"}`,
+			mockResponse: func(m *MockMilestoneUseCase) {
+				m.On("CreateMilestone", mock.Anything, mock.Anything).Return(nil, usecase.ErrDuplicateMilestone)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Developers may not edit milestones",
+			userRole:       "developer",
+			method:         http.MethodPost,
+			path:           "/milestones",
+			payload:        `{"name":"2.0"}`,
+			mockResponse:   func(m *MockMilestoneUseCase) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:     "Closed",
+			userRole: "manager",
+			method:   http.MethodPut,
+			path:     "/milestones/" + id.Hex(),
+			payload:  `{"name":"2.0","state":"closed"}`,
+			mockResponse: func(m *MockMilestoneUseCase) {
+				m.On("UpdateMilestone", mock.Anything, id, models.SaveMilestoneRequest{Name: "2.0", State: "closed"}).
+					Return(&models.Milestone{ID: id, Name: "2.0", State: "closed"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:     "Update not found",
+			userRole: "manager",
+			method:   http.MethodPut,
+			path:     "/milestones/" + id.Hex(),
+			payload:  `{"name":"2.0"}`,
+			mockResponse: func(m *MockMilestoneUseCase) {
+				m.On("UpdateMilestone", mock.Anything, id, mock.Anything).Return(nil, usecase.ErrMilestoneNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:     "Deleted",
+			userRole: "manager",
+			method:   http.MethodDelete,
+			path:     "/milestones/" + id.Hex(),
+			mockResponse: func(m *MockMilestoneUseCase) {
+				m.On("DeleteMilestone", mock.Anything, id).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Invalid ID",
+			userRole:       "manager",
+			method:         http.MethodDelete,
+			path:           "/milestones/nope",
+			mockResponse:   func(m *MockMilestoneUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMilestone := new(MockMilestoneUseCase)
+			tt.mockResponse(mockMilestone)
+			router := newMilestoneRouter(mockMilestone, tt.userRole)
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockMilestone.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMilestoneProgressAndReleaseNotes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	id := models.NewID()
+
+	mockMilestone := new(MockMilestoneUseCase)
+	mockMilestone.On("GetProgress", mock.Anything, id).
+		Return(&models.MilestoneProgress{Total: 4, Open: 1, Closed: 3, PercentComplete: 75}, nil)
+	mockMilestone.On("ReleaseNotes", mock.Anything, id).Return("# 2.0\n", nil)
+
+	t.Run("managers see both", func(t *testing.T) {
+		router := newMilestoneRouter(mockMilestone, "manager")
+
+		req, _ := http.NewRequest(http.MethodGet, "/milestones/"+id.Hex()+"/progress", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"percent_complete":75`)
+
+		req, _ = http.NewRequest(http.MethodGet, "/milestones/"+id.Hex()+"/release-notes", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/markdown; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "# 2.0\n", w.Body.String())
+	})
+
+	t.Run("developers see neither", func(t *testing.T) {
+		router := newMilestoneRouter(mockMilestone, "developer")
+		for _, path := range []string{"/progress", "/release-notes"} {
+			req, _ := http.NewRequest(http.MethodGet, "/milestones/"+id.Hex()+path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusForbidden, w.Code, path)
+		}
+	})
+	mockMilestone.AssertExpectations(t)
+}
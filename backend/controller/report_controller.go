@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"bug-tracker/authz"
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportController struct {
+	reportUseCase usecase.ReportUseCaseInterface
+	roles         *authz.Roles
+}
+
+func NewReportController(reportUseCase usecase.ReportUseCaseInterface, roles *authz.Roles) *ReportController {
+	return &ReportController{
+		reportUseCase: reportUseCase,
+		roles:         roles,
+	}
+}
+
+func (c *ReportController) GetSummary(ctx *gin.Context) {
+	filter, ok := c.bindFilter(ctx)
+	if !ok {
+		return
+	}
+
+	report, err := c.reportUseCase.GetSummary(ctx, filter)
+	if err != nil {
+		internalError(ctx, "Failed to generate summary report", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+func (c *ReportController) GetTrend(ctx *gin.Context) {
+	filter, ok := c.bindFilter(ctx)
+	if !ok {
+		return
+	}
+
+	interval := ctx.DefaultQuery("interval", "day")
+	if interval != "day" && interval != "week" && interval != "month" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "interval must be one of day, week, month"})
+		return
+	}
+
+	trend, err := c.reportUseCase.GetTrend(ctx, filter, interval)
+	if err != nil {
+		internalError(ctx, "Failed to generate trend report", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, trend)
+}
+
+func (c *ReportController) GetResolutionTime(ctx *gin.Context) {
+	filter, ok := c.bindFilter(ctx)
+	if !ok {
+		return
+	}
+
+	report, err := c.reportUseCase.GetResolutionTime(ctx, filter)
+	if err != nil {
+		internalError(ctx, "Failed to generate resolution time report", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+func (c *ReportController) GetAgeing(ctx *gin.Context) {
+	filter, ok := c.bindFilter(ctx)
+	if !ok {
+		return
+	}
+
+	buckets, err := c.reportUseCase.GetAgeing(ctx, filter)
+	if err != nil {
+		internalError(ctx, "Failed to generate ageing report", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, buckets)
+}
+
+func (c *ReportController) GetDeveloperThroughput(ctx *gin.Context) {
+	filter, ok := c.bindFilter(ctx)
+	if !ok {
+		return
+	}
+
+	throughput, err := c.reportUseCase.GetDeveloperThroughput(ctx, filter)
+	if err != nil {
+		internalError(ctx, "Failed to generate throughput report", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, throughput)
+}
+
+// bindFilter checks the caller may view reports and parses the from/to
+// query parameters, writing the error response itself when it returns false.
+func (c *ReportController) bindFilter(ctx *gin.Context) (models.ReportFilter, bool) {
+	var filter models.ReportFilter
+
+	if !authorize(ctx, c.roles, models.PermReportView) {
+		return filter, false
+	}
+
+	var err error
+	if filter.From, err = parseDateParam(ctx.Query("from"), false); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' date"})
+		return filter, false
+	}
+	if filter.To, err = parseDateParam(ctx.Query("to"), true); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' date"})
+		return filter, false
+	}
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "'from' must be before 'to'"})
+		return filter, false
+	}
+
+	return filter, true
+}
+
+// parseDateParam accepts RFC 3339 timestamps or plain YYYY-MM-DD dates.
+// A plain date used as an upper bound covers the whole day.
+func parseDateParam(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
@@ -0,0 +1,291 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+)
+
+// MockReportUseCase is a mock implementation of the ReportUseCaseInterface
+type MockReportUseCase struct {
+	mock.Mock
+}
+
+// Ensure MockReportUseCase implements ReportUseCaseInterface
+var _ usecase.ReportUseCaseInterface = (*MockReportUseCase)(nil)
+
+func (m *MockReportUseCase) GetSummary(ctx context.Context, filter models.ReportFilter) (*models.SummaryReport, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SummaryReport), args.Error(1)
+}
+
+func (m *MockReportUseCase) GetTrend(ctx context.Context, filter models.ReportFilter, interval string) ([]models.TrendPoint, error) {
+	args := m.Called(ctx, filter, interval)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TrendPoint), args.Error(1)
+}
+
+func (m *MockReportUseCase) GetResolutionTime(ctx context.Context, filter models.ReportFilter) (*models.ResolutionReport, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ResolutionReport), args.Error(1)
+}
+
+func (m *MockReportUseCase) GetAgeing(ctx context.Context, filter models.ReportFilter) ([]models.AgeingBucket, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.AgeingBucket), args.Error(1)
+}
+
+func (m *MockReportUseCase) GetDeveloperThroughput(ctx context.Context, filter models.ReportFilter) ([]models.DeveloperThroughputResponse, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DeveloperThroughputResponse), args.Error(1)
+}
+
+func setupReportRouter(reportController *ReportController, role string) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{Role: role})
+		c.Next()
+	})
+	router.GET("/reports/summary", reportController.GetSummary)
+	router.GET("/reports/trend", reportController.GetTrend)
+	router.GET("/reports/resolution-time", reportController.GetResolutionTime)
+	router.GET("/reports/ageing", reportController.GetAgeing)
+	return router
+}
+
+func TestGetSummaryReport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 31, 23, 59, 59, int(time.Second-time.Nanosecond), time.UTC)
+
+	tests := []struct {
+		name           string
+		userRole       string
+		query          string
+		mockResponse   func(*MockReportUseCase)
+		expectedStatus int
+		expectedBody   interface{}
+	}{
+		{
+			name:     "Summary as Manager with Date Range",
+			userRole: "manager",
+			query:    "?from=2024-01-01&to=2024-01-31",
+			mockResponse: func(m *MockReportUseCase) {
+				m.On("GetSummary", mock.Anything, models.ReportFilter{From: &from, To: &to}).Return(&models.SummaryReport{
+					ByStatus:   []models.CountBucket{{Key: "open", Count: 3}},
+					ByPriority: []models.CountBucket{{Key: "high", Count: 3}},
+					ByAssignee: []models.AssigneeCount{{Count: 3}},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"by_status":   []interface{}{map[string]interface{}{"key": "open", "count": float64(3)}},
+				"by_priority": []interface{}{map[string]interface{}{"key": "high", "count": float64(3)}},
+				"by_assignee": []interface{}{map[string]interface{}{"assignee": nil, "count": float64(3)}},
+			},
+		},
+		{
+			name:           "Forbidden for Developer",
+			userRole:       "developer",
+			mockResponse:   func(m *MockReportUseCase) {},
+			expectedStatus: http.StatusForbidden,
+			expectedBody: map[string]interface{}{
+				"error": "Your role lacks the report.view permission",
+			},
+		},
+		{
+			name:           "Invalid Date",
+			userRole:       "admin",
+			query:          "?from=yesterday",
+			mockResponse:   func(m *MockReportUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"error": "Invalid 'from' date",
+			},
+		},
+		{
+			name:           "Inverted Date Range",
+			userRole:       "admin",
+			query:          "?from=2024-02-01&to=2024-01-01",
+			mockResponse:   func(m *MockReportUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"error": "'from' must be before 'to'",
+			},
+		},
+		{
+			name:     "Error Generating Summary",
+			userRole: "admin",
+			mockResponse: func(m *MockReportUseCase) {
+				m.On("GetSummary", mock.Anything, models.ReportFilter{}).Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody: map[string]interface{}{
+				"error": "Failed to generate summary report",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockReportUseCase := new(MockReportUseCase)
+			tt.mockResponse(mockReportUseCase)
+
+			router := setupReportRouter(NewReportController(mockReportUseCase, nil), tt.userRole)
+
+			req, _ := http.NewRequest("GET", "/reports/summary"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedBody, response)
+
+			mockReportUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetTrendReport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		mockResponse   func(*MockReportUseCase)
+		expectedStatus int
+		expectedBody   interface{}
+	}{
+		{
+			name:  "Weekly Trend",
+			query: "?interval=week",
+			mockResponse: func(m *MockReportUseCase) {
+				m.On("GetTrend", mock.Anything, models.ReportFilter{}, "week").Return([]models.TrendPoint{
+					{Period: "2024-W01", Opened: 4, Closed: 1},
+					{Period: "2024-W02", Opened: 2, Closed: 5},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: []interface{}{
+				map[string]interface{}{"period": "2024-W01", "opened": float64(4), "closed": float64(1)},
+				map[string]interface{}{"period": "2024-W02", "opened": float64(2), "closed": float64(5)},
+			},
+		},
+		{
+			name:  "Defaults to Daily",
+			query: "",
+			mockResponse: func(m *MockReportUseCase) {
+				m.On("GetTrend", mock.Anything, models.ReportFilter{}, "day").Return([]models.TrendPoint{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []interface{}{},
+		},
+		{
+			name:           "Invalid Interval",
+			query:          "?interval=year",
+			mockResponse:   func(m *MockReportUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: map[string]interface{}{
+				"error": "interval must be one of day, week, month",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockReportUseCase := new(MockReportUseCase)
+			tt.mockResponse(mockReportUseCase)
+
+			router := setupReportRouter(NewReportController(mockReportUseCase, nil), "manager")
+
+			req, _ := http.NewRequest("GET", "/reports/trend"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+
+			var response interface{}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedBody, response)
+
+			mockReportUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetResolutionTimeAndAgeingReports(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockReportUseCase := new(MockReportUseCase)
+	mockReportUseCase.On("GetResolutionTime", mock.Anything, models.ReportFilter{}).Return(&models.ResolutionReport{
+		Resolved:  2,
+		MeanHours: 12.5,
+		MinHours:  1,
+		MaxHours:  24,
+	}, nil)
+	mockReportUseCase.On("GetAgeing", mock.Anything, models.ReportFilter{}).Return([]models.AgeingBucket{
+		{Label: "<1d", Count: 1},
+		{Label: "90d+", Count: 0},
+	}, nil)
+
+	router := setupReportRouter(NewReportController(mockReportUseCase, nil), "admin")
+
+	t.Run("Resolution Time", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/reports/resolution-time", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, map[string]interface{}{
+			"resolved":   float64(2),
+			"mean_hours": 12.5,
+			"min_hours":  float64(1),
+			"max_hours":  float64(24),
+		}, response)
+	})
+
+	t.Run("Ageing", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/reports/ageing", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response []interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response, 2)
+	})
+
+	mockReportUseCase.AssertExpectations(t)
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"bug-tracker/authz"
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleController lets admins edit roles and the permissions they grant
+type RoleController struct {
+	roleUseCase usecase.RoleUseCaseInterface
+	roles       *authz.Roles
+}
+
+func NewRoleController(roleUseCase usecase.RoleUseCaseInterface, roles *authz.Roles) *RoleController {
+	return &RoleController{
+		roleUseCase: roleUseCase,
+		roles:       roles,
+	}
+}
+
+func (c *RoleController) ListRoles(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermRoleManage) {
+		return
+	}
+
+	roles, err := c.roleUseCase.ListRoles(ctx)
+	if err != nil {
+		internalError(ctx, "Failed to fetch roles", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, roles)
+}
+
+// ListPermissions lists the permissions roles may grant
+func (c *RoleController) ListPermissions(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermRoleManage) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.Permissions)
+}
+
+// SaveRole sets the permissions of the role, creating it if needed
+func (c *RoleController) SaveRole(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermRoleManage) {
+		return
+	}
+
+	var req models.SaveRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := c.roleUseCase.SaveRole(ctx, ctx.Param("name"), req.Permissions)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidRoleName),
+			errors.Is(err, usecase.ErrUnknownPermission),
+			errors.Is(err, usecase.ErrBuiltinRole):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			internalError(ctx, "Failed to save role", err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, role)
+}
+
+func (c *RoleController) DeleteRole(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermRoleManage) {
+		return
+	}
+
+	if err := c.roleUseCase.DeleteRole(ctx, ctx.Param("name")); err != nil {
+		switch err {
+		case usecase.ErrBuiltinRole:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Built-in roles can't be deleted"})
+		case usecase.ErrRoleNotFound:
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Role not found"})
+		case usecase.ErrRoleInUse:
+			ctx.JSON(http.StatusConflict, gin.H{"error": "Role is given to users; change their roles first"})
+		default:
+			internalError(ctx, "Failed to delete role", err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
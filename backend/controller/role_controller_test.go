@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRoleUseCase is a mock implementation of the RoleUseCaseInterface
+type MockRoleUseCase struct {
+	mock.Mock
+}
+
+// Ensure MockRoleUseCase implements the interface
+var _ usecase.RoleUseCaseInterface = (*MockRoleUseCase)(nil)
+
+func (m *MockRoleUseCase) ListRoles(ctx context.Context) ([]models.Role, error) {
+	args := m.Called(ctx)
+	roles, _ := args.Get(0).([]models.Role)
+	return roles, args.Error(1)
+}
+
+func (m *MockRoleUseCase) SaveRole(ctx context.Context, name string, permissions []string) (*models.Role, error) {
+	args := m.Called(ctx, name, permissions)
+	role, _ := args.Get(0).(*models.Role)
+	return role, args.Error(1)
+}
+
+func (m *MockRoleUseCase) DeleteRole(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func newRoleRouter(mockRole *MockRoleUseCase, role string) *gin.Engine {
+	controller := NewRoleController(mockRole, nil)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", &models.User{ID: models.NewID(), Role: role})
+		c.Next()
+	})
+	router.GET("/roles", controller.ListRoles)
+	router.GET("/roles/permissions", controller.ListPermissions)
+	router.PUT("/roles/:name", controller.SaveRole)
+	router.DELETE("/roles/:name", controller.DeleteRole)
+	return router
+}
+
+func TestListRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRole := new(MockRoleUseCase)
+	mockRole.On("ListRoles", mock.Anything).
+		Return([]models.Role{{Name: "admin", Permissions: []string{models.PermRoleManage}}}, nil)
+	router := newRoleRouter(mockRole, "admin")
+
+	req, _ := http.NewRequest(http.MethodGet, "/roles", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var roles []models.Role
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &roles))
+	assert.Equal(t, "admin", roles[0].Name)
+
+	req, _ = http.NewRequest(http.MethodGet, "/roles/permissions", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var permissions []models.Permission
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &permissions))
+	assert.Len(t, permissions, len(models.Permissions))
+	mockRole.AssertExpectations(t)
+}
+
+func TestSaveRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		userRole       string
+		payload        string
+		mockResponse   func(*MockRoleUseCase)
+		expectedStatus int
+	}{
+		{
+			name:     "Saved",
+			userRole: "admin",
+			payload:  `{"permissions":["bug.view","bug.assign"]}`,
+			mockResponse: func(m *MockRoleUseCase) {
+				m.On("SaveRole", mock.Anything, "triager", []string{"bug.view", "bug.assign"}).
+					Return(&models.Role{Name: "triager", Permissions: []string{"bug.view", "bug.assign"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:     "Unknown permission",
+			userRole: "admin",
+			payload:  `{"permissions":["bug.fly"]}`,
+			mockResponse: func(m *MockRoleUseCase) {
+				m.On("SaveRole", mock.Anything, "triager", []string{"bug.fly"}).
+					Return(nil, fmt.Errorf("%w %q", usecase.ErrUnknownPermission, "bug.fly"))
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing permissions",
+			userRole:       "admin",
+			payload:        `{}`,
+			mockResponse:   func(m *MockRoleUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Managers may not edit roles",
+			userRole:       "manager",
+			payload:        `{"permissions":["bug.view"]}`,
+			mockResponse:   func(m *MockRoleUseCase) {},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRole := new(MockRoleUseCase)
+			tt.mockResponse(mockRole)
+			router := newRoleRouter(mockRole, tt.userRole)
+
+			req, _ := http.NewRequest(http.MethodPut, "/roles/triager", bytes.NewBufferString(tt.payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRole.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "Deleted", expectedStatus: http.StatusNoContent},
+		{name: "Built-in role", err: usecase.ErrBuiltinRole, expectedStatus: http.StatusBadRequest},
+		{name: "Unknown role", err: usecase.ErrRoleNotFound, expectedStatus: http.StatusNotFound},
+		{name: "Role in use", err: usecase.ErrRoleInUse, expectedStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRole := new(MockRoleUseCase)
+			mockRole.On("DeleteRole", mock.Anything, "triager").Return(tt.err)
+			router := newRoleRouter(mockRole, "admin")
+
+			req, _ := http.NewRequest(http.MethodDelete, "/roles/triager", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockRole.AssertExpectations(t)
+		})
+	}
+}
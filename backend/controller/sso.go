@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"net/http"
+
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ssoCookie holds the binding of a single sign-on in the browser that
+// began it
+const ssoCookie = "sso_binding"
+
+// setSSOCookie stores value for the single sign-on endpoints only. It is
+// sent along with the callback the frontend posts from its own site, but
+// not with requests other sites make.
+func setSSOCookie(ctx *gin.Context, value string, maxAge int) {
+	secure := ctx.Request.TLS != nil || ctx.GetHeader("X-Forwarded-Proto") == "https"
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(ssoCookie, value, maxAge, "/api/auth/oidc", "", secure, true)
+}
+
+// BeginSSO returns the identity provider URL to send the user to and the
+// state the provider will return with, and binds the state to this browser
+func (c *AuthController) BeginSSO(ctx *gin.Context) {
+	authorization, err := c.authUseCase.BeginSSO(ctx)
+	if err != nil {
+		if err == usecase.ErrSSODisabled {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Single sign-on is not configured"})
+			return
+		}
+		internalError(ctx, "Failed to start single sign-on", err)
+		return
+	}
+
+	setSSOCookie(ctx, authorization.Binding, int(usecase.SSOStateTTL.Seconds()))
+	ctx.JSON(http.StatusOK, authorization)
+}
+
+// CompleteSSO logs in with the code and state the identity provider
+// redirected back with
+func (c *AuthController) CompleteSSO(ctx *gin.Context) {
+	var req models.SSOCallbackRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Without the cookie this browser didn't begin the sign-on
+	binding, err := ctx.Cookie(ssoCookie)
+	if err != nil || binding == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Login expired; sign in again"})
+		return
+	}
+
+	result, err := c.authUseCase.CompleteSSO(ctx, req.Code, req.State, binding)
+	if err != nil {
+		switch err {
+		case usecase.ErrSSODisabled:
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Single sign-on is not configured"})
+		case usecase.ErrInvalidSSOState:
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Login expired; sign in again"})
+		case usecase.ErrSSOFailed:
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Single sign-on failed"})
+		case usecase.ErrSSONoEmail:
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "The identity provider didn't share an email address"})
+		case usecase.ErrSSONoRole:
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Your account has no access to the bug tracker"})
+		case usecase.ErrSSOAccountConflict:
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "An account with this email already exists; log in with its password"})
+		case usecase.ErrUserDeactivated:
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Account is deactivated"})
+		default:
+			internalError(ctx, "Failed to login", err)
+		}
+		return
+	}
+
+	setSSOCookie(ctx, "", -1)
+	ctx.JSON(http.StatusOK, LoginResponse{
+		Token: result.Token,
+		User:  *result.User,
+	})
+}
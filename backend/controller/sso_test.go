@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBeginSSO(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		authorization  *models.SSOAuthorization
+		err            error
+		expectedStatus int
+		expectedBody   map[string]interface{}
+		expectedCookie string
+	}{
+		{
+			name:           "Configured",
+			authorization:  &models.SSOAuthorization{AuthorizationURL: "https://idp.example.com/authorize?state=s", State: "s", Binding: "b"},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"authorization_url": "https://idp.example.com/authorize?state=s",
+				"state":             "s",
+			},
+			expectedCookie: "sso_binding=b; Path=/api/auth/oidc; Max-Age=600; HttpOnly; SameSite=Lax",
+		},
+		{
+			name:           "Not configured",
+			err:            usecase.ErrSSODisabled,
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   map[string]interface{}{"error": "Single sign-on is not configured"},
+		},
+		{
+			name:           "Provider unreachable",
+			err:            errors.New("dial tcp: connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   map[string]interface{}{"error": "Failed to start single sign-on"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuth := new(MockAuthUseCase)
+			mockAuth.On("BeginSSO", mock.Anything).Return(tt.authorization, tt.err)
+			controller := NewAuthController(mockAuth, nil)
+
+			router := gin.New()
+			router.GET("/oidc/login", controller.BeginSSO)
+
+			req, _ := http.NewRequest(http.MethodGet, "/oidc/login", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			var response map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedBody, response)
+			assert.Equal(t, tt.expectedCookie, w.Header().Get("Set-Cookie"))
+			mockAuth.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCompleteSSO(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := models.NewID()
+	tests := []struct {
+		name           string
+		payload        interface{}
+		cookie         string
+		mockResponse   func(*MockAuthUseCase)
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:    "Logged in",
+			payload: models.SSOCallbackRequest{Code: "code123", State: "state123"},
+			cookie:  "binding123",
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("CompleteSSO", mock.Anything, "code123", "state123", "binding123").
+					Return(&models.LoginResult{Token: "token123", User: &models.UserResponse{
+						ID:    userID,
+						Name:  "Ada",
+						Email: "ada@example.com",
+						Role:  "developer",
+					}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"token": "token123",
+				"user": map[string]interface{}{
+					"id":    userID.Hex(),
+					"name":  "Ada",
+					"email": "ada@example.com",
+					"role":  "developer",
+				},
+			},
+		},
+		{
+			name:           "Missing state",
+			payload:        map[string]string{"code": "code123"},
+			cookie:         "binding123",
+			mockResponse:   func(m *MockAuthUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Begun in another browser",
+			payload:        models.SSOCallbackRequest{Code: "code123", State: "state123"},
+			mockResponse:   func(m *MockAuthUseCase) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   map[string]interface{}{"error": "Login expired; sign in again"},
+		},
+	}
+
+	refusals := []struct {
+		err    error
+		status int
+	}{
+		{usecase.ErrSSODisabled, http.StatusNotFound},
+		{usecase.ErrInvalidSSOState, http.StatusUnauthorized},
+		{usecase.ErrSSOFailed, http.StatusUnauthorized},
+		{usecase.ErrSSONoEmail, http.StatusForbidden},
+		{usecase.ErrSSONoRole, http.StatusForbidden},
+		{usecase.ErrSSOAccountConflict, http.StatusForbidden},
+		{errors.New("connection reset"), http.StatusInternalServerError},
+	}
+	for _, refusal := range refusals {
+		err := refusal.err
+		tests = append(tests, struct {
+			name           string
+			payload        interface{}
+			cookie         string
+			mockResponse   func(*MockAuthUseCase)
+			expectedStatus int
+			expectedBody   map[string]interface{}
+		}{
+			name:    err.Error(),
+			payload: models.SSOCallbackRequest{Code: "code123", State: "state123"},
+			cookie:  "binding123",
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("CompleteSSO", mock.Anything, "code123", "state123", "binding123").Return(nil, err)
+			},
+			expectedStatus: refusal.status,
+		})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuth := new(MockAuthUseCase)
+			tt.mockResponse(mockAuth)
+			controller := NewAuthController(mockAuth, nil)
+
+			router := gin.New()
+			router.POST("/oidc/callback", controller.CompleteSSO)
+
+			payload, _ := json.Marshal(tt.payload)
+			req, _ := http.NewRequest(http.MethodPost, "/oidc/callback", bytes.NewBuffer(payload))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "sso_binding", Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != nil {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedBody, response)
+			}
+			mockAuth.AssertExpectations(t)
+		})
+	}
+}
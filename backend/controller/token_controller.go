@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"net/http"
+
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenController manages the current user's personal API tokens
+type TokenController struct {
+	tokenUseCase usecase.TokenUseCaseInterface
+}
+
+func NewTokenController(tokenUseCase usecase.TokenUseCaseInterface) *TokenController {
+	return &TokenController{
+		tokenUseCase: tokenUseCase,
+	}
+}
+
+func (c *TokenController) ListTokens(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*models.User)
+
+	tokens, err := c.tokenUseCase.ListTokens(ctx, user.ID)
+	if err != nil {
+		internalError(ctx, "Failed to fetch API tokens", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// CreateToken responds with the new token, the only time it is shown
+func (c *TokenController) CreateToken(ctx *gin.Context) {
+	var req models.CreateAPITokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := ctx.MustGet("user").(*models.User)
+	token, err := c.tokenUseCase.CreateToken(ctx, user, req)
+	if err != nil {
+		switch err {
+		case usecase.ErrExpiryInPast:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Expiry must be in the future"})
+		case usecase.ErrScopeNotPermitted:
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Only user managers can create admin tokens"})
+		default:
+			internalError(ctx, "Failed to create API token", err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, token)
+}
+
+func (c *TokenController) RevokeToken(ctx *gin.Context) {
+	tokenID, err := models.ParseID(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	user := ctx.MustGet("user").(*models.User)
+	if err := c.tokenUseCase.RevokeToken(ctx, user.ID, tokenID); err != nil {
+		switch err {
+		case usecase.ErrAPITokenNotFound:
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "API token not found"})
+		default:
+			internalError(ctx, "Failed to revoke API token", err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
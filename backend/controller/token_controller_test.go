@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTokenUseCase is a mock implementation of the TokenUseCaseInterface
+type MockTokenUseCase struct {
+	mock.Mock
+}
+
+// Ensure MockTokenUseCase implements the interface
+var _ usecase.TokenUseCaseInterface = (*MockTokenUseCase)(nil)
+
+func (m *MockTokenUseCase) CreateToken(ctx context.Context, user *models.User, req models.CreateAPITokenRequest) (*models.CreatedAPIToken, error) {
+	args := m.Called(ctx, user, req)
+	token, _ := args.Get(0).(*models.CreatedAPIToken)
+	return token, args.Error(1)
+}
+
+func (m *MockTokenUseCase) ListTokens(ctx context.Context, userID models.ID) ([]*models.APIToken, error) {
+	args := m.Called(ctx, userID)
+	tokens, _ := args.Get(0).([]*models.APIToken)
+	return tokens, args.Error(1)
+}
+
+func (m *MockTokenUseCase) RevokeToken(ctx context.Context, userID, id models.ID) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockTokenUseCase) ValidateAPIToken(ctx context.Context, token string) (*models.User, *models.APIToken, error) {
+	args := m.Called(ctx, token)
+	user, _ := args.Get(0).(*models.User)
+	apiToken, _ := args.Get(1).(*models.APIToken)
+	return user, apiToken, args.Error(2)
+}
+
+func TestCreateToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &models.User{ID: models.NewID(), Role: "developer"}
+	tests := []struct {
+		name           string
+		payload        string
+		mockResponse   func(*MockTokenUseCase)
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:    "Created",
+			payload: `{"name":"CI","scopes":["bugs:read"]}`,
+			mockResponse: func(m *MockTokenUseCase) {
+				m.On("CreateToken", mock.Anything, user, models.CreateAPITokenRequest{Name: "CI", Scopes: []string{"bugs:read"}}).
+					Return(&models.CreatedAPIToken{
+						APIToken: models.APIToken{Name: "CI", Prefix: "bt_abcdefgh", Scopes: []string{"bugs:read"}},
+						Token:    "bt_abcdefghijkl",
+					}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Unknown scope",
+			payload:        `{"name":"CI","scopes":["everything"]}`,
+			mockResponse:   func(m *MockTokenUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "No scopes",
+			payload:        `{"name":"CI","scopes":[]}`,
+			mockResponse:   func(m *MockTokenUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "Admin scope for non-admin",
+			payload: `{"name":"CI","scopes":["admin"]}`,
+			mockResponse: func(m *MockTokenUseCase) {
+				m.On("CreateToken", mock.Anything, user, mock.Anything).Return(nil, usecase.ErrScopeNotPermitted)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   map[string]interface{}{"error": "Only user managers can create admin tokens"},
+		},
+		{
+			name:    "Expiry in the past",
+			payload: `{"name":"CI","scopes":["bugs:read"],"expires_at":"2001-01-01T00:00:00Z"}`,
+			mockResponse: func(m *MockTokenUseCase) {
+				m.On("CreateToken", mock.Anything, user, mock.Anything).Return(nil, usecase.ErrExpiryInPast)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   map[string]interface{}{"error": "Expiry must be in the future"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTokens := new(MockTokenUseCase)
+			tt.mockResponse(mockTokens)
+			controller := NewTokenController(mockTokens)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user", user)
+				c.Next()
+			})
+			router.POST("/tokens", controller.CreateToken)
+
+			req, _ := http.NewRequest(http.MethodPost, "/tokens", bytes.NewBufferString(tt.payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != nil {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedBody, response)
+			}
+			if w.Code == http.StatusCreated {
+				// The secret is shown, the hash never is
+				assert.Contains(t, w.Body.String(), `"token":"bt_abcdefghijkl"`)
+				assert.NotContains(t, w.Body.String(), "hash")
+			}
+			mockTokens.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := &models.User{ID: models.NewID(), Role: "developer"}
+	tokenID := models.NewID()
+	tests := []struct {
+		name           string
+		path           string
+		mockResponse   func(*MockTokenUseCase)
+		expectedStatus int
+	}{
+		{
+			name: "Revoked",
+			path: "/tokens/" + tokenID.Hex(),
+			mockResponse: func(m *MockTokenUseCase) {
+				m.On("RevokeToken", mock.Anything, user.ID, tokenID).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "Someone else's token",
+			path: "/tokens/" + tokenID.Hex(),
+			mockResponse: func(m *MockTokenUseCase) {
+				m.On("RevokeToken", mock.Anything, user.ID, tokenID).Return(usecase.ErrAPITokenNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid ID",
+			path:           "/tokens/nope",
+			mockResponse:   func(m *MockTokenUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTokens := new(MockTokenUseCase)
+			tt.mockResponse(mockTokens)
+			controller := NewTokenController(mockTokens)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user", user)
+				c.Next()
+			})
+			router.DELETE("/tokens/:id", controller.RevokeToken)
+
+			req, _ := http.NewRequest(http.MethodDelete, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockTokens.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"net/http"
+
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyTwoFactor completes a login that returned a challenge
+func (c *AuthController) VerifyTwoFactor(ctx *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.authUseCase.VerifyTwoFactor(ctx, req.ChallengeToken, req.Code)
+	if lockedOut(ctx, err) {
+		return
+	}
+	if err != nil {
+		switch err {
+		case usecase.ErrInvalidCode:
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		case usecase.ErrInvalidChallenge:
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Login expired; sign in again"})
+		case usecase.ErrUserDeactivated:
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Account is deactivated"})
+		default:
+			internalError(ctx, "Failed to login", err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, LoginResponse{
+		Token: result.Token,
+		User:  *result.User,
+	})
+}
+
+// TwoFactorStatus shows the current user's two-factor settings
+func (c *AuthController) TwoFactorStatus(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*models.User)
+
+	status, err := c.authUseCase.TwoFactorStatus(ctx, user.ID)
+	if err != nil {
+		twoFactorError(ctx, "Failed to fetch two-factor status", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+// BeginTwoFactor starts two-factor enrollment for the current user
+func (c *AuthController) BeginTwoFactor(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*models.User)
+
+	enrollment, err := c.authUseCase.BeginTwoFactor(ctx, user.ID)
+	if err != nil {
+		twoFactorError(ctx, "Failed to start two-factor enrollment", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmTwoFactor enables two-factor authentication with a code from the
+// authenticator app
+func (c *AuthController) ConfirmTwoFactor(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*models.User)
+	var req models.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	confirmation, err := c.authUseCase.ConfirmTwoFactor(ctx, user.ID, req.Code)
+	if err != nil {
+		twoFactorError(ctx, "Failed to enable two-factor authentication", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, confirmation)
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes
+func (c *AuthController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*models.User)
+	var req models.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := c.authUseCase.RegenerateRecoveryCodes(ctx, user.ID, req.Code)
+	if err != nil {
+		twoFactorError(ctx, "Failed to regenerate recovery codes", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// DisableTwoFactor turns two-factor authentication off for the current
+// user
+func (c *AuthController) DisableTwoFactor(ctx *gin.Context) {
+	user := ctx.MustGet("user").(*models.User)
+	var req models.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.authUseCase.DisableTwoFactor(ctx, user.ID, req.Code); err != nil {
+		twoFactorError(ctx, "Failed to disable two-factor authentication", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ResetTwoFactor removes a user's two-factor settings, for user managers
+// helping users who lost their authenticator
+func (c *AuthController) ResetTwoFactor(ctx *gin.Context) {
+	if !authorize(ctx, c.roles, models.PermUserManage) {
+		return
+	}
+
+	userID, err := models.ParseID(ctx.Param("id"))
+	if err != nil || userID.IsZero() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := c.authUseCase.ResetTwoFactor(ctx, userID); err != nil {
+		twoFactorError(ctx, "Failed to reset two-factor authentication", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// twoFactorError responds to the errors of two-factor settings changes.
+// Wrong codes are 400 rather than 401, which would end the session.
+func twoFactorError(ctx *gin.Context, msg string, err error) {
+	if lockedOut(ctx, err) {
+		return
+	}
+	switch err {
+	case usecase.ErrUserNotFound:
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case usecase.ErrInvalidCode:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid two-factor code"})
+	case usecase.ErrTwoFactorEnabled:
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+	case usecase.ErrTwoFactorNotEnabled:
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not enabled"})
+	case usecase.ErrTwoFactorNotPending:
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Start two-factor enrollment first"})
+	case usecase.ErrTwoFactorChanged:
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Two-factor settings changed; try again"})
+	case usecase.ErrTwoFactorRequired:
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Your role requires two-factor authentication"})
+	default:
+		internalError(ctx, msg, err)
+	}
+}
@@ -0,0 +1,211 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestVerifyTwoFactor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := models.NewID()
+	tests := []struct {
+		name           string
+		payload        interface{}
+		mockResponse   func(*MockAuthUseCase)
+		expectedStatus int
+		expectedBody   map[string]interface{}
+	}{
+		{
+			name:    "Valid code",
+			payload: models.TwoFactorLoginRequest{ChallengeToken: "challenge123", Code: "123456"},
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("VerifyTwoFactor", mock.Anything, "challenge123", "123456").
+					Return(&models.LoginResult{Token: "token123", User: &models.UserResponse{
+						ID:    userID,
+						Name:  "Admin",
+						Email: "admin@example.com",
+						Role:  "admin",
+					}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: map[string]interface{}{
+				"token": "token123",
+				"user": map[string]interface{}{
+					"id":    userID.Hex(),
+					"name":  "Admin",
+					"email": "admin@example.com",
+					"role":  "admin",
+				},
+			},
+		},
+		{
+			name:    "Wrong code",
+			payload: models.TwoFactorLoginRequest{ChallengeToken: "challenge123", Code: "000000"},
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("VerifyTwoFactor", mock.Anything, "challenge123", "000000").Return(nil, usecase.ErrInvalidCode)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   map[string]interface{}{"error": "Invalid two-factor code"},
+		},
+		{
+			name:    "Expired challenge",
+			payload: models.TwoFactorLoginRequest{ChallengeToken: "expired", Code: "123456"},
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("VerifyTwoFactor", mock.Anything, "expired", "123456").Return(nil, usecase.ErrInvalidChallenge)
+			},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   map[string]interface{}{"error": "Login expired; sign in again"},
+		},
+		{
+			name:    "Locked account",
+			payload: models.TwoFactorLoginRequest{ChallengeToken: "challenge123", Code: "123456"},
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("VerifyTwoFactor", mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, &usecase.AccountLockedError{Until: time.Now().Add(time.Minute)})
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   map[string]interface{}{"error": "Too many failed logins; try again later"},
+		},
+		{
+			name:           "Missing code",
+			payload:        map[string]string{"challenge_token": "challenge123"},
+			mockResponse:   func(m *MockAuthUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuth := new(MockAuthUseCase)
+			tt.mockResponse(mockAuth)
+			controller := NewAuthController(mockAuth, nil)
+
+			router := gin.New()
+			router.POST("/login/2fa", controller.VerifyTwoFactor)
+
+			payload, _ := json.Marshal(tt.payload)
+			req, _ := http.NewRequest(http.MethodPost, "/login/2fa", bytes.NewBuffer(payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != nil {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedBody, response)
+			}
+			mockAuth.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := models.NewID()
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "Disabled", expectedStatus: http.StatusNoContent},
+		// Not 401, which would log the user out
+		{name: "Wrong code", err: usecase.ErrInvalidCode, expectedStatus: http.StatusBadRequest},
+		{name: "Not enabled", err: usecase.ErrTwoFactorNotEnabled, expectedStatus: http.StatusConflict},
+		{name: "Required by role", err: usecase.ErrTwoFactorRequired, expectedStatus: http.StatusForbidden},
+		{name: "Locked account", err: &usecase.AccountLockedError{Until: time.Now().Add(time.Minute)}, expectedStatus: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuth := new(MockAuthUseCase)
+			mockAuth.On("DisableTwoFactor", mock.Anything, userID, "123456").Return(tt.err)
+			controller := NewAuthController(mockAuth, nil)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user", &models.User{ID: userID, Role: "developer"})
+				c.Next()
+			})
+			router.POST("/2fa/disable", controller.DisableTwoFactor)
+
+			req, _ := http.NewRequest(http.MethodPost, "/2fa/disable", bytes.NewBufferString(`{"code":"123456"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockAuth.AssertExpectations(t)
+		})
+	}
+}
+
+func TestResetTwoFactor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := models.NewID()
+	tests := []struct {
+		name           string
+		userRole       string
+		mockResponse   func(*MockAuthUseCase)
+		expectedStatus int
+	}{
+		{
+			name:     "Admin resets",
+			userRole: "admin",
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("ResetTwoFactor", mock.Anything, userID).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:     "Unknown user",
+			userRole: "admin",
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("ResetTwoFactor", mock.Anything, userID).Return(usecase.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Managers may not reset",
+			userRole:       "manager",
+			mockResponse:   func(m *MockAuthUseCase) {},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuth := new(MockAuthUseCase)
+			tt.mockResponse(mockAuth)
+			controller := NewAuthController(mockAuth, nil)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user", &models.User{Role: tt.userRole})
+				c.Next()
+			})
+			router.DELETE("/users/:id/2fa", controller.ResetTwoFactor)
+
+			req, _ := http.NewRequest(http.MethodDelete, "/users/"+userID.Hex()+"/2fa", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockAuth.AssertExpectations(t)
+		})
+	}
+}
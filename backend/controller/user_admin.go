@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"net/http"
+
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChangeRole gives a user another role, for user managers
+func (c *AuthController) ChangeRole(ctx *gin.Context) {
+	userID, ok := c.otherUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req models.ChangeRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := c.authUseCase.ChangeRole(ctx, userID, req.Role)
+	if err != nil {
+		userAdminError(ctx, "Failed to change role", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// Deactivate stops a user from logging in, for user managers
+func (c *AuthController) Deactivate(ctx *gin.Context) {
+	userID, ok := c.otherUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.authUseCase.Deactivate(ctx, userID); err != nil {
+		userAdminError(ctx, "Failed to deactivate account", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// Reactivate lets a deactivated user log in again, for user managers
+func (c *AuthController) Reactivate(ctx *gin.Context) {
+	userID, ok := c.otherUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.authUseCase.Reactivate(ctx, userID); err != nil {
+		userAdminError(ctx, "Failed to reactivate account", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// otherUserID returns the user the request names, refusing users who
+// can't manage users and managers naming themselves, who could lock
+// themselves out
+func (c *AuthController) otherUserID(ctx *gin.Context) (models.ID, bool) {
+	if !authorize(ctx, c.roles, models.PermUserManage) {
+		return models.ID{}, false
+	}
+	user := ctx.MustGet("user").(*models.User)
+
+	userID, err := models.ParseID(ctx.Param("id"))
+	if err != nil || userID.IsZero() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return models.ID{}, false
+	}
+	if userID == user.ID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "You can't change your own account"})
+		return models.ID{}, false
+	}
+	return userID, true
+}
+
+func userAdminError(ctx *gin.Context, msg string, err error) {
+	switch err {
+	case usecase.ErrUserNotFound:
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case usecase.ErrRoleNotFound:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role"})
+	default:
+		internalError(ctx, msg, err)
+	}
+}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestChangeRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	adminID := models.NewID()
+	userID := models.NewID()
+	tests := []struct {
+		name           string
+		userRole       string
+		id             string
+		payload        interface{}
+		mockResponse   func(*MockAuthUseCase)
+		expectedStatus int
+	}{
+		{
+			name:     "Admin changes role",
+			userRole: "admin",
+			id:       userID.Hex(),
+			payload:  models.ChangeRoleRequest{Role: "manager"},
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("ChangeRole", mock.Anything, userID, "manager").
+					Return(&models.UserResponse{ID: userID, Role: "manager"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:     "Unknown user",
+			userRole: "admin",
+			id:       userID.Hex(),
+			payload:  models.ChangeRoleRequest{Role: "manager"},
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("ChangeRole", mock.Anything, userID, "manager").Return(nil, usecase.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:     "Unknown role",
+			userRole: "admin",
+			id:       userID.Hex(),
+			payload:  models.ChangeRoleRequest{Role: "owner"},
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("ChangeRole", mock.Anything, userID, "owner").Return(nil, usecase.ErrRoleNotFound)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Own account",
+			userRole:       "admin",
+			id:             adminID.Hex(),
+			payload:        models.ChangeRoleRequest{Role: "developer"},
+			mockResponse:   func(m *MockAuthUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Managers may not change roles",
+			userRole:       "manager",
+			id:             userID.Hex(),
+			payload:        models.ChangeRoleRequest{Role: "admin"},
+			mockResponse:   func(m *MockAuthUseCase) {},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuth := new(MockAuthUseCase)
+			tt.mockResponse(mockAuth)
+			controller := NewAuthController(mockAuth, nil)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user", &models.User{ID: adminID, Role: tt.userRole})
+				c.Next()
+			})
+			router.PUT("/users/:id/role", controller.ChangeRole)
+
+			payload, _ := json.Marshal(tt.payload)
+			req, _ := http.NewRequest(http.MethodPut, "/users/"+tt.id+"/role", bytes.NewBuffer(payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockAuth.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeactivate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	adminID := models.NewID()
+	userID := models.NewID()
+	tests := []struct {
+		name           string
+		userRole       string
+		path           string
+		mockResponse   func(*MockAuthUseCase)
+		expectedStatus int
+	}{
+		{
+			name:     "Admin deactivates account",
+			userRole: "admin",
+			path:     "/users/" + userID.Hex() + "/deactivate",
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("Deactivate", mock.Anything, userID).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:     "Admin reactivates account",
+			userRole: "admin",
+			path:     "/users/" + userID.Hex() + "/reactivate",
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("Reactivate", mock.Anything, userID).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:     "Unknown user",
+			userRole: "admin",
+			path:     "/users/" + userID.Hex() + "/deactivate",
+			mockResponse: func(m *MockAuthUseCase) {
+				m.On("Deactivate", mock.Anything, userID).Return(usecase.ErrUserNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Own account",
+			userRole:       "admin",
+			path:           "/users/" + adminID.Hex() + "/deactivate",
+			mockResponse:   func(m *MockAuthUseCase) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Managers may not deactivate",
+			userRole:       "manager",
+			path:           "/users/" + userID.Hex() + "/deactivate",
+			mockResponse:   func(m *MockAuthUseCase) {},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuth := new(MockAuthUseCase)
+			tt.mockResponse(mockAuth)
+			controller := NewAuthController(mockAuth, nil)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user", &models.User{ID: adminID, Role: tt.userRole})
+				c.Next()
+			})
+			router.POST("/users/:id/deactivate", controller.Deactivate)
+			router.POST("/users/:id/reactivate", controller.Reactivate)
+
+			req, _ := http.NewRequest(http.MethodPost, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			mockAuth.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Package health tracks whether the server can take traffic: dependency
+// checks, background workers and shutdown draining.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check statuses
+const (
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+	StatusDraining = "draining"
+)
+
+// checkTimeout bounds each check so one hung dependency can't stall probes
+const checkTimeout = 2 * time.Second
+
+var errWorkerStopped = errors.New("worker stopped")
+
+// Result is the outcome of one check
+type Result struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// Report is the outcome of every check. Status is ok only when all pass
+// and the server isn't draining.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type check struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Checker runs the readiness checks
+type Checker struct {
+	mu       sync.Mutex
+	checks   []check
+	draining atomic.Bool
+}
+
+func New() *Checker {
+	return &Checker{}
+}
+
+// Add registers a readiness check; fn returns an error while name is unusable
+func (c *Checker) Add(name string, fn func(ctx context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// Worker registers a background worker that must keep running for the
+// server to be ready. Call Done on the returned Worker when it exits.
+func (c *Checker) Worker(name string) *Worker {
+	w := &Worker{}
+	c.Add("worker:"+name, func(context.Context) error {
+		if w.stopped.Load() {
+			return errWorkerStopped
+		}
+		return nil
+	})
+	return w
+}
+
+// Drain makes readiness fail from now on, so load balancers stop sending
+// requests before the server shuts down
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Draining reports whether Drain has been called
+func (c *Checker) Draining() bool {
+	return c.draining.Load()
+}
+
+// Ready runs every check concurrently and reports the results
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.Lock()
+	checks := append([]check(nil), c.checks...)
+	c.mu.Unlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, chk := range checks {
+		wg.Add(1)
+		go func(i int, chk check) {
+			defer wg.Done()
+			results[i] = run(ctx, chk.fn)
+		}(i, chk)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, chk := range checks {
+		report.Checks[chk.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFailing
+		}
+	}
+	// Draining wins so operators can tell a deploy from an outage
+	if c.Draining() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+func run(ctx context.Context, fn func(ctx context.Context) error) Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := fn(ctx)
+	result := Result{
+		Status:     StatusOK,
+		DurationMS: float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Worker is a background goroutine readiness depends on
+type Worker struct {
+	stopped atomic.Bool
+}
+
+// Done marks the worker as stopped
+func (w *Worker) Done() {
+	w.stopped.Store(true)
+}
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReady(t *testing.T) {
+	checker := New()
+	assert.Equal(t, Report{Status: StatusOK, Checks: map[string]Result{}}, checker.Ready(context.Background()))
+
+	checker.Add("storage", func(context.Context) error { return nil })
+	report := checker.Ready(context.Background())
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Equal(t, StatusOK, report.Checks["storage"].Status)
+
+	checker.Add("migrations", func(context.Context) error { return errors.New("2 migrations pending") })
+	report = checker.Ready(context.Background())
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, StatusOK, report.Checks["storage"].Status)
+	assert.Equal(t, Result{Status: StatusFailing, Error: "2 migrations pending", DurationMS: report.Checks["migrations"].DurationMS}, report.Checks["migrations"])
+}
+
+func TestReadyTimesOutHungChecks(t *testing.T) {
+	checker := New()
+	checker.Add("hung", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	report := checker.Ready(ctx)
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["hung"].Error)
+}
+
+func TestWorker(t *testing.T) {
+	checker := New()
+	worker := checker.Worker("bug-metrics")
+	assert.Equal(t, StatusOK, checker.Ready(context.Background()).Status)
+
+	worker.Done()
+	report := checker.Ready(context.Background())
+	assert.Equal(t, StatusFailing, report.Status)
+	assert.Equal(t, "worker stopped", report.Checks["worker:bug-metrics"].Error)
+}
+
+func TestDrain(t *testing.T) {
+	checker := New()
+	checker.Add("storage", func(context.Context) error { return nil })
+	assert.False(t, checker.Draining())
+
+	checker.Drain()
+	assert.True(t, checker.Draining())
+	report := checker.Ready(context.Background())
+	assert.Equal(t, StatusDraining, report.Status)
+	assert.Equal(t, StatusOK, report.Checks["storage"].Status)
+}
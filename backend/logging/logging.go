@@ -0,0 +1,85 @@
+// Package logging builds the server's structured logger and carries
+// request-scoped loggers through contexts.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Redacted replaces the value of sensitive attributes
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are matched case-insensitively against any part of an
+// attribute key, so "new_password" and "X-Api-Key" are caught too
+var sensitiveKeys = []string{"password", "secret", "token", "authorization", "cookie", "api_key", "api-key"}
+
+type contextKey struct{}
+
+// New returns a logger writing JSON lines to w at the given level, with
+// sensitive attributes redacted
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	}))
+}
+
+// ParseLevel parses a level name such as "debug", "info", "warn" or "error"
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q; use debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+// NewContext returns a copy of ctx carrying logger
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// IsSensitive reports whether an attribute or header named key holds a secret
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func redact(_ []string, a slog.Attr) slog.Attr {
+	if IsSensitive(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	if header, ok := a.Value.Any().(http.Header); ok {
+		return slog.Any(a.Key, redactHeader(header))
+	}
+	return a
+}
+
+func redactHeader(header http.Header) map[string]string {
+	values := make(map[string]string, len(header))
+	for name := range header {
+		if IsSensitive(name) {
+			values[name] = Redacted
+			continue
+		}
+		values[name] = strings.Join(header.Values(name), ", ")
+	}
+	return values
+}
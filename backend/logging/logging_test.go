@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"bug-tracker/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	return entry
+}
+
+func TestRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer abc.def")
+	header.Set("Content-Type", "application/json")
+
+	logger.Info("test",
+		"password", "hunter22",
+		"new_password", "hunter23",
+		"Authorization", "Bearer abc.def",
+		"jwt_secret", "s3cret",
+		"user_id", "680f74774848325f4e61925e",
+		"headers", header,
+		slog.Group("request", "token", "abc.def", "method", "POST"),
+		"login", models.LoginRequest{Email: "dev@example.com", Password: "hunter22"},
+	)
+
+	assert.NotContains(t, buf.String(), "hunter2")
+	assert.NotContains(t, buf.String(), "abc.def")
+	assert.NotContains(t, buf.String(), "s3cret")
+
+	entry := decode(t, &buf)
+	assert.Equal(t, Redacted, entry["password"])
+	assert.Equal(t, Redacted, entry["Authorization"])
+	assert.Equal(t, "680f74774848325f4e61925e", entry["user_id"])
+	assert.Equal(t, map[string]interface{}{"Authorization": Redacted, "Content-Type": "application/json"}, entry["headers"])
+	assert.Equal(t, map[string]interface{}{"token": Redacted, "method": "POST"}, entry["request"])
+	assert.Equal(t, map[string]interface{}{"email": "dev@example.com"}, entry["login"])
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]slog.Level{
+		"debug": slog.LevelDebug,
+		"INFO":  slog.LevelInfo,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	} {
+		level, err := ParseLevel(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, level, name)
+	}
+
+	_, err := ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestLevelFiltering(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelWarn)
+
+	logger.Info("quiet")
+	assert.Empty(t, buf.String())
+
+	logger.Warn("loud")
+	assert.Equal(t, "loud", decode(t, &buf)["msg"])
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Same(t, slog.Default(), FromContext(context.Background()))
+
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo).With("request_id", "abc")
+	ctx := NewContext(context.Background(), logger)
+	assert.Same(t, logger, FromContext(ctx))
+
+	FromContext(ctx).Info("hello")
+	assert.Equal(t, "abc", decode(t, &buf)["request_id"])
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+
+	"bug-tracker/models"
+	"bug-tracker/usecase"
+)
+
+// Login results
+const (
+	loginSuccess = "success"
+	loginFailure = "failure"
+	loginLocked  = "locked"
+	loginError   = "error"
+	// loginTwoFactor is a correct password awaiting a second factor
+	loginTwoFactor = "two_factor"
+)
+
+// AuthUseCase wraps next so login attempts are counted by result
+func (m *Metrics) AuthUseCase(next usecase.AuthUseCaseInterface) usecase.AuthUseCaseInterface {
+	return &authUseCase{AuthUseCaseInterface: next, m: m}
+}
+
+type authUseCase struct {
+	usecase.AuthUseCaseInterface
+	m *Metrics
+}
+
+func (uc *authUseCase) Login(ctx context.Context, req models.LoginRequest) (*models.LoginResult, error) {
+	result, err := uc.AuthUseCaseInterface.Login(ctx, req)
+	if err == nil && result.Token == "" {
+		uc.m.logins.WithLabelValues(loginTwoFactor).Inc()
+	} else {
+		uc.count(err)
+	}
+	return result, err
+}
+
+func (uc *authUseCase) VerifyTwoFactor(ctx context.Context, challenge, code string) (*models.LoginResult, error) {
+	result, err := uc.AuthUseCaseInterface.VerifyTwoFactor(ctx, challenge, code)
+	uc.count(err)
+	return result, err
+}
+
+func (uc *authUseCase) CompleteSSO(ctx context.Context, code, state, binding string) (*models.LoginResult, error) {
+	result, err := uc.AuthUseCaseInterface.CompleteSSO(ctx, code, state, binding)
+	uc.count(err)
+	return result, err
+}
+
+// count records the result of a login step that ends in a session or err
+func (uc *authUseCase) count(err error) {
+	result := loginSuccess
+	switch {
+	case errors.Is(err, usecase.ErrUserNotFound), errors.Is(err, usecase.ErrInvalidPassword),
+		errors.Is(err, usecase.ErrInvalidCode), errors.Is(err, usecase.ErrInvalidChallenge),
+		errors.Is(err, usecase.ErrSSOFailed), errors.Is(err, usecase.ErrInvalidSSOState),
+		errors.Is(err, usecase.ErrSSONoEmail), errors.Is(err, usecase.ErrSSONoRole),
+		errors.Is(err, usecase.ErrSSOAccountConflict), errors.Is(err, usecase.ErrUserDeactivated):
+		result = loginFailure
+	case errors.Is(err, usecase.ErrAccountLocked):
+		result = loginLocked
+	case err != nil:
+		result = loginError
+	}
+	uc.m.logins.WithLabelValues(result).Inc()
+}
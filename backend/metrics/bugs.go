@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"bug-tracker/logging"
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+type bugKey struct {
+	status   string
+	priority string
+}
+
+// RefreshBugCounts recounts bugs by status and priority now and then every
+// interval until ctx is done. Failures are logged and retried next time.
+// Only one refresher may run per Metrics.
+func (m *Metrics) RefreshBugCounts(ctx context.Context, bugs repository.BugRepositoryInterface, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.refreshBugCounts(ctx, bugs); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Warn("failed to refresh bug metrics", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Metrics) refreshBugCounts(ctx context.Context, bugs repository.BugRepositoryInterface) error {
+	counts := make(map[bugKey]int)
+	err := bugs.Stream(ctx, models.BugFilter{}, func(bug *models.Bug) error {
+		counts[bugKey{bug.Status, bug.Priority}]++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Combinations that have emptied drop to zero rather than vanishing,
+	// so queries over them don't see gaps
+	for key := range counts {
+		m.bugLabels[key] = true
+	}
+	for key := range m.bugLabels {
+		m.bugs.WithLabelValues(key.status, key.priority).Set(float64(counts[key]))
+	}
+	m.bugsRefreshedAt.SetToCurrentTime()
+	return nil
+}
@@ -0,0 +1,121 @@
+// Package metrics collects the server's Prometheus metrics: HTTP traffic,
+// storage latency, logins and bug counts.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "bugtracker"
+
+// Metrics owns a registry with every collector the server exports
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	inFlight        prometheus.Gauge
+
+	storageDuration *prometheus.HistogramVec
+	storageErrors   *prometheus.CounterVec
+
+	logins *prometheus.CounterVec
+
+	bugs            *prometheus.GaugeVec
+	bugsRefreshedAt prometheus.Gauge
+	bugLabels       map[bugKey]bool
+}
+
+// New creates the collectors and registers them, together with the Go
+// runtime and process collectors, on a fresh registry
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests handled, by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time spent handling HTTP requests, by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "HTTP requests currently being handled.",
+		}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storage_operation_duration_seconds",
+			Help:      "Latency of repository operations, by backend, repository and method.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"backend", "repository", "method"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "storage_operation_errors_total",
+			Help:      "Failed repository operations, by backend, repository and method.",
+		}, []string{"backend", "repository", "method"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts, by result: success, failure (bad credentials, two-factor code, refused single sign-on or deactivated account), locked, two_factor (password accepted, awaiting a code) or error.",
+		}, []string{"result"}),
+		bugs: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "bugs",
+			Help:      "Bugs by status and priority, refreshed periodically.",
+		}, []string{"status", "priority"}),
+		bugsRefreshedAt: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "bugs_last_refresh_timestamp_seconds",
+			Help:      "Unix time of the last successful refresh of the bugs gauge.",
+		}),
+		bugLabels: make(map[bugKey]bool),
+	}
+
+	// Report every login result from the start so rates work immediately
+	for _, result := range []string{loginSuccess, loginFailure, loginLocked, loginTwoFactor, loginError} {
+		m.logins.WithLabelValues(result)
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.inFlight,
+		m.storageDuration,
+		m.storageErrors,
+		m.logins,
+		m.bugs,
+		m.bugsRefreshedAt,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// StartRequest counts a request as in flight. Call the returned function
+// with the matched route and response status once it has been handled.
+func (m *Metrics) StartRequest() func(method, route string, status int) {
+	start := time.Now()
+	m.inFlight.Inc()
+	return func(method, route string, status int) {
+		m.inFlight.Dec()
+		code := strconv.Itoa(status)
+		m.requests.WithLabelValues(method, route, code).Inc()
+		m.requestDuration.WithLabelValues(method, route, code).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+	"bug-tracker/repository/instrument"
+	"bug-tracker/repository/memory"
+	"bug-tracker/usecase"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingUsers fails every FindByID; other methods are not used
+type failingUsers struct {
+	repository.UserRepositoryInterface
+}
+
+func (failingUsers) FindByID(ctx context.Context, id models.ID) (*models.User, error) {
+	return nil, errors.New("connection reset")
+}
+
+// stubLogin returns err from Login, VerifyTwoFactor and CompleteSSO, or a challenge
+// from Login when twoFactor is set; other methods are not used
+type stubLogin struct {
+	usecase.AuthUseCaseInterface
+	err       error
+	twoFactor bool
+}
+
+func (s stubLogin) Login(ctx context.Context, req models.LoginRequest) (*models.LoginResult, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.twoFactor {
+		return &models.LoginResult{ChallengeToken: "challenge", TwoFactorRequired: true}, nil
+	}
+	return &models.LoginResult{Token: "token", User: &models.UserResponse{}}, nil
+}
+
+func (s stubLogin) VerifyTwoFactor(ctx context.Context, challenge, code string) (*models.LoginResult, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.LoginResult{Token: "token", User: &models.UserResponse{}}, nil
+}
+
+func (s stubLogin) CompleteSSO(ctx context.Context, code, state, binding string) (*models.LoginResult, error) {
+	return s.VerifyTwoFactor(ctx, "", code)
+}
+
+// histogramCount returns how many observations the histogram name has
+// recorded for the series with exactly these label values
+func histogramCount(t *testing.T, m *Metrics, name string, labels map[string]string) uint64 {
+	families, err := m.registry.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	series:
+		for _, metric := range family.GetMetric() {
+			for _, pair := range metric.GetLabel() {
+				if labels[pair.GetName()] != pair.GetValue() {
+					continue series
+				}
+			}
+			return metric.GetHistogram().GetSampleCount()
+		}
+	}
+	return 0
+}
+
+func TestStorageMetrics(t *testing.T) {
+	m := New()
+	ctx := context.Background()
+	users := instrument.Users(memory.NewUserRepository(), m.StorageHook("memory"))
+
+	user := &models.User{Name: "Dev", Email: "dev@example.com", Role: "developer"}
+	require.NoError(t, users.Create(ctx, user))
+	_, err := users.FindByID(ctx, user.ID)
+	require.NoError(t, err)
+	_, err = users.FindByID(ctx, models.NewID())
+	require.NoError(t, err)
+
+	// A taken email is recorded but isn't an error
+	err = users.Create(ctx, &models.User{Email: "dev@example.com"})
+	assert.ErrorIs(t, err, repository.ErrDuplicateEmail)
+
+	labels := map[string]string{"backend": "memory", "repository": "users", "method": "FindByID"}
+	assert.Equal(t, uint64(2), histogramCount(t, m, "bugtracker_storage_operation_duration_seconds", labels))
+	labels["method"] = "Create"
+	assert.Equal(t, uint64(2), histogramCount(t, m, "bugtracker_storage_operation_duration_seconds", labels))
+	assert.Equal(t, 0, testutil.CollectAndCount(m.storageErrors))
+
+	failing := instrument.Users(failingUsers{}, m.StorageHook("mongo"))
+	_, err = failing.FindByID(ctx, models.NewID())
+	assert.Error(t, err)
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.storageErrors.WithLabelValues("mongo", "users", "FindByID")))
+}
+
+func TestLoginMetrics(t *testing.T) {
+	m := New()
+	ctx := context.Background()
+
+	_, _ = m.AuthUseCase(stubLogin{}).Login(ctx, models.LoginRequest{})
+	_, _ = m.AuthUseCase(stubLogin{err: usecase.ErrInvalidPassword}).Login(ctx, models.LoginRequest{})
+	_, _ = m.AuthUseCase(stubLogin{err: usecase.ErrUserNotFound}).Login(ctx, models.LoginRequest{})
+	_, _ = m.AuthUseCase(stubLogin{err: &usecase.AccountLockedError{}}).Login(ctx, models.LoginRequest{})
+	_, err := m.AuthUseCase(stubLogin{err: errors.New("timeout")}).Login(ctx, models.LoginRequest{})
+	assert.EqualError(t, err, "timeout")
+
+	// Two-factor logins count the password and the code separately
+	_, _ = m.AuthUseCase(stubLogin{twoFactor: true}).Login(ctx, models.LoginRequest{})
+	_, _ = m.AuthUseCase(stubLogin{}).VerifyTwoFactor(ctx, "challenge", "123456")
+	_, _ = m.AuthUseCase(stubLogin{err: usecase.ErrInvalidCode}).VerifyTwoFactor(ctx, "challenge", "000000")
+
+	_, _ = m.AuthUseCase(stubLogin{}).CompleteSSO(ctx, "code", "state", "binding")
+	_, _ = m.AuthUseCase(stubLogin{err: usecase.ErrSSOAccountConflict}).CompleteSSO(ctx, "code", "state", "binding")
+
+	assert.Equal(t, float64(3), testutil.ToFloat64(m.logins.WithLabelValues("success")))
+	assert.Equal(t, float64(4), testutil.ToFloat64(m.logins.WithLabelValues("failure")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.logins.WithLabelValues("locked")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.logins.WithLabelValues("two_factor")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.logins.WithLabelValues("error")))
+}
+
+func TestRefreshBugCounts(t *testing.T) {
+	m := New()
+	ctx := context.Background()
+	bugs := memory.NewBugRepository()
+
+	// Create always opens bugs, so resolve one afterwards
+	for _, priority := range []string{"high", "high", "low"} {
+		require.NoError(t, bugs.Create(ctx, &models.Bug{Title: priority, Priority: priority}))
+	}
+	resolve := func(priority string) {
+		all, err := bugs.FindAll(ctx)
+		require.NoError(t, err)
+		for _, bug := range all {
+			if bug.Priority == priority {
+				require.NoError(t, bugs.UpdateStatus(ctx, bug.ID, "resolved"))
+			}
+		}
+	}
+	resolve("low")
+
+	require.NoError(t, m.refreshBugCounts(ctx, bugs))
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.bugs.WithLabelValues("open", "high")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.bugs.WithLabelValues("resolved", "low")))
+	assert.InDelta(t, float64(time.Now().Unix()), testutil.ToFloat64(m.bugsRefreshedAt), 5)
+
+	resolve("high")
+	require.NoError(t, m.refreshBugCounts(ctx, bugs))
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.bugs.WithLabelValues("open", "high")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.bugs.WithLabelValues("resolved", "high")))
+}
+
+func TestHandler(t *testing.T) {
+	m := New()
+	m.StartRequest()("GET", "/api/bugs", 200)
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(body), `bugtracker_http_requests_total{method="GET",route="/api/bugs",status="200"} 1`)
+	assert.Contains(t, string(body), `bugtracker_http_requests_in_flight 0`)
+	assert.Contains(t, string(body), `bugtracker_logins_total{result="success"} 0`)
+	assert.Contains(t, string(body), `go_goroutines`)
+}
@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bug-tracker/repository"
+	"bug-tracker/repository/instrument"
+)
+
+// StorageHook times repository operations and counts their failures,
+// labelled with the backend name
+func (m *Metrics) StorageHook(backend string) instrument.Hook {
+	return func(ctx context.Context, repo, method string) (context.Context, func(error)) {
+		start := time.Now()
+		return ctx, func(err error) {
+			m.storageDuration.WithLabelValues(backend, repo, method).Observe(time.Since(start).Seconds())
+			// A taken email is a normal outcome, not a storage failure
+			if err != nil && !errors.Is(err, repository.ErrDuplicateEmail) {
+				m.storageErrors.WithLabelValues(backend, repo, method).Inc()
+			}
+		}
+	}
+}
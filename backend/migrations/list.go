@@ -0,0 +1,111 @@
+package migrations
+
+import (
+	"context"
+
+	"bug-tracker/models"
+	"bug-tracker/repository/mongodb"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// All is the list of built-in migrations. Never change or renumber a
+// migration once released; add a new one instead.
+var All = []Migration{
+	{
+		// Later index changes edit mongodb.EnsureIndexes and add a new
+		// migration calling it again.
+		Version: 1,
+		Name:    "create_indexes",
+		Up:      mongodb.EnsureIndexes,
+	},
+	{
+		Version: 2,
+		Name:    "backfill_resolved_at",
+		Up:      backfillResolvedAt,
+	},
+	{
+		// Adds the text index used by bug search
+		Version: 3,
+		Name:    "create_text_index",
+		Up:      mongodb.EnsureIndexes,
+	},
+	{
+		// Adds the api_tokens indexes
+		Version: 4,
+		Name:    "create_api_token_indexes",
+		Up:      mongodb.EnsureIndexes,
+	},
+	{
+		// Adds the index on users' single sign-on identity
+		Version: 5,
+		Name:    "create_oidc_index",
+		Up:      mongodb.EnsureIndexes,
+	},
+	{
+		Version: 6,
+		Name:    "grant_field_manage",
+		Up:      grantFieldManage,
+	},
+	{
+		Version: 7,
+		Name:    "grant_template_manage",
+		Up:      grantTemplateManage,
+	},
+	{
+		Version: 8,
+		Name:    "grant_milestone_manage",
+		Up:      grantMilestoneManage,
+	},
+	{
+		// Adds the index on bugs' fix version
+		Version: 9,
+		Name:    "create_fix_version_index",
+		Up:      mongodb.EnsureIndexes,
+	},
+}
+
+// backfillResolvedAt sets resolved_at on bugs resolved before it was tracked,
+// using the last update as the best available approximation.
+func backfillResolvedAt(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("bugs").UpdateMany(
+		ctx,
+		bson.M{"status": "resolved", "resolved_at": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"resolved_at": "$updated_at"}}}},
+	)
+	return err
+}
+
+// grantFieldManage gives a stored admin role the field.manage permission
+// added with custom fields. New installs get it with the built-in roles.
+func grantFieldManage(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("roles").UpdateOne(
+		ctx,
+		bson.M{"_id": models.RoleAdmin, "permissions": bson.M{"$ne": models.PermFieldManage}},
+		bson.M{"$push": bson.M{"permissions": models.PermFieldManage}},
+	)
+	return err
+}
+
+// grantTemplateManage gives stored admin and manager roles the
+// template.manage permission added with bug templates
+func grantTemplateManage(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("roles").UpdateMany(
+		ctx,
+		bson.M{"_id": bson.M{"$in": []string{models.RoleAdmin, models.RoleManager}}, "permissions": bson.M{"$ne": models.PermTemplateManage}},
+		bson.M{"$push": bson.M{"permissions": models.PermTemplateManage}},
+	)
+	return err
+}
+
+// grantMilestoneManage gives stored admin and manager roles the
+// milestone.manage permission added with milestones
+func grantMilestoneManage(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("roles").UpdateMany(
+		ctx,
+		bson.M{"_id": bson.M{"$in": []string{models.RoleAdmin, models.RoleManager}}, "permissions": bson.M{"$ne": models.PermMilestoneManage}},
+		bson.M{"$push": bson.M{"permissions": models.PermMilestoneManage}},
+	)
+	return err
+}
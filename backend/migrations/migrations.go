@@ -0,0 +1,252 @@
+// Package migrations applies versioned schema and data changes to the
+// database. Applied versions are recorded in the schema_migrations
+// collection so each migration runs exactly once.
+package migrations
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"bug-tracker/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	migrationsCollection = "schema_migrations"
+	lockCollection       = "schema_migrations_lock"
+)
+
+// ErrLocked is returned when another process is applying migrations
+var ErrLocked = errors.New("migrations are being applied by another process")
+
+// ErrLockLost is returned when the lease expired and was taken over while
+// a migration ran, so its version isn't recorded
+var ErrLockLost = errors.New("migration lock was lost to another process")
+
+// The lease is renewed well before it expires, so only a runner that has
+// stopped making progress loses it. Variables so tests can shorten them.
+var (
+	leaseTTL     = 10 * time.Minute
+	leaseRenewal = time.Minute
+)
+
+// Migration is a single versioned change. Up must be safe to re-run if it
+// fails partway, since the version is only recorded after it succeeds.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+}
+
+type AppliedMigration struct {
+	Version   int       `bson:"_id" json:"version"`
+	Name      string    `bson:"name" json:"name"`
+	AppliedAt time.Time `bson:"applied_at" json:"applied_at"`
+}
+
+type Runner struct {
+	db         *mongo.Database
+	migrations []Migration
+}
+
+// NewRunner returns a runner for the given migrations, or for the built-in
+// list when none are given.
+func NewRunner(db *mongo.Database, migrations ...Migration) *Runner {
+	if len(migrations) == 0 {
+		migrations = All
+	}
+
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	return &Runner{db: db, migrations: sorted}
+}
+
+// Applied returns the migrations recorded as applied, oldest first
+func (r *Runner) Applied(ctx context.Context) ([]AppliedMigration, error) {
+	collection := r.db.Collection(migrationsCollection)
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	applied := []AppliedMigration{}
+	if err := cursor.All(ctx, &applied); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// Pending returns the migrations that have not been applied yet
+func (r *Runner) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := r.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(map[int]bool, len(applied))
+	for _, m := range applied {
+		done[m.Version] = true
+	}
+
+	var pending []Migration
+	for _, m := range r.migrations {
+		if !done[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in version order and returns the ones
+// it applied. It stops at the first failure.
+func (r *Runner) Up(ctx context.Context) ([]Migration, error) {
+	lease, err := r.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer lease.release()
+
+	pending, err := r.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := r.db.Collection(migrationsCollection)
+	var applied []Migration
+	for _, m := range pending {
+		if err := m.Up(ctx, r.db); err != nil {
+			return applied, fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+
+		// Another runner may have started on the same versions once ours
+		// was taken over
+		if err := lease.check(ctx); err != nil {
+			return applied, fmt.Errorf("recording migration %d: %w", m.Version, err)
+		}
+		record := AppliedMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}
+		if _, err := collection.InsertOne(ctx, record); err != nil {
+			return applied, fmt.Errorf("recording migration %d: %w", m.Version, err)
+		}
+		applied = append(applied, m)
+	}
+
+	return applied, nil
+}
+
+// lease is the lock a runner holds while it applies migrations
+type lease struct {
+	collection *mongo.Collection
+	owner      string
+	logger     *slog.Logger
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// lock takes a lease so two processes never apply migrations concurrently.
+// Leases expire so a crashed runner doesn't block deploys forever; a
+// running one renews its lease until it is released.
+func (r *Runner) lock(ctx context.Context) (*lease, error) {
+	collection := r.db.Collection(lockCollection)
+	now := time.Now()
+
+	// The owner token lets the unlock tell our lease from one taken over by
+	// another process after ours expired
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	owner := hex.EncodeToString(token)
+
+	// Clear a lease abandoned by a crashed process
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": "lock", "expires_at": bson.M{"$lt": now}})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = collection.InsertOne(ctx, bson.M{"_id": "lock", "owner": owner, "expires_at": now.Add(leaseTTL)})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	l := &lease{
+		collection: collection,
+		owner:      owner,
+		logger:     logging.FromContext(ctx),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go l.renew()
+	return l, nil
+}
+
+// renew extends the lease every leaseRenewal until it is released
+func (l *lease) renew() {
+	defer close(l.done)
+	ticker := time.NewTicker(leaseRenewal)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			filter := bson.M{"_id": "lock", "owner": l.owner}
+			update := bson.M{"$set": bson.M{"expires_at": time.Now().Add(leaseTTL)}}
+			result, err := l.collection.UpdateOne(context.Background(), filter, update)
+			if err != nil {
+				// The next tick tries again before the lease runs out
+				l.logger.Warn("failed to renew migration lock", "error", err)
+				continue
+			}
+			if result.MatchedCount == 0 {
+				l.logger.Error("migration lock was taken over")
+				return
+			}
+		}
+	}
+}
+
+// check returns ErrLockLost unless the lease is still ours and unexpired
+func (l *lease) check(ctx context.Context) error {
+	count, err := l.collection.CountDocuments(ctx, bson.M{
+		"_id":        "lock",
+		"owner":      l.owner,
+		"expires_at": bson.M{"$gt": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// release stops renewing the lease and gives it up, unless another
+// process has taken it over
+func (l *lease) release() {
+	close(l.stop)
+	<-l.done
+	if _, err := l.collection.DeleteOne(context.Background(), bson.M{"_id": "lock", "owner": l.owner}); err != nil {
+		// The lease expires on its own, so this only delays the next deploy
+		l.logger.Warn("failed to release migration lock", "error", err)
+	}
+}
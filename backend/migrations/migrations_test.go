@@ -0,0 +1,161 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// setupTestDB connects to the database in MONGO_TEST_URI, skipping the test
+// when it isn't set.
+func setupTestDB(t *testing.T) *mongo.Database {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+
+	db := client.Database("bug_tracker_migrations_test")
+	t.Cleanup(func() {
+		_ = db.Drop(context.Background())
+		_ = client.Disconnect(context.Background())
+	})
+	return db
+}
+
+func TestRunnerUp(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	var runs []int
+	migration := func(version int) Migration {
+		return Migration{
+			Version: version,
+			Name:    "test",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				runs = append(runs, version)
+				return nil
+			},
+		}
+	}
+
+	runner := NewRunner(db, migration(2), migration(1))
+
+	applied, err := runner.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 2)
+	assert.Equal(t, []int{1, 2}, runs)
+
+	// A second run applies nothing
+	applied, err = runner.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	// New migrations are picked up on the next run
+	runner = NewRunner(db, migration(1), migration(2), migration(3))
+	pending, err := runner.Pending(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 3, pending[0].Version)
+
+	_, err = runner.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, runs)
+
+	recorded, err := runner.Applied(ctx)
+	require.NoError(t, err)
+	assert.Len(t, recorded, 3)
+}
+
+func TestRunnerStopsOnFailure(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	failing := errors.New("boom")
+	runner := NewRunner(db,
+		Migration{Version: 1, Name: "ok", Up: func(context.Context, *mongo.Database) error { return nil }},
+		Migration{Version: 2, Name: "fails", Up: func(context.Context, *mongo.Database) error { return failing }},
+		Migration{Version: 3, Name: "never", Up: func(context.Context, *mongo.Database) error { return nil }},
+	)
+
+	applied, err := runner.Up(ctx)
+	assert.ErrorIs(t, err, failing)
+	assert.Len(t, applied, 1)
+
+	pending, err := runner.Pending(ctx)
+	require.NoError(t, err)
+	assert.Len(t, pending, 2)
+}
+
+func TestRunnerLock(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	_, err := db.Collection(lockCollection).InsertOne(ctx, bson.M{"_id": "lock", "expires_at": time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+
+	_, err = NewRunner(db).Up(ctx)
+	assert.Equal(t, ErrLocked, err)
+
+	// An expired lease is taken over
+	_, err = db.Collection(lockCollection).UpdateOne(ctx, bson.M{"_id": "lock"}, bson.M{"$set": bson.M{"expires_at": time.Now().Add(-time.Minute)}})
+	require.NoError(t, err)
+
+	_, err = NewRunner(db, Migration{Version: 1, Name: "noop", Up: func(context.Context, *mongo.Database) error { return nil }}).Up(ctx)
+	assert.NoError(t, err)
+
+	// A lease taken over by another process while ours had expired is left
+	// for that process to release
+	takeOver := func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(lockCollection).ReplaceOne(ctx, bson.M{"_id": "lock"}, bson.M{"owner": "other", "expires_at": time.Now().Add(time.Minute)})
+		return err
+	}
+	runner := NewRunner(db, Migration{Version: 2, Name: "take_over", Up: takeOver})
+	applied, err := runner.Up(ctx)
+	assert.ErrorIs(t, err, ErrLockLost)
+	assert.Empty(t, applied)
+
+	// The version is left for the new owner to apply
+	pending, err := runner.Pending(ctx)
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	count, err := db.Collection(lockCollection).CountDocuments(ctx, bson.M{"_id": "lock", "owner": "other"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestRunnerRenewsLock(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	ttl, renewal := leaseTTL, leaseRenewal
+	leaseTTL, leaseRenewal = 200*time.Millisecond, 20*time.Millisecond
+	t.Cleanup(func() { leaseTTL, leaseRenewal = ttl, renewal })
+
+	// The migration outlasts the lease several times over
+	slow := func(ctx context.Context, db *mongo.Database) error {
+		time.Sleep(time.Second)
+		return nil
+	}
+	applied, err := NewRunner(db, Migration{Version: 1, Name: "slow", Up: slow}).Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 1)
+
+	count, err := db.Collection(lockCollection).CountDocuments(ctx, bson.M{})
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
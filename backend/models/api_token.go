@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// API token scopes. Admin covers the other scopes too.
+const (
+	ScopeBugsRead  = "bugs:read"
+	ScopeBugsWrite = "bugs:write"
+	ScopeAdmin     = "admin"
+)
+
+// APITokenPrefix starts every personal API token, which tells them apart
+// from login tokens
+const APITokenPrefix = "bt_"
+
+// APIToken is a personal access token for scripts and CI. Only a hash of
+// the token is stored.
+type APIToken struct {
+	ID     ID     `bson:"_id,omitempty" json:"id"`
+	UserID ID     `bson:"user_id" json:"-"`
+	Name   string `bson:"name" json:"name"`
+	// Prefix is the start of the token, so users can tell tokens apart
+	Prefix     string     `bson:"prefix" json:"prefix"`
+	Hash       string     `bson:"hash" json:"-"`
+	Scopes     []string   `bson:"scopes" json:"scopes"`
+	ExpiresAt  *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+}
+
+// Expired reports whether the token has expired at now
+func (t *APIToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// Allows reports whether the token grants scope
+func (t *APIToken) Allows(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPITokenRequest creates a token. Tokens without ExpiresAt don't
+// expire.
+type CreateAPITokenRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=bugs:read bugs:write admin"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreatedAPIToken is a new token with its secret, which is shown once
+type CreatedAPIToken struct {
+	APIToken
+	Token string `json:"token"`
+}
@@ -2,32 +2,55 @@ package models
 
 import (
 	"time"
-
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Bug struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Title       string             `bson:"title" json:"title"`
-	Description string             `bson:"description" json:"description"`
-	Status      string             `bson:"status" json:"status"`     // "open", "in-progress", "resolved"
-	Priority    string             `bson:"priority" json:"priority"` // "low", "medium", "high", "critical"
-	ReportedBy  primitive.ObjectID `bson:"reported_by" json:"reported_by"`
-	AssignedTo  primitive.ObjectID `bson:"assigned_to,omitempty" json:"assigned_to,omitempty"`
-	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	ID          ID          `bson:"_id,omitempty" json:"id,omitempty"`
+	Title       string      `bson:"title" json:"title"`
+	Description string      `bson:"description" json:"description"`
+	Status      string      `bson:"status" json:"status"`     // "open", "in-progress", "resolved"
+	Priority    string      `bson:"priority" json:"priority"` // "low", "medium", "high", "critical"
+	ReportedBy  ID          `bson:"reported_by" json:"reported_by"`
+	AssignedTo  ID          `bson:"assigned_to,omitempty" json:"assigned_to,omitempty"`
+	CreatedAt   time.Time   `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time   `bson:"updated_at" json:"updated_at"`
+	ResolvedAt  *time.Time  `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+	ExternalID  string      `bson:"external_id,omitempty" json:"external_id,omitempty"` // source tracker ID for imported bugs
+	Fields      FieldValues `bson:"fields,omitempty" json:"fields,omitempty"`
+	Labels      []string    `bson:"labels,omitempty" json:"labels,omitempty"`
+	// AffectedVersion and FixVersion are the milestones the bug was found
+	// in and is to be fixed in
+	AffectedVersion ID `bson:"affected_version,omitempty" json:"affected_version,omitempty"`
+	FixVersion      ID `bson:"fix_version,omitempty" json:"fix_version,omitempty"`
 }
 
 type CreateBugRequest struct {
+	// TemplateID names a bug template that fills in what the request
+	// leaves out, and whose required sections the description must fill
+	TemplateID  ID     `json:"template_id"`
 	Title       string `json:"title" binding:"required"`
-	Description string `json:"description" binding:"required"`
-	Priority    string `json:"priority" binding:"required,oneof=low medium high critical"`
+	Description string `json:"description"`
+	// Priority may only be left out when the template sets one
+	Priority        string   `json:"priority" binding:"omitempty,oneof=low medium high critical"`
+	Labels          []string `json:"labels"`
+	AffectedVersion ID       `json:"affected_version"`
+	FixVersion      ID       `json:"fix_version"`
+	// Fields holds custom field values by key
+	Fields map[string]interface{} `json:"fields"`
 }
 
 type UpdateBugRequest struct {
 	Title       string `json:"title" binding:"omitempty"`
 	Description string `json:"description" binding:"omitempty"`
 	Priority    string `json:"priority" binding:"omitempty,oneof=low medium high critical"`
+	// Labels replaces the labels when given; an empty list removes them
+	Labels *[]string `json:"labels"`
+	// AffectedVersion and FixVersion set the milestones when given; an
+	// empty string clears one
+	AffectedVersion *ID `json:"affected_version"`
+	FixVersion      *ID `json:"fix_version"`
+	// Fields sets the custom fields it names; null clears one
+	Fields map[string]interface{} `json:"fields"`
 }
 
 type UpdateBugStatusRequest struct {
@@ -35,17 +58,105 @@ type UpdateBugStatusRequest struct {
 }
 
 type AssignBugRequest struct {
-	DeveloperID primitive.ObjectID `json:"developer_id" binding:"required"`
+	DeveloperID ID `json:"developer_id" binding:"required"`
 }
 
 type BugResponse struct {
-	ID          primitive.ObjectID `json:"id"`
-	Title       string             `json:"title"`
-	Description string             `json:"description"`
-	Status      string             `json:"status"`
-	Priority    string             `json:"priority"`
-	ReportedBy  UserResponse       `json:"reported_by"`
-	AssignedTo  *UserResponse      `json:"assigned_to,omitempty"`
-	CreatedAt   time.Time          `json:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at"`
+	ID          ID            `json:"id"`
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Status      string        `json:"status"`
+	Priority    string        `json:"priority"`
+	ReportedBy  UserResponse  `json:"reported_by"`
+	AssignedTo  *UserResponse `json:"assigned_to,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	ResolvedAt  *time.Time    `json:"resolved_at,omitempty"`
+	Fields      FieldValues   `json:"fields,omitempty"`
+	Labels      []string      `json:"labels,omitempty"`
+	// AffectedVersion and FixVersion are nil when unset
+	AffectedVersion *MilestoneRef `json:"affected_version,omitempty"`
+	FixVersion      *MilestoneRef `json:"fix_version,omitempty"`
+}
+
+// Involves reports whether the user reported the bug or is assigned it
+func (b *Bug) Involves(userID ID) bool {
+	return b.ReportedBy == userID || !b.AssignedTo.IsZero() && b.AssignedTo == userID
+}
+
+// Involves is Bug.Involves for a bug as clients see it
+func (b *BugResponse) Involves(userID ID) bool {
+	return b.ReportedBy.ID == userID || b.AssignedTo != nil && b.AssignedTo.ID == userID
+}
+
+// BugFilter narrows bug listings and exports. Zero values match everything.
+type BugFilter struct {
+	Status     string
+	Priority   string
+	AssignedTo *ID
+	ReportedBy *ID
+	// InvolvedUser matches bugs that user reported or is assigned
+	InvolvedUser *ID
+	From         *time.Time
+	To           *time.Time
+	// AffectedVersion and FixVersion match bugs with that milestone
+	AffectedVersion *ID
+	FixVersion      *ID
+	Fields          []FieldFilter
+	Sort            BugSort
+}
+
+// FieldFilter matches bugs whose custom field Key has Value, or for
+// multi-select fields includes it. Callers may give Value as a string;
+// the bug use case converts it to the field's type.
+type FieldFilter struct {
+	Key   string
+	Value interface{}
+}
+
+// FieldPrefix marks custom field keys where bug attributes are expected,
+// as in the sort key "fields.environment"
+const FieldPrefix = "fields."
+
+// SortColumns are the bug attributes listings can be sorted by, besides
+// custom fields
+var SortColumns = []string{"created_at", "updated_at", "title"}
+
+// BugSort orders bug listings by Key, one of SortColumns or a custom field
+// key with FieldPrefix. Bugs without a value for a custom field come last,
+// and ties are broken oldest first. The zero value orders oldest first.
+type BugSort struct {
+	Key  string
+	Desc bool
+}
+
+// BugSearch is a full-text search over bug titles and descriptions. A bug
+// matches when it contains every word of Query.
+type BugSearch struct {
+	Query        string
+	InvolvedUser *ID // restricts results to bugs that user reported or is assigned
+	Limit        int
+}
+
+// ExportOptions controls the shape of a bug export
+type ExportOptions struct {
+	Format  string   // "csv" or "ndjson"
+	Columns []string // empty selects DefaultExportColumns
+	BOM     bool     // prefix CSV output with a UTF-8 byte order mark for Excel
+}
+
+// ExportColumns lists every column that can be selected in an export,
+// besides custom fields by their key with FieldPrefix
+var ExportColumns = []string{
+	"id", "title", "description", "status", "priority",
+	"reporter_id", "reporter_name", "reporter_email",
+	"assignee_id", "assignee_name", "assignee_email",
+	"created_at", "updated_at", "resolved_at", "labels",
+	"affected_version", "fix_version",
+}
+
+var DefaultExportColumns = []string{
+	"id", "title", "status", "priority",
+	"reporter_name", "assignee_name",
+	"created_at", "updated_at", "resolved_at",
 }
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// Custom field types
+const (
+	FieldText   = "text"
+	FieldNumber = "number"
+	FieldEnum   = "enum"
+	// FieldDate values are dates written YYYY-MM-DD
+	FieldDate = "date"
+	// FieldUser values are user IDs
+	FieldUser        = "user"
+	FieldMultiSelect = "multi_select"
+)
+
+// CustomField is an extra attribute of bugs defined by admins, such as the
+// environment or browser a bug was seen in
+type CustomField struct {
+	Key  string `bson:"_id" json:"key"`
+	Name string `bson:"name" json:"name"`
+	Type string `bson:"type" json:"type"`
+	// Options are the values enum and multi-select fields allow
+	Options []string `bson:"options" json:"options,omitempty"`
+	// Required fields must be given when bugs are reported
+	Required  bool      `bson:"required" json:"required"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// SaveCustomFieldRequest defines a custom field, or changes its definition
+type SaveCustomFieldRequest struct {
+	Name     string   `json:"name" binding:"required,max=100"`
+	Type     string   `json:"type" binding:"required,oneof=text number enum date user multi_select"`
+	Options  []string `json:"options"`
+	Required bool     `json:"required"`
+}
+
+// FieldValues holds the custom field values of a bug by field key. Number
+// values are float64, multi-select values []string and the rest strings.
+type FieldValues map[string]interface{}
+
+// UnmarshalJSON decodes lists of strings as []string
+func (v *FieldValues) UnmarshalJSON(data []byte) error {
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*v = FieldValues(values)
+	v.Normalize()
+	return nil
+}
+
+// Normalize converts values decoded from storage to the types FieldValues
+// holds: lists of strings to []string and integers to float64. Other
+// values are left alone.
+func (v FieldValues) Normalize() {
+	for key, value := range v {
+		rv := reflect.ValueOf(value)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v[key] = float64(rv.Int())
+		case reflect.Slice:
+			list := make([]string, rv.Len())
+			ok := true
+			for i := range list {
+				s, isString := rv.Index(i).Interface().(string)
+				if !isString {
+					ok = false
+					break
+				}
+				list[i] = s
+			}
+			if ok {
+				v[key] = list
+			}
+		}
+	}
+}
+
+// Clone returns a copy of v that shares no memory with it
+func (v FieldValues) Clone() FieldValues {
+	if v == nil {
+		return nil
+	}
+	clone := make(FieldValues, len(v))
+	for key, value := range v {
+		if list, ok := value.([]string); ok {
+			value = append([]string{}, list...)
+		}
+		clone[key] = value
+	}
+	return clone
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrInvalidID is returned when parsing a malformed ID
+var ErrInvalidID = errors.New("invalid id")
+
+// ID identifies a stored entity. IDs are 12 bytes written as 24 hex
+// characters, the same layout as MongoDB ObjectIDs, so existing IDs stay
+// valid whichever backend stores them. The zero ID means "none".
+type ID [12]byte
+
+// NilID is the zero ID
+var NilID ID
+
+var (
+	idProcess [5]byte
+	idCounter atomic.Uint32
+)
+
+func init() {
+	var seed [8]byte
+	if _, err := rand.Read(idProcess[:]); err != nil {
+		panic(err)
+	}
+	if _, err := rand.Read(seed[:]); err != nil {
+		panic(err)
+	}
+	idCounter.Store(binary.BigEndian.Uint32(seed[:4]))
+}
+
+// NewID returns a new unique ID. IDs start with their creation time in
+// seconds, so they sort roughly in creation order.
+func NewID() ID {
+	var id ID
+	binary.BigEndian.PutUint32(id[0:4], uint32(time.Now().Unix()))
+	copy(id[4:9], idProcess[:])
+
+	count := idCounter.Add(1)
+	id[9] = byte(count >> 16)
+	id[10] = byte(count >> 8)
+	id[11] = byte(count)
+	return id
+}
+
+// ParseID parses the hex form of an ID
+func ParseID(s string) (ID, error) {
+	var id ID
+	if len(s) != 2*len(id) {
+		return NilID, ErrInvalidID
+	}
+	if _, err := hex.Decode(id[:], []byte(s)); err != nil {
+		return NilID, ErrInvalidID
+	}
+	return id, nil
+}
+
+func (id ID) Hex() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id ID) String() string {
+	return id.Hex()
+}
+
+func (id ID) IsZero() bool {
+	return id == NilID
+}
+
+// MarshalText encodes the ID as hex, which also makes it a string in JSON
+func (id ID) MarshalText() ([]byte, error) {
+	return []byte(id.Hex()), nil
+}
+
+// UnmarshalText accepts the hex form, or an empty string for the zero ID
+func (id *ID) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*id = NilID
+		return nil
+	}
+	parsed, err := ParseID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewID(t *testing.T) {
+	a, b := NewID(), NewID()
+	assert.NotEqual(t, a, b)
+	assert.False(t, a.IsZero())
+	assert.True(t, NilID.IsZero())
+	assert.Len(t, a.Hex(), 24)
+}
+
+func TestParseID(t *testing.T) {
+	id, err := ParseID("680f74774848325f4e61925e")
+	require.NoError(t, err)
+	assert.Equal(t, "680f74774848325f4e61925e", id.Hex())
+	assert.Equal(t, "680f74774848325f4e61925e", id.String())
+
+	for _, invalid := range []string{"", "680f7477", "680f74774848325f4e61925z", "680f74774848325f4e61925e00"} {
+		_, err := ParseID(invalid)
+		assert.ErrorIs(t, err, ErrInvalidID, invalid)
+	}
+}
+
+func TestIDJSON(t *testing.T) {
+	id, err := ParseID("680f74774848325f4e61925e")
+	require.NoError(t, err)
+
+	data, err := json.Marshal(UserResponse{ID: id, Name: "Dev"})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"id":"680f74774848325f4e61925e"`)
+
+	var req AssignBugRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"developer_id":"680f74774848325f4e61925e"}`), &req))
+	assert.Equal(t, id, req.DeveloperID)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"developer_id":""}`), &req))
+	assert.True(t, req.DeveloperID.IsZero())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"developer_id":"not-an-id"}`), &req))
+	assert.Error(t, json.Unmarshal([]byte(`{"developer_id":42}`), &req))
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// ImportOptions describes where imported bugs come from and how to read them
+type ImportOptions struct {
+	Format string `json:"format"` // "csv", "github" or "jira"
+	// Mapping maps bug fields (title, description, status, priority,
+	// reporter, assignee, created_at, resolved_at, external_id) to source
+	// CSV column names. Only used by the generic csv format.
+	Mapping map[string]string `json:"mapping,omitempty"`
+	// UserMap maps source user identities (GitHub logins, Jira usernames)
+	// to the email of an existing user. Identities that already look like
+	// emails are looked up directly.
+	UserMap map[string]string `json:"user_map,omitempty"`
+	DryRun  bool              `json:"dry_run"`
+}
+
+// ImportRecord is a single bug read from an import source, before validation
+type ImportRecord struct {
+	Line        int
+	ExternalID  string
+	Title       string
+	Description string
+	Status      string
+	Priority    string
+	Labels      []string
+	Reporter    string
+	Assignee    string
+	CreatedAt   *time.Time
+	ResolvedAt  *time.Time
+	Err         error // set when the source row itself could not be read
+}
+
+type ImportRowResult struct {
+	Line       int      `json:"line"`
+	ExternalID string   `json:"external_id"`
+	Title      string   `json:"title"`
+	Action     string   `json:"action"` // "create", "skip" or "error"
+	BugID      *ID      `json:"bug_id,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
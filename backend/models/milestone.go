@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// Milestone states
+const (
+	MilestoneOpen   = "open"
+	MilestoneClosed = "closed"
+)
+
+// Milestone is a release. Bugs name the milestone they were found in as
+// their affected version and the one they are fixed in as their fix
+// version.
+type Milestone struct {
+	ID          ID     `bson:"_id,omitempty" json:"id"`
+	Name        string `bson:"name" json:"name"`
+	Description string `bson:"description" json:"description"`
+	// DueDate is the planned release date as YYYY-MM-DD, or empty
+	DueDate   string    `bson:"due_date" json:"due_date"`
+	State     string    `bson:"state" json:"state"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+	// ClosedAt is when the milestone was closed, nil while it is open
+	ClosedAt *time.Time `bson:"closed_at,omitempty" json:"closed_at,omitempty"`
+}
+
+// SaveMilestoneRequest creates a milestone or replaces its details
+type SaveMilestoneRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=2000"`
+	DueDate     string `json:"due_date" binding:"omitempty,datetime=2006-01-02"`
+	// State defaults to open, and is kept on update when left out
+	State string `json:"state" binding:"omitempty,oneof=open closed"`
+}
+
+// MilestoneRef names a milestone in bug responses
+type MilestoneRef struct {
+	ID   ID     `json:"id"`
+	Name string `json:"name"`
+}
+
+// MilestoneProgress counts the bugs to be fixed in a milestone
+type MilestoneProgress struct {
+	Milestone *Milestone `json:"milestone"`
+	Total     int        `json:"total"`
+	// Open counts open and in-progress bugs, Closed resolved ones
+	Open            int             `json:"open"`
+	Closed          int             `json:"closed"`
+	PercentComplete float64         `json:"percent_complete"`
+	ByStatus        []CountBucket   `json:"by_status"`
+	Burndown        []BurndownPoint `json:"burndown"`
+}
+
+// BurndownPoint is the number of a milestone's bugs still open at the end
+// of a day
+type BurndownPoint struct {
+	Date      string `json:"date"`
+	Remaining int    `json:"remaining"`
+	// Ideal is what would remain burning down evenly to the due date. It is
+	// left out for milestones without one.
+	Ideal *float64 `json:"ideal,omitempty"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+// ReportFilter restricts report queries to bugs within a date range.
+// A nil bound leaves that side of the range open.
+type ReportFilter struct {
+	From *time.Time
+	To   *time.Time
+}
+
+type CountBucket struct {
+	Key   string `bson:"_id" json:"key"`
+	Count int    `bson:"count" json:"count"`
+}
+
+type AssigneeBucket struct {
+	AssigneeID ID  `bson:"_id"`
+	Count      int `bson:"count"`
+}
+
+type AssigneeCount struct {
+	Assignee *UserResponse `json:"assignee"` // nil for unassigned bugs
+	Count    int           `json:"count"`
+}
+
+type SummaryReport struct {
+	ByStatus   []CountBucket   `json:"by_status"`
+	ByPriority []CountBucket   `json:"by_priority"`
+	ByAssignee []AssigneeCount `json:"by_assignee"`
+}
+
+type TrendPoint struct {
+	Period string `json:"period"`
+	Opened int    `json:"opened"`
+	Closed int    `json:"closed"`
+}
+
+type ResolutionReport struct {
+	Resolved  int     `bson:"count" json:"resolved"`
+	MeanHours float64 `bson:"mean_hours" json:"mean_hours"`
+	MinHours  float64 `bson:"min_hours" json:"min_hours"`
+	MaxHours  float64 `bson:"max_hours" json:"max_hours"`
+}
+
+type AgeingBucket struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// DeveloperThroughput is the raw per-assignee aggregation result
+type DeveloperThroughput struct {
+	DeveloperID ID      `bson:"_id" json:"-"`
+	Assigned    int     `bson:"assigned" json:"assigned"`
+	Resolved    int     `bson:"resolved" json:"resolved"`
+	MeanHours   float64 `bson:"mean_hours" json:"mean_resolution_hours"`
+}
+
+type DeveloperThroughputResponse struct {
+	Developer UserResponse `json:"developer"`
+	DeveloperThroughput
+}
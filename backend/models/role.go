@@ -0,0 +1,132 @@
+package models
+
+import (
+	"regexp"
+	"time"
+)
+
+// Permissions roles may grant
+const (
+	// PermBugView lets users see every bug; without it they see the bugs
+	// they reported or are assigned
+	PermBugView   = "bug.view"
+	PermBugCreate = "bug.create"
+	// PermBugEdit lets users edit any bug; reporters and assignees may
+	// always edit theirs
+	PermBugEdit = "bug.edit"
+	// PermBugStatusChange lets users change the status of any bug;
+	// assignees may always change theirs
+	PermBugStatusChange = "bug.status.change"
+	PermBugAssign       = "bug.assign"
+	// PermBugAssignable lets bugs be assigned to users
+	PermBugAssignable = "bug.assignable"
+	PermBugDelete     = "bug.delete"
+	PermBugImport     = "bug.import"
+	PermReportView    = "report.view"
+	// PermUserManage covers lockouts, two-factor resets, role changes and
+	// deactivation
+	PermUserManage  = "user.manage"
+	PermRoleManage  = "role.manage"
+	PermFieldManage = "field.manage"
+	// PermTemplateManage lets users edit the templates bugs are reported from
+	PermTemplateManage = "template.manage"
+	// PermMilestoneManage lets users edit milestones. Setting the versions
+	// of a bug takes the right to edit it.
+	PermMilestoneManage = "milestone.manage"
+)
+
+// Permission describes a permission for role editors
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Permissions lists every permission, in the order editors show them
+var Permissions = []Permission{
+	{PermBugView, "See every bug, not only those assigned to you"},
+	{PermBugCreate, "Report bugs"},
+	{PermBugEdit, "Edit any bug, not only those you reported or are assigned"},
+	{PermBugStatusChange, "Change the status of any bug, not only those assigned to you"},
+	{PermBugAssign, "Assign bugs"},
+	{PermBugAssignable, "Be assigned bugs"},
+	{PermBugDelete, "Delete bugs"},
+	{PermBugImport, "Import bugs"},
+	{PermReportView, "View reports"},
+	{PermUserManage, "Unlock accounts, reset two-factor authentication, change roles and deactivate accounts"},
+	{PermRoleManage, "Edit roles and their permissions"},
+	{PermFieldManage, "Define the custom fields of bugs"},
+	{PermTemplateManage, "Edit bug templates"},
+	{PermMilestoneManage, "Edit milestones"},
+}
+
+// ValidPermission reports whether name is a known permission
+func ValidPermission(name string) bool {
+	for _, p := range Permissions {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// ValidRoleName reports whether name is 1 to 32 lowercase letters, digits,
+// '-' or '_'
+func ValidRoleName(name string) bool {
+	return roleNamePattern.MatchString(name)
+}
+
+// Built-in roles. They can be edited but not deleted, and admin must keep
+// role.manage, so admins can't lock themselves out.
+const (
+	RoleAdmin     = "admin"
+	RoleManager   = "manager"
+	RoleDeveloper = "developer"
+)
+
+// BuiltinRole reports whether name is a built-in role
+func BuiltinRole(name string) bool {
+	return name == RoleAdmin || name == RoleManager || name == RoleDeveloper
+}
+
+// Role is a named set of permissions users are given
+type Role struct {
+	Name        string    `bson:"_id" json:"name"`
+	Permissions []string  `bson:"permissions" json:"permissions"`
+	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Has reports whether the role grants permission
+func (r *Role) Has(permission string) bool {
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRoles returns the built-in roles as first set up
+func DefaultRoles() []*Role {
+	var admin []string
+	for _, p := range Permissions {
+		if p.Name != PermBugAssignable {
+			admin = append(admin, p.Name)
+		}
+	}
+	return []*Role{
+		{Name: RoleAdmin, Permissions: admin},
+		{Name: RoleDeveloper, Permissions: []string{PermBugCreate, PermBugAssignable}},
+		{Name: RoleManager, Permissions: []string{
+			PermBugView, PermBugCreate, PermBugEdit, PermBugStatusChange,
+			PermBugAssign, PermBugDelete, PermBugImport, PermReportView,
+			PermTemplateManage, PermMilestoneManage,
+		}},
+	}
+}
+
+// SaveRoleRequest sets the permissions of a role, creating it if needed
+type SaveRoleRequest struct {
+	Permissions []string `json:"permissions" binding:"required"`
+}
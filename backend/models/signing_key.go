@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// SigningKey signs login tokens. Tokens name the key that signed them by
+// its ID in the kid header.
+type SigningKey struct {
+	ID        ID     `bson:"_id,omitempty"`
+	Algorithm string `bson:"algorithm"`
+	// PrivateKey is the PKCS #8 encoding of the key
+	PrivateKey []byte `bson:"private_key"`
+	// ActiveAt is when the key starts signing. Keys are published before
+	// then so verifiers have them by the time tokens use them.
+	ActiveAt  time.Time `bson:"active_at"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// JSONWebKey is a public key in the JWK format of RFC 7517
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	// N and E are the modulus and exponent of RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Curve and X are the curve and public key of Ed25519 keys
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet is what /.well-known/jwks.json serves
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
@@ -0,0 +1,25 @@
+package models
+
+// OIDCIdentity links a user to their account at an OpenID Connect provider
+type OIDCIdentity struct {
+	Issuer  string `bson:"issuer" json:"issuer"`
+	Subject string `bson:"subject" json:"subject"`
+}
+
+// SSOAuthorization starts a single sign-on. The client sends the user to
+// AuthorizationURL and keeps State to check against the one the provider
+// returns.
+type SSOAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+	// Binding must come back with the state. It is kept in a cookie, out
+	// of reach of scripts.
+	Binding string `json:"-"`
+}
+
+// SSOCallbackRequest completes a single sign-on with what the provider
+// returned to the redirect URL
+type SSOCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// BugTemplate gives bug reports a structure. Its Description is a Markdown
+// skeleton whose headings, such as "## Steps to reproduce", name sections
+// reporters fill in.
+type BugTemplate struct {
+	ID   ID     `bson:"_id,omitempty" json:"id"`
+	Name string `bson:"name" json:"name"`
+	// TitlePrefix starts the title of bugs reported from the template
+	TitlePrefix string `bson:"title_prefix" json:"title_prefix"`
+	Description string `bson:"description" json:"description"`
+	// RequiredSections are headings of Description whose sections must be
+	// filled in
+	RequiredSections []string `bson:"required_sections" json:"required_sections"`
+	// Priority and Labels are the defaults of bugs reported from the template
+	Priority  string    `bson:"priority" json:"priority"`
+	Labels    []string  `bson:"labels" json:"labels"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// SaveBugTemplateRequest creates a bug template or replaces its contents
+type SaveBugTemplateRequest struct {
+	Name             string   `json:"name" binding:"required,max=100"`
+	TitlePrefix      string   `json:"title_prefix" binding:"max=50"`
+	Description      string   `json:"description" binding:"required"`
+	RequiredSections []string `json:"required_sections"`
+	Priority         string   `json:"priority" binding:"omitempty,oneof=low medium high critical"`
+	Labels           []string `json:"labels"`
+}
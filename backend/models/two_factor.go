@@ -0,0 +1,56 @@
+package models
+
+// TwoFactor holds a user's TOTP settings. The secret is stored while
+// enrollment is pending, and Enabled once a code has confirmed it.
+type TwoFactor struct {
+	Secret  string `bson:"secret" json:"secret"`
+	Enabled bool   `bson:"enabled" json:"enabled"`
+	// LastStep is the TOTP period of the last accepted code, so no code
+	// can be used twice
+	LastStep int64 `bson:"last_step" json:"last_step"`
+	// RecoveryCodes are SHA-256 hashes of the unused recovery codes
+	RecoveryCodes []string `bson:"recovery_codes" json:"recovery_codes"`
+}
+
+// TwoFactorEnabled reports whether the user must enter a code to log in
+func (u *User) TwoFactorEnabled() bool {
+	return u.TwoFactor != nil && u.TwoFactor.Enabled
+}
+
+// TwoFactorStatus is what users see of their own two-factor settings
+type TwoFactorStatus struct {
+	Enabled bool `json:"enabled"`
+	// Pending is true between enrollment and confirmation
+	Pending bool `json:"pending"`
+	// Required is true when the user's role must use two-factor
+	// authentication
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// TwoFactorEnrollment is the secret to add to an authenticator app, as
+// text and as an otpauth:// URI for a QR code
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// TwoFactorConfirmation completes enrollment. The recovery codes are shown
+// once; only their hashes are stored. Token is a fresh session token, so
+// users who had to enroll before logging in end up logged in.
+type TwoFactorConfirmation struct {
+	RecoveryCodes []string     `json:"recovery_codes"`
+	Token         string       `json:"token"`
+	User          UserResponse `json:"user"`
+}
+
+// TwoFactorCodeRequest carries a TOTP or recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorLoginRequest completes a login that returned a challenge
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
@@ -1,27 +1,73 @@
 package models
 
 import (
+	"log/slog"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type User struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Name      string             `bson:"name" json:"name"`
-	Email     string             `bson:"email" json:"email"`
-	Password  string             `bson:"password" json:"-"`
-	Role      string             `bson:"role" json:"role"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	ID        ID        `bson:"_id,omitempty" json:"id,omitempty"`
+	Name      string    `bson:"name" json:"name"`
+	Email     string    `bson:"email" json:"email"`
+	Password  string    `bson:"password" json:"-"`
+	Role      string    `bson:"role" json:"role"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+	// FailedLogins counts wrong passwords since the last successful login
+	FailedLogins int `bson:"failed_logins,omitempty" json:"-"`
+	// LockedUntil is set while the account is locked after too many
+	// failed logins
+	LockedUntil *time.Time `bson:"locked_until,omitempty" json:"-"`
+	// TwoFactor is nil until the user starts setting up two-factor
+	// authentication
+	TwoFactor *TwoFactor `bson:"two_factor,omitempty" json:"-"`
+	// OIDC is set once the user has signed in with single sign-on
+	OIDC *OIDCIdentity `bson:"oidc,omitempty" json:"-"`
+	// DeactivatedAt is set while an admin has deactivated the account
+	DeactivatedAt *time.Time `bson:"deactivated_at,omitempty" json:"-"`
+}
+
+// Deactivated reports whether the account has been deactivated
+func (u *User) Deactivated() bool {
+	return u.DeactivatedAt != nil
+}
+
+// Locked reports whether the account is locked out at now
+func (u *User) Locked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// LockoutStatus is what admins see of an account's failed logins
+type LockoutStatus struct {
+	User         UserResponse `json:"user"`
+	FailedLogins int          `json:"failed_logins"`
+	Locked       bool         `json:"locked"`
+	LockedUntil  *time.Time   `json:"locked_until,omitempty"`
+}
+
+// LockoutStatus reports the user's failed logins and lock at now
+func (u *User) LockoutStatus(now time.Time) LockoutStatus {
+	status := LockoutStatus{User: u.ToResponse(), FailedLogins: u.FailedLogins}
+	if u.Locked(now) {
+		status.Locked = true
+		status.LockedUntil = u.LockedUntil
+	}
+	return status
 }
 
 type UserResponse struct {
-	ID    primitive.ObjectID `json:"id"`
-	Name  string             `json:"name"`
-	Email string             `json:"email"`
-	Role  string             `json:"role"`
+	ID          ID     `json:"id"`
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	Role        string `json:"role"`
+	Deactivated bool   `json:"deactivated,omitempty"`
+}
+
+// ChangeRoleRequest gives a user another role
+type ChangeRoleRequest struct {
+	Role string `json:"role" binding:"required"`
 }
 
 type LoginRequest struct {
@@ -29,6 +75,19 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required,min=6"`
 }
 
+// LoginResult is what a correct password earns: a session Token, or a
+// ChallengeToken to exchange for one once the second factor is checked
+type LoginResult struct {
+	Token string        `json:"token,omitempty"`
+	User  *UserResponse `json:"user,omitempty"`
+	// ChallengeToken is set instead of Token when the user must enter a
+	// two-factor code, or set two-factor authentication up first when
+	// EnrollmentRequired
+	ChallengeToken     string `json:"challenge_token,omitempty"`
+	TwoFactorRequired  bool   `json:"two_factor_required,omitempty"`
+	EnrollmentRequired bool   `json:"enrollment_required,omitempty"`
+}
+
 type RegisterRequest struct {
 	Name     string `json:"name" binding:"required,min=2"`
 	Email    string `json:"email" binding:"required,email"`
@@ -36,6 +95,20 @@ type RegisterRequest struct {
 	Role     string `json:"role" binding:"required,oneof=admin developer manager"`
 }
 
+// LogValue omits the password when the request is logged
+func (r LoginRequest) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("email", r.Email))
+}
+
+// LogValue omits the password when the request is logged
+func (r RegisterRequest) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("name", r.Name),
+		slog.String("email", r.Email),
+		slog.String("role", r.Role),
+	)
+}
+
 // HashPassword hashes the user's password
 func (u *User) HashPassword() error {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
@@ -54,9 +127,10 @@ func (u *User) CheckPassword(password string) error {
 // ToResponse converts User to UserResponse
 func (u *User) ToResponse() UserResponse {
 	return UserResponse{
-		ID:    u.ID,
-		Name:  u.Name,
-		Email: u.Email,
-		Role:  u.Role,
+		ID:          u.ID,
+		Name:        u.Name,
+		Email:       u.Email,
+		Role:        u.Role,
+		Deactivated: u.Deactivated(),
 	}
 }
@@ -0,0 +1,49 @@
+package oidc
+
+// Claims are the verified claims of an ID token
+type Claims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	// PreferredUsername is the username the user goes by at the provider
+	PreferredUsername string
+
+	raw map[string]interface{}
+}
+
+func newClaims(raw map[string]interface{}) *Claims {
+	c := &Claims{raw: raw}
+	c.Issuer, _ = raw["iss"].(string)
+	c.Subject, _ = raw["sub"].(string)
+	c.Email, _ = raw["email"].(string)
+	c.Name, _ = raw["name"].(string)
+	c.PreferredUsername, _ = raw["preferred_username"].(string)
+	switch verified := raw["email_verified"].(type) {
+	case bool:
+		c.EmailVerified = verified
+	case string:
+		// Some providers send the boolean as a string
+		c.EmailVerified = verified == "true"
+	}
+	return c
+}
+
+// Strings returns the values of claim name, which may be a string or a
+// list of strings such as groups
+func (c *Claims) Strings(name string) []string {
+	switch v := c.raw[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,326 @@
+// Package oidc signs users in with an OpenID Connect provider using the
+// authorization code flow with PKCE. It discovers the provider's endpoints,
+// exchanges codes for ID tokens and verifies them against the provider's
+// published keys.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrInvalidIDToken is returned for ID tokens that fail verification
+	ErrInvalidIDToken = errors.New("oidc: invalid ID token")
+)
+
+const (
+	// keyRefreshInterval limits how often an unknown key ID refetches the
+	// provider's keys, so forged tokens can't make us hammer it
+	keyRefreshInterval = time.Minute
+	// clockSkew is the leeway given to the provider's clock
+	clockSkew = time.Minute
+	// maxResponseSize caps what we read from the provider
+	maxResponseSize = 1 << 20
+)
+
+// signingMethods are the ID token algorithms accepted. The keys come from
+// the provider, so HMAC, which would use the client secret, is left out.
+var signingMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+// Config describes the client registered with the provider
+type Config struct {
+	// Issuer is the provider's URL, which serves
+	// /.well-known/openid-configuration
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// HTTPClient defaults to a client with a 10 second timeout
+	HTTPClient *http.Client
+}
+
+// ProviderError is an error response from the provider's token endpoint
+type ProviderError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *ProviderError) Error() string {
+	if e.Description != "" {
+		return "oidc: " + e.Code + ": " + e.Description
+	}
+	return "oidc: " + e.Code
+}
+
+// Provider is an OpenID Connect provider. Its endpoints are discovered on
+// first use, so the server starts while the provider is down.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu       sync.Mutex
+	metadata *metadata
+	keys     map[string]interface{}
+	// keysFetched is when keys were last fetched
+	keysFetched time.Time
+}
+
+// metadata is the part of the discovery document we use
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func NewProvider(cfg Config) *Provider {
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{cfg: cfg, client: client}
+}
+
+// AuthCodeURL returns the provider page that signs the user in and
+// redirects back to the redirect URL with a code and state. challenge is
+// the S256 challenge of the verifier later given to Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(md.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return md.AuthorizationEndpoint + sep + query.Encode(), nil
+}
+
+// Exchange trades an authorization code for an ID token and returns its
+// verified claims. nonce must match the one given to AuthCodeURL.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	md, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+		"client_id":     {p.cfg.ClientID},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, md.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		// RFC 6749 section 2.3.1 form-encodes the credentials first
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var response struct {
+		ProviderError
+		IDToken string `json:"id_token"`
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("oidc: reading token response: %w", err)
+	}
+	if err := json.Unmarshal(body, &response); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("oidc: decoding token response: %w", err)
+	}
+	if response.Code != "" {
+		return nil, &response.ProviderError
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: token endpoint returned %s", resp.Status)
+	}
+	if response.IDToken == "" {
+		return nil, fmt.Errorf("%w: token response has no ID token", ErrInvalidIDToken)
+	}
+
+	return p.verify(ctx, md, response.IDToken, nonce)
+}
+
+// verify checks the ID token's signature, issuer, audience, lifetime and
+// nonce
+func (p *Provider) verify(ctx context.Context, md *metadata, raw, nonce string) (*Claims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return p.key(ctx, md, kid)
+		},
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(md.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	// With several audiences the token must name us as the party it was
+	// issued to
+	if azp, ok := claims["azp"].(string); ok && azp != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: issued to %q", ErrInvalidIDToken, azp)
+	}
+
+	result := newClaims(claims)
+	if result.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+	return result, nil
+}
+
+// discover fetches the provider's metadata once it succeeds
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var md metadata
+	wellKnown := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &md); err != nil {
+		return nil, fmt.Errorf("oidc: discovery failed: %w", err)
+	}
+	// OpenID Connect Discovery section 4.3
+	if md.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc: discovery returned issuer %q, expected %q", md.Issuer, p.cfg.Issuer)
+	}
+	if md.AuthorizationEndpoint == "" || md.TokenEndpoint == "" || md.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery document lacks an endpoint")
+	}
+	p.metadata = &md
+	return p.metadata, nil
+}
+
+// key returns the provider's signing key kid, refetching the key set when
+// the key is unknown since providers rotate keys
+func (p *Provider) key(ctx context.Context, md *metadata, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookup(kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetched) < keyRefreshInterval {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, md.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("fetching keys: %w", err)
+	}
+	keys := make(map[string]interface{})
+	for _, jwk := range set.Keys {
+		// Skip encryption keys and key types we don't know
+		if key, err := jwk.publicKey(); err == nil && (jwk.Use == "" || jwk.Use == "sig") {
+			keys[jwk.Kid] = key
+		}
+	}
+	p.keys = keys
+	p.keysFetched = time.Now()
+
+	if key, ok := p.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// lookup finds key kid. Tokens without a key ID may use the only key.
+func (p *Provider) lookup(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
+
+// NewVerifier returns a random PKCE code verifier
+func NewVerifier() (string, error) {
+	return randomString(32)
+}
+
+// NewNonce returns a random value for the state or nonce parameters
+func NewNonce() (string, error) {
+	return randomString(16)
+}
+
+// S256 returns the PKCE challenge of verifier
+func S256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString(size int) (string, error) {
+	raw := make([]byte, size)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -0,0 +1,159 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"bug-tracker/oidc/oidctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "https://app.example.com/sso/callback"
+
+func newTestProvider(t *testing.T, secret string) (*Provider, *oidctest.Server) {
+	server := oidctest.NewServer(t, "bug-tracker", secret)
+	return NewProvider(Config{
+		Issuer:       server.URL,
+		ClientID:     "bug-tracker",
+		ClientSecret: secret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+	}), server
+}
+
+// signIn runs the flow up to the code and returns it with the verifier
+// and nonce
+func signIn(t *testing.T, p *Provider, server *oidctest.Server, claims map[string]interface{}) (code, verifier, nonce string) {
+	t.Helper()
+	verifier, err := NewVerifier()
+	require.NoError(t, err)
+	nonce, err = NewNonce()
+	require.NoError(t, err)
+
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", nonce, S256(verifier))
+	require.NoError(t, err)
+	code, state := server.Authorize(t, authURL, claims)
+	assert.Equal(t, "state-1", state)
+	return code, verifier, nonce
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	p, server := newTestProvider(t, "")
+
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", "nonce-1", "challenge")
+	require.NoError(t, err)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	query := u.Query()
+	assert.Equal(t, redirectURL, query.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "state-1", query.Get("state"))
+	assert.Equal(t, "nonce-1", query.Get("nonce"))
+	assert.Equal(t, "challenge", query.Get("code_challenge"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	server := oidctest.NewServer(t, "bug-tracker", "")
+	p := NewProvider(Config{Issuer: server.URL + "/", ClientID: "bug-tracker"})
+
+	_, err := p.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	assert.ErrorContains(t, err, "issuer")
+}
+
+func TestS256(t *testing.T) {
+	// RFC 7636 appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", S256("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
+
+func TestExchange(t *testing.T) {
+	for _, secret := range []string{"", "s3cret:/+"} {
+		p, server := newTestProvider(t, secret)
+		code, verifier, nonce := signIn(t, p, server, map[string]interface{}{
+			"email":          "ada@example.com",
+			"email_verified": true,
+			"name":           "Ada Lovelace",
+			"groups":         []string{"staff", "bt-admins"},
+		})
+
+		claims, err := p.Exchange(context.Background(), code, verifier, nonce)
+		require.NoError(t, err, "secret %q", secret)
+		assert.Equal(t, server.URL, claims.Issuer)
+		assert.Equal(t, "user-1", claims.Subject)
+		assert.Equal(t, "ada@example.com", claims.Email)
+		assert.True(t, claims.EmailVerified)
+		assert.Equal(t, "Ada Lovelace", claims.Name)
+		assert.Equal(t, []string{"staff", "bt-admins"}, claims.Strings("groups"))
+		assert.Nil(t, claims.Strings("missing"))
+
+		// Codes work once
+		_, err = p.Exchange(context.Background(), code, verifier, nonce)
+		var providerErr *ProviderError
+		require.True(t, errors.As(err, &providerErr))
+		assert.Equal(t, "invalid_grant", providerErr.Code)
+	}
+}
+
+func TestExchangeRejects(t *testing.T) {
+	p, server := newTestProvider(t, "")
+	ctx := context.Background()
+	hour := time.Hour
+
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+	}{
+		{name: "other audience", claims: map[string]interface{}{"aud": "someone-else"}},
+		{name: "other issuer", claims: map[string]interface{}{"iss": "https://evil.example.com"}},
+		{name: "expired", claims: map[string]interface{}{"exp": time.Now().Add(-hour).Unix()}},
+		{name: "no expiry", claims: map[string]interface{}{"exp": nil}},
+		{name: "no subject", claims: map[string]interface{}{"sub": nil}},
+		{name: "issued to another party", claims: map[string]interface{}{"aud": []string{"bug-tracker", "other"}, "azp": "other"}},
+	}
+	for _, tt := range tests {
+		code, verifier, nonce := signIn(t, p, server, tt.claims)
+		_, err := p.Exchange(ctx, code, verifier, nonce)
+		assert.ErrorIs(t, err, ErrInvalidIDToken, tt.name)
+	}
+
+	// A replayed ID token carries another login's nonce
+	code, verifier, _ := signIn(t, p, server, nil)
+	_, err := p.Exchange(ctx, code, verifier, "other-nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+
+	// PKCE: an intercepted code is useless without the verifier
+	code, _, nonce := signIn(t, p, server, nil)
+	other, err := NewVerifier()
+	require.NoError(t, err)
+	_, err = p.Exchange(ctx, code, other, nonce)
+	var providerErr *ProviderError
+	require.True(t, errors.As(err, &providerErr))
+	assert.Equal(t, "invalid_grant", providerErr.Code)
+}
+
+func TestKeyRotation(t *testing.T) {
+	p, server := newTestProvider(t, "")
+	ctx := context.Background()
+
+	code, verifier, nonce := signIn(t, p, server, nil)
+	_, err := p.Exchange(ctx, code, verifier, nonce)
+	require.NoError(t, err)
+
+	server.RotateKey(t)
+
+	// Unknown keys don't refetch the key set more than once a minute
+	code, verifier, nonce = signIn(t, p, server, nil)
+	_, err = p.Exchange(ctx, code, verifier, nonce)
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+
+	p.keysFetched = time.Now().Add(-keyRefreshInterval)
+	code, verifier, nonce = signIn(t, p, server, nil)
+	_, err = p.Exchange(ctx, code, verifier, nonce)
+	assert.NoError(t, err)
+}
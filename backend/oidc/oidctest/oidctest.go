@@ -0,0 +1,203 @@
+// Package oidctest is a stand-in OpenID Connect provider for tests. It
+// serves discovery, keys and a token endpoint that checks PKCE, and signs
+// users in without a login page: tests call Authorize with the claims the
+// user should have.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// Server is a running stand-in provider. Its URL is the issuer.
+type Server struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	mu     sync.Mutex
+	key    *rsa.PrivateKey
+	kid    string
+	grants map[string]grant
+	serial int
+}
+
+// grant is an authorization code waiting to be exchanged
+type grant struct {
+	claims      jwt.MapClaims
+	challenge   string
+	redirectURI string
+}
+
+// NewServer starts a provider for the given client, stopped when the test
+// ends. An empty secret makes a public client.
+func NewServer(t *testing.T, clientID, clientSecret string) *Server {
+	t.Helper()
+	s := &Server{ClientID: clientID, ClientSecret: clientSecret, grants: make(map[string]grant)}
+	s.RotateKey(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/keys", s.keys)
+	mux.HandleFunc("/token", s.token)
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// RotateKey replaces the signing key, as providers do from time to time
+func (s *Server) RotateKey(t *testing.T) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serial++
+	s.key = key
+	s.kid = "key-" + strconv.Itoa(s.serial)
+}
+
+// Authorize signs a user with claims in at authURL, as returned by
+// AuthCodeURL, and returns the code and state the provider redirects back
+// with. claims override the defaults, which are a valid ID token for
+// subject "user-1"; a nil value removes a claim.
+func (s *Server) Authorize(t *testing.T, authURL string, claims map[string]interface{}) (code, state string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := u.Query()
+	require.Equal(t, s.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	require.Equal(t, "code", query.Get("response_type"))
+	require.Equal(t, s.ClientID, query.Get("client_id"))
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+	require.NotEmpty(t, query.Get("code_challenge"))
+
+	now := time.Now()
+	idClaims := jwt.MapClaims{
+		"iss":   s.URL,
+		"sub":   "user-1",
+		"aud":   s.ClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": query.Get("nonce"),
+	}
+	for name, value := range claims {
+		if value == nil {
+			delete(idClaims, name)
+		} else {
+			idClaims[name] = value
+		}
+	}
+
+	code = randomString()
+	s.mu.Lock()
+	s.grants[code] = grant{claims: idClaims, challenge: query.Get("code_challenge"), redirectURI: query.Get("redirect_uri")}
+	s.mu.Unlock()
+	return code, query.Get("state")
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/keys",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) keys(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": s.kid,
+			"n":   base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	fail := func(code string) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+	}
+	if r.Method != http.MethodPost || r.ParseForm() != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		fail("invalid_request")
+		return
+	}
+	if s.ClientSecret != "" {
+		id, secret, ok := r.BasicAuth()
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+		if !ok || id != s.ClientID || secret != s.ClientSecret {
+			fail("invalid_client")
+			return
+		}
+	} else if r.PostForm.Get("client_id") != s.ClientID {
+		fail("invalid_client")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code := r.PostForm.Get("code")
+	g, ok := s.grants[code]
+	// Codes work once
+	delete(s.grants, code)
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || g.redirectURI != r.PostForm.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+		fail("invalid_grant")
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, g.claims)
+	token.Header["kid"] = s.kid
+	idToken, err := token.SignedString(s.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
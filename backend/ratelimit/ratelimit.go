@@ -0,0 +1,156 @@
+// Package ratelimit implements token bucket rate limits. Buckets live in a
+// Store, so limits can be shared between replicas by a store backed by a
+// shared cache; MemoryStore keeps them in process.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit allows Requests per Per, in bursts of up to Requests. The zero
+// Limit allows everything.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// ParseLimit parses limits such as "10/1m", or "off" for no limit
+func ParseLimit(s string) (Limit, error) {
+	if s == "" || s == "off" {
+		return Limit{}, nil
+	}
+	requests, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("%q is not a limit such as 10/1m", s)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("%q is not a limit such as 10/1m", s)
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("%q is not a limit such as 10/1m", s)
+	}
+	return Limit{Requests: n, Per: d}, nil
+}
+
+// UnmarshalText lets limits be read from configuration
+func (l *Limit) UnmarshalText(text []byte) error {
+	parsed, err := ParseLimit(string(text))
+	if err != nil {
+		return err
+	}
+	*l = parsed
+	return nil
+}
+
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "off"
+	}
+	return strconv.Itoa(l.Requests) + "/" + l.Per.String()
+}
+
+// Enabled reports whether l limits anything
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Per > 0
+}
+
+// Result is the outcome of taking a token
+type Result struct {
+	Allowed bool
+	// Remaining is the number of requests left in the current burst
+	Remaining int
+	// RetryAfter is how long until the next request would be allowed,
+	// when this one was not
+	RetryAfter time.Duration
+}
+
+// Store holds token buckets by key
+type Store interface {
+	// Take removes a token from key's bucket if one is left
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// sweepInterval is how often MemoryStore forgets buckets that have refilled
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in process memory. Buckets that have refilled
+// are dropped, so memory stays proportional to recent clients.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// Ensure MemoryStore implements Store
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	if !limit.Enabled() {
+		return Result{Allowed: true, Remaining: math.MaxInt32}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Requests), last: now, limit: limit}
+		s.buckets[key] = b
+	}
+	b.refill(now)
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / b.rate() * float64(time.Second))
+		return Result{RetryAfter: wait}, nil
+	}
+	b.tokens--
+	return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+// sweep drops the buckets that would be full by now. The caller must hold
+// the lock.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Requests) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// rate is the bucket's refill rate in tokens per second
+func (b *bucket) rate() float64 {
+	return float64(b.limit.Requests) / b.limit.Per.Seconds()
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Requests), b.tokens+elapsed.Seconds()*b.rate())
+		b.last = now
+	}
+}
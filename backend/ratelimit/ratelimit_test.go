@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Limit
+		wantErr  bool
+	}{
+		{input: "10/1m", expected: Limit{Requests: 10, Per: time.Minute}},
+		{input: "300/1s", expected: Limit{Requests: 300, Per: time.Second}},
+		{input: "off", expected: Limit{}},
+		{input: "", expected: Limit{}},
+		{input: "10", wantErr: true},
+		{input: "0/1m", wantErr: true},
+		{input: "10/soon", wantErr: true},
+		{input: "10/-1m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			limit, err := ParseLimit(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, limit)
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Requests: 3, Per: time.Minute}
+
+	// A full bucket allows a burst
+	for remaining := 2; remaining >= 0; remaining-- {
+		result, err := store.Take(ctx, "a", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, remaining, result.Remaining)
+	}
+
+	result, err := store.Take(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 20*time.Second, result.RetryAfter)
+
+	// Other keys have their own bucket
+	result, err = store.Take(ctx, "b", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// One token refills every 20 seconds
+	now = now.Add(20 * time.Second)
+	result, err = store.Take(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	result, err = store.Take(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	// Refilled buckets are forgotten
+	now = now.Add(time.Hour)
+	_, err = store.Take(ctx, "c", limit)
+	require.NoError(t, err)
+	assert.Len(t, store.buckets, 1)
+
+	// Disabled limits allow everything
+	for i := 0; i < 10; i++ {
+		result, err = store.Take(ctx, "d", Limit{})
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+}
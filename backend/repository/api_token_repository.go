@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"bug-tracker/models"
+)
+
+type APITokenRepositoryInterface interface {
+	// Create stores token, setting its ID and creation time
+	Create(ctx context.Context, token *models.APIToken) error
+	FindByID(ctx context.Context, id models.ID) (*models.APIToken, error)
+	FindByHash(ctx context.Context, hash string) (*models.APIToken, error)
+	// FindByUser returns the user's tokens, oldest first
+	FindByUser(ctx context.Context, userID models.ID) ([]*models.APIToken, error)
+	Delete(ctx context.Context, id models.ID) error
+	// SetLastUsed records when the token was last used
+	SetLastUsed(ctx context.Context, id models.ID, at time.Time) error
+}
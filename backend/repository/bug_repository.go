@@ -1,144 +1,27 @@
 package repository
 
 import (
-	"bug-tracker/models"
 	"context"
-	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
+	"bug-tracker/models"
 )
 
 type BugRepositoryInterface interface {
 	Create(ctx context.Context, bug *models.Bug) error
-	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Bug, error)
+	FindByID(ctx context.Context, id models.ID) (*models.Bug, error)
 	FindAll(ctx context.Context) ([]*models.Bug, error)
-	FindByAssignee(ctx context.Context, assigneeID primitive.ObjectID) ([]*models.Bug, error)
-	UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error
-	AssignToDeveloper(ctx context.Context, bugID, developerID primitive.ObjectID) error
+	FindByAssignee(ctx context.Context, assigneeID models.ID) ([]*models.Bug, error)
+	UpdateStatus(ctx context.Context, id models.ID, status string) error
+	AssignToDeveloper(ctx context.Context, bugID, developerID models.ID) error
 	Update(ctx context.Context, bug *models.Bug) error
-	Delete(ctx context.Context, id primitive.ObjectID) error
-}
-
-type BugRepository struct {
-	db *mongo.Database
-}
-
-func NewBugRepository(db *mongo.Database) *BugRepository {
-	return &BugRepository{db: db}
-}
-
-func (r *BugRepository) Create(ctx context.Context, bug *models.Bug) error {
-	collection := r.db.Collection("bugs")
-
-	bug.CreatedAt = time.Now()
-	bug.UpdatedAt = time.Now()
-	bug.Status = "open"
-
-	result, err := collection.InsertOne(ctx, bug)
-	if err != nil {
-		return err
-	}
-
-	bug.ID = result.InsertedID.(primitive.ObjectID)
-	return nil
-}
-
-func (r *BugRepository) FindAll(ctx context.Context) ([]*models.Bug, error) {
-	collection := r.db.Collection("bugs")
-
-	cursor, err := collection.Find(ctx, bson.M{})
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-
-	var bugs []*models.Bug
-	if err = cursor.All(ctx, &bugs); err != nil {
-		return nil, err
-	}
-
-	return bugs, nil
-}
-
-func (r *BugRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Bug, error) {
-	collection := r.db.Collection("bugs")
-
-	var bug models.Bug
-	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&bug)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, nil
-		}
-		return nil, err
-	}
-
-	return &bug, nil
-}
-
-func (r *BugRepository) FindByAssignee(ctx context.Context, developerID primitive.ObjectID) ([]*models.Bug, error) {
-	collection := r.db.Collection("bugs")
-
-	cursor, err := collection.Find(ctx, bson.M{"assigned_to": developerID})
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-
-	var bugs []*models.Bug
-	if err = cursor.All(ctx, &bugs); err != nil {
-		return nil, err
-	}
-
-	return bugs, nil
-}
-
-func (r *BugRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error {
-	collection := r.db.Collection("bugs")
-
-	update := bson.M{
-		"$set": bson.M{
-			"status":     status,
-			"updated_at": time.Now(),
-		},
-	}
-
-	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update)
-	return err
-}
-
-func (r *BugRepository) AssignToDeveloper(ctx context.Context, bugID, developerID primitive.ObjectID) error {
-	collection := r.db.Collection("bugs")
-
-	update := bson.M{
-		"$set": bson.M{
-			"assigned_to": developerID,
-			"updated_at":  time.Now(),
-		},
-	}
-
-	_, err := collection.UpdateOne(ctx, bson.M{"_id": bugID}, update)
-	return err
-}
-
-func (r *BugRepository) Update(ctx context.Context, bug *models.Bug) error {
-	collection := r.db.Collection("bugs")
-
-	bug.UpdatedAt = time.Now()
-
-	_, err := collection.ReplaceOne(
-		ctx,
-		bson.M{"_id": bug.ID},
-		bug,
-	)
-
-	return err
-}
-
-func (r *BugRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
-	collection := r.db.Collection("bugs")
-
-	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
-	return err
+	Delete(ctx context.Context, id models.ID) error
+	Stream(ctx context.Context, filter models.BugFilter, fn func(*models.Bug) error) error
+	FindByExternalID(ctx context.Context, externalID string) (*models.Bug, error)
+	CreateIfNotExists(ctx context.Context, bug *models.Bug) (bool, error)
+	Search(ctx context.Context, search models.BugSearch) ([]*models.Bug, error)
+	// RemoveField deletes the values of a custom field from every bug
+	RemoveField(ctx context.Context, key string) error
+	// ClearMilestone unsets a milestone wherever bugs name it as their
+	// affected or fix version
+	ClearMilestone(ctx context.Context, id models.ID) error
 }
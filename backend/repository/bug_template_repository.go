@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"bug-tracker/models"
+)
+
+type BugTemplateRepositoryInterface interface {
+	// Create stores template, setting its ID and timestamps
+	Create(ctx context.Context, template *models.BugTemplate) error
+	FindByID(ctx context.Context, id models.ID) (*models.BugTemplate, error)
+	// FindAll returns every template ordered by name
+	FindAll(ctx context.Context) ([]*models.BugTemplate, error)
+	// Update replaces the template with template.ID, setting UpdatedAt
+	Update(ctx context.Context, template *models.BugTemplate) error
+	Delete(ctx context.Context, id models.ID) error
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"bug-tracker/models"
+)
+
+type CustomFieldRepositoryInterface interface {
+	// FindAll returns every custom field ordered by key
+	FindAll(ctx context.Context) ([]*models.CustomField, error)
+	// Save creates or replaces the field with field.Key, setting UpdatedAt
+	Save(ctx context.Context, field *models.CustomField) error
+	Delete(ctx context.Context, key string) error
+}
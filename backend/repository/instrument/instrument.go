@@ -0,0 +1,464 @@
+// Package instrument wraps repositories so every call is reported to a
+// Hook, which metrics and tracing use to observe storage without each
+// backend knowing about them.
+package instrument
+
+import (
+	"context"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// Hook is called when a repository method starts. The returned context is
+// passed to the method and done is called with its error once it returns.
+type Hook func(ctx context.Context, repository, method string) (_ context.Context, done func(err error))
+
+// Chain combines hooks; the first hook's context is passed to the second
+func Chain(hooks ...Hook) Hook {
+	return func(ctx context.Context, repository, method string) (context.Context, func(error)) {
+		dones := make([]func(error), len(hooks))
+		for i, hook := range hooks {
+			ctx, dones[i] = hook(ctx, repository, method)
+		}
+		return ctx, func(err error) {
+			for i := len(dones) - 1; i >= 0; i-- {
+				dones[i](err)
+			}
+		}
+	}
+}
+
+// Users reports the operations of next to hook
+func Users(next repository.UserRepositoryInterface, hook Hook) repository.UserRepositoryInterface {
+	return &userRepository{next: next, hook: hook}
+}
+
+type userRepository struct {
+	next repository.UserRepositoryInterface
+	hook Hook
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) (err error) {
+	ctx, done := r.hook(ctx, "users", "Create")
+	defer func() { done(err) }()
+	return r.next.Create(ctx, user)
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (_ *models.User, err error) {
+	ctx, done := r.hook(ctx, "users", "FindByEmail")
+	defer func() { done(err) }()
+	return r.next.FindByEmail(ctx, email)
+}
+
+func (r *userRepository) FindByOIDC(ctx context.Context, issuer, subject string) (_ *models.User, err error) {
+	ctx, done := r.hook(ctx, "users", "FindByOIDC")
+	defer func() { done(err) }()
+	return r.next.FindByOIDC(ctx, issuer, subject)
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id models.ID) (_ *models.User, err error) {
+	ctx, done := r.hook(ctx, "users", "FindByID")
+	defer func() { done(err) }()
+	return r.next.FindByID(ctx, id)
+}
+
+func (r *userRepository) Update(ctx context.Context, user *models.User) (err error) {
+	ctx, done := r.hook(ctx, "users", "Update")
+	defer func() { done(err) }()
+	return r.next.Update(ctx, user)
+}
+
+func (r *userRepository) Delete(ctx context.Context, id models.ID) (err error) {
+	ctx, done := r.hook(ctx, "users", "Delete")
+	defer func() { done(err) }()
+	return r.next.Delete(ctx, id)
+}
+
+func (r *userRepository) FindByRole(ctx context.Context, role string) (_ []*models.User, err error) {
+	ctx, done := r.hook(ctx, "users", "FindByRole")
+	defer func() { done(err) }()
+	return r.next.FindByRole(ctx, role)
+}
+
+func (r *userRepository) FindAll(ctx context.Context) (_ []*models.User, err error) {
+	ctx, done := r.hook(ctx, "users", "FindAll")
+	defer func() { done(err) }()
+	return r.next.FindAll(ctx)
+}
+
+func (r *userRepository) RecordLoginFailure(ctx context.Context, id models.ID) (_ int, err error) {
+	ctx, done := r.hook(ctx, "users", "RecordLoginFailure")
+	defer func() { done(err) }()
+	return r.next.RecordLoginFailure(ctx, id)
+}
+
+func (r *userRepository) SetLockout(ctx context.Context, id models.ID, failedLogins int, lockedUntil *time.Time) (err error) {
+	ctx, done := r.hook(ctx, "users", "SetLockout")
+	defer func() { done(err) }()
+	return r.next.SetLockout(ctx, id, failedLogins, lockedUntil)
+}
+
+func (r *userRepository) SetTwoFactor(ctx context.Context, id models.ID, prev, next *models.TwoFactor) (_ bool, err error) {
+	ctx, done := r.hook(ctx, "users", "SetTwoFactor")
+	defer func() { done(err) }()
+	return r.next.SetTwoFactor(ctx, id, prev, next)
+}
+
+// Bugs reports the operations of next to hook
+func Bugs(next repository.BugRepositoryInterface, hook Hook) repository.BugRepositoryInterface {
+	return &bugRepository{next: next, hook: hook}
+}
+
+type bugRepository struct {
+	next repository.BugRepositoryInterface
+	hook Hook
+}
+
+func (r *bugRepository) Create(ctx context.Context, bug *models.Bug) (err error) {
+	ctx, done := r.hook(ctx, "bugs", "Create")
+	defer func() { done(err) }()
+	return r.next.Create(ctx, bug)
+}
+
+func (r *bugRepository) FindByID(ctx context.Context, id models.ID) (_ *models.Bug, err error) {
+	ctx, done := r.hook(ctx, "bugs", "FindByID")
+	defer func() { done(err) }()
+	return r.next.FindByID(ctx, id)
+}
+
+func (r *bugRepository) FindAll(ctx context.Context) (_ []*models.Bug, err error) {
+	ctx, done := r.hook(ctx, "bugs", "FindAll")
+	defer func() { done(err) }()
+	return r.next.FindAll(ctx)
+}
+
+func (r *bugRepository) FindByAssignee(ctx context.Context, assigneeID models.ID) (_ []*models.Bug, err error) {
+	ctx, done := r.hook(ctx, "bugs", "FindByAssignee")
+	defer func() { done(err) }()
+	return r.next.FindByAssignee(ctx, assigneeID)
+}
+
+func (r *bugRepository) UpdateStatus(ctx context.Context, id models.ID, status string) (err error) {
+	ctx, done := r.hook(ctx, "bugs", "UpdateStatus")
+	defer func() { done(err) }()
+	return r.next.UpdateStatus(ctx, id, status)
+}
+
+func (r *bugRepository) AssignToDeveloper(ctx context.Context, bugID, developerID models.ID) (err error) {
+	ctx, done := r.hook(ctx, "bugs", "AssignToDeveloper")
+	defer func() { done(err) }()
+	return r.next.AssignToDeveloper(ctx, bugID, developerID)
+}
+
+func (r *bugRepository) Update(ctx context.Context, bug *models.Bug) (err error) {
+	ctx, done := r.hook(ctx, "bugs", "Update")
+	defer func() { done(err) }()
+	return r.next.Update(ctx, bug)
+}
+
+func (r *bugRepository) Delete(ctx context.Context, id models.ID) (err error) {
+	ctx, done := r.hook(ctx, "bugs", "Delete")
+	defer func() { done(err) }()
+	return r.next.Delete(ctx, id)
+}
+
+// Stream's call includes the time spent in fn, e.g. writing an export
+func (r *bugRepository) Stream(ctx context.Context, filter models.BugFilter, fn func(*models.Bug) error) (err error) {
+	ctx, done := r.hook(ctx, "bugs", "Stream")
+	defer func() { done(err) }()
+	return r.next.Stream(ctx, filter, fn)
+}
+
+func (r *bugRepository) FindByExternalID(ctx context.Context, externalID string) (_ *models.Bug, err error) {
+	ctx, done := r.hook(ctx, "bugs", "FindByExternalID")
+	defer func() { done(err) }()
+	return r.next.FindByExternalID(ctx, externalID)
+}
+
+func (r *bugRepository) CreateIfNotExists(ctx context.Context, bug *models.Bug) (_ bool, err error) {
+	ctx, done := r.hook(ctx, "bugs", "CreateIfNotExists")
+	defer func() { done(err) }()
+	return r.next.CreateIfNotExists(ctx, bug)
+}
+
+func (r *bugRepository) Search(ctx context.Context, search models.BugSearch) (_ []*models.Bug, err error) {
+	ctx, done := r.hook(ctx, "bugs", "Search")
+	defer func() { done(err) }()
+	return r.next.Search(ctx, search)
+}
+
+func (r *bugRepository) RemoveField(ctx context.Context, key string) (err error) {
+	ctx, done := r.hook(ctx, "bugs", "RemoveField")
+	defer func() { done(err) }()
+	return r.next.RemoveField(ctx, key)
+}
+
+func (r *bugRepository) ClearMilestone(ctx context.Context, id models.ID) (err error) {
+	ctx, done := r.hook(ctx, "bugs", "ClearMilestone")
+	defer func() { done(err) }()
+	return r.next.ClearMilestone(ctx, id)
+}
+
+// Reports reports the operations of next to hook
+func Reports(next repository.ReportRepositoryInterface, hook Hook) repository.ReportRepositoryInterface {
+	return &reportRepository{next: next, hook: hook}
+}
+
+type reportRepository struct {
+	next repository.ReportRepositoryInterface
+	hook Hook
+}
+
+func (r *reportRepository) CountByStatus(ctx context.Context, filter models.ReportFilter) (_ []models.CountBucket, err error) {
+	ctx, done := r.hook(ctx, "reports", "CountByStatus")
+	defer func() { done(err) }()
+	return r.next.CountByStatus(ctx, filter)
+}
+
+func (r *reportRepository) CountByPriority(ctx context.Context, filter models.ReportFilter) (_ []models.CountBucket, err error) {
+	ctx, done := r.hook(ctx, "reports", "CountByPriority")
+	defer func() { done(err) }()
+	return r.next.CountByPriority(ctx, filter)
+}
+
+func (r *reportRepository) CountByAssignee(ctx context.Context, filter models.ReportFilter) (_ []models.AssigneeBucket, err error) {
+	ctx, done := r.hook(ctx, "reports", "CountByAssignee")
+	defer func() { done(err) }()
+	return r.next.CountByAssignee(ctx, filter)
+}
+
+func (r *reportRepository) Trend(ctx context.Context, filter models.ReportFilter, interval string) (_ []models.TrendPoint, err error) {
+	ctx, done := r.hook(ctx, "reports", "Trend")
+	defer func() { done(err) }()
+	return r.next.Trend(ctx, filter, interval)
+}
+
+func (r *reportRepository) ResolutionTime(ctx context.Context, filter models.ReportFilter) (_ *models.ResolutionReport, err error) {
+	ctx, done := r.hook(ctx, "reports", "ResolutionTime")
+	defer func() { done(err) }()
+	return r.next.ResolutionTime(ctx, filter)
+}
+
+func (r *reportRepository) Ageing(ctx context.Context, filter models.ReportFilter, now time.Time) (_ []models.AgeingBucket, err error) {
+	ctx, done := r.hook(ctx, "reports", "Ageing")
+	defer func() { done(err) }()
+	return r.next.Ageing(ctx, filter, now)
+}
+
+func (r *reportRepository) DeveloperThroughput(ctx context.Context, filter models.ReportFilter) (_ []models.DeveloperThroughput, err error) {
+	ctx, done := r.hook(ctx, "reports", "DeveloperThroughput")
+	defer func() { done(err) }()
+	return r.next.DeveloperThroughput(ctx, filter)
+}
+
+// APITokens reports the operations of next to hook
+func APITokens(next repository.APITokenRepositoryInterface, hook Hook) repository.APITokenRepositoryInterface {
+	return &apiTokenRepository{next: next, hook: hook}
+}
+
+type apiTokenRepository struct {
+	next repository.APITokenRepositoryInterface
+	hook Hook
+}
+
+func (r *apiTokenRepository) Create(ctx context.Context, token *models.APIToken) (err error) {
+	ctx, done := r.hook(ctx, "api_tokens", "Create")
+	defer func() { done(err) }()
+	return r.next.Create(ctx, token)
+}
+
+func (r *apiTokenRepository) FindByID(ctx context.Context, id models.ID) (_ *models.APIToken, err error) {
+	ctx, done := r.hook(ctx, "api_tokens", "FindByID")
+	defer func() { done(err) }()
+	return r.next.FindByID(ctx, id)
+}
+
+func (r *apiTokenRepository) FindByHash(ctx context.Context, hash string) (_ *models.APIToken, err error) {
+	ctx, done := r.hook(ctx, "api_tokens", "FindByHash")
+	defer func() { done(err) }()
+	return r.next.FindByHash(ctx, hash)
+}
+
+func (r *apiTokenRepository) FindByUser(ctx context.Context, userID models.ID) (_ []*models.APIToken, err error) {
+	ctx, done := r.hook(ctx, "api_tokens", "FindByUser")
+	defer func() { done(err) }()
+	return r.next.FindByUser(ctx, userID)
+}
+
+func (r *apiTokenRepository) Delete(ctx context.Context, id models.ID) (err error) {
+	ctx, done := r.hook(ctx, "api_tokens", "Delete")
+	defer func() { done(err) }()
+	return r.next.Delete(ctx, id)
+}
+
+func (r *apiTokenRepository) SetLastUsed(ctx context.Context, id models.ID, at time.Time) (err error) {
+	ctx, done := r.hook(ctx, "api_tokens", "SetLastUsed")
+	defer func() { done(err) }()
+	return r.next.SetLastUsed(ctx, id, at)
+}
+
+// SigningKeys reports the operations of next to hook
+func SigningKeys(next repository.SigningKeyRepositoryInterface, hook Hook) repository.SigningKeyRepositoryInterface {
+	return &signingKeyRepository{next: next, hook: hook}
+}
+
+type signingKeyRepository struct {
+	next repository.SigningKeyRepositoryInterface
+	hook Hook
+}
+
+func (r *signingKeyRepository) Create(ctx context.Context, key *models.SigningKey) (err error) {
+	ctx, done := r.hook(ctx, "signing_keys", "Create")
+	defer func() { done(err) }()
+	return r.next.Create(ctx, key)
+}
+
+func (r *signingKeyRepository) FindAll(ctx context.Context) (_ []*models.SigningKey, err error) {
+	ctx, done := r.hook(ctx, "signing_keys", "FindAll")
+	defer func() { done(err) }()
+	return r.next.FindAll(ctx)
+}
+
+func (r *signingKeyRepository) Delete(ctx context.Context, id models.ID) (err error) {
+	ctx, done := r.hook(ctx, "signing_keys", "Delete")
+	defer func() { done(err) }()
+	return r.next.Delete(ctx, id)
+}
+
+// Roles reports the operations of next to hook
+func Roles(next repository.RoleRepositoryInterface, hook Hook) repository.RoleRepositoryInterface {
+	return &roleRepository{next: next, hook: hook}
+}
+
+type roleRepository struct {
+	next repository.RoleRepositoryInterface
+	hook Hook
+}
+
+func (r *roleRepository) FindAll(ctx context.Context) (_ []*models.Role, err error) {
+	ctx, done := r.hook(ctx, "roles", "FindAll")
+	defer func() { done(err) }()
+	return r.next.FindAll(ctx)
+}
+
+func (r *roleRepository) Save(ctx context.Context, role *models.Role) (err error) {
+	ctx, done := r.hook(ctx, "roles", "Save")
+	defer func() { done(err) }()
+	return r.next.Save(ctx, role)
+}
+
+func (r *roleRepository) Delete(ctx context.Context, name string) (err error) {
+	ctx, done := r.hook(ctx, "roles", "Delete")
+	defer func() { done(err) }()
+	return r.next.Delete(ctx, name)
+}
+
+// CustomFields reports the operations of next to hook
+func CustomFields(next repository.CustomFieldRepositoryInterface, hook Hook) repository.CustomFieldRepositoryInterface {
+	return &customFieldRepository{next: next, hook: hook}
+}
+
+type customFieldRepository struct {
+	next repository.CustomFieldRepositoryInterface
+	hook Hook
+}
+
+func (r *customFieldRepository) FindAll(ctx context.Context) (_ []*models.CustomField, err error) {
+	ctx, done := r.hook(ctx, "custom_fields", "FindAll")
+	defer func() { done(err) }()
+	return r.next.FindAll(ctx)
+}
+
+func (r *customFieldRepository) Save(ctx context.Context, field *models.CustomField) (err error) {
+	ctx, done := r.hook(ctx, "custom_fields", "Save")
+	defer func() { done(err) }()
+	return r.next.Save(ctx, field)
+}
+
+func (r *customFieldRepository) Delete(ctx context.Context, key string) (err error) {
+	ctx, done := r.hook(ctx, "custom_fields", "Delete")
+	defer func() { done(err) }()
+	return r.next.Delete(ctx, key)
+}
+
+// BugTemplates reports the operations of next to hook
+func BugTemplates(next repository.BugTemplateRepositoryInterface, hook Hook) repository.BugTemplateRepositoryInterface {
+	return &bugTemplateRepository{next: next, hook: hook}
+}
+
+type bugTemplateRepository struct {
+	next repository.BugTemplateRepositoryInterface
+	hook Hook
+}
+
+func (r *bugTemplateRepository) Create(ctx context.Context, template *models.BugTemplate) (err error) {
+	ctx, done := r.hook(ctx, "bug_templates", "Create")
+	defer func() { done(err) }()
+	return r.next.Create(ctx, template)
+}
+
+func (r *bugTemplateRepository) FindByID(ctx context.Context, id models.ID) (_ *models.BugTemplate, err error) {
+	ctx, done := r.hook(ctx, "bug_templates", "FindByID")
+	defer func() { done(err) }()
+	return r.next.FindByID(ctx, id)
+}
+
+func (r *bugTemplateRepository) FindAll(ctx context.Context) (_ []*models.BugTemplate, err error) {
+	ctx, done := r.hook(ctx, "bug_templates", "FindAll")
+	defer func() { done(err) }()
+	return r.next.FindAll(ctx)
+}
+
+func (r *bugTemplateRepository) Update(ctx context.Context, template *models.BugTemplate) (err error) {
+	ctx, done := r.hook(ctx, "bug_templates", "Update")
+	defer func() { done(err) }()
+	return r.next.Update(ctx, template)
+}
+
+func (r *bugTemplateRepository) Delete(ctx context.Context, id models.ID) (err error) {
+	ctx, done := r.hook(ctx, "bug_templates", "Delete")
+	defer func() { done(err) }()
+	return r.next.Delete(ctx, id)
+}
+
+// Milestones reports the operations of next to hook
+func Milestones(next repository.MilestoneRepositoryInterface, hook Hook) repository.MilestoneRepositoryInterface {
+	return &milestoneRepository{next: next, hook: hook}
+}
+
+type milestoneRepository struct {
+	next repository.MilestoneRepositoryInterface
+	hook Hook
+}
+
+func (r *milestoneRepository) Create(ctx context.Context, milestone *models.Milestone) (err error) {
+	ctx, done := r.hook(ctx, "milestones", "Create")
+	defer func() { done(err) }()
+	return r.next.Create(ctx, milestone)
+}
+
+func (r *milestoneRepository) FindByID(ctx context.Context, id models.ID) (_ *models.Milestone, err error) {
+	ctx, done := r.hook(ctx, "milestones", "FindByID")
+	defer func() { done(err) }()
+	return r.next.FindByID(ctx, id)
+}
+
+func (r *milestoneRepository) FindAll(ctx context.Context) (_ []*models.Milestone, err error) {
+	ctx, done := r.hook(ctx, "milestones", "FindAll")
+	defer func() { done(err) }()
+	return r.next.FindAll(ctx)
+}
+
+func (r *milestoneRepository) Update(ctx context.Context, milestone *models.Milestone) (err error) {
+	ctx, done := r.hook(ctx, "milestones", "Update")
+	defer func() { done(err) }()
+	return r.next.Update(ctx, milestone)
+}
+
+func (r *milestoneRepository) Delete(ctx context.Context, id models.ID) (err error) {
+	ctx, done := r.hook(ctx, "milestones", "Delete")
+	defer func() { done(err) }()
+	return r.next.Delete(ctx, id)
+}
@@ -0,0 +1,89 @@
+package instrument
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+	"bug-tracker/repository/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey string
+
+// call is one repository call seen by a recording hook
+type call struct {
+	hook   string
+	method string
+	err    error
+	done   bool
+}
+
+// recorder returns a hook that appends its calls to calls and tags the
+// context with its name
+func recorder(name string, calls *[]call) Hook {
+	return func(ctx context.Context, repo, method string) (context.Context, func(error)) {
+		*calls = append(*calls, call{hook: name, method: repo + "." + method})
+		ctx = context.WithValue(ctx, ctxKey(name), true)
+		return ctx, func(err error) {
+			*calls = append(*calls, call{hook: name, method: repo + "." + method, err: err, done: true})
+		}
+	}
+}
+
+// contextChecker fails FindByID unless both hooks tagged the context
+type contextChecker struct {
+	repository.UserRepositoryInterface
+}
+
+func (contextChecker) FindByID(ctx context.Context, id models.ID) (*models.User, error) {
+	if ctx.Value(ctxKey("outer")) == nil || ctx.Value(ctxKey("inner")) == nil {
+		return nil, errors.New("hook context was not passed on")
+	}
+	return nil, errors.New("not found")
+}
+
+func TestChain(t *testing.T) {
+	var calls []call
+	users := Users(contextChecker{}, Chain(recorder("outer", &calls), recorder("inner", &calls)))
+
+	_, err := users.FindByID(context.Background(), models.NewID())
+	require.EqualError(t, err, "not found")
+
+	assert.Equal(t, []call{
+		{hook: "outer", method: "users.FindByID"},
+		{hook: "inner", method: "users.FindByID"},
+		{hook: "inner", method: "users.FindByID", err: err, done: true},
+		{hook: "outer", method: "users.FindByID", err: err, done: true},
+	}, calls)
+}
+
+func TestWrappersReportEveryCall(t *testing.T) {
+	var calls []call
+	hook := recorder("hook", &calls)
+	ctx := context.Background()
+
+	bugRepo := memory.NewBugRepository()
+	bugs := Bugs(bugRepo, hook)
+	reports := Reports(memory.NewReportRepository(bugRepo), hook)
+
+	bug := &models.Bug{Title: "Crash", Priority: "high"}
+	require.NoError(t, bugs.Create(ctx, bug))
+	found, err := bugs.FindByID(ctx, bug.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Crash", found.Title)
+	_, err = reports.CountByStatus(ctx, models.ReportFilter{})
+	require.NoError(t, err)
+
+	var finished []string
+	for _, c := range calls {
+		if c.done {
+			finished = append(finished, c.method)
+		}
+	}
+	assert.Equal(t, []string{"bugs.Create", "bugs.FindByID", "reports.CountByStatus"}, finished)
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// Ensure APITokenRepository implements APITokenRepositoryInterface
+var _ repository.APITokenRepositoryInterface = (*APITokenRepository)(nil)
+
+type APITokenRepository struct {
+	mu sync.RWMutex
+	// tokens is kept in creation order
+	tokens []models.APIToken
+}
+
+func NewAPITokenRepository() *APITokenRepository {
+	return &APITokenRepository{}
+}
+
+func (r *APITokenRepository) Create(ctx context.Context, token *models.APIToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token.ID = models.NewID()
+	token.CreatedAt = time.Now()
+	r.tokens = append(r.tokens, copyAPIToken(token))
+	return nil
+}
+
+func (r *APITokenRepository) FindByID(ctx context.Context, id models.ID) (*models.APIToken, error) {
+	return r.findOne(func(token *models.APIToken) bool { return token.ID == id }), nil
+}
+
+func (r *APITokenRepository) FindByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	return r.findOne(func(token *models.APIToken) bool { return token.Hash == hash }), nil
+}
+
+func (r *APITokenRepository) FindByUser(ctx context.Context, userID models.ID) ([]*models.APIToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tokens []*models.APIToken
+	for i := range r.tokens {
+		if r.tokens[i].UserID == userID {
+			token := copyAPIToken(&r.tokens[i])
+			tokens = append(tokens, &token)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *APITokenRepository) Delete(ctx context.Context, id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.tokens {
+		if r.tokens[i].ID == id {
+			r.tokens = append(r.tokens[:i], r.tokens[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *APITokenRepository) SetLastUsed(ctx context.Context, id models.ID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.tokens {
+		if r.tokens[i].ID == id {
+			r.tokens[i].LastUsedAt = &at
+		}
+	}
+	return nil
+}
+
+// findOne returns a copy of the first token matching keep, or nil
+func (r *APITokenRepository) findOne(keep func(*models.APIToken) bool) *models.APIToken {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := range r.tokens {
+		if keep(&r.tokens[i]) {
+			token := copyAPIToken(&r.tokens[i])
+			return &token
+		}
+	}
+	return nil
+}
+
+// copyAPIToken copies token so callers can't change stored scopes
+func copyAPIToken(token *models.APIToken) models.APIToken {
+	clone := *token
+	clone.Scopes = append([]string(nil), token.Scopes...)
+	return clone
+}
@@ -0,0 +1,376 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// Ensure BugRepository implements BugRepositoryInterface
+var _ repository.BugRepositoryInterface = (*BugRepository)(nil)
+
+type BugRepository struct {
+	mu sync.RWMutex
+	// bugs is kept in insertion order, matching MongoDB's natural order
+	bugs []models.Bug
+}
+
+func NewBugRepository() *BugRepository {
+	return &BugRepository{}
+}
+
+func (r *BugRepository) Create(ctx context.Context, bug *models.Bug) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bug.ID = models.NewID()
+	bug.CreatedAt = time.Now()
+	bug.UpdatedAt = time.Now()
+	bug.Status = "open"
+
+	r.bugs = append(r.bugs, copyBug(bug))
+	return nil
+}
+
+func (r *BugRepository) FindAll(ctx context.Context) ([]*models.Bug, error) {
+	return r.find(func(*models.Bug) bool { return true }), nil
+}
+
+func (r *BugRepository) FindByID(ctx context.Context, id models.ID) (*models.Bug, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if i := r.index(id); i >= 0 {
+		bug := copyBug(&r.bugs[i])
+		return &bug, nil
+	}
+	return nil, nil
+}
+
+func (r *BugRepository) FindByAssignee(ctx context.Context, developerID models.ID) ([]*models.Bug, error) {
+	return r.find(func(bug *models.Bug) bool { return bug.AssignedTo == developerID }), nil
+}
+
+func (r *BugRepository) UpdateStatus(ctx context.Context, id models.ID, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.index(id)
+	if i < 0 {
+		return nil
+	}
+
+	now := time.Now()
+	bug := &r.bugs[i]
+	bug.Status = status
+	bug.UpdatedAt = now
+
+	// Track when a bug was resolved so reports can compute resolution times
+	if status == "resolved" {
+		bug.ResolvedAt = &now
+	} else {
+		bug.ResolvedAt = nil
+	}
+	return nil
+}
+
+func (r *BugRepository) AssignToDeveloper(ctx context.Context, bugID, developerID models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if i := r.index(bugID); i >= 0 {
+		r.bugs[i].AssignedTo = developerID
+		r.bugs[i].UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *BugRepository) Update(ctx context.Context, bug *models.Bug) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bug.UpdatedAt = time.Now()
+
+	if i := r.index(bug.ID); i >= 0 {
+		r.bugs[i] = copyBug(bug)
+	}
+	return nil
+}
+
+func (r *BugRepository) Delete(ctx context.Context, id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if i := r.index(id); i >= 0 {
+		r.bugs = append(r.bugs[:i], r.bugs[i+1:]...)
+	}
+	return nil
+}
+
+// Stream calls fn for each bug matching filter in filter.Sort order. It
+// works on a snapshot, so fn may safely call back into the repository.
+func (r *BugRepository) Stream(ctx context.Context, filter models.BugFilter, fn func(*models.Bug) error) error {
+	bugs := r.find(func(bug *models.Bug) bool { return matchesFilter(bug, filter) })
+	sort.SliceStable(bugs, func(i, j int) bool {
+		return bugs[i].CreatedAt.Before(bugs[j].CreatedAt)
+	})
+	sortBugs(bugs, filter.Sort)
+
+	for _, bug := range bugs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(bug); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *BugRepository) FindByExternalID(ctx context.Context, externalID string) (*models.Bug, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := range r.bugs {
+		if r.bugs[i].ExternalID == externalID {
+			bug := copyBug(&r.bugs[i])
+			return &bug, nil
+		}
+	}
+	return nil, nil
+}
+
+// CreateIfNotExists inserts bug unless one with the same ExternalID already
+// exists, in which case bug.ID is set to the existing bug's ID. Unlike Create
+// it keeps the bug's own timestamps and status.
+func (r *BugRepository) CreateIfNotExists(ctx context.Context, bug *models.Bug) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.bugs {
+		if r.bugs[i].ExternalID == bug.ExternalID {
+			bug.ID = r.bugs[i].ID
+			return false, nil
+		}
+	}
+
+	if bug.CreatedAt.IsZero() {
+		bug.CreatedAt = time.Now()
+	}
+	if bug.UpdatedAt.IsZero() {
+		bug.UpdatedAt = bug.CreatedAt
+	}
+	bug.ID = models.NewID()
+
+	r.bugs = append(r.bugs, copyBug(bug))
+	return true, nil
+}
+
+// Search returns up to search.Limit bugs containing every search term. There
+// is no relevance ranking, so the newest bugs come first.
+func (r *BugRepository) Search(ctx context.Context, search models.BugSearch) ([]*models.Bug, error) {
+	terms := repository.SearchTerms(search.Query)
+	if len(terms) == 0 {
+		return []*models.Bug{}, nil
+	}
+
+	bugs := r.find(func(bug *models.Bug) bool {
+		if search.InvolvedUser != nil && !bug.Involves(*search.InvolvedUser) {
+			return false
+		}
+		words := make(map[string]bool)
+		for _, word := range repository.SearchTerms(bug.Title + " " + bug.Description) {
+			words[word] = true
+		}
+		for _, term := range terms {
+			if !words[term] {
+				return false
+			}
+		}
+		return true
+	})
+
+	results := []*models.Bug{}
+	for i := len(bugs) - 1; i >= 0; i-- {
+		if search.Limit > 0 && len(results) == search.Limit {
+			break
+		}
+		results = append(results, bugs[i])
+	}
+	return results, nil
+}
+
+// RemoveField deletes the values of a custom field from every bug
+func (r *BugRepository) RemoveField(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.bugs {
+		delete(r.bugs[i].Fields, key)
+		if len(r.bugs[i].Fields) == 0 {
+			r.bugs[i].Fields = nil
+		}
+	}
+	return nil
+}
+
+// ClearMilestone unsets a milestone wherever bugs name it as their affected
+// or fix version
+func (r *BugRepository) ClearMilestone(ctx context.Context, id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.bugs {
+		if r.bugs[i].AffectedVersion == id {
+			r.bugs[i].AffectedVersion = models.NilID
+		}
+		if r.bugs[i].FixVersion == id {
+			r.bugs[i].FixVersion = models.NilID
+		}
+	}
+	return nil
+}
+
+// find returns copies of the bugs matching keep in insertion order
+func (r *BugRepository) find(keep func(*models.Bug) bool) []*models.Bug {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var bugs []*models.Bug
+	for i := range r.bugs {
+		if keep(&r.bugs[i]) {
+			bug := copyBug(&r.bugs[i])
+			bugs = append(bugs, &bug)
+		}
+	}
+	return bugs
+}
+
+// index returns the position of the bug with id, or -1. The caller must
+// hold the lock.
+func (r *BugRepository) index(id models.ID) int {
+	for i := range r.bugs {
+		if r.bugs[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// copyBug returns a copy of bug that shares no memory with it, so callers
+// can't modify stored bugs through the values they are given.
+func copyBug(bug *models.Bug) models.Bug {
+	c := *bug
+	if bug.ResolvedAt != nil {
+		resolvedAt := *bug.ResolvedAt
+		c.ResolvedAt = &resolvedAt
+	}
+	c.Fields = bug.Fields.Clone()
+	if bug.Labels != nil {
+		c.Labels = append([]string{}, bug.Labels...)
+	}
+	return c
+}
+
+func matchesFilter(bug *models.Bug, filter models.BugFilter) bool {
+	switch {
+	case filter.Status != "" && bug.Status != filter.Status:
+		return false
+	case filter.Priority != "" && bug.Priority != filter.Priority:
+		return false
+	case filter.AssignedTo != nil && bug.AssignedTo != *filter.AssignedTo:
+		return false
+	case filter.ReportedBy != nil && bug.ReportedBy != *filter.ReportedBy:
+		return false
+	case filter.InvolvedUser != nil && !bug.Involves(*filter.InvolvedUser):
+		return false
+	case filter.AffectedVersion != nil && bug.AffectedVersion != *filter.AffectedVersion:
+		return false
+	case filter.FixVersion != nil && bug.FixVersion != *filter.FixVersion:
+		return false
+	}
+	for _, f := range filter.Fields {
+		if !fieldMatches(bug.Fields[f.Key], f.Value) {
+			return false
+		}
+	}
+	return inRange(bug.CreatedAt, models.ReportFilter{From: filter.From, To: filter.To})
+}
+
+// inRange reports whether t falls within the filter's inclusive range
+func inRange(t time.Time, filter models.ReportFilter) bool {
+	if filter.From != nil && t.Before(*filter.From) {
+		return false
+	}
+	if filter.To != nil && t.After(*filter.To) {
+		return false
+	}
+	return true
+}
+
+// fieldMatches reports whether a custom field value is want, or for lists
+// includes it
+func fieldMatches(value, want interface{}) bool {
+	if list, ok := value.([]string); ok {
+		for _, item := range list {
+			if item == want {
+				return true
+			}
+		}
+		return false
+	}
+	return value != nil && value == want
+}
+
+// sortBugs orders bugs, already oldest first, by sort. The sort is stable,
+// so ties stay oldest first.
+func sortBugs(bugs []*models.Bug, by models.BugSort) {
+	if key, ok := strings.CutPrefix(by.Key, models.FieldPrefix); ok {
+		sort.SliceStable(bugs, func(i, j int) bool {
+			a, b := bugs[i].Fields[key], bugs[j].Fields[key]
+			if a == nil || b == nil {
+				// Bugs without a value come last in either direction
+				return a != nil && b == nil
+			}
+			if by.Desc {
+				a, b = b, a
+			}
+			return fieldLess(a, b)
+		})
+		return
+	}
+
+	var less func(a, b *models.Bug) bool
+	switch by.Key {
+	case "updated_at":
+		less = func(a, b *models.Bug) bool { return a.UpdatedAt.Before(b.UpdatedAt) }
+	case "title":
+		less = func(a, b *models.Bug) bool { return a.Title < b.Title }
+	default:
+		less = func(a, b *models.Bug) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	}
+	sort.SliceStable(bugs, func(i, j int) bool {
+		if by.Desc {
+			return less(bugs[j], bugs[i])
+		}
+		return less(bugs[i], bugs[j])
+	})
+}
+
+// fieldLess orders custom field values of the same type
+func fieldLess(a, b interface{}) bool {
+	switch a := a.(type) {
+	case float64:
+		n, _ := b.(float64)
+		return a < n
+	case string:
+		s, _ := b.(string)
+		return a < s
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// Ensure BugTemplateRepository implements BugTemplateRepositoryInterface
+var _ repository.BugTemplateRepositoryInterface = (*BugTemplateRepository)(nil)
+
+type BugTemplateRepository struct {
+	mu        sync.RWMutex
+	templates map[models.ID]models.BugTemplate
+}
+
+func NewBugTemplateRepository() *BugTemplateRepository {
+	return &BugTemplateRepository{templates: make(map[models.ID]models.BugTemplate)}
+}
+
+func (r *BugTemplateRepository) Create(ctx context.Context, template *models.BugTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	template.ID = models.NewID()
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = template.CreatedAt
+	r.templates[template.ID] = *cloneBugTemplate(*template)
+	return nil
+}
+
+func (r *BugTemplateRepository) FindByID(ctx context.Context, id models.ID) (*models.BugTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	template, ok := r.templates[id]
+	if !ok {
+		return nil, nil
+	}
+	return cloneBugTemplate(template), nil
+}
+
+func (r *BugTemplateRepository) FindAll(ctx context.Context) ([]*models.BugTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	templates := make([]*models.BugTemplate, 0, len(r.templates))
+	for _, template := range r.templates {
+		templates = append(templates, cloneBugTemplate(template))
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Name != templates[j].Name {
+			return templates[i].Name < templates[j].Name
+		}
+		return templates[i].ID.Hex() < templates[j].ID.Hex()
+	})
+	return templates, nil
+}
+
+func (r *BugTemplateRepository) Update(ctx context.Context, template *models.BugTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.templates[template.ID]; !ok {
+		return nil
+	}
+	template.UpdatedAt = time.Now()
+	r.templates[template.ID] = *cloneBugTemplate(*template)
+	return nil
+}
+
+func (r *BugTemplateRepository) Delete(ctx context.Context, id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.templates, id)
+	return nil
+}
+
+// cloneBugTemplate copies template so callers can't change the stored lists
+func cloneBugTemplate(template models.BugTemplate) *models.BugTemplate {
+	template.RequiredSections = append([]string{}, template.RequiredSections...)
+	template.Labels = append([]string{}, template.Labels...)
+	return &template
+}
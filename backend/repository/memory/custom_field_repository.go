@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// Ensure CustomFieldRepository implements CustomFieldRepositoryInterface
+var _ repository.CustomFieldRepositoryInterface = (*CustomFieldRepository)(nil)
+
+type CustomFieldRepository struct {
+	mu     sync.RWMutex
+	fields map[string]models.CustomField
+}
+
+func NewCustomFieldRepository() *CustomFieldRepository {
+	return &CustomFieldRepository{fields: make(map[string]models.CustomField)}
+}
+
+func (r *CustomFieldRepository) FindAll(ctx context.Context) ([]*models.CustomField, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fields := make([]*models.CustomField, 0, len(r.fields))
+	for _, field := range r.fields {
+		fields = append(fields, cloneCustomField(field))
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	return fields, nil
+}
+
+func (r *CustomFieldRepository) Save(ctx context.Context, field *models.CustomField) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	field.UpdatedAt = time.Now()
+	r.fields[field.Key] = *cloneCustomField(*field)
+	return nil
+}
+
+func (r *CustomFieldRepository) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.fields, key)
+	return nil
+}
+
+// cloneCustomField copies field so callers can't change the stored options
+func cloneCustomField(field models.CustomField) *models.CustomField {
+	field.Options = append([]string{}, field.Options...)
+	return &field
+}
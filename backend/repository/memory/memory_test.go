@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+	"bug-tracker/repository/repotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBugRepository(t *testing.T) {
+	repotest.RunBugRepositoryTests(t, func(t *testing.T) repository.BugRepositoryInterface {
+		return NewBugRepository()
+	})
+}
+
+func TestUserRepository(t *testing.T) {
+	repotest.RunUserRepositoryTests(t, func(t *testing.T) repository.UserRepositoryInterface {
+		return NewUserRepository()
+	})
+}
+
+func TestAPITokenRepository(t *testing.T) {
+	repotest.RunAPITokenRepositoryTests(t, func(t *testing.T) repository.APITokenRepositoryInterface {
+		return NewAPITokenRepository()
+	})
+}
+
+func TestSigningKeyRepository(t *testing.T) {
+	repotest.RunSigningKeyRepositoryTests(t, func(t *testing.T) repository.SigningKeyRepositoryInterface {
+		return NewSigningKeyRepository()
+	})
+}
+
+func TestRoleRepository(t *testing.T) {
+	repotest.RunRoleRepositoryTests(t, func(t *testing.T) repository.RoleRepositoryInterface {
+		return NewRoleRepository()
+	})
+}
+
+func TestCustomFieldRepository(t *testing.T) {
+	repotest.RunCustomFieldRepositoryTests(t, func(t *testing.T) repository.CustomFieldRepositoryInterface {
+		return NewCustomFieldRepository()
+	})
+}
+
+func TestBugTemplateRepository(t *testing.T) {
+	repotest.RunBugTemplateRepositoryTests(t, func(t *testing.T) repository.BugTemplateRepositoryInterface {
+		return NewBugTemplateRepository()
+	})
+}
+
+func TestMilestoneRepository(t *testing.T) {
+	repotest.RunMilestoneRepositoryTests(t, func(t *testing.T) repository.MilestoneRepositoryInterface {
+		return NewMilestoneRepository()
+	})
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	repo := NewBugRepository()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bug := &models.Bug{Title: "Bug", Priority: "low"}
+			assert.NoError(t, repo.Create(ctx, bug))
+			assert.NoError(t, repo.UpdateStatus(ctx, bug.ID, "resolved"))
+			_, err := repo.FindAll(ctx)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	bugs, err := repo.FindAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, bugs, 20)
+}
+
+func TestReportRepository(t *testing.T) {
+	repotest.RunReportRepositoryTests(t, func(t *testing.T) (repository.BugRepositoryInterface, repository.ReportRepositoryInterface) {
+		bugs := NewBugRepository()
+		return bugs, NewReportRepository(bugs)
+	})
+}
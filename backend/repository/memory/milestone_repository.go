@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// Ensure MilestoneRepository implements MilestoneRepositoryInterface
+var _ repository.MilestoneRepositoryInterface = (*MilestoneRepository)(nil)
+
+type MilestoneRepository struct {
+	mu         sync.RWMutex
+	milestones map[models.ID]models.Milestone
+}
+
+func NewMilestoneRepository() *MilestoneRepository {
+	return &MilestoneRepository{milestones: make(map[models.ID]models.Milestone)}
+}
+
+func (r *MilestoneRepository) Create(ctx context.Context, milestone *models.Milestone) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	milestone.ID = models.NewID()
+	milestone.CreatedAt = time.Now()
+	milestone.UpdatedAt = milestone.CreatedAt
+	r.milestones[milestone.ID] = *cloneMilestone(*milestone)
+	return nil
+}
+
+func (r *MilestoneRepository) FindByID(ctx context.Context, id models.ID) (*models.Milestone, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	milestone, ok := r.milestones[id]
+	if !ok {
+		return nil, nil
+	}
+	return cloneMilestone(milestone), nil
+}
+
+func (r *MilestoneRepository) FindAll(ctx context.Context) ([]*models.Milestone, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	milestones := make([]*models.Milestone, 0, len(r.milestones))
+	for _, milestone := range r.milestones {
+		milestones = append(milestones, cloneMilestone(milestone))
+	}
+	sort.Slice(milestones, func(i, j int) bool {
+		a, b := milestones[i], milestones[j]
+		if a.DueDate != b.DueDate {
+			// Milestones without a due date come last
+			return b.DueDate == "" || (a.DueDate != "" && a.DueDate < b.DueDate)
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID.Hex() < b.ID.Hex()
+	})
+	return milestones, nil
+}
+
+func (r *MilestoneRepository) Update(ctx context.Context, milestone *models.Milestone) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.milestones[milestone.ID]; !ok {
+		return nil
+	}
+	milestone.UpdatedAt = time.Now()
+	r.milestones[milestone.ID] = *cloneMilestone(*milestone)
+	return nil
+}
+
+func (r *MilestoneRepository) Delete(ctx context.Context, id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.milestones, id)
+	return nil
+}
+
+// cloneMilestone copies milestone so callers can't change the stored
+// closing time
+func cloneMilestone(milestone models.Milestone) *models.Milestone {
+	if milestone.ClosedAt != nil {
+		closedAt := *milestone.ClosedAt
+		milestone.ClosedAt = &closedAt
+	}
+	return &milestone
+}
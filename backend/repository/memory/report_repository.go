@@ -0,0 +1,219 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// Ensure ReportRepository implements ReportRepositoryInterface
+var _ repository.ReportRepositoryInterface = (*ReportRepository)(nil)
+
+// ReportRepository computes reports from the bugs held by a BugRepository
+type ReportRepository struct {
+	bugs *BugRepository
+}
+
+func NewReportRepository(bugs *BugRepository) *ReportRepository {
+	return &ReportRepository{bugs: bugs}
+}
+
+func (r *ReportRepository) CountByStatus(ctx context.Context, filter models.ReportFilter) ([]models.CountBucket, error) {
+	return r.countBy(func(bug *models.Bug) string { return bug.Status }, filter), nil
+}
+
+func (r *ReportRepository) CountByPriority(ctx context.Context, filter models.ReportFilter) ([]models.CountBucket, error) {
+	return r.countBy(func(bug *models.Bug) string { return bug.Priority }, filter), nil
+}
+
+func (r *ReportRepository) countBy(key func(*models.Bug) string, filter models.ReportFilter) []models.CountBucket {
+	counts := make(map[string]int)
+	for _, bug := range r.created(filter) {
+		counts[key(bug)]++
+	}
+
+	buckets := []models.CountBucket{}
+	for k, count := range counts {
+		buckets = append(buckets, models.CountBucket{Key: k, Count: count})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Key < buckets[j].Key
+	})
+	return buckets
+}
+
+func (r *ReportRepository) CountByAssignee(ctx context.Context, filter models.ReportFilter) ([]models.AssigneeBucket, error) {
+	counts := make(map[models.ID]int)
+	for _, bug := range r.created(filter) {
+		counts[bug.AssignedTo]++
+	}
+
+	buckets := []models.AssigneeBucket{}
+	for id, count := range counts {
+		buckets = append(buckets, models.AssigneeBucket{AssigneeID: id, Count: count})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		if buckets[i].Count != buckets[j].Count {
+			return buckets[i].Count > buckets[j].Count
+		}
+		return buckets[i].AssigneeID.Hex() < buckets[j].AssigneeID.Hex()
+	})
+	return buckets, nil
+}
+
+func (r *ReportRepository) Trend(ctx context.Context, filter models.ReportFilter, interval string) ([]models.TrendPoint, error) {
+	points := make(map[string]*models.TrendPoint)
+	point := func(t time.Time) *models.TrendPoint {
+		key := trendPeriod(t, interval)
+		if _, ok := points[key]; !ok {
+			points[key] = &models.TrendPoint{Period: key}
+		}
+		return points[key]
+	}
+
+	for _, bug := range r.bugs.find(func(*models.Bug) bool { return true }) {
+		if inRange(bug.CreatedAt, filter) {
+			point(bug.CreatedAt).Opened++
+		}
+		if resolvedOn, ok := resolvedOn(bug); ok && inRange(resolvedOn, filter) {
+			point(resolvedOn).Closed++
+		}
+	}
+
+	trend := make([]models.TrendPoint, 0, len(points))
+	for _, p := range points {
+		trend = append(trend, *p)
+	}
+	sort.Slice(trend, func(i, j int) bool {
+		return trend[i].Period < trend[j].Period
+	})
+	return trend, nil
+}
+
+func (r *ReportRepository) ResolutionTime(ctx context.Context, filter models.ReportFilter) (*models.ResolutionReport, error) {
+	report := &models.ResolutionReport{}
+	var total float64
+
+	for _, bug := range r.bugs.find(func(*models.Bug) bool { return true }) {
+		resolvedOn, ok := resolvedOn(bug)
+		if !ok || !inRange(resolvedOn, filter) {
+			continue
+		}
+
+		hours := resolvedOn.Sub(bug.CreatedAt).Hours()
+		if report.Resolved == 0 || hours < report.MinHours {
+			report.MinHours = hours
+		}
+		if report.Resolved == 0 || hours > report.MaxHours {
+			report.MaxHours = hours
+		}
+		total += hours
+		report.Resolved++
+	}
+
+	if report.Resolved > 0 {
+		report.MeanHours = total / float64(report.Resolved)
+	}
+	return report, nil
+}
+
+func (r *ReportRepository) Ageing(ctx context.Context, filter models.ReportFilter, now time.Time) ([]models.AgeingBucket, error) {
+	counts := make(map[int]int)
+	for _, bug := range r.created(filter) {
+		if bug.Status == "resolved" {
+			continue
+		}
+
+		days := now.Sub(bug.CreatedAt).Hours() / 24
+		lower := repository.AgeingBoundaries[0]
+		for _, boundary := range repository.AgeingBoundaries {
+			if days >= float64(boundary) {
+				lower = boundary
+			}
+		}
+		counts[lower]++
+	}
+
+	buckets := make([]models.AgeingBucket, len(repository.AgeingBoundaries))
+	for i, days := range repository.AgeingBoundaries {
+		buckets[i] = models.AgeingBucket{Label: repository.AgeingLabels[days], Count: counts[days]}
+	}
+	return buckets, nil
+}
+
+func (r *ReportRepository) DeveloperThroughput(ctx context.Context, filter models.ReportFilter) ([]models.DeveloperThroughput, error) {
+	byDeveloper := make(map[models.ID]*models.DeveloperThroughput)
+	totalHours := make(map[models.ID]float64)
+
+	for _, bug := range r.bugs.find(func(bug *models.Bug) bool { return !bug.AssignedTo.IsZero() }) {
+		assigned := inRange(bug.CreatedAt, filter)
+		resolvedOn, resolved := resolvedOn(bug)
+		resolved = resolved && inRange(resolvedOn, filter)
+		if !assigned && !resolved {
+			continue
+		}
+
+		t, ok := byDeveloper[bug.AssignedTo]
+		if !ok {
+			t = &models.DeveloperThroughput{DeveloperID: bug.AssignedTo}
+			byDeveloper[bug.AssignedTo] = t
+		}
+		if assigned {
+			t.Assigned++
+		}
+		if resolved {
+			t.Resolved++
+			totalHours[bug.AssignedTo] += resolvedOn.Sub(bug.CreatedAt).Hours()
+		}
+	}
+
+	results := []models.DeveloperThroughput{}
+	for id, t := range byDeveloper {
+		if t.Resolved > 0 {
+			t.MeanHours = totalHours[id] / float64(t.Resolved)
+		}
+		results = append(results, *t)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Resolved != results[j].Resolved {
+			return results[i].Resolved > results[j].Resolved
+		}
+		return results[i].DeveloperID.Hex() < results[j].DeveloperID.Hex()
+	})
+	return results, nil
+}
+
+// created returns the bugs created within the filter's range
+func (r *ReportRepository) created(filter models.ReportFilter) []*models.Bug {
+	return r.bugs.find(func(bug *models.Bug) bool { return inRange(bug.CreatedAt, filter) })
+}
+
+// resolvedOn returns when a resolved bug was resolved, falling back to its
+// last update for bugs resolved before resolved_at was tracked.
+func resolvedOn(bug *models.Bug) (time.Time, bool) {
+	if bug.Status != "resolved" {
+		return time.Time{}, false
+	}
+	if bug.ResolvedAt != nil {
+		return *bug.ResolvedAt, true
+	}
+	return bug.UpdatedAt, true
+}
+
+// trendPeriod formats t like the Mongo repository's $dateToString buckets
+func trendPeriod(t time.Time, interval string) string {
+	t = t.UTC()
+	switch interval {
+	case "week":
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case "month":
+		return t.Format("2006-01")
+	default:
+		return t.Format("2006-01-02")
+	}
+}
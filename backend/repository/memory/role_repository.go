@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// Ensure RoleRepository implements RoleRepositoryInterface
+var _ repository.RoleRepositoryInterface = (*RoleRepository)(nil)
+
+type RoleRepository struct {
+	mu    sync.RWMutex
+	roles map[string]models.Role
+}
+
+func NewRoleRepository() *RoleRepository {
+	return &RoleRepository{roles: make(map[string]models.Role)}
+}
+
+func (r *RoleRepository) FindAll(ctx context.Context) ([]*models.Role, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	roles := make([]*models.Role, 0, len(r.roles))
+	for _, role := range r.roles {
+		roles = append(roles, cloneRole(role))
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+func (r *RoleRepository) Save(ctx context.Context, role *models.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role.UpdatedAt = time.Now()
+	r.roles[role.Name] = *cloneRole(*role)
+	return nil
+}
+
+func (r *RoleRepository) Delete(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.roles, name)
+	return nil
+}
+
+// cloneRole copies role so callers can't change the stored permissions
+func cloneRole(role models.Role) *models.Role {
+	role.Permissions = append([]string{}, role.Permissions...)
+	return &role
+}
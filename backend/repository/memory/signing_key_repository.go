@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// Ensure SigningKeyRepository implements SigningKeyRepositoryInterface
+var _ repository.SigningKeyRepositoryInterface = (*SigningKeyRepository)(nil)
+
+type SigningKeyRepository struct {
+	mu   sync.RWMutex
+	keys []models.SigningKey
+}
+
+func NewSigningKeyRepository() *SigningKeyRepository {
+	return &SigningKeyRepository{}
+}
+
+func (r *SigningKeyRepository) Create(ctx context.Context, key *models.SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key.ID = models.NewID()
+	key.CreatedAt = time.Now()
+	r.keys = append(r.keys, copySigningKey(key))
+	return nil
+}
+
+func (r *SigningKeyRepository) FindAll(ctx context.Context) ([]*models.SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]*models.SigningKey, len(r.keys))
+	for i := range r.keys {
+		key := copySigningKey(&r.keys[i])
+		keys[i] = &key
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].ActiveAt.Before(keys[j].ActiveAt) })
+	return keys, nil
+}
+
+func (r *SigningKeyRepository) Delete(ctx context.Context, id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.keys {
+		if r.keys[i].ID == id {
+			r.keys = append(r.keys[:i], r.keys[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// copySigningKey copies key so callers can't change the stored key
+func copySigningKey(key *models.SigningKey) models.SigningKey {
+	clone := *key
+	clone.PrivateKey = append([]byte(nil), key.PrivateKey...)
+	return clone
+}
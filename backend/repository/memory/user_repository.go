@@ -0,0 +1,201 @@
+// Package memory implements the repository interfaces in process memory.
+// Data is lost when the process exits, which makes these repositories
+// suitable for tests and demos but not for production.
+package memory
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// Ensure UserRepository implements UserRepositoryInterface
+var _ repository.UserRepositoryInterface = (*UserRepository)(nil)
+
+type UserRepository struct {
+	mu    sync.RWMutex
+	users map[models.ID]models.User
+}
+
+func NewUserRepository() *UserRepository {
+	return &UserRepository{users: make(map[models.ID]models.User)}
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.emailTaken(user.Email, models.NilID) {
+		return repository.ErrDuplicateEmail
+	}
+
+	user.ID = models.NewID()
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+
+	r.users[user.ID] = *cloneUser(*user)
+	return nil
+}
+
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return cloneUser(user), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *UserRepository) FindByOIDC(ctx context.Context, issuer, subject string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.OIDC != nil && user.OIDC.Issuer == issuer && user.OIDC.Subject == subject {
+			return cloneUser(user), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id models.ID) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+	return cloneUser(user), nil
+}
+
+// Update replaces the stored user, inserting it if it doesn't exist
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.emailTaken(user.Email, user.ID) {
+		return repository.ErrDuplicateEmail
+	}
+
+	user.UpdatedAt = time.Now()
+	r.users[user.ID] = *cloneUser(*user)
+	return nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users, id)
+	return nil
+}
+
+func (r *UserRepository) FindByRole(ctx context.Context, role string) ([]*models.User, error) {
+	return r.find(func(user *models.User) bool { return user.Role == role }), nil
+}
+
+func (r *UserRepository) FindAll(ctx context.Context) ([]*models.User, error) {
+	return r.find(func(*models.User) bool { return true }), nil
+}
+
+func (r *UserRepository) RecordLoginFailure(ctx context.Context, id models.ID) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return 0, nil
+	}
+	user.FailedLogins++
+	r.users[id] = user
+	return user.FailedLogins, nil
+}
+
+func (r *UserRepository) SetLockout(ctx context.Context, id models.ID, failedLogins int, lockedUntil *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil
+	}
+	user.FailedLogins = failedLogins
+	user.LockedUntil = lockedUntil
+	r.users[id] = user
+	return nil
+}
+
+func (r *UserRepository) SetTwoFactor(ctx context.Context, id models.ID, prev, next *models.TwoFactor) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || !reflect.DeepEqual(user.TwoFactor, prev) {
+		return false, nil
+	}
+	user.TwoFactor = cloneTwoFactor(next)
+	r.users[id] = user
+	return true, nil
+}
+
+// cloneUser copies user so callers can't change what is stored through
+// its pointers
+func cloneUser(user models.User) *models.User {
+	user.TwoFactor = cloneTwoFactor(user.TwoFactor)
+	if user.OIDC != nil {
+		identity := *user.OIDC
+		user.OIDC = &identity
+	}
+	if user.DeactivatedAt != nil {
+		deactivatedAt := *user.DeactivatedAt
+		user.DeactivatedAt = &deactivatedAt
+	}
+	return &user
+}
+
+// cloneTwoFactor copies tf so callers can't change stored settings
+func cloneTwoFactor(tf *models.TwoFactor) *models.TwoFactor {
+	if tf == nil {
+		return nil
+	}
+	clone := *tf
+	clone.RecoveryCodes = append([]string(nil), tf.RecoveryCodes...)
+	return &clone
+}
+
+// find returns copies of the users matching keep, oldest first
+func (r *UserRepository) find(keep func(*models.User) bool) []*models.User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []*models.User
+	for _, user := range r.users {
+		if keep(&user) {
+			users = append(users, cloneUser(user))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].CreatedAt.Before(users[j].CreatedAt)
+	})
+	return users
+}
+
+// emailTaken reports whether a user other than except uses email. The
+// caller must hold the lock.
+func (r *UserRepository) emailTaken(email string, except models.ID) bool {
+	for id, user := range r.users {
+		if user.Email == email && id != except {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"bug-tracker/models"
+)
+
+type MilestoneRepositoryInterface interface {
+	// Create stores milestone, setting its ID and timestamps
+	Create(ctx context.Context, milestone *models.Milestone) error
+	FindByID(ctx context.Context, id models.ID) (*models.Milestone, error)
+	// FindAll returns every milestone ordered by due date, those without one
+	// last, then by name
+	FindAll(ctx context.Context) ([]*models.Milestone, error)
+	// Update replaces the milestone with milestone.ID, setting UpdatedAt
+	Update(ctx context.Context, milestone *models.Milestone) error
+	Delete(ctx context.Context, id models.ID) error
+}
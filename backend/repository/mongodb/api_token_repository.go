@@ -0,0 +1,94 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Ensure APITokenRepository implements APITokenRepositoryInterface
+var _ repository.APITokenRepositoryInterface = (*APITokenRepository)(nil)
+
+type APITokenRepository struct {
+	db *mongo.Database
+}
+
+func NewAPITokenRepository(db *mongo.Database) *APITokenRepository {
+	return &APITokenRepository{db: withIDCodec(db)}
+}
+
+func (r *APITokenRepository) Create(ctx context.Context, token *models.APIToken) error {
+	collection := r.db.Collection("api_tokens")
+
+	token.CreatedAt = time.Now()
+
+	result, err := collection.InsertOne(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	token.ID = models.ID(result.InsertedID.(primitive.ObjectID))
+	return nil
+}
+
+func (r *APITokenRepository) FindByID(ctx context.Context, id models.ID) (*models.APIToken, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *APITokenRepository) FindByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	return r.findOne(ctx, bson.M{"hash": hash})
+}
+
+func (r *APITokenRepository) findOne(ctx context.Context, filter bson.M) (*models.APIToken, error) {
+	collection := r.db.Collection("api_tokens")
+
+	var token models.APIToken
+	err := collection.FindOne(ctx, filter).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *APITokenRepository) FindByUser(ctx context.Context, userID models.ID) ([]*models.APIToken, error) {
+	collection := r.db.Collection("api_tokens")
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tokens []*models.APIToken
+	if err = cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (r *APITokenRepository) Delete(ctx context.Context, id models.ID) error {
+	collection := r.db.Collection("api_tokens")
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *APITokenRepository) SetLastUsed(ctx context.Context, id models.ID, at time.Time) error {
+	collection := r.db.Collection("api_tokens")
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": at}})
+	return err
+}
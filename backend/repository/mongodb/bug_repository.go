@@ -0,0 +1,357 @@
+package mongodb
+
+import (
+	"bug-tracker/models"
+	"bug-tracker/repository"
+	"context"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Ensure BugRepository implements BugRepositoryInterface
+var _ repository.BugRepositoryInterface = (*BugRepository)(nil)
+
+type BugRepository struct {
+	db *mongo.Database
+}
+
+func NewBugRepository(db *mongo.Database) *BugRepository {
+	return &BugRepository{db: withIDCodec(db)}
+}
+
+func (r *BugRepository) Create(ctx context.Context, bug *models.Bug) error {
+	collection := r.db.Collection("bugs")
+
+	bug.CreatedAt = time.Now()
+	bug.UpdatedAt = time.Now()
+	bug.Status = "open"
+
+	result, err := collection.InsertOne(ctx, bug)
+	if err != nil {
+		return err
+	}
+
+	bug.ID = models.ID(result.InsertedID.(primitive.ObjectID))
+	return nil
+}
+
+func (r *BugRepository) FindAll(ctx context.Context) ([]*models.Bug, error) {
+	collection := r.db.Collection("bugs")
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var bugs []*models.Bug
+	if err = cursor.All(ctx, &bugs); err != nil {
+		return nil, err
+	}
+
+	return bugs, nil
+}
+
+func (r *BugRepository) FindByID(ctx context.Context, id models.ID) (*models.Bug, error) {
+	collection := r.db.Collection("bugs")
+
+	var bug models.Bug
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&bug)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &bug, nil
+}
+
+func (r *BugRepository) FindByAssignee(ctx context.Context, developerID models.ID) ([]*models.Bug, error) {
+	collection := r.db.Collection("bugs")
+
+	cursor, err := collection.Find(ctx, bson.M{"assigned_to": developerID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var bugs []*models.Bug
+	if err = cursor.All(ctx, &bugs); err != nil {
+		return nil, err
+	}
+
+	return bugs, nil
+}
+
+func (r *BugRepository) UpdateStatus(ctx context.Context, id models.ID, status string) error {
+	collection := r.db.Collection("bugs")
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":     status,
+			"updated_at": now,
+		},
+	}
+
+	// Track when a bug was resolved so reports can compute resolution times
+	if status == "resolved" {
+		update["$set"].(bson.M)["resolved_at"] = now
+	} else {
+		update["$unset"] = bson.M{"resolved_at": ""}
+	}
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+func (r *BugRepository) AssignToDeveloper(ctx context.Context, bugID, developerID models.ID) error {
+	collection := r.db.Collection("bugs")
+
+	update := bson.M{
+		"$set": bson.M{
+			"assigned_to": developerID,
+			"updated_at":  time.Now(),
+		},
+	}
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": bugID}, update)
+	return err
+}
+
+func (r *BugRepository) Update(ctx context.Context, bug *models.Bug) error {
+	collection := r.db.Collection("bugs")
+
+	bug.UpdatedAt = time.Now()
+
+	_, err := collection.ReplaceOne(
+		ctx,
+		bson.M{"_id": bug.ID},
+		bug,
+	)
+
+	return err
+}
+
+func (r *BugRepository) Delete(ctx context.Context, id models.ID) error {
+	collection := r.db.Collection("bugs")
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// Stream calls fn for each bug matching filter in filter.Sort order,
+// decoding one document at a time from the cursor so large result sets are
+// never held in memory. Iteration stops at the first error returned by fn.
+func (r *BugRepository) Stream(ctx context.Context, filter models.BugFilter, fn func(*models.Bug) error) error {
+	cursor, err := r.find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var bug models.Bug
+		if err := cursor.Decode(&bug); err != nil {
+			return err
+		}
+		if err := fn(&bug); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// find returns a cursor over the bugs matching filter in filter.Sort order
+func (r *BugRepository) find(ctx context.Context, filter models.BugFilter) (*mongo.Cursor, error) {
+	collection := r.db.Collection("bugs")
+
+	dir := 1
+	if filter.Sort.Desc {
+		dir = -1
+	}
+	if strings.HasPrefix(filter.Sort.Key, models.FieldPrefix) {
+		// $sort puts missing values first when ascending, so bugs are
+		// sorted on whether they have one first
+		missing := bson.M{"$eq": bson.A{bson.M{"$type": "$" + filter.Sort.Key}, "missing"}}
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: bugFilterQuery(filter)}},
+			{{Key: "$addFields", Value: bson.M{"sort_missing": missing}}},
+			{{Key: "$sort", Value: bson.D{
+				{Key: "sort_missing", Value: 1},
+				{Key: filter.Sort.Key, Value: dir},
+				{Key: "created_at", Value: 1},
+				{Key: "_id", Value: 1},
+			}}},
+			{{Key: "$project", Value: bson.M{"sort_missing": 0}}},
+		}
+		return collection.Aggregate(ctx, pipeline)
+	}
+
+	sort := bson.D{{Key: "created_at", Value: dir}}
+	switch filter.Sort.Key {
+	case "updated_at", "title":
+		sort = bson.D{{Key: filter.Sort.Key, Value: dir}, {Key: "created_at", Value: 1}}
+	}
+	return collection.Find(ctx, bugFilterQuery(filter), options.Find().SetSort(sort))
+}
+
+func (r *BugRepository) FindByExternalID(ctx context.Context, externalID string) (*models.Bug, error) {
+	collection := r.db.Collection("bugs")
+
+	var bug models.Bug
+	err := collection.FindOne(ctx, bson.M{"external_id": externalID}).Decode(&bug)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &bug, nil
+}
+
+// CreateIfNotExists inserts bug unless one with the same ExternalID already
+// exists, in which case bug.ID is set to the existing bug's ID. Unlike Create
+// it keeps the bug's own timestamps and status. It reports whether a new
+// document was inserted.
+func (r *BugRepository) CreateIfNotExists(ctx context.Context, bug *models.Bug) (bool, error) {
+	collection := r.db.Collection("bugs")
+
+	if bug.CreatedAt.IsZero() {
+		bug.CreatedAt = time.Now()
+	}
+	if bug.UpdatedAt.IsZero() {
+		bug.UpdatedAt = bug.CreatedAt
+	}
+
+	result, err := collection.UpdateOne(
+		ctx,
+		bson.M{"external_id": bug.ExternalID},
+		bson.M{"$setOnInsert": bug},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	if result.UpsertedID == nil {
+		existing, err := r.FindByExternalID(ctx, bug.ExternalID)
+		if err != nil {
+			return false, err
+		}
+		if existing != nil {
+			bug.ID = existing.ID
+		}
+		return false, nil
+	}
+
+	bug.ID = models.ID(result.UpsertedID.(primitive.ObjectID))
+	return true, nil
+}
+
+// Search returns up to search.Limit bugs matching every search term, best
+// matches first. It relies on the title_description_text index.
+func (r *BugRepository) Search(ctx context.Context, search models.BugSearch) ([]*models.Bug, error) {
+	terms := repository.SearchTerms(search.Query)
+	if len(terms) == 0 {
+		return []*models.Bug{}, nil
+	}
+
+	// Quoting each term makes $text require all of them instead of any
+	for i, term := range terms {
+		terms[i] = `"` + term + `"`
+	}
+	query := bson.M{"$text": bson.M{"$search": strings.Join(terms, " ")}}
+	if search.InvolvedUser != nil {
+		query["$or"] = involvedUserMatch(*search.InvolvedUser)
+	}
+
+	score := bson.M{"score": bson.M{"$meta": "textScore"}}
+	opts := options.Find().SetProjection(score).SetSort(score).SetLimit(int64(search.Limit))
+	cursor, err := r.db.Collection("bugs").Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	bugs := []*models.Bug{}
+	if err = cursor.All(ctx, &bugs); err != nil {
+		return nil, err
+	}
+
+	return bugs, nil
+}
+
+// RemoveField deletes the values of a custom field from every bug
+func (r *BugRepository) RemoveField(ctx context.Context, key string) error {
+	collection := r.db.Collection("bugs")
+
+	path := models.FieldPrefix + key
+	_, err := collection.UpdateMany(ctx,
+		bson.M{path: bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{path: ""}},
+	)
+	return err
+}
+
+// ClearMilestone unsets a milestone wherever bugs name it as their affected
+// or fix version
+func (r *BugRepository) ClearMilestone(ctx context.Context, id models.ID) error {
+	collection := r.db.Collection("bugs")
+
+	for _, key := range []string{"affected_version", "fix_version"} {
+		_, err := collection.UpdateMany(ctx, bson.M{key: id}, bson.M{"$unset": bson.M{key: ""}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// involvedUserMatch is an $or matching bugs the user reported or is assigned
+func involvedUserMatch(userID models.ID) bson.A {
+	return bson.A{bson.M{"reported_by": userID}, bson.M{"assigned_to": userID}}
+}
+
+func bugFilterQuery(filter models.BugFilter) bson.M {
+	query := dateRangeMatch("created_at", models.ReportFilter{From: filter.From, To: filter.To})
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.Priority != "" {
+		query["priority"] = filter.Priority
+	}
+	if filter.AssignedTo != nil {
+		query["assigned_to"] = *filter.AssignedTo
+	}
+	if filter.ReportedBy != nil {
+		query["reported_by"] = *filter.ReportedBy
+	}
+	if filter.InvolvedUser != nil {
+		query["$or"] = involvedUserMatch(*filter.InvolvedUser)
+	}
+	if filter.AffectedVersion != nil {
+		query["affected_version"] = *filter.AffectedVersion
+	}
+	if filter.FixVersion != nil {
+		query["fix_version"] = *filter.FixVersion
+	}
+	if len(filter.Fields) > 0 {
+		// Matching a list checks whether it includes the value. $and lets
+		// one field be filtered on more than once.
+		var conds bson.A
+		for _, f := range filter.Fields {
+			conds = append(conds, bson.M{models.FieldPrefix + f.Key: f.Value})
+		}
+		query["$and"] = conds
+	}
+	return query
+}
@@ -1,4 +1,4 @@
-package repository
+package mongodb
 
 import (
 	"bug-tracker/models"
@@ -9,7 +9,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -21,7 +20,8 @@ func setupTestDB(t *testing.T) (*mongo.Database, func()) {
 	client, err := mongo.Connect(ctx, options.Client().ApplyURI(testDBURI))
 	require.NoError(t, err)
 
-	db := client.Database("bug_tracker_test")
+	// Direct collection access in tests needs the same ID conversion as the repositories
+	db := withIDCodec(client.Database("bug_tracker_test"))
 
 	// Return a cleanup function
 	cleanup := func() {
@@ -49,7 +49,7 @@ func TestCreate(t *testing.T) {
 	ctx := context.Background()
 
 	// Create test data
-	reporterID := primitive.NewObjectID()
+	reporterID := models.NewID()
 	bug := &models.Bug{
 		Title:       "Test Bug",
 		Description: "This is a test bug",
@@ -102,7 +102,7 @@ func TestFindByID(t *testing.T) {
 	ctx := context.Background()
 
 	// Create a test bug
-	reporterID := primitive.NewObjectID()
+	reporterID := models.NewID()
 	bug := &models.Bug{
 		Title:       "Test Bug",
 		Description: "This is a test bug",
@@ -127,7 +127,7 @@ func TestFindByID(t *testing.T) {
 
 	// Test case 2: Not found
 	t.Run("Not Found", func(t *testing.T) {
-		nonExistentID := primitive.NewObjectID()
+		nonExistentID := models.NewID()
 		foundBug, err := repo.FindByID(ctx, nonExistentID)
 		assert.NoError(t, err)
 		assert.Nil(t, foundBug)
@@ -142,7 +142,7 @@ func TestFindAll(t *testing.T) {
 	ctx := context.Background()
 
 	// Create test bugs
-	reporterID := primitive.NewObjectID()
+	reporterID := models.NewID()
 	bugs := []*models.Bug{
 		{
 			Title:       "Bug 1",
@@ -196,9 +196,9 @@ func TestFindByAssignee(t *testing.T) {
 	ctx := context.Background()
 
 	// Create test bugs with different assignees
-	reporterID := primitive.NewObjectID()
-	assigneeID1 := primitive.NewObjectID()
-	assigneeID2 := primitive.NewObjectID()
+	reporterID := models.NewID()
+	assigneeID1 := models.NewID()
+	assigneeID2 := models.NewID()
 
 	bugs := []*models.Bug{
 		{
@@ -258,7 +258,7 @@ func TestFindByAssignee(t *testing.T) {
 
 	// Test case 3: Find bugs for non-existent developer
 	t.Run("Find Bugs for Non-existent Developer", func(t *testing.T) {
-		nonExistentID := primitive.NewObjectID()
+		nonExistentID := models.NewID()
 		foundBugs, err := repo.FindByAssignee(ctx, nonExistentID)
 		assert.NoError(t, err)
 		assert.Empty(t, foundBugs)
@@ -273,7 +273,7 @@ func TestUpdate(t *testing.T) {
 	ctx := context.Background()
 
 	// Create a test bug
-	reporterID := primitive.NewObjectID()
+	reporterID := models.NewID()
 	bug := &models.Bug{
 		Title:       "Original Title",
 		Description: "Original description",
@@ -306,7 +306,7 @@ func TestUpdate(t *testing.T) {
 	// Test case 2: Update non-existent bug
 	t.Run("Not Found", func(t *testing.T) {
 		nonExistentBug := &models.Bug{
-			ID:          primitive.NewObjectID(),
+			ID:          models.NewID(),
 			Title:       "Non-existent",
 			Description: "This bug doesn't exist",
 			Status:      "open",
@@ -327,7 +327,7 @@ func TestDelete(t *testing.T) {
 	ctx := context.Background()
 
 	// Create a test bug
-	reporterID := primitive.NewObjectID()
+	reporterID := models.NewID()
 	bug := &models.Bug{
 		Title:       "Bug to Delete",
 		Description: "This bug will be deleted",
@@ -353,7 +353,7 @@ func TestDelete(t *testing.T) {
 
 	// Test case 2: Delete non-existent bug
 	t.Run("Not Found", func(t *testing.T) {
-		nonExistentID := primitive.NewObjectID()
+		nonExistentID := models.NewID()
 		err := repo.Delete(ctx, nonExistentID)
 		assert.NoError(t, err) // MongoDB's DeleteOne doesn't return error for non-existent documents
 	})
@@ -367,7 +367,7 @@ func TestUpdateStatus(t *testing.T) {
 	ctx := context.Background()
 
 	// Create a test bug
-	reporterID := primitive.NewObjectID()
+	reporterID := models.NewID()
 	bug := &models.Bug{
 		Title:       "Test Bug",
 		Description: "This is a test bug",
@@ -394,7 +394,7 @@ func TestUpdateStatus(t *testing.T) {
 
 	// Test case 2: Update non-existent bug
 	t.Run("Not Found", func(t *testing.T) {
-		nonExistentID := primitive.NewObjectID()
+		nonExistentID := models.NewID()
 		err := repo.UpdateStatus(ctx, nonExistentID, "in-progress")
 		assert.NoError(t, err) // MongoDB's UpdateOne doesn't return error for non-existent documents
 	})
@@ -408,7 +408,7 @@ func TestAssignToDeveloper(t *testing.T) {
 	ctx := context.Background()
 
 	// Create a test bug
-	reporterID := primitive.NewObjectID()
+	reporterID := models.NewID()
 	bug := &models.Bug{
 		Title:       "Test Bug",
 		Description: "This is a test bug",
@@ -423,7 +423,7 @@ func TestAssignToDeveloper(t *testing.T) {
 
 	// Test case 1: Successful assignment
 	t.Run("Success", func(t *testing.T) {
-		developerID := primitive.NewObjectID()
+		developerID := models.NewID()
 		err := repo.AssignToDeveloper(ctx, bug.ID, developerID)
 		assert.NoError(t, err)
 
@@ -435,8 +435,8 @@ func TestAssignToDeveloper(t *testing.T) {
 
 	// Test case 2: Assign to non-existent bug
 	t.Run("Not Found", func(t *testing.T) {
-		nonExistentID := primitive.NewObjectID()
-		developerID := primitive.NewObjectID()
+		nonExistentID := models.NewID()
+		developerID := models.NewID()
 		err := repo.AssignToDeveloper(ctx, nonExistentID, developerID)
 		assert.NoError(t, err) // MongoDB's UpdateOne doesn't return error for non-existent documents
 	})
@@ -0,0 +1,101 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Ensure BugTemplateRepository implements BugTemplateRepositoryInterface
+var _ repository.BugTemplateRepositoryInterface = (*BugTemplateRepository)(nil)
+
+type BugTemplateRepository struct {
+	db *mongo.Database
+}
+
+func NewBugTemplateRepository(db *mongo.Database) *BugTemplateRepository {
+	return &BugTemplateRepository{db: withIDCodec(db)}
+}
+
+func (r *BugTemplateRepository) Create(ctx context.Context, template *models.BugTemplate) error {
+	collection := r.db.Collection("bug_templates")
+
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = template.CreatedAt
+	fillTemplateLists(template)
+
+	result, err := collection.InsertOne(ctx, template)
+	if err != nil {
+		return err
+	}
+
+	template.ID = models.ID(result.InsertedID.(primitive.ObjectID))
+	return nil
+}
+
+func (r *BugTemplateRepository) FindByID(ctx context.Context, id models.ID) (*models.BugTemplate, error) {
+	collection := r.db.Collection("bug_templates")
+
+	var template models.BugTemplate
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&template)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+func (r *BugTemplateRepository) FindAll(ctx context.Context) ([]*models.BugTemplate, error) {
+	collection := r.db.Collection("bug_templates")
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var templates []*models.BugTemplate
+	if err = cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (r *BugTemplateRepository) Update(ctx context.Context, template *models.BugTemplate) error {
+	collection := r.db.Collection("bug_templates")
+
+	template.UpdatedAt = time.Now()
+	fillTemplateLists(template)
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": template.ID}, template)
+	return err
+}
+
+func (r *BugTemplateRepository) Delete(ctx context.Context, id models.ID) error {
+	collection := r.db.Collection("bug_templates")
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// fillTemplateLists stores missing lists as empty arrays rather than null
+func fillTemplateLists(template *models.BugTemplate) {
+	if template.RequiredSections == nil {
+		template.RequiredSections = []string{}
+	}
+	if template.Labels == nil {
+		template.Labels = []string{}
+	}
+}
@@ -0,0 +1,93 @@
+package mongodb_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"bug-tracker/repository"
+	"bug-tracker/repository/mongodb"
+	"bug-tracker/repository/repotest"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// conformanceDB connects to the database in MONGO_TEST_URI, skipping the
+// test when it isn't set. Each call starts from empty collections.
+func conformanceDB(t *testing.T) *mongo.Database {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Disconnect(context.Background()) })
+
+	db := client.Database("bug_tracker_conformance_test")
+	require.NoError(t, db.Drop(ctx))
+	// Duplicate-email detection on update relies on the unique index
+	require.NoError(t, mongodb.EnsureIndexes(ctx, db))
+	return db
+}
+
+func TestMongoBugRepositoryConformance(t *testing.T) {
+	repotest.RunBugRepositoryTests(t, func(t *testing.T) repository.BugRepositoryInterface {
+		return mongodb.NewBugRepository(conformanceDB(t))
+	})
+}
+
+func TestMongoUserRepositoryConformance(t *testing.T) {
+	repotest.RunUserRepositoryTests(t, func(t *testing.T) repository.UserRepositoryInterface {
+		return mongodb.NewUserRepository(conformanceDB(t))
+	})
+}
+
+func TestMongoAPITokenRepositoryConformance(t *testing.T) {
+	repotest.RunAPITokenRepositoryTests(t, func(t *testing.T) repository.APITokenRepositoryInterface {
+		return mongodb.NewAPITokenRepository(conformanceDB(t))
+	})
+}
+
+func TestMongoSigningKeyRepositoryConformance(t *testing.T) {
+	repotest.RunSigningKeyRepositoryTests(t, func(t *testing.T) repository.SigningKeyRepositoryInterface {
+		return mongodb.NewSigningKeyRepository(conformanceDB(t))
+	})
+}
+
+func TestMongoRoleRepositoryConformance(t *testing.T) {
+	repotest.RunRoleRepositoryTests(t, func(t *testing.T) repository.RoleRepositoryInterface {
+		return mongodb.NewRoleRepository(conformanceDB(t))
+	})
+}
+
+func TestMongoCustomFieldRepositoryConformance(t *testing.T) {
+	repotest.RunCustomFieldRepositoryTests(t, func(t *testing.T) repository.CustomFieldRepositoryInterface {
+		return mongodb.NewCustomFieldRepository(conformanceDB(t))
+	})
+}
+
+func TestMongoBugTemplateRepositoryConformance(t *testing.T) {
+	repotest.RunBugTemplateRepositoryTests(t, func(t *testing.T) repository.BugTemplateRepositoryInterface {
+		return mongodb.NewBugTemplateRepository(conformanceDB(t))
+	})
+}
+
+func TestMongoMilestoneRepositoryConformance(t *testing.T) {
+	repotest.RunMilestoneRepositoryTests(t, func(t *testing.T) repository.MilestoneRepositoryInterface {
+		return mongodb.NewMilestoneRepository(conformanceDB(t))
+	})
+}
+
+func TestMongoReportRepositoryConformance(t *testing.T) {
+	repotest.RunReportRepositoryTests(t, func(t *testing.T) (repository.BugRepositoryInterface, repository.ReportRepositoryInterface) {
+		db := conformanceDB(t)
+		return mongodb.NewBugRepository(db), mongodb.NewReportRepository(db)
+	})
+}
@@ -0,0 +1,61 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Ensure CustomFieldRepository implements CustomFieldRepositoryInterface
+var _ repository.CustomFieldRepositoryInterface = (*CustomFieldRepository)(nil)
+
+type CustomFieldRepository struct {
+	db *mongo.Database
+}
+
+func NewCustomFieldRepository(db *mongo.Database) *CustomFieldRepository {
+	return &CustomFieldRepository{db: db}
+}
+
+func (r *CustomFieldRepository) FindAll(ctx context.Context) ([]*models.CustomField, error) {
+	collection := r.db.Collection("custom_fields")
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var fields []*models.CustomField
+	if err = cursor.All(ctx, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+func (r *CustomFieldRepository) Save(ctx context.Context, field *models.CustomField) error {
+	collection := r.db.Collection("custom_fields")
+
+	field.UpdatedAt = time.Now()
+	if field.Options == nil {
+		field.Options = []string{}
+	}
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": field.Key}, field, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *CustomFieldRepository) Delete(ctx context.Context, key string) error {
+	collection := r.db.Collection("custom_fields")
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
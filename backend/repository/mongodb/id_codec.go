@@ -0,0 +1,105 @@
+package mongodb
+
+import (
+	"fmt"
+	"reflect"
+
+	"bug-tracker/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	tID          = reflect.TypeOf(models.ID{})
+	tFieldValues = reflect.TypeOf(models.FieldValues{})
+)
+
+// registry stores models.ID values as ObjectIDs, so documents written before
+// the domain had its own ID type decode unchanged. It also decodes custom
+// field values to the types models.FieldValues holds.
+var registry = newRegistry()
+
+func newRegistry() *bsoncodec.Registry {
+	reg := bson.NewRegistry()
+	reg.RegisterTypeEncoder(tID, bsoncodec.ValueEncoderFunc(encodeID))
+	reg.RegisterTypeDecoder(tID, bsoncodec.ValueDecoderFunc(decodeID))
+	reg.RegisterTypeDecoder(tFieldValues, bsoncodec.ValueDecoderFunc(decodeFieldValues))
+	return reg
+}
+
+func encodeID(_ bsoncodec.EncodeContext, vw bsonrw.ValueWriter, val reflect.Value) error {
+	if !val.IsValid() || val.Type() != tID {
+		return bsoncodec.ValueEncoderError{Name: "encodeID", Types: []reflect.Type{tID}, Received: val}
+	}
+	return vw.WriteObjectID(primitive.ObjectID(val.Interface().(models.ID)))
+}
+
+func decodeID(_ bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {
+	if !val.CanSet() || val.Type() != tID {
+		return bsoncodec.ValueDecoderError{Name: "decodeID", Types: []reflect.Type{tID}, Received: val}
+	}
+
+	var id models.ID
+	switch vr.Type() {
+	case bsontype.ObjectID:
+		oid, err := vr.ReadObjectID()
+		if err != nil {
+			return err
+		}
+		id = models.ID(oid)
+	case bsontype.String:
+		s, err := vr.ReadString()
+		if err != nil {
+			return err
+		}
+		if id, err = models.ParseID(s); err != nil {
+			return err
+		}
+	case bsontype.Null:
+		if err := vr.ReadNull(); err != nil {
+			return err
+		}
+	case bsontype.Undefined:
+		if err := vr.ReadUndefined(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("cannot decode %v into an ID", vr.Type())
+	}
+
+	val.Set(reflect.ValueOf(id))
+	return nil
+}
+
+// mapCodec decodes custom field values before they are normalized
+var mapCodec = bsoncodec.NewMapCodec()
+
+func decodeFieldValues(dc bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {
+	if !val.CanSet() || val.Type() != tFieldValues {
+		return bsoncodec.ValueDecoderError{Name: "decodeFieldValues", Types: []reflect.Type{tFieldValues}, Received: val}
+	}
+	if err := mapCodec.DecodeValue(dc, vr, val); err != nil {
+		return err
+	}
+
+	// Lists decode as primitive.A, and removing the last value leaves {}
+	values := val.Interface().(models.FieldValues)
+	if len(values) == 0 {
+		val.Set(reflect.Zero(tFieldValues))
+		return nil
+	}
+	values.Normalize()
+	return nil
+}
+
+// withIDCodec returns db configured to convert between models.ID and
+// ObjectID. Every Mongo repository wraps its database with it.
+func withIDCodec(db *mongo.Database) *mongo.Database {
+	return db.Client().Database(db.Name(), options.Database().SetRegistry(registry))
+}
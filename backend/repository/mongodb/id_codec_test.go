@@ -0,0 +1,68 @@
+package mongodb
+
+import (
+	"testing"
+
+	"bug-tracker/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+func TestIDCodec(t *testing.T) {
+	bug := models.Bug{ID: models.NewID(), Title: "Crash", ReportedBy: models.NewID()}
+
+	data, err := bson.MarshalWithRegistry(registry, bug)
+	require.NoError(t, err)
+
+	raw := bson.Raw(data)
+	assert.Equal(t, bsontype.ObjectID, raw.Lookup("_id").Type)
+	assert.Equal(t, bsontype.ObjectID, raw.Lookup("reported_by").Type)
+	// The zero ID is still omitted
+	_, err = raw.LookupErr("assigned_to")
+	assert.Error(t, err)
+
+	var decoded models.Bug
+	require.NoError(t, bson.UnmarshalWithRegistry(registry, data, &decoded))
+	assert.Equal(t, bug.ID, decoded.ID)
+	assert.Equal(t, bug.ReportedBy, decoded.ReportedBy)
+	assert.True(t, decoded.AssignedTo.IsZero())
+
+	// Grouping on a missing field yields a null _id
+	data, err = bson.Marshal(bson.M{"_id": nil, "count": 2})
+	require.NoError(t, err)
+	var bucket models.AssigneeBucket
+	require.NoError(t, bson.UnmarshalWithRegistry(registry, data, &bucket))
+	assert.True(t, bucket.AssigneeID.IsZero())
+	assert.Equal(t, 2, bucket.Count)
+}
+
+func TestFieldValuesCodec(t *testing.T) {
+	bug := models.Bug{ID: models.NewID(), Title: "Crash", Fields: models.FieldValues{
+		"environment": "prod",
+		"severity":    float64(2),
+		"browsers":    []string{"firefox", "safari"},
+	}}
+
+	data, err := bson.MarshalWithRegistry(registry, bug)
+	require.NoError(t, err)
+	var decoded models.Bug
+	require.NoError(t, bson.UnmarshalWithRegistry(registry, data, &decoded))
+	assert.Equal(t, bug.Fields, decoded.Fields)
+
+	// Integers written by other tools decode as numbers, and an empty
+	// document as no values
+	data, err = bson.Marshal(bson.M{"title": "Crash", "fields": bson.M{"severity": int32(3)}})
+	require.NoError(t, err)
+	decoded = models.Bug{}
+	require.NoError(t, bson.UnmarshalWithRegistry(registry, data, &decoded))
+	assert.Equal(t, models.FieldValues{"severity": float64(3)}, decoded.Fields)
+
+	data, err = bson.Marshal(bson.M{"title": "Crash", "fields": bson.M{}})
+	require.NoError(t, err)
+	decoded = models.Bug{}
+	require.NoError(t, bson.UnmarshalWithRegistry(registry, data, &decoded))
+	assert.Nil(t, decoded.Fields)
+}
@@ -0,0 +1,93 @@
+package mongodb
+
+import (
+	"context"
+
+	"bug-tracker/logging"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collectionIndexes lists the indexes each collection should have
+var collectionIndexes = map[string][]mongo.IndexModel{
+	"users": {
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName("email_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "role", Value: 1}},
+			Options: options.Index().SetName("role"),
+		},
+		{
+			Keys: bson.D{{Key: "oidc.issuer", Value: 1}, {Key: "oidc.subject", Value: 1}},
+			Options: options.Index().SetName("oidc_unique").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"oidc": bson.M{"$exists": true}}),
+		},
+	},
+	"bugs": {
+		{
+			Keys:    bson.D{{Key: "assigned_to", Value: 1}, {Key: "status", Value: 1}},
+			Options: options.Index().SetName("assigned_to_status"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("status_created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("created_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "external_id", Value: 1}},
+			Options: options.Index().SetName("external_id_unique").SetUnique(true).SetSparse(true),
+		},
+		{
+			// No language, so words are matched as typed like the SQL backends
+			Keys:    bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().SetName("title_description_text").SetDefaultLanguage("none"),
+		},
+		{
+			Keys:    bson.D{{Key: "fix_version", Value: 1}, {Key: "status", Value: 1}},
+			Options: options.Index().SetName("fix_version_status").SetSparse(true),
+		},
+	},
+	"api_tokens": {
+		{
+			Keys:    bson.D{{Key: "hash", Value: 1}},
+			Options: options.Index().SetName("hash_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("user_id_created_at"),
+		},
+	},
+}
+
+// EnsureIndexes creates any missing indexes. It is safe to run repeatedly.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	for name, indexes := range collectionIndexes {
+		if _, err := db.Collection(name).Indexes().CreateMany(ctx, indexes); err != nil {
+			return err
+		}
+		logging.FromContext(ctx).Debug("ensured indexes", "collection", name, "count", len(indexes))
+	}
+	return nil
+}
+
+// RebuildIndexes drops every index except _id and creates them again, which
+// also removes indexes that are no longer defined.
+func RebuildIndexes(ctx context.Context, db *mongo.Database) error {
+	for name := range collectionIndexes {
+		if _, err := db.Collection(name).Indexes().DropAll(ctx); err != nil {
+			// Dropping indexes of a collection that doesn't exist yet fails
+			if cmdErr, ok := err.(mongo.CommandError); !ok || cmdErr.Name != "NamespaceNotFound" {
+				return err
+			}
+		}
+		logging.FromContext(ctx).Debug("dropped indexes", "collection", name)
+	}
+	return EnsureIndexes(ctx, db)
+}
@@ -0,0 +1,99 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Ensure MilestoneRepository implements MilestoneRepositoryInterface
+var _ repository.MilestoneRepositoryInterface = (*MilestoneRepository)(nil)
+
+type MilestoneRepository struct {
+	db *mongo.Database
+}
+
+func NewMilestoneRepository(db *mongo.Database) *MilestoneRepository {
+	return &MilestoneRepository{db: withIDCodec(db)}
+}
+
+func (r *MilestoneRepository) Create(ctx context.Context, milestone *models.Milestone) error {
+	collection := r.db.Collection("milestones")
+
+	milestone.CreatedAt = time.Now()
+	milestone.UpdatedAt = milestone.CreatedAt
+
+	result, err := collection.InsertOne(ctx, milestone)
+	if err != nil {
+		return err
+	}
+
+	milestone.ID = models.ID(result.InsertedID.(primitive.ObjectID))
+	return nil
+}
+
+func (r *MilestoneRepository) FindByID(ctx context.Context, id models.ID) (*models.Milestone, error) {
+	collection := r.db.Collection("milestones")
+
+	var milestone models.Milestone
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&milestone)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &milestone, nil
+}
+
+func (r *MilestoneRepository) FindAll(ctx context.Context) ([]*models.Milestone, error) {
+	collection := r.db.Collection("milestones")
+
+	// Empty due dates would sort first, so milestones are sorted on
+	// whether they have one first
+	pipeline := mongo.Pipeline{
+		{{Key: "$addFields", Value: bson.M{"sort_missing": bson.M{"$eq": bson.A{"$due_date", ""}}}}},
+		{{Key: "$sort", Value: bson.D{
+			{Key: "sort_missing", Value: 1},
+			{Key: "due_date", Value: 1},
+			{Key: "name", Value: 1},
+			{Key: "_id", Value: 1},
+		}}},
+		{{Key: "$project", Value: bson.M{"sort_missing": 0}}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var milestones []*models.Milestone
+	if err = cursor.All(ctx, &milestones); err != nil {
+		return nil, err
+	}
+
+	return milestones, nil
+}
+
+func (r *MilestoneRepository) Update(ctx context.Context, milestone *models.Milestone) error {
+	collection := r.db.Collection("milestones")
+
+	milestone.UpdatedAt = time.Now()
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": milestone.ID}, milestone)
+	return err
+}
+
+func (r *MilestoneRepository) Delete(ctx context.Context, id models.ID) error {
+	collection := r.db.Collection("milestones")
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
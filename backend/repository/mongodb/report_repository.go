@@ -0,0 +1,284 @@
+package mongodb
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Trend intervals and the $dateToString formats used to bucket them
+var trendFormats = map[string]string{
+	"day":   "%Y-%m-%d",
+	"week":  "%G-W%V",
+	"month": "%Y-%m",
+}
+
+// resolvedAtExpr falls back to updated_at for bugs resolved before resolved_at was tracked
+var resolvedAtExpr = bson.M{"$ifNull": bson.A{"$resolved_at", "$updated_at"}}
+
+// resolutionHoursExpr is the time between creation and resolution in hours
+var resolutionHoursExpr = bson.M{"$divide": bson.A{
+	bson.M{"$subtract": bson.A{resolvedAtExpr, "$created_at"}},
+	float64(time.Hour / time.Millisecond),
+}}
+
+// Ensure ReportRepository implements ReportRepositoryInterface
+var _ repository.ReportRepositoryInterface = (*ReportRepository)(nil)
+
+type ReportRepository struct {
+	db *mongo.Database
+}
+
+func NewReportRepository(db *mongo.Database) *ReportRepository {
+	return &ReportRepository{db: withIDCodec(db)}
+}
+
+func (r *ReportRepository) CountByStatus(ctx context.Context, filter models.ReportFilter) ([]models.CountBucket, error) {
+	return r.countBy(ctx, "$status", filter)
+}
+
+func (r *ReportRepository) CountByPriority(ctx context.Context, filter models.ReportFilter) ([]models.CountBucket, error) {
+	return r.countBy(ctx, "$priority", filter)
+}
+
+func (r *ReportRepository) countBy(ctx context.Context, field string, filter models.ReportFilter) ([]models.CountBucket, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: dateRangeMatch("created_at", filter)}},
+		{{Key: "$group", Value: bson.M{"_id": field, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	buckets := []models.CountBucket{}
+	if err := r.aggregate(ctx, pipeline, &buckets); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+func (r *ReportRepository) CountByAssignee(ctx context.Context, filter models.ReportFilter) ([]models.AssigneeBucket, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: dateRangeMatch("created_at", filter)}},
+		{{Key: "$group", Value: bson.M{"_id": "$assigned_to", "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.M{"count": -1}}},
+	}
+
+	buckets := []models.AssigneeBucket{}
+	if err := r.aggregate(ctx, pipeline, &buckets); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+func (r *ReportRepository) Trend(ctx context.Context, filter models.ReportFilter, interval string) ([]models.TrendPoint, error) {
+	format, ok := trendFormats[interval]
+	if !ok {
+		format = trendFormats["day"]
+	}
+
+	type periodCount struct {
+		Period string `bson:"_id"`
+		Count  int    `bson:"count"`
+	}
+
+	opened := []periodCount{}
+	openedPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: dateRangeMatch("created_at", filter)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"$dateToString": bson.M{"format": format, "date": "$created_at"}},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+	if err := r.aggregate(ctx, openedPipeline, &opened); err != nil {
+		return nil, err
+	}
+
+	closed := []periodCount{}
+	closedPipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": "resolved"}}},
+		{{Key: "$addFields", Value: bson.M{"resolved_on": resolvedAtExpr}}},
+		{{Key: "$match", Value: dateRangeMatch("resolved_on", filter)}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"$dateToString": bson.M{"format": format, "date": "$resolved_on"}},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+	if err := r.aggregate(ctx, closedPipeline, &closed); err != nil {
+		return nil, err
+	}
+
+	points := make(map[string]*models.TrendPoint)
+	for _, c := range opened {
+		points[c.Period] = &models.TrendPoint{Period: c.Period, Opened: c.Count}
+	}
+	for _, c := range closed {
+		if p, ok := points[c.Period]; ok {
+			p.Closed = c.Count
+			continue
+		}
+		points[c.Period] = &models.TrendPoint{Period: c.Period, Closed: c.Count}
+	}
+
+	trend := make([]models.TrendPoint, 0, len(points))
+	for _, p := range points {
+		trend = append(trend, *p)
+	}
+	// All period formats sort chronologically as strings
+	sort.Slice(trend, func(i, j int) bool {
+		return trend[i].Period < trend[j].Period
+	})
+
+	return trend, nil
+}
+
+func (r *ReportRepository) ResolutionTime(ctx context.Context, filter models.ReportFilter) (*models.ResolutionReport, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": "resolved"}}},
+		{{Key: "$addFields", Value: bson.M{"resolved_on": resolvedAtExpr}}},
+		{{Key: "$match", Value: dateRangeMatch("resolved_on", filter)}},
+		{{Key: "$project", Value: bson.M{"hours": resolutionHoursExpr}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        nil,
+			"count":      bson.M{"$sum": 1},
+			"mean_hours": bson.M{"$avg": "$hours"},
+			"min_hours":  bson.M{"$min": "$hours"},
+			"max_hours":  bson.M{"$max": "$hours"},
+		}}},
+	}
+
+	var results []models.ResolutionReport
+	if err := r.aggregate(ctx, pipeline, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &models.ResolutionReport{}, nil
+	}
+
+	return &results[0], nil
+}
+
+func (r *ReportRepository) Ageing(ctx context.Context, filter models.ReportFilter, now time.Time) ([]models.AgeingBucket, error) {
+	match := dateRangeMatch("created_at", filter)
+	match["status"] = bson.M{"$ne": "resolved"}
+
+	boundaries := bson.A{}
+	for _, days := range repository.AgeingBoundaries {
+		boundaries = append(boundaries, days)
+	}
+	// $bucket requires an upper bound; anything older lands in the last bucket
+	boundaries = append(boundaries, 1<<31-1)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$project", Value: bson.M{"age_days": bson.M{"$divide": bson.A{
+			bson.M{"$subtract": bson.A{now, "$created_at"}},
+			float64(24 * time.Hour / time.Millisecond),
+		}}}}},
+		{{Key: "$bucket", Value: bson.M{
+			"groupBy":    "$age_days",
+			"boundaries": boundaries,
+			"default":    -1,
+			"output":     bson.M{"count": bson.M{"$sum": 1}},
+		}}},
+	}
+
+	var results []struct {
+		LowerBound int `bson:"_id"`
+		Count      int `bson:"count"`
+	}
+	if err := r.aggregate(ctx, pipeline, &results); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]int)
+	for _, res := range results {
+		// The default bucket only catches clock skew, which is effectively brand new
+		if res.LowerBound < 0 {
+			res.LowerBound = 0
+		}
+		counts[res.LowerBound] += res.Count
+	}
+
+	// Always report every bucket so charts have a stable shape
+	buckets := make([]models.AgeingBucket, len(repository.AgeingBoundaries))
+	for i, days := range repository.AgeingBoundaries {
+		buckets[i] = models.AgeingBucket{Label: repository.AgeingLabels[days], Count: counts[days]}
+	}
+
+	return buckets, nil
+}
+
+func (r *ReportRepository) DeveloperThroughput(ctx context.Context, filter models.ReportFilter) ([]models.DeveloperThroughput, error) {
+	createdInRange := bson.A{}
+	resolvedInRange := bson.A{bson.M{"$eq": bson.A{"$status", "resolved"}}}
+	if filter.From != nil {
+		createdInRange = append(createdInRange, bson.M{"$gte": bson.A{"$created_at", *filter.From}})
+		resolvedInRange = append(resolvedInRange, bson.M{"$gte": bson.A{resolvedAtExpr, *filter.From}})
+	}
+	if filter.To != nil {
+		createdInRange = append(createdInRange, bson.M{"$lte": bson.A{"$created_at", *filter.To}})
+		resolvedInRange = append(resolvedInRange, bson.M{"$lte": bson.A{resolvedAtExpr, *filter.To}})
+	}
+	// An empty $and is true, counting every assigned bug without a range
+	isAssigned := bson.M{"$and": createdInRange}
+	isResolved := bson.M{"$and": resolvedInRange}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"assigned_to": bson.M{"$exists": true}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$assigned_to",
+			"assigned": bson.M{"$sum": bson.M{"$cond": bson.A{isAssigned, 1, 0}}},
+			"resolved": bson.M{"$sum": bson.M{"$cond": bson.A{isResolved, 1, 0}}},
+			"mean_hours": bson.M{"$avg": bson.M{"$cond": bson.A{
+				isResolved, resolutionHoursExpr, nil,
+			}}},
+		}}},
+		{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"assigned": bson.M{"$gt": 0}},
+			bson.M{"resolved": bson.M{"$gt": 0}},
+		}}}},
+		// _id breaks ties in the same order as the other backends
+		{{Key: "$sort", Value: bson.D{{Key: "resolved", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+
+	results := []models.DeveloperThroughput{}
+	if err := r.aggregate(ctx, pipeline, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *ReportRepository) aggregate(ctx context.Context, pipeline mongo.Pipeline, results interface{}) error {
+	collection := r.db.Collection("bugs")
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.All(ctx, results)
+}
+
+// dateRangeMatch builds a $match stage restricting field to the filter's range
+func dateRangeMatch(field string, filter models.ReportFilter) bson.M {
+	match := bson.M{}
+	if filter.From == nil && filter.To == nil {
+		return match
+	}
+
+	rng := bson.M{}
+	if filter.From != nil {
+		rng["$gte"] = *filter.From
+	}
+	if filter.To != nil {
+		rng["$lte"] = *filter.To
+	}
+	match[field] = rng
+	return match
+}
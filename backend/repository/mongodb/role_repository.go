@@ -0,0 +1,61 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Ensure RoleRepository implements RoleRepositoryInterface
+var _ repository.RoleRepositoryInterface = (*RoleRepository)(nil)
+
+type RoleRepository struct {
+	db *mongo.Database
+}
+
+func NewRoleRepository(db *mongo.Database) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+func (r *RoleRepository) FindAll(ctx context.Context) ([]*models.Role, error) {
+	collection := r.db.Collection("roles")
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var roles []*models.Role
+	if err = cursor.All(ctx, &roles); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (r *RoleRepository) Save(ctx context.Context, role *models.Role) error {
+	collection := r.db.Collection("roles")
+
+	role.UpdatedAt = time.Now()
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": role.Name}, role, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *RoleRepository) Delete(ctx context.Context, name string) error {
+	collection := r.db.Collection("roles")
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": name})
+	return err
+}
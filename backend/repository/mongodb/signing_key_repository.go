@@ -0,0 +1,64 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Ensure SigningKeyRepository implements SigningKeyRepositoryInterface
+var _ repository.SigningKeyRepositoryInterface = (*SigningKeyRepository)(nil)
+
+type SigningKeyRepository struct {
+	db *mongo.Database
+}
+
+func NewSigningKeyRepository(db *mongo.Database) *SigningKeyRepository {
+	return &SigningKeyRepository{db: withIDCodec(db)}
+}
+
+func (r *SigningKeyRepository) Create(ctx context.Context, key *models.SigningKey) error {
+	collection := r.db.Collection("signing_keys")
+
+	key.CreatedAt = time.Now()
+
+	result, err := collection.InsertOne(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	key.ID = models.ID(result.InsertedID.(primitive.ObjectID))
+	return nil
+}
+
+func (r *SigningKeyRepository) FindAll(ctx context.Context) ([]*models.SigningKey, error) {
+	collection := r.db.Collection("signing_keys")
+
+	opts := options.Find().SetSort(bson.D{{Key: "active_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var keys []*models.SigningKey
+	if err = cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *SigningKeyRepository) Delete(ctx context.Context, id models.ID) error {
+	collection := r.db.Collection("signing_keys")
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,213 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Ensure UserRepository implements UserRepositoryInterface
+var _ repository.UserRepositoryInterface = (*UserRepository)(nil)
+
+type UserRepository struct {
+	db *mongo.Database
+}
+
+func NewUserRepository(db *mongo.Database) *UserRepository {
+	return &UserRepository{db: withIDCodec(db)}
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	collection := r.db.Collection("users")
+
+	// Check for existing user with the same email. The unique index on
+	// email catches concurrent registrations that slip past this check.
+	existingUser, err := r.FindByEmail(ctx, user.Email)
+	if err != nil {
+		return err
+	}
+	if existingUser != nil {
+		return repository.ErrDuplicateEmail
+	}
+
+	// Set timestamps
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+
+	result, err := collection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return repository.ErrDuplicateEmail
+	}
+	if err != nil {
+		return err
+	}
+
+	user.ID = models.ID(result.InsertedID.(primitive.ObjectID))
+	return nil
+}
+
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	collection := r.db.Collection("users")
+
+	var user models.User
+	err := collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) FindByOIDC(ctx context.Context, issuer, subject string) (*models.User, error) {
+	collection := r.db.Collection("users")
+
+	var user models.User
+	err := collection.FindOne(ctx, bson.M{"oidc.issuer": issuer, "oidc.subject": subject}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id models.ID) (*models.User, error) {
+	collection := r.db.Collection("users")
+
+	var user models.User
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	collection := r.db.Collection("users")
+
+	user.UpdatedAt = time.Now()
+
+	_, err := collection.ReplaceOne(
+		ctx,
+		bson.M{"_id": user.ID},
+		user,
+		options.Replace().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return repository.ErrDuplicateEmail
+	}
+
+	return err
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id models.ID) error {
+	collection := r.db.Collection("users")
+
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *UserRepository) FindByRole(ctx context.Context, role string) ([]*models.User, error) {
+	collection := r.db.Collection("users")
+
+	cursor, err := collection.Find(ctx, bson.M{"role": role})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*models.User
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) FindAll(ctx context.Context) ([]*models.User, error) {
+	collection := r.db.Collection("users")
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*models.User
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) RecordLoginFailure(ctx context.Context, id models.ID) (int, error) {
+	collection := r.db.Collection("users")
+
+	var user models.User
+	err := collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"failed_logins": 1}},
+		options.FindOneAndUpdate().
+			SetReturnDocument(options.After).
+			SetProjection(bson.M{"failed_logins": 1}),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return user.FailedLogins, nil
+}
+
+func (r *UserRepository) SetLockout(ctx context.Context, id models.ID, failedLogins int, lockedUntil *time.Time) error {
+	collection := r.db.Collection("users")
+
+	set := bson.M{"failed_logins": failedLogins}
+	update := bson.M{"$set": set}
+	if lockedUntil != nil {
+		set["locked_until"] = *lockedUntil
+	} else {
+		update["$unset"] = bson.M{"locked_until": ""}
+	}
+
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+func (r *UserRepository) SetTwoFactor(ctx context.Context, id models.ID, prev, next *models.TwoFactor) (bool, error) {
+	collection := r.db.Collection("users")
+
+	// A nil prev matches a missing field as well as null
+	filter := bson.M{"_id": id, "two_factor": prev}
+	update := bson.M{"$set": bson.M{"two_factor": next}}
+	if next == nil {
+		update = bson.M{"$unset": bson.M{"two_factor": ""}}
+	}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
@@ -1,7 +1,8 @@
-package repository
+package mongodb
 
 import (
 	"bug-tracker/models"
+	"bug-tracker/repository"
 	"context"
 	"testing"
 	"time"
@@ -9,7 +10,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func TestUserCreate(t *testing.T) {
@@ -68,7 +68,7 @@ func TestUserCreate(t *testing.T) {
 		}
 
 		err = repo.Create(ctx, user2)
-		assert.Error(t, err) // Should fail due to unique email constraint
+		assert.ErrorIs(t, err, repository.ErrDuplicateEmail) // Should fail due to unique email constraint
 	})
 }
 
@@ -103,7 +103,7 @@ func TestUserFindByID(t *testing.T) {
 
 	// Test case 2: Not found
 	t.Run("Not Found", func(t *testing.T) {
-		nonExistentID := primitive.NewObjectID()
+		nonExistentID := models.NewID()
 		foundUser, err := repo.FindByID(ctx, nonExistentID)
 		assert.NoError(t, err)
 		assert.Nil(t, foundUser)
@@ -250,7 +250,7 @@ func TestUserUpdate(t *testing.T) {
 	// Test case 2: Update non-existent user
 	t.Run("Not Found", func(t *testing.T) {
 		nonExistentUser := &models.User{
-			ID:        primitive.NewObjectID(),
+			ID:        models.NewID(),
 			Name:      "Non-existent",
 			Email:     "nonexistent@example.com",
 			Password:  "hashedpassword",
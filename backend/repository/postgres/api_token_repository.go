@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+	"bug-tracker/repository/sqlutil"
+)
+
+// Ensure APITokenRepository implements APITokenRepositoryInterface
+var _ repository.APITokenRepositoryInterface = (*APITokenRepository)(nil)
+
+const apiTokenColumns = "id, user_id, name, prefix, hash, scopes, expires_at, last_used_at, created_at"
+
+type APITokenRepository struct {
+	db *sql.DB
+}
+
+func NewAPITokenRepository(db *sql.DB) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+func (r *APITokenRepository) Create(ctx context.Context, token *models.APIToken) error {
+	id := models.NewID()
+	now := time.Now()
+
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO api_tokens ("+apiTokenColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		id.Hex(), token.UserID.Hex(), token.Name, token.Prefix, token.Hash, sqlutil.Strings(token.Scopes),
+		token.ExpiresAt, token.LastUsedAt, now)
+	if err != nil {
+		return err
+	}
+
+	token.ID = id
+	token.CreatedAt = now
+	return nil
+}
+
+func (r *APITokenRepository) FindByID(ctx context.Context, id models.ID) (*models.APIToken, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+apiTokenColumns+" FROM api_tokens WHERE id = $1", id.Hex())
+	return scanOptionalAPIToken(row)
+}
+
+func (r *APITokenRepository) FindByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+apiTokenColumns+" FROM api_tokens WHERE hash = $1", hash)
+	return scanOptionalAPIToken(row)
+}
+
+func (r *APITokenRepository) FindByUser(ctx context.Context, userID models.ID) ([]*models.APIToken, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+apiTokenColumns+" FROM api_tokens WHERE user_id = $1 ORDER BY created_at, id", userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*models.APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+func (r *APITokenRepository) Delete(ctx context.Context, id models.ID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM api_tokens WHERE id = $1", id.Hex())
+	return err
+}
+
+func (r *APITokenRepository) SetLastUsed(ctx context.Context, id models.ID, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE api_tokens SET last_used_at = $2 WHERE id = $1", id.Hex(), at)
+	return err
+}
+
+func scanAPIToken(row sqlutil.Scanner) (*models.APIToken, error) {
+	var token models.APIToken
+	var expiresAt, lastUsedAt sql.NullTime
+	err := row.Scan(
+		sqlutil.ScanID(&token.ID), sqlutil.ScanID(&token.UserID), &token.Name, &token.Prefix, &token.Hash,
+		sqlutil.ScanStrings(&token.Scopes), &expiresAt, &lastUsedAt, &token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		token.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	return &token, nil
+}
+
+// scanOptionalAPIToken returns nil when row is empty, like the Mongo repository
+func scanOptionalAPIToken(row *sql.Row) (*models.APIToken, error) {
+	token, err := scanAPIToken(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return token, err
+}
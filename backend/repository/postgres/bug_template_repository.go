@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+	"bug-tracker/repository/sqlutil"
+)
+
+// Ensure BugTemplateRepository implements BugTemplateRepositoryInterface
+var _ repository.BugTemplateRepositoryInterface = (*BugTemplateRepository)(nil)
+
+const bugTemplateColumns = "id, name, title_prefix, description, required_sections, priority, labels, created_at, updated_at"
+
+type BugTemplateRepository struct {
+	db *sql.DB
+}
+
+func NewBugTemplateRepository(db *sql.DB) *BugTemplateRepository {
+	return &BugTemplateRepository{db: db}
+}
+
+func (r *BugTemplateRepository) Create(ctx context.Context, template *models.BugTemplate) error {
+	id := models.NewID()
+	now := time.Now()
+
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO bug_templates ("+bugTemplateColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)",
+		id.Hex(), template.Name, template.TitlePrefix, template.Description, sqlutil.Strings(template.RequiredSections),
+		template.Priority, sqlutil.Strings(template.Labels), now)
+	if err != nil {
+		return err
+	}
+
+	template.ID = id
+	template.CreatedAt = now
+	template.UpdatedAt = now
+	return nil
+}
+
+func (r *BugTemplateRepository) FindByID(ctx context.Context, id models.ID) (*models.BugTemplate, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+bugTemplateColumns+" FROM bug_templates WHERE id = $1", id.Hex())
+	template, err := scanBugTemplate(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return template, err
+}
+
+func (r *BugTemplateRepository) FindAll(ctx context.Context) ([]*models.BugTemplate, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+bugTemplateColumns+" FROM bug_templates ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []*models.BugTemplate
+	for rows.Next() {
+		template, err := scanBugTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	return templates, rows.Err()
+}
+
+func (r *BugTemplateRepository) Update(ctx context.Context, template *models.BugTemplate) error {
+	now := time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE bug_templates SET
+			name = $2, title_prefix = $3, description = $4, required_sections = $5,
+			priority = $6, labels = $7, updated_at = $8
+		WHERE id = $1`,
+		template.ID.Hex(), template.Name, template.TitlePrefix, template.Description, sqlutil.Strings(template.RequiredSections),
+		template.Priority, sqlutil.Strings(template.Labels), now)
+	if err != nil {
+		return err
+	}
+
+	template.UpdatedAt = now
+	return nil
+}
+
+func (r *BugTemplateRepository) Delete(ctx context.Context, id models.ID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM bug_templates WHERE id = $1", id.Hex())
+	return err
+}
+
+func scanBugTemplate(row sqlutil.Scanner) (*models.BugTemplate, error) {
+	var template models.BugTemplate
+	err := row.Scan(
+		sqlutil.ScanID(&template.ID), &template.Name, &template.TitlePrefix, &template.Description,
+		sqlutil.ScanStrings(&template.RequiredSections), &template.Priority, sqlutil.ScanStrings(&template.Labels),
+		&template.CreatedAt, &template.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"time"
+
+	"bug-tracker/logging"
+	"bug-tracker/repository/sqlutil"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey identifies the advisory lock held while migrating
+const migrationLockKey = 7352001
+
+// ErrLocked is returned when another process is applying migrations
+var ErrLocked = errors.New("migrations are being applied by another process")
+
+// Migration is one SQL file from the migrations directory
+type Migration = sqlutil.Migration
+
+type AppliedMigration = sqlutil.AppliedMigration
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB) *Migrator {
+	migrations, err := sqlutil.LoadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		// The files are embedded, so this only fails on a bad file name
+		panic(err)
+	}
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Applied returns the migrations recorded as applied, oldest first
+func (m *Migrator) Applied(ctx context.Context) ([]AppliedMigration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version, name, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := []AppliedMigration{}
+	for rows.Next() {
+		var a AppliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+// Pending returns the migrations that have not been applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return sqlutil.Pending(m.migrations, applied), nil
+}
+
+// Up applies every pending migration in version order and returns the ones
+// it applied. Each migration runs in its own transaction together with its
+// schema_migrations record, so a failed migration leaves no trace.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	// Advisory locks belong to a session, so hold one connection throughout
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", migrationLockKey).Scan(&locked); err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, ErrLocked
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey); err != nil {
+			logging.FromContext(ctx).Warn("failed to release migration lock", "error", err)
+		}
+	}()
+
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range pending {
+		if err := m.apply(ctx, conn, migration); err != nil {
+			return applied, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.SQL); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)",
+		migration.Version, migration.Name, time.Now())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL
+	)`)
+	return err
+}
@@ -0,0 +1,34 @@
+-- IDs are the 24 character hex form of models.ID so they stay valid when
+-- data moves between backends.
+
+CREATE TABLE users (
+    id         CHAR(24) PRIMARY KEY,
+    name       TEXT NOT NULL,
+    email      TEXT NOT NULL,
+    password   TEXT NOT NULL,
+    role       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT users_email_unique UNIQUE (email)
+);
+
+CREATE INDEX users_role ON users (role);
+
+CREATE TABLE bugs (
+    id          CHAR(24) PRIMARY KEY,
+    title       TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    status      TEXT NOT NULL,
+    priority    TEXT NOT NULL,
+    reported_by CHAR(24) NOT NULL,
+    assigned_to CHAR(24),
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL,
+    resolved_at TIMESTAMPTZ,
+    external_id TEXT,
+    CONSTRAINT bugs_external_id_unique UNIQUE (external_id)
+);
+
+CREATE INDEX bugs_assigned_to_status ON bugs (assigned_to, status);
+CREATE INDEX bugs_status_created_at ON bugs (status, created_at DESC);
+CREATE INDEX bugs_created_at ON bugs (created_at);
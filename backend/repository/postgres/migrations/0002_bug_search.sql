@@ -0,0 +1,3 @@
+-- Full-text index for bug search. The 'simple' configuration matches words as
+-- typed, without stemming or stop words, like the other backends.
+CREATE INDEX bugs_search ON bugs USING GIN (to_tsvector('simple', title || ' ' || description));
@@ -0,0 +1,4 @@
+-- Consecutive failed logins and the lockout they trigger
+ALTER TABLE users
+    ADD COLUMN failed_logins INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN locked_until  TIMESTAMPTZ;
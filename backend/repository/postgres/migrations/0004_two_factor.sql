@@ -0,0 +1,2 @@
+-- TOTP secret, replay guard and hashed recovery codes
+ALTER TABLE users ADD COLUMN two_factor JSONB;
@@ -0,0 +1,15 @@
+-- Personal API tokens. Only a SHA-256 hash of each token is stored.
+CREATE TABLE api_tokens (
+    id           CHAR(24) PRIMARY KEY,
+    user_id      CHAR(24) NOT NULL,
+    name         TEXT NOT NULL,
+    prefix       TEXT NOT NULL,
+    hash         TEXT NOT NULL,
+    scopes       JSONB NOT NULL,
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL,
+    CONSTRAINT api_tokens_hash_unique UNIQUE (hash)
+);
+
+CREATE INDEX api_tokens_user_id_created_at ON api_tokens (user_id, created_at);
@@ -0,0 +1,4 @@
+-- The single sign-on account a user is linked to
+ALTER TABLE users ADD COLUMN oidc_issuer TEXT;
+ALTER TABLE users ADD COLUMN oidc_subject TEXT;
+ALTER TABLE users ADD CONSTRAINT users_oidc_unique UNIQUE (oidc_issuer, oidc_subject);
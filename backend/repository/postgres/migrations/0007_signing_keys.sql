@@ -0,0 +1,9 @@
+-- Keys that sign login tokens, published at /.well-known/jwks.json.
+-- private_key is PKCS #8 DER.
+CREATE TABLE signing_keys (
+    id          CHAR(24) PRIMARY KEY,
+    algorithm   TEXT NOT NULL,
+    private_key BYTEA NOT NULL,
+    active_at   TIMESTAMPTZ NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,7 @@
+-- Roles and the permissions they grant. The built-in roles are created
+-- when the server starts.
+CREATE TABLE roles (
+    name        TEXT PRIMARY KEY,
+    permissions JSONB NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL
+);
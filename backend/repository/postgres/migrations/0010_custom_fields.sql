@@ -0,0 +1,17 @@
+-- Custom field definitions, with the options of enum and multi-select
+-- fields
+CREATE TABLE custom_fields (
+    key        TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    type       TEXT NOT NULL,
+    options    JSONB NOT NULL,
+    required   BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- Custom field values of each bug by field key
+ALTER TABLE bugs ADD COLUMN fields JSONB;
+
+-- The admin role created before field.manage existed gets it too
+UPDATE roles SET permissions = permissions || '["field.manage"]'::jsonb
+WHERE name = 'admin' AND NOT permissions @> '["field.manage"]'::jsonb;
@@ -0,0 +1,20 @@
+-- Templates bugs are reported from
+CREATE TABLE bug_templates (
+    id                CHAR(24) PRIMARY KEY,
+    name              TEXT NOT NULL,
+    title_prefix      TEXT NOT NULL,
+    description       TEXT NOT NULL,
+    required_sections JSONB NOT NULL,
+    priority          TEXT NOT NULL,
+    labels            JSONB NOT NULL,
+    created_at        TIMESTAMPTZ NOT NULL,
+    updated_at        TIMESTAMPTZ NOT NULL
+);
+
+-- Labels of each bug
+ALTER TABLE bugs ADD COLUMN labels JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+-- The admin and manager roles created before template.manage existed get
+-- it too
+UPDATE roles SET permissions = permissions || '["template.manage"]'::jsonb
+WHERE name IN ('admin', 'manager') AND NOT permissions @> '["template.manage"]'::jsonb;
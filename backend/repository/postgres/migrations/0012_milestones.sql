@@ -0,0 +1,21 @@
+-- Releases bugs are found in and fixed in. Due dates are YYYY-MM-DD, or
+-- empty.
+CREATE TABLE milestones (
+    id          CHAR(24) PRIMARY KEY,
+    name        TEXT NOT NULL,
+    description TEXT NOT NULL,
+    due_date    TEXT NOT NULL,
+    state       TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL,
+    closed_at   TIMESTAMPTZ
+);
+
+ALTER TABLE bugs ADD COLUMN affected_version CHAR(24);
+ALTER TABLE bugs ADD COLUMN fix_version CHAR(24);
+CREATE INDEX bugs_fix_version_status ON bugs (fix_version, status);
+
+-- The admin and manager roles created before milestone.manage existed get
+-- it too
+UPDATE roles SET permissions = permissions || '["milestone.manage"]'::jsonb
+WHERE name IN ('admin', 'manager') AND NOT permissions @> '["milestone.manage"]'::jsonb;
@@ -0,0 +1,39 @@
+// Package postgres implements the repository interfaces on PostgreSQL.
+// Schema changes are plain SQL files in the migrations directory, applied in
+// order by Migrator.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"bug-tracker/repository/sqlutil"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" database/sql driver
+)
+
+// Open connects to the database at url and verifies the connection
+func Open(ctx context.Context, url string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", url)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// isUniqueViolation reports whether err violates the named unique constraint
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
+
+// newParams returns a statement builder using PostgreSQL's $n placeholders
+func newParams() *sqlutil.Params {
+	return &sqlutil.Params{Prefix: "$"}
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"bug-tracker/repository"
+	"bug-tracker/repository/repotest"
+	"bug-tracker/repository/sqlutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestDB connects to the database in POSTGRES_TEST_URL, skipping the
+// test when it isn't set. Each call starts from a freshly migrated schema.
+func setupTestDB(t *testing.T) *sql.DB {
+	url := os.Getenv("POSTGRES_TEST_URL")
+	if url == "" {
+		t.Skip("POSTGRES_TEST_URL not set")
+	}
+
+	ctx := context.Background()
+	db, err := Open(ctx, url)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.ExecContext(ctx, "DROP TABLE IF EXISTS bugs, users, api_tokens, signing_keys, roles, custom_fields, bug_templates, milestones, schema_migrations CASCADE")
+	require.NoError(t, err)
+	_, err = NewMigrator(db).Up(ctx)
+	require.NoError(t, err)
+	return db
+}
+
+func TestBugRepository(t *testing.T) {
+	repotest.RunBugRepositoryTests(t, func(t *testing.T) repository.BugRepositoryInterface {
+		return NewBugRepository(setupTestDB(t))
+	})
+}
+
+func TestUserRepository(t *testing.T) {
+	repotest.RunUserRepositoryTests(t, func(t *testing.T) repository.UserRepositoryInterface {
+		return NewUserRepository(setupTestDB(t))
+	})
+}
+
+func TestAPITokenRepository(t *testing.T) {
+	repotest.RunAPITokenRepositoryTests(t, func(t *testing.T) repository.APITokenRepositoryInterface {
+		return NewAPITokenRepository(setupTestDB(t))
+	})
+}
+
+func TestSigningKeyRepository(t *testing.T) {
+	repotest.RunSigningKeyRepositoryTests(t, func(t *testing.T) repository.SigningKeyRepositoryInterface {
+		return NewSigningKeyRepository(setupTestDB(t))
+	})
+}
+
+func TestRoleRepository(t *testing.T) {
+	repotest.RunRoleRepositoryTests(t, func(t *testing.T) repository.RoleRepositoryInterface {
+		return NewRoleRepository(setupTestDB(t))
+	})
+}
+
+func TestCustomFieldRepository(t *testing.T) {
+	repotest.RunCustomFieldRepositoryTests(t, func(t *testing.T) repository.CustomFieldRepositoryInterface {
+		return NewCustomFieldRepository(setupTestDB(t))
+	})
+}
+
+func TestBugTemplateRepository(t *testing.T) {
+	repotest.RunBugTemplateRepositoryTests(t, func(t *testing.T) repository.BugTemplateRepositoryInterface {
+		return NewBugTemplateRepository(setupTestDB(t))
+	})
+}
+
+func TestMilestoneRepository(t *testing.T) {
+	repotest.RunMilestoneRepositoryTests(t, func(t *testing.T) repository.MilestoneRepositoryInterface {
+		return NewMilestoneRepository(setupTestDB(t))
+	})
+}
+
+func TestMigrator(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	migrator := NewMigrator(db)
+
+	applied, err := migrator.Applied(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrator.migrations))
+
+	// Re-running is a no-op
+	again, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	pending, err := migrator.Pending(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := sqlutil.LoadMigrations(migrationFiles, "migrations")
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.Equal(t, "init", migrations[0].Name)
+}
+
+func TestReportRepository(t *testing.T) {
+	repotest.RunReportRepositoryTests(t, func(t *testing.T) (repository.BugRepositoryInterface, repository.ReportRepositoryInterface) {
+		db := setupTestDB(t)
+		return NewBugRepository(db), NewReportRepository(db)
+	})
+}
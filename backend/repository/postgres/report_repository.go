@@ -0,0 +1,223 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+	"bug-tracker/repository/sqlutil"
+)
+
+// Ensure ReportRepository implements ReportRepositoryInterface
+var _ repository.ReportRepositoryInterface = (*ReportRepository)(nil)
+
+// trendFormats are the to_char patterns matching the Mongo repository's
+// period keys
+var trendFormats = map[string]string{
+	"day":   "YYYY-MM-DD",
+	"week":  `IYYY-"W"IW`,
+	"month": "YYYY-MM",
+}
+
+// resolvedAtExpr falls back to updated_at for bugs resolved before resolved_at was tracked
+const resolvedAtExpr = "COALESCE(resolved_at, updated_at)"
+
+// resolutionHoursExpr is the time between creation and resolution in hours
+const resolutionHoursExpr = "EXTRACT(EPOCH FROM " + resolvedAtExpr + " - created_at) / 3600"
+
+type ReportRepository struct {
+	db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+func (r *ReportRepository) CountByStatus(ctx context.Context, filter models.ReportFilter) ([]models.CountBucket, error) {
+	return r.countBy(ctx, "status", filter)
+}
+
+func (r *ReportRepository) CountByPriority(ctx context.Context, filter models.ReportFilter) ([]models.CountBucket, error) {
+	return r.countBy(ctx, "priority", filter)
+}
+
+// countBy counts bugs per value of column, which must be a trusted name
+func (r *ReportRepository) countBy(ctx context.Context, column string, filter models.ReportFilter) ([]models.CountBucket, error) {
+	p := newParams()
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+column+", COUNT(*) FROM bugs"+sqlutil.Where(p.DateRange("created_at", filter))+" GROUP BY "+column+" ORDER BY "+column,
+		p.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []models.CountBucket{}
+	for rows.Next() {
+		var b models.CountBucket
+		if err := rows.Scan(&b.Key, &b.Count); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
+
+func (r *ReportRepository) CountByAssignee(ctx context.Context, filter models.ReportFilter) ([]models.AssigneeBucket, error) {
+	p := newParams()
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT assigned_to, COUNT(*) FROM bugs"+sqlutil.Where(p.DateRange("created_at", filter))+" GROUP BY assigned_to ORDER BY COUNT(*) DESC, assigned_to",
+		p.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []models.AssigneeBucket{}
+	for rows.Next() {
+		var b models.AssigneeBucket
+		if err := rows.Scan(sqlutil.ScanID(&b.AssigneeID), &b.Count); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
+
+func (r *ReportRepository) Trend(ctx context.Context, filter models.ReportFilter, interval string) ([]models.TrendPoint, error) {
+	format, ok := trendFormats[interval]
+	if !ok {
+		format = trendFormats["day"]
+	}
+	period := func(expr string) string {
+		return fmt.Sprintf("to_char(%s AT TIME ZONE 'UTC', '%s')", expr, format)
+	}
+
+	p := newParams()
+	opened := p.DateRange("created_at", filter)
+	closed := append([]string{"status = 'resolved'"}, p.DateRange(resolvedAtExpr, filter)...)
+
+	query := `
+		SELECT period, SUM(opened), SUM(closed) FROM (
+			SELECT ` + period("created_at") + ` AS period, 1 AS opened, 0 AS closed
+			FROM bugs` + sqlutil.Where(opened) + `
+			UNION ALL
+			SELECT ` + period(resolvedAtExpr) + `, 0, 1
+			FROM bugs` + sqlutil.Where(closed) + `
+		) counts
+		GROUP BY period
+		ORDER BY period`
+
+	rows, err := r.db.QueryContext(ctx, query, p.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trend := []models.TrendPoint{}
+	for rows.Next() {
+		var point models.TrendPoint
+		if err := rows.Scan(&point.Period, &point.Opened, &point.Closed); err != nil {
+			return nil, err
+		}
+		trend = append(trend, point)
+	}
+	return trend, rows.Err()
+}
+
+func (r *ReportRepository) ResolutionTime(ctx context.Context, filter models.ReportFilter) (*models.ResolutionReport, error) {
+	p := newParams()
+	conds := append([]string{"status = 'resolved'"}, p.DateRange(resolvedAtExpr, filter)...)
+
+	hours := "(" + resolutionHoursExpr + ")"
+	query := fmt.Sprintf(
+		"SELECT COUNT(*), COALESCE(AVG(%[1]s), 0), COALESCE(MIN(%[1]s), 0), COALESCE(MAX(%[1]s), 0) FROM bugs%[2]s",
+		hours, sqlutil.Where(conds))
+
+	report := &models.ResolutionReport{}
+	err := r.db.QueryRowContext(ctx, query, p.Args...).
+		Scan(&report.Resolved, &report.MeanHours, &report.MinHours, &report.MaxHours)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (r *ReportRepository) Ageing(ctx context.Context, filter models.ReportFilter, now time.Time) ([]models.AgeingBucket, error) {
+	p := newParams()
+	age := "EXTRACT(EPOCH FROM " + p.Bind(now) + "::timestamptz - created_at) / 86400"
+	conds := append([]string{"status <> 'resolved'"}, p.DateRange("created_at", filter)...)
+
+	// width_bucket returns how many thresholds the age reaches, which is the
+	// index of its bucket in AgeingBoundaries. Anything younger than the
+	// first upper bound, including clock skew, lands in bucket 0.
+	thresholds := make([]string, 0, len(repository.AgeingBoundaries)-1)
+	for _, days := range repository.AgeingBoundaries[1:] {
+		thresholds = append(thresholds, fmt.Sprint(days))
+	}
+	query := fmt.Sprintf(
+		"SELECT width_bucket((%s)::numeric, ARRAY[%s]::numeric[]), COUNT(*) FROM bugs%s GROUP BY 1",
+		age, strings.Join(thresholds, ", "), sqlutil.Where(conds))
+
+	rows, err := r.db.QueryContext(ctx, query, p.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]int, len(repository.AgeingBoundaries))
+	for rows.Next() {
+		var index, count int
+		if err := rows.Scan(&index, &count); err != nil {
+			return nil, err
+		}
+		counts[index] += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Always report every bucket so charts have a stable shape
+	buckets := make([]models.AgeingBucket, len(repository.AgeingBoundaries))
+	for i, days := range repository.AgeingBoundaries {
+		buckets[i] = models.AgeingBucket{Label: repository.AgeingLabels[days], Count: counts[i]}
+	}
+	return buckets, nil
+}
+
+func (r *ReportRepository) DeveloperThroughput(ctx context.Context, filter models.ReportFilter) ([]models.DeveloperThroughput, error) {
+	p := newParams()
+	isAssigned := strings.Join(append([]string{"assigned_to IS NOT NULL"}, p.DateRange("created_at", filter)...), " AND ")
+	isResolved := strings.Join(append([]string{"status = 'resolved'"}, p.DateRange(resolvedAtExpr, filter)...), " AND ")
+
+	query := fmt.Sprintf(`
+		SELECT assigned_to,
+			COUNT(*) FILTER (WHERE %[1]s),
+			COUNT(*) FILTER (WHERE %[2]s),
+			COALESCE(AVG(%[3]s) FILTER (WHERE %[2]s), 0)
+		FROM bugs
+		WHERE assigned_to IS NOT NULL AND ((%[1]s) OR (%[2]s))
+		GROUP BY assigned_to
+		ORDER BY 3 DESC, assigned_to`,
+		isAssigned, isResolved, resolutionHoursExpr)
+
+	rows, err := r.db.QueryContext(ctx, query, p.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.DeveloperThroughput{}
+	for rows.Next() {
+		var t models.DeveloperThroughput
+		if err := rows.Scan(sqlutil.ScanID(&t.DeveloperID), &t.Assigned, &t.Resolved, &t.MeanHours); err != nil {
+			return nil, err
+		}
+		results = append(results, t)
+	}
+	return results, rows.Err()
+}
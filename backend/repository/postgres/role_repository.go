@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+	"bug-tracker/repository/sqlutil"
+)
+
+// Ensure RoleRepository implements RoleRepositoryInterface
+var _ repository.RoleRepositoryInterface = (*RoleRepository)(nil)
+
+type RoleRepository struct {
+	db *sql.DB
+}
+
+func NewRoleRepository(db *sql.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+func (r *RoleRepository) FindAll(ctx context.Context) ([]*models.Role, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT name, permissions, updated_at FROM roles ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*models.Role
+	for rows.Next() {
+		var role models.Role
+		if err := rows.Scan(&role.Name, sqlutil.ScanStrings(&role.Permissions), &role.UpdatedAt); err != nil {
+			return nil, err
+		}
+		roles = append(roles, &role)
+	}
+	return roles, rows.Err()
+}
+
+func (r *RoleRepository) Save(ctx context.Context, role *models.Role) error {
+	now := time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO roles (name, permissions, updated_at) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET
+			permissions = EXCLUDED.permissions,
+			updated_at = EXCLUDED.updated_at`,
+		role.Name, sqlutil.Strings(role.Permissions), now)
+	if err != nil {
+		return err
+	}
+
+	role.UpdatedAt = now
+	return nil
+}
+
+func (r *RoleRepository) Delete(ctx context.Context, name string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM roles WHERE name = $1", name)
+	return err
+}
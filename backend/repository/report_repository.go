@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"bug-tracker/models"
+)
+
+type ReportRepositoryInterface interface {
+	CountByStatus(ctx context.Context, filter models.ReportFilter) ([]models.CountBucket, error)
+	CountByPriority(ctx context.Context, filter models.ReportFilter) ([]models.CountBucket, error)
+	CountByAssignee(ctx context.Context, filter models.ReportFilter) ([]models.AssigneeBucket, error)
+	Trend(ctx context.Context, filter models.ReportFilter, interval string) ([]models.TrendPoint, error)
+	ResolutionTime(ctx context.Context, filter models.ReportFilter) (*models.ResolutionReport, error)
+	Ageing(ctx context.Context, filter models.ReportFilter, now time.Time) ([]models.AgeingBucket, error)
+	// DeveloperThroughput counts, per assignee, the assigned bugs created in
+	// the filter's range and the bugs resolved in it, most resolved first.
+	// Assignment times aren't recorded, so creation stands in for them.
+	DeveloperThroughput(ctx context.Context, filter models.ReportFilter) ([]models.DeveloperThroughput, error)
+}
+
+// AgeingBoundaries are the lower bounds, in days, of the open-bug age buckets.
+// Every backend reports these buckets, labelled by AgeingLabels.
+var AgeingBoundaries = []int{0, 1, 7, 30, 90}
+
+var AgeingLabels = map[int]string{
+	0:  "<1d",
+	1:  "1-7d",
+	7:  "7-30d",
+	30: "30-90d",
+	90: "90d+",
+}
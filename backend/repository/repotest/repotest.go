@@ -0,0 +1,1171 @@
+// Package repotest is a conformance suite for repository implementations.
+// Every backend runs the same tests so they stay interchangeable: a use
+// case must behave identically whichever repository it is given.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// precision is the timestamp resolution every backend must preserve
+const precision = time.Millisecond
+
+// RunBugRepositoryTests runs the bug repository suite. newRepo must return
+// an empty repository each time it is called.
+func RunBugRepositoryTests(t *testing.T, newRepo func(t *testing.T) repository.BugRepositoryInterface) {
+	ctx := context.Background()
+
+	newBug := func(title string) *models.Bug {
+		return &models.Bug{
+			Title:       title,
+			Description: "Description of " + title,
+			Priority:    "high",
+			ReportedBy:  models.NewID(),
+		}
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		repo := newRepo(t)
+
+		bug := newBug("Crash")
+		bug.Status = "resolved"
+		require.NoError(t, repo.Create(ctx, bug))
+		assert.False(t, bug.ID.IsZero())
+		assert.Equal(t, "open", bug.Status)
+		assert.False(t, bug.CreatedAt.IsZero())
+
+		found, err := repo.FindByID(ctx, bug.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, bug.Title, found.Title)
+		assert.Equal(t, bug.Description, found.Description)
+		assert.Equal(t, "open", found.Status)
+		assert.Equal(t, bug.Priority, found.Priority)
+		assert.Equal(t, bug.ReportedBy, found.ReportedBy)
+		assert.WithinDuration(t, bug.CreatedAt, found.CreatedAt, precision)
+	})
+
+	t.Run("FindByID not found", func(t *testing.T) {
+		repo := newRepo(t)
+
+		found, err := repo.FindByID(ctx, models.NewID())
+		assert.NoError(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("FindAll and FindByAssignee", func(t *testing.T) {
+		repo := newRepo(t)
+		developerID := models.NewID()
+
+		for _, title := range []string{"One", "Two", "Three"} {
+			require.NoError(t, repo.Create(ctx, newBug(title)))
+		}
+		assigned := newBug("Assigned")
+		require.NoError(t, repo.Create(ctx, assigned))
+		require.NoError(t, repo.AssignToDeveloper(ctx, assigned.ID, developerID))
+
+		all, err := repo.FindAll(ctx)
+		require.NoError(t, err)
+		assert.Len(t, all, 4)
+
+		mine, err := repo.FindByAssignee(ctx, developerID)
+		require.NoError(t, err)
+		require.Len(t, mine, 1)
+		assert.Equal(t, assigned.ID, mine[0].ID)
+		assert.Equal(t, developerID, mine[0].AssignedTo)
+
+		none, err := repo.FindByAssignee(ctx, models.NewID())
+		require.NoError(t, err)
+		assert.Empty(t, none)
+	})
+
+	t.Run("UpdateStatus tracks resolution", func(t *testing.T) {
+		repo := newRepo(t)
+		bug := newBug("Crash")
+		require.NoError(t, repo.Create(ctx, bug))
+
+		require.NoError(t, repo.UpdateStatus(ctx, bug.ID, "resolved"))
+		found, err := repo.FindByID(ctx, bug.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "resolved", found.Status)
+		require.NotNil(t, found.ResolvedAt)
+		assert.WithinDuration(t, time.Now(), *found.ResolvedAt, time.Minute)
+
+		require.NoError(t, repo.UpdateStatus(ctx, bug.ID, "open"))
+		found, err = repo.FindByID(ctx, bug.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "open", found.Status)
+		assert.Nil(t, found.ResolvedAt)
+	})
+
+	t.Run("Update and Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		bug := newBug("Crash")
+		require.NoError(t, repo.Create(ctx, bug))
+
+		bug.Title = "Crash on startup"
+		bug.Priority = "critical"
+		require.NoError(t, repo.Update(ctx, bug))
+
+		found, err := repo.FindByID(ctx, bug.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Crash on startup", found.Title)
+		assert.Equal(t, "critical", found.Priority)
+
+		require.NoError(t, repo.Delete(ctx, bug.ID))
+		found, err = repo.FindByID(ctx, bug.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, found)
+
+		// Deleting and updating missing bugs is not an error
+		assert.NoError(t, repo.Delete(ctx, bug.ID))
+		assert.NoError(t, repo.UpdateStatus(ctx, bug.ID, "resolved"))
+	})
+
+	t.Run("returned bugs are copies", func(t *testing.T) {
+		repo := newRepo(t)
+		bug := newBug("Crash")
+		require.NoError(t, repo.Create(ctx, bug))
+
+		bug.Title = "Changed without saving"
+		found, err := repo.FindByID(ctx, bug.ID)
+		require.NoError(t, err)
+		found.Title = "Also changed without saving"
+
+		found, err = repo.FindByID(ctx, bug.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Crash", found.Title)
+	})
+
+	t.Run("Stream filters and orders by creation", func(t *testing.T) {
+		repo := newRepo(t)
+		developerID := models.NewID()
+		base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+		// Insert out of order to check the sort
+		for i, day := range []int{3, 1, 2, 4} {
+			bug := newBug("Bug")
+			bug.Status = "open"
+			bug.ExternalID = "test:" + string(rune('a'+i))
+			bug.CreatedAt = base.AddDate(0, 0, day)
+			if day != 4 {
+				bug.AssignedTo = developerID
+			}
+			_, err := repo.CreateIfNotExists(ctx, bug)
+			require.NoError(t, err)
+		}
+
+		var days []int
+		from := base.AddDate(0, 0, 2)
+		err := repo.Stream(ctx, models.BugFilter{AssignedTo: &developerID, From: &from}, func(bug *models.Bug) error {
+			days = append(days, bug.CreatedAt.Day())
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int{3, 4}, days)
+
+		stop := errors.New("stop")
+		calls := 0
+		err = repo.Stream(ctx, models.BugFilter{}, func(*models.Bug) error {
+			calls++
+			return stop
+		})
+		assert.Equal(t, stop, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Stream filters by involved user", func(t *testing.T) {
+		repo := newRepo(t)
+		userID := models.NewID()
+
+		reported := newBug("Reported")
+		reported.ReportedBy = userID
+		assigned := newBug("Assigned")
+		assigned.AssignedTo = userID
+		for _, bug := range []*models.Bug{reported, assigned, newBug("Unrelated")} {
+			require.NoError(t, repo.Create(ctx, bug))
+		}
+
+		var titles []string
+		err := repo.Stream(ctx, models.BugFilter{InvolvedUser: &userID}, func(bug *models.Bug) error {
+			titles = append(titles, bug.Title)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"Reported", "Assigned"}, titles)
+	})
+
+	t.Run("custom fields", func(t *testing.T) {
+		repo := newRepo(t)
+
+		bug := newBug("Layout broken")
+		bug.Fields = models.FieldValues{
+			"environment": "staging",
+			"severity":    float64(2),
+			"browsers":    []string{"firefox", "safari"},
+		}
+		require.NoError(t, repo.Create(ctx, bug))
+
+		found, err := repo.FindByID(ctx, bug.ID)
+		require.NoError(t, err)
+		assert.Equal(t, bug.Fields, found.Fields)
+
+		found.Fields["severity"] = float64(3)
+		delete(found.Fields, "browsers")
+		require.NoError(t, repo.Update(ctx, found))
+		found, err = repo.FindByID(ctx, bug.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.FieldValues{"environment": "staging", "severity": float64(3)}, found.Fields)
+
+		plain := newBug("No fields")
+		require.NoError(t, repo.Create(ctx, plain))
+		found, err = repo.FindByID(ctx, plain.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found.Fields)
+
+		require.NoError(t, repo.RemoveField(ctx, "environment"))
+		require.NoError(t, repo.RemoveField(ctx, "severity"))
+		found, err = repo.FindByID(ctx, bug.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found.Fields)
+	})
+
+	t.Run("labels", func(t *testing.T) {
+		repo := newRepo(t)
+
+		bug := newBug("Layout broken")
+		bug.Labels = []string{"ui", "regression"}
+		require.NoError(t, repo.Create(ctx, bug))
+
+		found, err := repo.FindByID(ctx, bug.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ui", "regression"}, found.Labels)
+
+		found.Labels = nil
+		require.NoError(t, repo.Update(ctx, found))
+		found, err = repo.FindByID(ctx, bug.ID)
+		require.NoError(t, err)
+		assert.Empty(t, found.Labels)
+	})
+
+	t.Run("versions", func(t *testing.T) {
+		repo := newRepo(t)
+		release, next := models.NewID(), models.NewID()
+
+		found := newBug("Found in release")
+		found.AffectedVersion = release
+		require.NoError(t, repo.Create(ctx, found))
+		fixed := newBug("Fixed in next")
+		fixed.AffectedVersion = release
+		fixed.FixVersion = next
+		require.NoError(t, repo.Create(ctx, fixed))
+		require.NoError(t, repo.Create(ctx, newBug("Unversioned")))
+
+		stored, err := repo.FindByID(ctx, fixed.ID)
+		require.NoError(t, err)
+		assert.Equal(t, release, stored.AffectedVersion)
+		assert.Equal(t, next, stored.FixVersion)
+
+		titles := func(filter models.BugFilter) []string {
+			var titles []string
+			require.NoError(t, repo.Stream(ctx, filter, func(bug *models.Bug) error {
+				titles = append(titles, bug.Title)
+				return nil
+			}))
+			return titles
+		}
+		assert.Equal(t, []string{"Found in release", "Fixed in next"}, titles(models.BugFilter{AffectedVersion: &release}))
+		assert.Equal(t, []string{"Fixed in next"}, titles(models.BugFilter{FixVersion: &next}))
+
+		require.NoError(t, repo.ClearMilestone(ctx, release))
+		assert.Empty(t, titles(models.BugFilter{AffectedVersion: &release}))
+		stored, err = repo.FindByID(ctx, fixed.ID)
+		require.NoError(t, err)
+		assert.True(t, stored.AffectedVersion.IsZero())
+		assert.Equal(t, next, stored.FixVersion)
+
+		stored.FixVersion = models.NilID
+		require.NoError(t, repo.Update(ctx, stored))
+		assert.Empty(t, titles(models.BugFilter{FixVersion: &next}))
+	})
+
+	t.Run("Stream filters and sorts by custom fields", func(t *testing.T) {
+		repo := newRepo(t)
+		base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+		values := []models.FieldValues{
+			{"severity": float64(10), "environment": "prod", "browsers": []string{"firefox"}},
+			{"severity": float64(2), "environment": "staging", "browsers": []string{"firefox", "safari"}},
+			nil,
+			{"severity": float64(2), "environment": "prod"},
+		}
+		for i, fields := range values {
+			bug := newBug("Bug " + string(rune('a'+i)))
+			bug.ExternalID = "test:" + string(rune('a'+i))
+			bug.CreatedAt = base.AddDate(0, 0, i)
+			bug.Fields = fields
+			_, err := repo.CreateIfNotExists(ctx, bug)
+			require.NoError(t, err)
+		}
+
+		titles := func(filter models.BugFilter) []string {
+			var titles []string
+			err := repo.Stream(ctx, filter, func(bug *models.Bug) error {
+				titles = append(titles, bug.Title)
+				return nil
+			})
+			require.NoError(t, err)
+			return titles
+		}
+
+		assert.Equal(t, []string{"Bug a", "Bug d"}, titles(models.BugFilter{
+			Fields: []models.FieldFilter{{Key: "environment", Value: "prod"}},
+		}))
+		assert.Equal(t, []string{"Bug b", "Bug d"}, titles(models.BugFilter{
+			Fields: []models.FieldFilter{{Key: "severity", Value: float64(2)}},
+		}))
+		assert.Equal(t, []string{"Bug a", "Bug b"}, titles(models.BugFilter{
+			Fields: []models.FieldFilter{{Key: "browsers", Value: "firefox"}},
+		}))
+		assert.Equal(t, []string{"Bug b"}, titles(models.BugFilter{
+			Fields: []models.FieldFilter{{Key: "browsers", Value: "firefox"}, {Key: "browsers", Value: "safari"}},
+		}))
+
+		// Numbers sort numerically, ties oldest first, and bugs without a
+		// value last in either direction
+		assert.Equal(t, []string{"Bug b", "Bug d", "Bug a", "Bug c"}, titles(models.BugFilter{
+			Sort: models.BugSort{Key: "fields.severity"},
+		}))
+		assert.Equal(t, []string{"Bug a", "Bug b", "Bug d", "Bug c"}, titles(models.BugFilter{
+			Sort: models.BugSort{Key: "fields.severity", Desc: true},
+		}))
+		assert.Equal(t, []string{"Bug b", "Bug a", "Bug d", "Bug c"}, titles(models.BugFilter{
+			Sort: models.BugSort{Key: "fields.environment", Desc: true},
+		}))
+		assert.Equal(t, []string{"Bug d", "Bug c", "Bug b", "Bug a"}, titles(models.BugFilter{
+			Sort: models.BugSort{Key: "created_at", Desc: true},
+		}))
+		assert.Equal(t, []string{"Bug d", "Bug c", "Bug b", "Bug a"}, titles(models.BugFilter{
+			Sort: models.BugSort{Key: "title", Desc: true},
+		}))
+	})
+
+	t.Run("CreateIfNotExists is idempotent", func(t *testing.T) {
+		repo := newRepo(t)
+		createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		resolvedAt := createdAt.Add(time.Hour)
+
+		bug := newBug("Imported")
+		bug.Status = "resolved"
+		bug.ExternalID = "test:1"
+		bug.CreatedAt = createdAt
+		bug.ResolvedAt = &resolvedAt
+
+		created, err := repo.CreateIfNotExists(ctx, bug)
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.False(t, bug.ID.IsZero())
+
+		found, err := repo.FindByExternalID(ctx, "test:1")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, bug.ID, found.ID)
+		assert.Equal(t, "resolved", found.Status)
+		assert.True(t, createdAt.Equal(found.CreatedAt))
+		require.NotNil(t, found.ResolvedAt)
+		assert.True(t, resolvedAt.Equal(*found.ResolvedAt))
+
+		again := newBug("Imported again")
+		again.ExternalID = "test:1"
+		created, err = repo.CreateIfNotExists(ctx, again)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, bug.ID, again.ID)
+
+		all, err := repo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+		assert.Equal(t, "Imported", all[0].Title)
+
+		missing, err := repo.FindByExternalID(ctx, "test:2")
+		assert.NoError(t, err)
+		assert.Nil(t, missing)
+	})
+
+	t.Run("Search matches every word", func(t *testing.T) {
+		repo := newRepo(t)
+		developerID := models.NewID()
+
+		login := newBug("Login page crashes")
+		login.Description = "Clicking submit on the login form crashes Safari"
+		require.NoError(t, repo.Create(ctx, login))
+		require.NoError(t, repo.AssignToDeveloper(ctx, login.ID, developerID))
+
+		export := newBug("Export is slow")
+		export.Description = "CSV export takes minutes for large projects"
+		require.NoError(t, repo.Create(ctx, export))
+
+		titles := func(search models.BugSearch) []string {
+			t.Helper()
+			bugs, err := repo.Search(ctx, search)
+			require.NoError(t, err)
+			require.NotNil(t, bugs)
+			titles := []string{}
+			for _, bug := range bugs {
+				titles = append(titles, bug.Title)
+			}
+			return titles
+		}
+
+		assert.Equal(t, []string{"Login page crashes"}, titles(models.BugSearch{Query: "login"}))
+		assert.Equal(t, []string{"Login page crashes"}, titles(models.BugSearch{Query: "SAFARI crashes!"}))
+		assert.Equal(t, []string{"Export is slow"}, titles(models.BugSearch{Query: "csv"}))
+		assert.Empty(t, titles(models.BugSearch{Query: "login csv"}))
+		assert.Empty(t, titles(models.BugSearch{Query: "log"}))
+		assert.Empty(t, titles(models.BugSearch{Query: `" * -`}))
+		assert.Empty(t, titles(models.BugSearch{Query: "csv", InvolvedUser: &developerID}))
+		assert.Equal(t, []string{"Login page crashes"}, titles(models.BugSearch{Query: "crashes", InvolvedUser: &developerID}))
+		assert.Empty(t, titles(models.BugSearch{Query: "slow OR login"}))
+
+		// Edits are searchable straight away
+		export.Title = "Export times out"
+		require.NoError(t, repo.Update(ctx, export))
+		assert.Empty(t, titles(models.BugSearch{Query: "slow"}))
+		assert.Equal(t, []string{"Export times out"}, titles(models.BugSearch{Query: "times"}))
+
+		require.NoError(t, repo.Delete(ctx, login.ID))
+		assert.Empty(t, titles(models.BugSearch{Query: "login"}))
+	})
+}
+
+// RunUserRepositoryTests runs the user repository suite. newRepo must return
+// an empty repository each time it is called.
+func RunUserRepositoryTests(t *testing.T, newRepo func(t *testing.T) repository.UserRepositoryInterface) {
+	ctx := context.Background()
+
+	newUser := func(email, role string) *models.User {
+		return &models.User{
+			Name:     "User " + email,
+			Email:    email,
+			Password: "hashedpassword",
+			Role:     role,
+		}
+	}
+
+	t.Run("Create and find", func(t *testing.T) {
+		repo := newRepo(t)
+
+		user := newUser("dev@example.com", "developer")
+		require.NoError(t, repo.Create(ctx, user))
+		assert.False(t, user.ID.IsZero())
+		assert.False(t, user.CreatedAt.IsZero())
+
+		byID, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		require.NotNil(t, byID)
+		assert.Equal(t, user.Email, byID.Email)
+		assert.Equal(t, user.Password, byID.Password)
+		assert.Equal(t, user.Role, byID.Role)
+
+		byEmail, err := repo.FindByEmail(ctx, user.Email)
+		require.NoError(t, err)
+		require.NotNil(t, byEmail)
+		assert.Equal(t, user.ID, byEmail.ID)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repo := newRepo(t)
+
+		byID, err := repo.FindByID(ctx, models.NewID())
+		assert.NoError(t, err)
+		assert.Nil(t, byID)
+
+		byEmail, err := repo.FindByEmail(ctx, "nobody@example.com")
+		assert.NoError(t, err)
+		assert.Nil(t, byEmail)
+	})
+
+	t.Run("duplicate email", func(t *testing.T) {
+		repo := newRepo(t)
+
+		require.NoError(t, repo.Create(ctx, newUser("same@example.com", "developer")))
+		err := repo.Create(ctx, newUser("same@example.com", "manager"))
+		assert.ErrorIs(t, err, repository.ErrDuplicateEmail)
+
+		other := newUser("other@example.com", "developer")
+		require.NoError(t, repo.Create(ctx, other))
+		other.Email = "same@example.com"
+		assert.ErrorIs(t, repo.Update(ctx, other), repository.ErrDuplicateEmail)
+	})
+
+	t.Run("Update and Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newUser("dev@example.com", "developer")
+		require.NoError(t, repo.Create(ctx, user))
+
+		user.Name = "Renamed"
+		require.NoError(t, repo.Update(ctx, user))
+		found, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Renamed", found.Name)
+
+		require.NoError(t, repo.Delete(ctx, user.ID))
+		found, err = repo.FindByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("login failures and lockout", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newUser("dev@example.com", "developer")
+		require.NoError(t, repo.Create(ctx, user))
+
+		for want := 1; want <= 2; want++ {
+			failures, err := repo.RecordLoginFailure(ctx, user.ID)
+			require.NoError(t, err)
+			assert.Equal(t, want, failures)
+		}
+
+		until := time.Now().Add(time.Hour)
+		require.NoError(t, repo.SetLockout(ctx, user.ID, 2, &until))
+		found, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, found.FailedLogins)
+		require.NotNil(t, found.LockedUntil)
+		assert.WithinDuration(t, until, *found.LockedUntil, precision)
+		assert.True(t, found.Locked(time.Now()))
+
+		// Updating the profile keeps the lockout
+		found.Name = "Renamed"
+		require.NoError(t, repo.Update(ctx, found))
+		found, err = repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.True(t, found.Locked(time.Now()))
+
+		require.NoError(t, repo.SetLockout(ctx, user.ID, 0, nil))
+		found, err = repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Zero(t, found.FailedLogins)
+		assert.Nil(t, found.LockedUntil)
+
+		failures, err := repo.RecordLoginFailure(ctx, models.NewID())
+		assert.NoError(t, err)
+		assert.Zero(t, failures)
+		assert.NoError(t, repo.SetLockout(ctx, models.NewID(), 1, nil))
+	})
+
+	t.Run("two-factor settings", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newUser("admin@example.com", "admin")
+		require.NoError(t, repo.Create(ctx, user))
+
+		pending := &models.TwoFactor{Secret: "JBSWY3DPEHPK3PXP"}
+		ok, err := repo.SetTwoFactor(ctx, user.ID, nil, pending)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		found, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, pending, found.TwoFactor)
+		assert.False(t, found.TwoFactorEnabled())
+
+		enabled := &models.TwoFactor{Secret: pending.Secret, Enabled: true, LastStep: 42, RecoveryCodes: []string{"a", "b"}}
+		ok, err = repo.SetTwoFactor(ctx, user.ID, found.TwoFactor, enabled)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		// A stale prev loses, so a code can't be spent twice
+		ok, err = repo.SetTwoFactor(ctx, user.ID, pending, &models.TwoFactor{Secret: "other"})
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		// Updating the profile keeps the settings
+		found, err = repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		found.Name = "Renamed"
+		require.NoError(t, repo.Update(ctx, found))
+		found, err = repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, enabled, found.TwoFactor)
+		assert.True(t, found.TwoFactorEnabled())
+
+		used := &models.TwoFactor{Secret: pending.Secret, Enabled: true, LastStep: 42, RecoveryCodes: []string{"b"}}
+		ok, err = repo.SetTwoFactor(ctx, user.ID, found.TwoFactor, used)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		found, err = repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		ok, err = repo.SetTwoFactor(ctx, user.ID, found.TwoFactor, nil)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		found, err = repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found.TwoFactor)
+
+		ok, err = repo.SetTwoFactor(ctx, models.NewID(), nil, pending)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("OIDC identity", func(t *testing.T) {
+		repo := newRepo(t)
+		issuer := "https://id.example.com"
+
+		provisioned := newUser("sso@example.com", "developer")
+		provisioned.OIDC = &models.OIDCIdentity{Issuer: issuer, Subject: "sub-1"}
+		require.NoError(t, repo.Create(ctx, provisioned))
+
+		local := newUser("local@example.com", "manager")
+		require.NoError(t, repo.Create(ctx, local))
+		found, err := repo.FindByID(ctx, local.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found.OIDC)
+
+		found, err = repo.FindByOIDC(ctx, issuer, "sub-1")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, provisioned.ID, found.ID)
+		assert.Equal(t, provisioned.OIDC, found.OIDC)
+
+		// The same subject at another issuer is someone else
+		found, err = repo.FindByOIDC(ctx, "https://other.example.com", "sub-1")
+		assert.NoError(t, err)
+		assert.Nil(t, found)
+
+		// Linking an existing account
+		local.OIDC = &models.OIDCIdentity{Issuer: issuer, Subject: "sub-2"}
+		require.NoError(t, repo.Update(ctx, local))
+		found, err = repo.FindByOIDC(ctx, issuer, "sub-2")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, local.ID, found.ID)
+	})
+
+	t.Run("Deactivation", func(t *testing.T) {
+		repo := newRepo(t)
+		user := newUser("leaver@example.com", "developer")
+		require.NoError(t, repo.Create(ctx, user))
+
+		deactivatedAt := time.Now().Truncate(time.Second)
+		user.DeactivatedAt = &deactivatedAt
+		require.NoError(t, repo.Update(ctx, user))
+		found, err := repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found.DeactivatedAt)
+		assert.True(t, deactivatedAt.Equal(*found.DeactivatedAt))
+
+		user.DeactivatedAt = nil
+		require.NoError(t, repo.Update(ctx, user))
+		found, err = repo.FindByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Nil(t, found.DeactivatedAt)
+	})
+
+	t.Run("FindByRole and FindAll", func(t *testing.T) {
+		repo := newRepo(t)
+
+		emails := []string{"a@example.com", "b@example.com", "c@example.com"}
+		for i, email := range emails {
+			role := "developer"
+			if i == 1 {
+				role = "manager"
+			}
+			require.NoError(t, repo.Create(ctx, newUser(email, role)))
+			// Keep creation times distinct at the backends' precision
+			time.Sleep(2 * precision)
+		}
+
+		developers, err := repo.FindByRole(ctx, "developer")
+		require.NoError(t, err)
+		assert.Len(t, developers, 2)
+
+		admins, err := repo.FindByRole(ctx, "admin")
+		require.NoError(t, err)
+		assert.Empty(t, admins)
+
+		all, err := repo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, all, 3)
+		for i, user := range all {
+			assert.Equal(t, emails[i], user.Email)
+		}
+	})
+}
+
+// RunAPITokenRepositoryTests runs the API token repository suite. newRepo
+// must return an empty repository each time it is called.
+func RunAPITokenRepositoryTests(t *testing.T, newRepo func(t *testing.T) repository.APITokenRepositoryInterface) {
+	ctx := context.Background()
+
+	newToken := func(userID models.ID, hash string) *models.APIToken {
+		return &models.APIToken{
+			UserID: userID,
+			Name:   "CI " + hash,
+			Prefix: "bt_" + hash,
+			Hash:   hash,
+			Scopes: []string{models.ScopeBugsRead, models.ScopeBugsWrite},
+		}
+	}
+
+	t.Run("Create and find", func(t *testing.T) {
+		repo := newRepo(t)
+		userID := models.NewID()
+
+		token := newToken(userID, "hash1")
+		expiresAt := time.Now().Add(time.Hour)
+		token.ExpiresAt = &expiresAt
+		require.NoError(t, repo.Create(ctx, token))
+		assert.False(t, token.ID.IsZero())
+		assert.False(t, token.CreatedAt.IsZero())
+
+		for _, find := range []func() (*models.APIToken, error){
+			func() (*models.APIToken, error) { return repo.FindByID(ctx, token.ID) },
+			func() (*models.APIToken, error) { return repo.FindByHash(ctx, "hash1") },
+		} {
+			found, err := find()
+			require.NoError(t, err)
+			require.NotNil(t, found)
+			assert.Equal(t, token.ID, found.ID)
+			assert.Equal(t, userID, found.UserID)
+			assert.Equal(t, token.Name, found.Name)
+			assert.Equal(t, token.Prefix, found.Prefix)
+			assert.Equal(t, token.Scopes, found.Scopes)
+			require.NotNil(t, found.ExpiresAt)
+			assert.WithinDuration(t, expiresAt, *found.ExpiresAt, precision)
+			assert.Nil(t, found.LastUsedAt)
+			assert.WithinDuration(t, token.CreatedAt, found.CreatedAt, precision)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		repo := newRepo(t)
+
+		found, err := repo.FindByID(ctx, models.NewID())
+		assert.NoError(t, err)
+		assert.Nil(t, found)
+
+		found, err = repo.FindByHash(ctx, "missing")
+		assert.NoError(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("FindByUser, SetLastUsed and Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		userID := models.NewID()
+
+		var tokens []*models.APIToken
+		for _, hash := range []string{"a", "b", "c"} {
+			token := newToken(userID, hash)
+			require.NoError(t, repo.Create(ctx, token))
+			tokens = append(tokens, token)
+			// Keep creation times distinct at the backends' precision
+			time.Sleep(2 * precision)
+		}
+		require.NoError(t, repo.Create(ctx, newToken(models.NewID(), "other")))
+
+		mine, err := repo.FindByUser(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, mine, 3)
+		for i, token := range mine {
+			assert.Equal(t, tokens[i].ID, token.ID)
+		}
+
+		usedAt := time.Now()
+		require.NoError(t, repo.SetLastUsed(ctx, tokens[0].ID, usedAt))
+		found, err := repo.FindByID(ctx, tokens[0].ID)
+		require.NoError(t, err)
+		require.NotNil(t, found.LastUsedAt)
+		assert.WithinDuration(t, usedAt, *found.LastUsedAt, precision)
+
+		require.NoError(t, repo.Delete(ctx, tokens[1].ID))
+		found, err = repo.FindByHash(ctx, "b")
+		require.NoError(t, err)
+		assert.Nil(t, found)
+
+		mine, err = repo.FindByUser(ctx, userID)
+		require.NoError(t, err)
+		assert.Len(t, mine, 2)
+
+		none, err := repo.FindByUser(ctx, models.NewID())
+		require.NoError(t, err)
+		assert.Empty(t, none)
+	})
+}
+
+// RunSigningKeyRepositoryTests runs the signing key repository suite.
+// newRepo must return an empty repository each time it is called.
+func RunSigningKeyRepositoryTests(t *testing.T, newRepo func(t *testing.T) repository.SigningKeyRepositoryInterface) {
+	ctx := context.Background()
+
+	t.Run("Create, FindAll and Delete", func(t *testing.T) {
+		repo := newRepo(t)
+
+		keys, err := repo.FindAll(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+
+		now := time.Now()
+		// Created out of order: FindAll sorts by activation
+		var created []*models.SigningKey
+		for _, activeAt := range []time.Time{now.Add(time.Hour), now.Add(-time.Hour), now} {
+			key := &models.SigningKey{Algorithm: "EdDSA", PrivateKey: []byte{0x30, 0x2e, byte(len(created))}, ActiveAt: activeAt}
+			require.NoError(t, repo.Create(ctx, key))
+			assert.False(t, key.ID.IsZero())
+			assert.False(t, key.CreatedAt.IsZero())
+			created = append(created, key)
+		}
+
+		keys, err = repo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, keys, 3)
+		for i, want := range []*models.SigningKey{created[1], created[2], created[0]} {
+			assert.Equal(t, want.ID, keys[i].ID)
+			assert.Equal(t, "EdDSA", keys[i].Algorithm)
+			assert.Equal(t, want.PrivateKey, keys[i].PrivateKey)
+			assert.WithinDuration(t, want.ActiveAt, keys[i].ActiveAt, precision)
+			assert.WithinDuration(t, want.CreatedAt, keys[i].CreatedAt, precision)
+		}
+
+		require.NoError(t, repo.Delete(ctx, created[1].ID))
+		// Deleting a missing key is not an error
+		require.NoError(t, repo.Delete(ctx, models.NewID()))
+		keys, err = repo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.Equal(t, created[2].ID, keys[0].ID)
+	})
+}
+
+// RunRoleRepositoryTests runs the role repository suite. newRepo must
+// return an empty repository each time it is called.
+func RunRoleRepositoryTests(t *testing.T, newRepo func(t *testing.T) repository.RoleRepositoryInterface) {
+	ctx := context.Background()
+
+	t.Run("Save, FindAll and Delete", func(t *testing.T) {
+		repo := newRepo(t)
+
+		roles, err := repo.FindAll(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, roles)
+
+		for _, name := range []string{"triager", "auditor"} {
+			role := &models.Role{Name: name, Permissions: []string{models.PermBugView}}
+			require.NoError(t, repo.Save(ctx, role))
+			assert.False(t, role.UpdatedAt.IsZero())
+		}
+		empty := &models.Role{Name: "guest"}
+		require.NoError(t, repo.Save(ctx, empty))
+
+		// Saving again replaces the permissions
+		triager := &models.Role{Name: "triager", Permissions: []string{models.PermBugView, models.PermBugAssign}}
+		require.NoError(t, repo.Save(ctx, triager))
+
+		roles, err = repo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, roles, 3)
+		assert.Equal(t, "auditor", roles[0].Name)
+		assert.Equal(t, []string{models.PermBugView}, roles[0].Permissions)
+		assert.Equal(t, "guest", roles[1].Name)
+		assert.Empty(t, roles[1].Permissions)
+		assert.Equal(t, "triager", roles[2].Name)
+		assert.Equal(t, triager.Permissions, roles[2].Permissions)
+		assert.WithinDuration(t, triager.UpdatedAt, roles[2].UpdatedAt, precision)
+
+		require.NoError(t, repo.Delete(ctx, "auditor"))
+		// Deleting a missing role is not an error
+		require.NoError(t, repo.Delete(ctx, "missing"))
+		roles, err = repo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, roles, 2)
+		assert.Equal(t, "guest", roles[0].Name)
+	})
+}
+
+// RunCustomFieldRepositoryTests runs the custom field repository suite.
+// newRepo must return an empty repository each time it is called.
+func RunCustomFieldRepositoryTests(t *testing.T, newRepo func(t *testing.T) repository.CustomFieldRepositoryInterface) {
+	ctx := context.Background()
+
+	t.Run("Save, FindAll and Delete", func(t *testing.T) {
+		repo := newRepo(t)
+
+		fields, err := repo.FindAll(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, fields)
+
+		environment := &models.CustomField{Key: "environment", Name: "Environment", Type: models.FieldEnum, Options: []string{"prod", "staging"}, Required: true}
+		require.NoError(t, repo.Save(ctx, environment))
+		assert.False(t, environment.UpdatedAt.IsZero())
+		require.NoError(t, repo.Save(ctx, &models.CustomField{Key: "customer", Name: "Customer", Type: models.FieldText}))
+
+		// Saving again replaces the definition
+		environment = &models.CustomField{Key: "environment", Name: "Env", Type: models.FieldEnum, Options: []string{"prod", "staging", "dev"}}
+		require.NoError(t, repo.Save(ctx, environment))
+
+		fields, err = repo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, fields, 2)
+		assert.Equal(t, "customer", fields[0].Key)
+		assert.Equal(t, models.FieldText, fields[0].Type)
+		assert.Empty(t, fields[0].Options)
+		assert.Equal(t, "environment", fields[1].Key)
+		assert.Equal(t, "Env", fields[1].Name)
+		assert.Equal(t, environment.Options, fields[1].Options)
+		assert.False(t, fields[1].Required)
+		assert.WithinDuration(t, environment.UpdatedAt, fields[1].UpdatedAt, precision)
+
+		require.NoError(t, repo.Delete(ctx, "customer"))
+		// Deleting a missing field is not an error
+		require.NoError(t, repo.Delete(ctx, "missing"))
+		fields, err = repo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, fields, 1)
+		assert.Equal(t, "environment", fields[0].Key)
+	})
+}
+
+// RunBugTemplateRepositoryTests runs the bug template repository suite.
+// newRepo must return an empty repository each time it is called.
+func RunBugTemplateRepositoryTests(t *testing.T, newRepo func(t *testing.T) repository.BugTemplateRepositoryInterface) {
+	ctx := context.Background()
+
+	t.Run("Create, FindByID, FindAll, Update and Delete", func(t *testing.T) {
+		repo := newRepo(t)
+
+		templates, err := repo.FindAll(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, templates)
+
+		crash := &models.BugTemplate{
+			Name:             "Crash",
+			TitlePrefix:      "[Crash]",
+			Description:      "## Steps to reproduce\n\n## Stack trace\n",
+			RequiredSections: []string{"Steps to reproduce"},
+			Priority:         "high",
+			Labels:           []string{"crash"},
+		}
+		require.NoError(t, repo.Create(ctx, crash))
+		assert.False(t, crash.ID.IsZero())
+		assert.False(t, crash.CreatedAt.IsZero())
+		require.NoError(t, repo.Create(ctx, &models.BugTemplate{Name: "Bug report", Description: "## Details\n"}))
+
+		found, err := repo.FindByID(ctx, crash.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, crash.Name, found.Name)
+		assert.Equal(t, crash.TitlePrefix, found.TitlePrefix)
+		assert.Equal(t, crash.Description, found.Description)
+		assert.Equal(t, crash.RequiredSections, found.RequiredSections)
+		assert.Equal(t, crash.Priority, found.Priority)
+		assert.Equal(t, crash.Labels, found.Labels)
+		assert.WithinDuration(t, crash.CreatedAt, found.CreatedAt, precision)
+
+		missing, err := repo.FindByID(ctx, models.NewID())
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+
+		found.Name = "Crash report"
+		found.Labels = nil
+		require.NoError(t, repo.Update(ctx, found))
+
+		templates, err = repo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, templates, 2)
+		assert.Equal(t, "Bug report", templates[0].Name)
+		assert.Empty(t, templates[0].Labels)
+		assert.Equal(t, "Crash report", templates[1].Name)
+		assert.Empty(t, templates[1].Labels)
+		assert.Equal(t, []string{"Steps to reproduce"}, templates[1].RequiredSections)
+
+		require.NoError(t, repo.Delete(ctx, crash.ID))
+		templates, err = repo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, templates, 1)
+		assert.Equal(t, "Bug report", templates[0].Name)
+	})
+}
+
+// RunMilestoneRepositoryTests runs the milestone repository suite. newRepo
+// must return an empty repository each time it is called.
+func RunMilestoneRepositoryTests(t *testing.T, newRepo func(t *testing.T) repository.MilestoneRepositoryInterface) {
+	ctx := context.Background()
+
+	t.Run("Create, FindByID, FindAll, Update and Delete", func(t *testing.T) {
+		repo := newRepo(t)
+
+		milestones, err := repo.FindAll(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, milestones)
+
+		v2 := &models.Milestone{Name: "2.0", Description: "The big one", DueDate: "2025-03-01", State: models.MilestoneOpen}
+		require.NoError(t, repo.Create(ctx, v2))
+		assert.False(t, v2.ID.IsZero())
+		assert.False(t, v2.CreatedAt.IsZero())
+		require.NoError(t, repo.Create(ctx, &models.Milestone{Name: "Backlog", State: models.MilestoneOpen}))
+		require.NoError(t, repo.Create(ctx, &models.Milestone{Name: "1.0", DueDate: "2024-12-01", State: models.MilestoneOpen}))
+
+		found, err := repo.FindByID(ctx, v2.ID)
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, v2.Name, found.Name)
+		assert.Equal(t, v2.Description, found.Description)
+		assert.Equal(t, v2.DueDate, found.DueDate)
+		assert.Equal(t, models.MilestoneOpen, found.State)
+		assert.Nil(t, found.ClosedAt)
+		assert.WithinDuration(t, v2.CreatedAt, found.CreatedAt, precision)
+
+		missing, err := repo.FindByID(ctx, models.NewID())
+		require.NoError(t, err)
+		assert.Nil(t, missing)
+
+		closedAt := time.Now()
+		found.State = models.MilestoneClosed
+		found.ClosedAt = &closedAt
+		require.NoError(t, repo.Update(ctx, found))
+
+		// Ordered by due date, those without one last
+		milestones, err = repo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, milestones, 3)
+		assert.Equal(t, "1.0", milestones[0].Name)
+		assert.Equal(t, "2.0", milestones[1].Name)
+		assert.Equal(t, models.MilestoneClosed, milestones[1].State)
+		require.NotNil(t, milestones[1].ClosedAt)
+		assert.WithinDuration(t, closedAt, *milestones[1].ClosedAt, precision)
+		assert.Equal(t, "Backlog", milestones[2].Name)
+
+		require.NoError(t, repo.Delete(ctx, v2.ID))
+		milestones, err = repo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, milestones, 2)
+	})
+}
+
+// RunReportRepositoryTests runs the report repository suite. newRepos must
+// return an empty bug repository and the report repository reading it.
+func RunReportRepositoryTests(t *testing.T, newRepos func(t *testing.T) (repository.BugRepositoryInterface, repository.ReportRepositoryInterface)) {
+	ctx := context.Background()
+	bugs, reports := newRepos(t)
+
+	// Times in another zone are stored as UTC, so periods and ranges are the
+	// same as for UTC input
+	now := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC).In(time.FixedZone("UTC+9", 9*60*60))
+	developerID := models.NewID()
+	add := func(status, priority string, created time.Time, resolvedAfter time.Duration, assigned bool) {
+		bug := &models.Bug{
+			Title:      "Bug",
+			Status:     status,
+			Priority:   priority,
+			ReportedBy: models.NewID(),
+			CreatedAt:  created,
+			ExternalID: models.NewID().Hex(),
+		}
+		if resolvedAfter > 0 {
+			resolvedAt := created.Add(resolvedAfter)
+			bug.ResolvedAt = &resolvedAt
+		}
+		if assigned {
+			bug.AssignedTo = developerID
+		}
+		_, err := bugs.CreateIfNotExists(ctx, bug)
+		require.NoError(t, err)
+	}
+
+	add("open", "high", now.Add(-2*time.Hour), 0, true)
+	add("open", "low", now.AddDate(0, 0, -10), 0, false)
+	add("resolved", "high", now.AddDate(0, 0, -3), 2*time.Hour, true)
+	add("resolved", "high", now.AddDate(0, 0, -2), 4*time.Hour, true)
+
+	t.Run("counts", func(t *testing.T) {
+		byStatus, err := reports.CountByStatus(ctx, models.ReportFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, []models.CountBucket{{Key: "open", Count: 2}, {Key: "resolved", Count: 2}}, byStatus)
+
+		byPriority, err := reports.CountByPriority(ctx, models.ReportFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, []models.CountBucket{{Key: "high", Count: 3}, {Key: "low", Count: 1}}, byPriority)
+
+		byAssignee, err := reports.CountByAssignee(ctx, models.ReportFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, []models.AssigneeBucket{
+			{AssigneeID: developerID, Count: 3},
+			{AssigneeID: models.NilID, Count: 1},
+		}, byAssignee)
+	})
+
+	t.Run("date range", func(t *testing.T) {
+		to := now.AddDate(0, 0, -30)
+		byStatus, err := reports.CountByStatus(ctx, models.ReportFilter{To: &to})
+		require.NoError(t, err)
+		assert.Empty(t, byStatus)
+
+		from := now.AddDate(0, 0, -5)
+		byStatus, err = reports.CountByStatus(ctx, models.ReportFilter{From: &from})
+		require.NoError(t, err)
+		assert.Equal(t, []models.CountBucket{{Key: "open", Count: 1}, {Key: "resolved", Count: 2}}, byStatus)
+	})
+
+	t.Run("Trend", func(t *testing.T) {
+		trend, err := reports.Trend(ctx, models.ReportFilter{}, "month")
+		require.NoError(t, err)
+		assert.Equal(t, []models.TrendPoint{{Period: "2024-03", Opened: 4, Closed: 2}}, trend)
+
+		trend, err = reports.Trend(ctx, models.ReportFilter{}, "week")
+		require.NoError(t, err)
+		assert.Equal(t, []models.TrendPoint{
+			{Period: "2024-W10", Opened: 1, Closed: 0},
+			{Period: "2024-W11", Opened: 1, Closed: 1},
+			{Period: "2024-W12", Opened: 2, Closed: 1},
+		}, trend)
+	})
+
+	t.Run("ResolutionTime", func(t *testing.T) {
+		resolution, err := reports.ResolutionTime(ctx, models.ReportFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, &models.ResolutionReport{Resolved: 2, MeanHours: 3, MinHours: 2, MaxHours: 4}, resolution)
+	})
+
+	t.Run("Ageing", func(t *testing.T) {
+		ageing, err := reports.Ageing(ctx, models.ReportFilter{}, now)
+		require.NoError(t, err)
+		assert.Equal(t, []models.AgeingBucket{
+			{Label: "<1d", Count: 1},
+			{Label: "1-7d", Count: 0},
+			{Label: "7-30d", Count: 1},
+			{Label: "30-90d", Count: 0},
+			{Label: "90d+", Count: 0},
+		}, ageing)
+	})
+
+	t.Run("DeveloperThroughput", func(t *testing.T) {
+		throughput, err := reports.DeveloperThroughput(ctx, models.ReportFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, []models.DeveloperThroughput{
+			{DeveloperID: developerID, Assigned: 3, Resolved: 2, MeanHours: 3},
+		}, throughput)
+
+		// The open bug and the one resolved two days ago were created in
+		// range, and only the latter was resolved in it
+		from := now.AddDate(0, 0, -2).Add(-time.Hour)
+		throughput, err = reports.DeveloperThroughput(ctx, models.ReportFilter{From: &from})
+		require.NoError(t, err)
+		assert.Equal(t, []models.DeveloperThroughput{
+			{DeveloperID: developerID, Assigned: 2, Resolved: 1, MeanHours: 4},
+		}, throughput)
+
+		to := now.AddDate(0, 0, -30)
+		throughput, err = reports.DeveloperThroughput(ctx, models.ReportFilter{To: &to})
+		require.NoError(t, err)
+		assert.Empty(t, throughput)
+	})
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"bug-tracker/models"
+)
+
+type RoleRepositoryInterface interface {
+	// FindAll returns every role ordered by name
+	FindAll(ctx context.Context) ([]*models.Role, error)
+	// Save creates or replaces the role with role.Name, setting UpdatedAt
+	Save(ctx context.Context, role *models.Role) error
+	Delete(ctx context.Context, name string) error
+}
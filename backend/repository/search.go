@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SearchTerms splits a search query into lower-case words, the unit every
+// backend matches on. Punctuation separates words and is otherwise ignored,
+// so terms are safe to embed in a backend's query syntax.
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
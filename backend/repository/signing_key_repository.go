@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"bug-tracker/models"
+)
+
+type SigningKeyRepositoryInterface interface {
+	// Create stores key, setting its ID and creation time
+	Create(ctx context.Context, key *models.SigningKey) error
+	// FindAll returns every key in the order they start signing
+	FindAll(ctx context.Context) ([]*models.SigningKey, error)
+	Delete(ctx context.Context, id models.ID) error
+}
@@ -0,0 +1,325 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+	"bug-tracker/repository/sqlutil"
+)
+
+// Ensure BugRepository implements BugRepositoryInterface
+var _ repository.BugRepositoryInterface = (*BugRepository)(nil)
+
+const bugColumns = "id, title, description, status, priority, reported_by, assigned_to, created_at, updated_at, resolved_at, external_id, fields, labels, affected_version, fix_version"
+
+type BugRepository struct {
+	db *sql.DB
+}
+
+func NewBugRepository(db *sql.DB) *BugRepository {
+	return &BugRepository{db: db}
+}
+
+func (r *BugRepository) Create(ctx context.Context, bug *models.Bug) error {
+	bug.ID = models.NewID()
+	bug.CreatedAt = time.Now()
+	bug.UpdatedAt = time.Now()
+	bug.Status = "open"
+
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO bugs ("+bugColumns+") VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13, ?14, ?15)",
+		bugValues(bug)...)
+	return err
+}
+
+func (r *BugRepository) FindAll(ctx context.Context) ([]*models.Bug, error) {
+	return r.query(ctx, "SELECT "+bugColumns+" FROM bugs ORDER BY created_at, id")
+}
+
+func (r *BugRepository) FindByID(ctx context.Context, id models.ID) (*models.Bug, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+bugColumns+" FROM bugs WHERE id = ?1", id.Hex())
+	return scanOptionalBug(row)
+}
+
+func (r *BugRepository) FindByAssignee(ctx context.Context, developerID models.ID) ([]*models.Bug, error) {
+	return r.query(ctx, "SELECT "+bugColumns+" FROM bugs WHERE assigned_to = ?1 ORDER BY created_at, id", developerID.Hex())
+}
+
+func (r *BugRepository) UpdateStatus(ctx context.Context, id models.ID, status string) error {
+	now := time.Now().UTC()
+
+	// Track when a bug was resolved so reports can compute resolution times
+	var resolvedAt *time.Time
+	if status == "resolved" {
+		resolvedAt = &now
+	}
+
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE bugs SET status = ?2, updated_at = ?3, resolved_at = ?4 WHERE id = ?1",
+		id.Hex(), status, now, resolvedAt)
+	return err
+}
+
+func (r *BugRepository) AssignToDeveloper(ctx context.Context, bugID, developerID models.ID) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE bugs SET assigned_to = ?2, updated_at = ?3 WHERE id = ?1",
+		bugID.Hex(), sqlutil.NullableID(developerID), time.Now().UTC())
+	return err
+}
+
+func (r *BugRepository) Update(ctx context.Context, bug *models.Bug) error {
+	bug.UpdatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE bugs SET
+			title = ?2, description = ?3, status = ?4, priority = ?5,
+			reported_by = ?6, assigned_to = ?7, created_at = ?8, updated_at = ?9,
+			resolved_at = ?10, external_id = ?11, fields = ?12, labels = ?13,
+			affected_version = ?14, fix_version = ?15
+		WHERE id = ?1`,
+		bugValues(bug)...)
+	return err
+}
+
+func (r *BugRepository) Delete(ctx context.Context, id models.ID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM bugs WHERE id = ?1", id.Hex())
+	return err
+}
+
+// Stream calls fn for each bug matching filter in filter.Sort order, reading rows
+// one at a time so large result sets are never held in memory. Iteration
+// stops at the first error returned by fn.
+func (r *BugRepository) Stream(ctx context.Context, filter models.BugFilter, fn func(*models.Bug) error) error {
+	p := newParams()
+	var conds []string
+	if filter.Status != "" {
+		conds = append(conds, "status = "+p.Bind(filter.Status))
+	}
+	if filter.Priority != "" {
+		conds = append(conds, "priority = "+p.Bind(filter.Priority))
+	}
+	if filter.AssignedTo != nil {
+		conds = append(conds, "assigned_to = "+p.Bind(filter.AssignedTo.Hex()))
+	}
+	if filter.ReportedBy != nil {
+		conds = append(conds, "reported_by = "+p.Bind(filter.ReportedBy.Hex()))
+	}
+	if filter.InvolvedUser != nil {
+		conds = append(conds, involvedUserCond(p, *filter.InvolvedUser))
+	}
+	if filter.AffectedVersion != nil {
+		conds = append(conds, "affected_version = "+p.Bind(filter.AffectedVersion.Hex()))
+	}
+	if filter.FixVersion != nil {
+		conds = append(conds, "fix_version = "+p.Bind(filter.FixVersion.Hex()))
+	}
+	conds = append(conds, p.DateRange("created_at", models.ReportFilter{From: filter.From, To: filter.To})...)
+	for _, f := range filter.Fields {
+		// json_each yields a scalar value itself, and each item of a list
+		conds = append(conds, "EXISTS (SELECT 1 FROM json_each(bugs.fields, "+p.Bind(sqlutil.FieldPath(f.Key))+") WHERE value = "+p.Bind(f.Value)+")")
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+bugColumns+" FROM bugs"+sqlutil.Where(conds)+orderBy(p, filter.Sort), p.Args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		bug, err := scanBug(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(bug); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *BugRepository) FindByExternalID(ctx context.Context, externalID string) (*models.Bug, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+bugColumns+" FROM bugs WHERE external_id = ?1", externalID)
+	return scanOptionalBug(row)
+}
+
+// CreateIfNotExists inserts bug unless one with the same ExternalID already
+// exists, in which case bug.ID is set to the existing bug's ID. Unlike Create
+// it keeps the bug's own timestamps and status. It reports whether a new row
+// was inserted.
+func (r *BugRepository) CreateIfNotExists(ctx context.Context, bug *models.Bug) (bool, error) {
+	if bug.CreatedAt.IsZero() {
+		bug.CreatedAt = time.Now()
+	}
+	if bug.UpdatedAt.IsZero() {
+		bug.UpdatedAt = bug.CreatedAt
+	}
+
+	id := models.NewID()
+	values := bugValues(bug)
+	values[0] = id.Hex()
+
+	result, err := r.db.ExecContext(ctx,
+		"INSERT INTO bugs ("+bugColumns+") VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13, ?14, ?15) ON CONFLICT (external_id) DO NOTHING",
+		values...)
+	if err != nil {
+		return false, err
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if inserted > 0 {
+		bug.ID = id
+		return true, nil
+	}
+
+	existing, err := r.FindByExternalID(ctx, bug.ExternalID)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		bug.ID = existing.ID
+	}
+	return false, nil
+}
+
+// Search returns up to search.Limit bugs containing every search term, best
+// matches first, using the bugs_fts full-text index.
+func (r *BugRepository) Search(ctx context.Context, search models.BugSearch) ([]*models.Bug, error) {
+	terms := repository.SearchTerms(search.Query)
+	if len(terms) == 0 {
+		return []*models.Bug{}, nil
+	}
+
+	// Quoted terms are plain strings to FTS5, never operators, and a bug
+	// must contain all of them
+	for i, term := range terms {
+		terms[i] = `"` + term + `"`
+	}
+
+	p := newParams()
+	stmt := `
+		WITH matches AS (SELECT rowid, rank FROM bugs_fts WHERE bugs_fts MATCH ` + p.Bind(strings.Join(terms, " ")) + `)
+		SELECT ` + bugColumns + ` FROM bugs JOIN matches ON matches.rowid = bugs.seq`
+	if search.InvolvedUser != nil {
+		stmt += " WHERE " + involvedUserCond(p, *search.InvolvedUser)
+	}
+	stmt += " ORDER BY matches.rank, created_at DESC"
+	if search.Limit > 0 {
+		stmt += " LIMIT " + p.Bind(search.Limit)
+	}
+
+	bugs, err := r.query(ctx, stmt, p.Args...)
+	if bugs == nil && err == nil {
+		bugs = []*models.Bug{}
+	}
+	return bugs, err
+}
+
+// involvedUserCond matches bugs the user reported or is assigned
+func involvedUserCond(p *sqlutil.Params, userID models.ID) string {
+	id := p.Bind(userID.Hex())
+	return "(reported_by = " + id + " OR assigned_to = " + id + ")"
+}
+
+// RemoveField deletes the values of a custom field from every bug
+func (r *BugRepository) RemoveField(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE bugs SET fields = json_remove(fields, ?1) WHERE json_type(fields, ?1) IS NOT NULL",
+		sqlutil.FieldPath(key))
+	return err
+}
+
+// ClearMilestone unsets a milestone wherever bugs name it as their affected
+// or fix version
+func (r *BugRepository) ClearMilestone(ctx context.Context, id models.ID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE bugs SET
+			affected_version = CASE WHEN affected_version = ?1 THEN NULL ELSE affected_version END,
+			fix_version = CASE WHEN fix_version = ?1 THEN NULL ELSE fix_version END
+		WHERE affected_version = ?1 OR fix_version = ?1`,
+		id.Hex())
+	return err
+}
+
+// orderBy returns the ORDER BY clause for sort
+func orderBy(p *sqlutil.Params, sort models.BugSort) string {
+	dir := ""
+	if sort.Desc {
+		dir = " DESC"
+	}
+	if key, ok := strings.CutPrefix(sort.Key, models.FieldPrefix); ok {
+		value := "json_extract(fields, " + p.Bind(sqlutil.FieldPath(key)) + ")"
+		return " ORDER BY " + value + " IS NULL, " + value + dir + ", created_at, id"
+	}
+	switch sort.Key {
+	case "updated_at", "title":
+		return " ORDER BY " + sort.Key + dir + ", created_at, id"
+	}
+	return " ORDER BY created_at" + dir + ", id" + dir
+}
+
+func (r *BugRepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.Bug, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bugs []*models.Bug
+	for rows.Next() {
+		bug, err := scanBug(rows)
+		if err != nil {
+			return nil, err
+		}
+		bugs = append(bugs, bug)
+	}
+	return bugs, rows.Err()
+}
+
+// bugValues returns the column values of bug in bugColumns order
+func bugValues(bug *models.Bug) []interface{} {
+	return []interface{}{
+		bug.ID.Hex(), bug.Title, bug.Description, bug.Status, bug.Priority,
+		bug.ReportedBy.Hex(), sqlutil.NullableID(bug.AssignedTo), bug.CreatedAt.UTC(), bug.UpdatedAt.UTC(),
+		nullableTime(bug.ResolvedAt), sqlutil.NullableString(bug.ExternalID), sqlutil.FieldValues(bug.Fields), sqlutil.Strings(bug.Labels),
+		sqlutil.NullableID(bug.AffectedVersion), sqlutil.NullableID(bug.FixVersion),
+	}
+}
+
+func scanBug(row sqlutil.Scanner) (*models.Bug, error) {
+	var bug models.Bug
+	var resolvedAt sql.NullTime
+	var externalID sql.NullString
+
+	err := row.Scan(
+		sqlutil.ScanID(&bug.ID), &bug.Title, &bug.Description, &bug.Status, &bug.Priority,
+		sqlutil.ScanID(&bug.ReportedBy), sqlutil.ScanID(&bug.AssignedTo), &bug.CreatedAt, &bug.UpdatedAt,
+		&resolvedAt, &externalID, sqlutil.ScanFieldValues(&bug.Fields), sqlutil.ScanStrings(&bug.Labels),
+		sqlutil.ScanID(&bug.AffectedVersion), sqlutil.ScanID(&bug.FixVersion),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolvedAt.Valid {
+		bug.ResolvedAt = &resolvedAt.Time
+	}
+	bug.ExternalID = externalID.String
+	return &bug, nil
+}
+
+// scanOptionalBug returns nil when row is empty, like the Mongo repository
+func scanOptionalBug(row *sql.Row) (*models.Bug, error) {
+	bug, err := scanBug(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return bug, err
+}
@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+	"bug-tracker/repository/sqlutil"
+)
+
+// Ensure CustomFieldRepository implements CustomFieldRepositoryInterface
+var _ repository.CustomFieldRepositoryInterface = (*CustomFieldRepository)(nil)
+
+type CustomFieldRepository struct {
+	db *sql.DB
+}
+
+func NewCustomFieldRepository(db *sql.DB) *CustomFieldRepository {
+	return &CustomFieldRepository{db: db}
+}
+
+func (r *CustomFieldRepository) FindAll(ctx context.Context) ([]*models.CustomField, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT key, name, type, options, required, updated_at FROM custom_fields ORDER BY key")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fields []*models.CustomField
+	for rows.Next() {
+		var field models.CustomField
+		err := rows.Scan(&field.Key, &field.Name, &field.Type, sqlutil.ScanStrings(&field.Options), &field.Required, &field.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, &field)
+	}
+	return fields, rows.Err()
+}
+
+func (r *CustomFieldRepository) Save(ctx context.Context, field *models.CustomField) error {
+	now := time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO custom_fields (key, name, type, options, required, updated_at) VALUES (?1, ?2, ?3, ?4, ?5, ?6)
+		ON CONFLICT (key) DO UPDATE SET
+			name = EXCLUDED.name,
+			type = EXCLUDED.type,
+			options = EXCLUDED.options,
+			required = EXCLUDED.required,
+			updated_at = EXCLUDED.updated_at`,
+		field.Key, field.Name, field.Type, sqlutil.Strings(field.Options), field.Required, now.UTC())
+	if err != nil {
+		return err
+	}
+
+	field.UpdatedAt = now
+	return nil
+}
+
+func (r *CustomFieldRepository) Delete(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM custom_fields WHERE key = ?1", key)
+	return err
+}
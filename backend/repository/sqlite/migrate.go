@@ -0,0 +1,127 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"time"
+
+	"bug-tracker/repository/sqlutil"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is one SQL file from the migrations directory
+type Migration = sqlutil.Migration
+
+type AppliedMigration = sqlutil.AppliedMigration
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB) *Migrator {
+	migrations, err := sqlutil.LoadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		// The files are embedded, so this only fails on a bad file name
+		panic(err)
+	}
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Applied returns the migrations recorded as applied, oldest first
+func (m *Migrator) Applied(ctx context.Context) ([]AppliedMigration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version, name, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := []AppliedMigration{}
+	for rows.Next() {
+		var a AppliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+// Pending returns the migrations that have not been applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sqlutil.Pending(m.migrations, applied), nil
+}
+
+// Up applies every pending migration in version order and returns the ones
+// it applied. Each migration runs in its own transaction together with its
+// schema_migrations record, so a failed migration leaves no trace.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range m.migrations {
+		ok, err := m.apply(ctx, migration)
+		if err != nil {
+			return applied, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+		if ok {
+			applied = append(applied, migration)
+		}
+	}
+	return applied, nil
+}
+
+// apply runs migration unless it is already recorded and reports whether it
+// ran. Transactions hold SQLite's write lock from the start, so a process
+// migrating concurrently waits and then sees the record.
+func (m *Migrator) apply(ctx context.Context, migration Migration) (bool, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var done bool
+	err = tx.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = ?1)", migration.Version).Scan(&done)
+	if err != nil || done {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, migration.SQL); err != nil {
+		return false, err
+	}
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?1, ?2, ?3)",
+		migration.Version, migration.Name, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`)
+	return err
+}
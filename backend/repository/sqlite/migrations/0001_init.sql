@@ -0,0 +1,52 @@
+-- IDs are the 24 character hex form of models.ID so they stay valid when
+-- data moves between backends. Timestamps are UTC text, which sorts in time
+-- order.
+
+CREATE TABLE users (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    email      TEXT NOT NULL UNIQUE,
+    password   TEXT NOT NULL,
+    role       TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE INDEX users_role ON users (role);
+
+CREATE TABLE bugs (
+    -- seq is the rowid the full-text index refers to. Declaring it keeps it
+    -- stable, since VACUUM may renumber an implicit rowid.
+    seq         INTEGER PRIMARY KEY,
+    id          TEXT NOT NULL UNIQUE,
+    title       TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    status      TEXT NOT NULL,
+    priority    TEXT NOT NULL,
+    reported_by TEXT NOT NULL,
+    assigned_to TEXT,
+    created_at  DATETIME NOT NULL,
+    updated_at  DATETIME NOT NULL,
+    resolved_at DATETIME,
+    external_id TEXT UNIQUE
+);
+
+CREATE INDEX bugs_assigned_to_status ON bugs (assigned_to, status);
+CREATE INDEX bugs_status_created_at ON bugs (status, created_at DESC);
+CREATE INDEX bugs_created_at ON bugs (created_at);
+
+-- Full-text index over bug titles and descriptions, kept in sync by triggers
+CREATE VIRTUAL TABLE bugs_fts USING fts5(title, description, content='bugs', content_rowid='seq');
+
+CREATE TRIGGER bugs_fts_insert AFTER INSERT ON bugs BEGIN
+    INSERT INTO bugs_fts (rowid, title, description) VALUES (new.seq, new.title, new.description);
+END;
+
+CREATE TRIGGER bugs_fts_delete AFTER DELETE ON bugs BEGIN
+    INSERT INTO bugs_fts (bugs_fts, rowid, title, description) VALUES ('delete', old.seq, old.title, old.description);
+END;
+
+CREATE TRIGGER bugs_fts_update AFTER UPDATE OF title, description ON bugs BEGIN
+    INSERT INTO bugs_fts (bugs_fts, rowid, title, description) VALUES ('delete', old.seq, old.title, old.description);
+    INSERT INTO bugs_fts (rowid, title, description) VALUES (new.seq, new.title, new.description);
+END;
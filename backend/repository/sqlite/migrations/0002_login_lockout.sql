@@ -0,0 +1,3 @@
+-- Consecutive failed logins and the lockout they trigger
+ALTER TABLE users ADD COLUMN failed_logins INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN locked_until DATETIME;
@@ -0,0 +1,2 @@
+-- TOTP secret, replay guard and hashed recovery codes, as JSON
+ALTER TABLE users ADD COLUMN two_factor TEXT;
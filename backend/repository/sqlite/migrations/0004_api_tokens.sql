@@ -0,0 +1,15 @@
+-- Personal API tokens. Only a SHA-256 hash of each token is stored; scopes
+-- are a JSON array.
+CREATE TABLE api_tokens (
+    id           TEXT PRIMARY KEY,
+    user_id      TEXT NOT NULL,
+    name         TEXT NOT NULL,
+    prefix       TEXT NOT NULL,
+    hash         TEXT NOT NULL UNIQUE,
+    scopes       TEXT NOT NULL,
+    expires_at   DATETIME,
+    last_used_at DATETIME,
+    created_at   DATETIME NOT NULL
+);
+
+CREATE INDEX api_tokens_user_id_created_at ON api_tokens (user_id, created_at);
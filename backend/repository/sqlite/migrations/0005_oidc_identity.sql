@@ -0,0 +1,5 @@
+-- The single sign-on account a user is linked to
+ALTER TABLE users ADD COLUMN oidc_issuer TEXT;
+ALTER TABLE users ADD COLUMN oidc_subject TEXT;
+
+CREATE UNIQUE INDEX users_oidc ON users (oidc_issuer, oidc_subject);
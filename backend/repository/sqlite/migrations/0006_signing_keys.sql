@@ -0,0 +1,9 @@
+-- Keys that sign login tokens, published at /.well-known/jwks.json.
+-- private_key is PKCS #8 DER.
+CREATE TABLE signing_keys (
+    id          TEXT PRIMARY KEY,
+    algorithm   TEXT NOT NULL,
+    private_key BLOB NOT NULL,
+    active_at   DATETIME NOT NULL,
+    created_at  DATETIME NOT NULL
+);
@@ -0,0 +1,2 @@
+-- When an admin deactivated the account; NULL while it is active
+ALTER TABLE users ADD COLUMN deactivated_at DATETIME;
@@ -0,0 +1,7 @@
+-- Roles and the permissions they grant, as a JSON array. The built-in
+-- roles are created when the server starts.
+CREATE TABLE roles (
+    name        TEXT PRIMARY KEY,
+    permissions TEXT NOT NULL,
+    updated_at  DATETIME NOT NULL
+);
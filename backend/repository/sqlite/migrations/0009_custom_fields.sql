@@ -0,0 +1,18 @@
+-- Custom field definitions, with the options of enum and multi-select
+-- fields as a JSON array
+CREATE TABLE custom_fields (
+    key        TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    type       TEXT NOT NULL,
+    options    TEXT NOT NULL,
+    required   INTEGER NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+-- Custom field values of each bug, as a JSON object by field key
+ALTER TABLE bugs ADD COLUMN fields TEXT;
+
+-- The admin role created before field.manage existed gets it too
+UPDATE roles SET permissions = json_insert(permissions, '$[#]', 'field.manage')
+WHERE name = 'admin'
+  AND NOT EXISTS (SELECT 1 FROM json_each(roles.permissions) WHERE value = 'field.manage');
@@ -0,0 +1,22 @@
+-- Templates bugs are reported from, with their required sections and
+-- labels as JSON arrays
+CREATE TABLE bug_templates (
+    id                TEXT PRIMARY KEY,
+    name              TEXT NOT NULL,
+    title_prefix      TEXT NOT NULL,
+    description       TEXT NOT NULL,
+    required_sections TEXT NOT NULL,
+    priority          TEXT NOT NULL,
+    labels            TEXT NOT NULL,
+    created_at        DATETIME NOT NULL,
+    updated_at        DATETIME NOT NULL
+);
+
+-- Labels of each bug, as a JSON array
+ALTER TABLE bugs ADD COLUMN labels TEXT NOT NULL DEFAULT '[]';
+
+-- The admin and manager roles created before template.manage existed get
+-- it too
+UPDATE roles SET permissions = json_insert(permissions, '$[#]', 'template.manage')
+WHERE name IN ('admin', 'manager')
+  AND NOT EXISTS (SELECT 1 FROM json_each(roles.permissions) WHERE value = 'template.manage');
@@ -0,0 +1,22 @@
+-- Releases bugs are found in and fixed in. Due dates are YYYY-MM-DD, or
+-- empty.
+CREATE TABLE milestones (
+    id          TEXT PRIMARY KEY,
+    name        TEXT NOT NULL,
+    description TEXT NOT NULL,
+    due_date    TEXT NOT NULL,
+    state       TEXT NOT NULL,
+    created_at  DATETIME NOT NULL,
+    updated_at  DATETIME NOT NULL,
+    closed_at   DATETIME
+);
+
+ALTER TABLE bugs ADD COLUMN affected_version TEXT;
+ALTER TABLE bugs ADD COLUMN fix_version TEXT;
+CREATE INDEX bugs_fix_version_status ON bugs (fix_version, status);
+
+-- The admin and manager roles created before milestone.manage existed get
+-- it too
+UPDATE roles SET permissions = json_insert(permissions, '$[#]', 'milestone.manage')
+WHERE name IN ('admin', 'manager')
+  AND NOT EXISTS (SELECT 1 FROM json_each(roles.permissions) WHERE value = 'milestone.manage');
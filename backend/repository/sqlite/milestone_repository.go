@@ -0,0 +1,107 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+	"bug-tracker/repository/sqlutil"
+)
+
+// Ensure MilestoneRepository implements MilestoneRepositoryInterface
+var _ repository.MilestoneRepositoryInterface = (*MilestoneRepository)(nil)
+
+const milestoneColumns = "id, name, description, due_date, state, created_at, updated_at, closed_at"
+
+type MilestoneRepository struct {
+	db *sql.DB
+}
+
+func NewMilestoneRepository(db *sql.DB) *MilestoneRepository {
+	return &MilestoneRepository{db: db}
+}
+
+func (r *MilestoneRepository) Create(ctx context.Context, milestone *models.Milestone) error {
+	id := models.NewID()
+	now := time.Now()
+
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO milestones ("+milestoneColumns+") VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?6, ?7)",
+		id.Hex(), milestone.Name, milestone.Description, milestone.DueDate, milestone.State, now.UTC(), nullableTime(milestone.ClosedAt))
+	if err != nil {
+		return err
+	}
+
+	milestone.ID = id
+	milestone.CreatedAt = now
+	milestone.UpdatedAt = now
+	return nil
+}
+
+func (r *MilestoneRepository) FindByID(ctx context.Context, id models.ID) (*models.Milestone, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+milestoneColumns+" FROM milestones WHERE id = ?1", id.Hex())
+	milestone, err := scanMilestone(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return milestone, err
+}
+
+func (r *MilestoneRepository) FindAll(ctx context.Context) ([]*models.Milestone, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+milestoneColumns+" FROM milestones ORDER BY due_date = '', due_date, name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var milestones []*models.Milestone
+	for rows.Next() {
+		milestone, err := scanMilestone(rows)
+		if err != nil {
+			return nil, err
+		}
+		milestones = append(milestones, milestone)
+	}
+	return milestones, rows.Err()
+}
+
+func (r *MilestoneRepository) Update(ctx context.Context, milestone *models.Milestone) error {
+	now := time.Now()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE milestones SET
+			name = ?2, description = ?3, due_date = ?4, state = ?5,
+			updated_at = ?6, closed_at = ?7
+		WHERE id = ?1`,
+		milestone.ID.Hex(), milestone.Name, milestone.Description, milestone.DueDate, milestone.State, now.UTC(), nullableTime(milestone.ClosedAt))
+	if err != nil {
+		return err
+	}
+
+	milestone.UpdatedAt = now
+	return nil
+}
+
+func (r *MilestoneRepository) Delete(ctx context.Context, id models.ID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM milestones WHERE id = ?1", id.Hex())
+	return err
+}
+
+func scanMilestone(row sqlutil.Scanner) (*models.Milestone, error) {
+	var milestone models.Milestone
+	var closedAt sql.NullTime
+	err := row.Scan(
+		sqlutil.ScanID(&milestone.ID), &milestone.Name, &milestone.Description, &milestone.DueDate, &milestone.State,
+		&milestone.CreatedAt, &milestone.UpdatedAt, &closedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		milestone.ClosedAt = &closedAt.Time
+	}
+	return &milestone, nil
+}
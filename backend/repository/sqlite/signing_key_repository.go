@@ -0,0 +1,64 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+	"bug-tracker/repository/sqlutil"
+)
+
+// Ensure SigningKeyRepository implements SigningKeyRepositoryInterface
+var _ repository.SigningKeyRepositoryInterface = (*SigningKeyRepository)(nil)
+
+const signingKeyColumns = "id, algorithm, private_key, active_at, created_at"
+
+type SigningKeyRepository struct {
+	db *sql.DB
+}
+
+func NewSigningKeyRepository(db *sql.DB) *SigningKeyRepository {
+	return &SigningKeyRepository{db: db}
+}
+
+func (r *SigningKeyRepository) Create(ctx context.Context, key *models.SigningKey) error {
+	id := models.NewID()
+	now := time.Now()
+
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO signing_keys ("+signingKeyColumns+") VALUES (?1, ?2, ?3, ?4, ?5)",
+		id.Hex(), key.Algorithm, key.PrivateKey, key.ActiveAt.UTC(), now.UTC())
+	if err != nil {
+		return err
+	}
+
+	key.ID = id
+	key.CreatedAt = now
+	return nil
+}
+
+func (r *SigningKeyRepository) FindAll(ctx context.Context) ([]*models.SigningKey, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+signingKeyColumns+" FROM signing_keys ORDER BY active_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.SigningKey
+	for rows.Next() {
+		var key models.SigningKey
+		err := rows.Scan(sqlutil.ScanID(&key.ID), &key.Algorithm, &key.PrivateKey, &key.ActiveAt, &key.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, &key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *SigningKeyRepository) Delete(ctx context.Context, id models.ID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM signing_keys WHERE id = ?1", id.Hex())
+	return err
+}
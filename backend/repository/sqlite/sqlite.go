@@ -0,0 +1,74 @@
+// Package sqlite implements the repository interfaces on an embedded SQLite
+// database, for single-node deployments without a database server. It uses
+// a pure-Go driver, so the binary still builds without cgo.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"bug-tracker/repository/sqlutil"
+
+	sqlitedriver "modernc.org/sqlite" // also registers the "sqlite" database/sql driver
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Open opens the database file at path, creating it if needed. The database
+// runs in WAL mode so readers, including backups, don't block the writer.
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	query := url.Values{}
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "synchronous(NORMAL)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Add("_pragma", "foreign_keys(ON)")
+	// Store times as UTC text that SQLite's date functions understand
+	query.Set("_time_format", "sqlite")
+	// Take the write lock when a transaction begins rather than on its first
+	// write, so concurrent transactions wait instead of failing
+	query.Set("_txlock", "immediate")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Backup writes a consistent snapshot of db to a new file at path. It can run
+// while the server is using the database; path must not exist yet.
+func Backup(ctx context.Context, db *sql.DB, path string) error {
+	_, err := db.ExecContext(ctx, "VACUUM INTO ?1", path)
+	return err
+}
+
+// isUniqueViolation reports whether err violates the unique index on column,
+// given as table.column
+func isUniqueViolation(err error, column string) bool {
+	var sqliteErr *sqlitedriver.Error
+	return errors.As(err, &sqliteErr) &&
+		sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE &&
+		strings.Contains(sqliteErr.Error(), column)
+}
+
+// newParams returns a statement builder using SQLite's ?n placeholders
+func newParams() *sqlutil.Params {
+	return &sqlutil.Params{Prefix: "?"}
+}
+
+// nullableTime stores a nil time as NULL. Like every time bound to a
+// statement it is converted to UTC, so stored timestamps compare as text.
+func nullableTime(t *time.Time) driver.Value {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
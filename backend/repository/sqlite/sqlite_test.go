@@ -0,0 +1,185 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+	"bug-tracker/repository/repotest"
+	"bug-tracker/repository/sqlutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestDB opens a freshly migrated database in a temporary directory
+func setupTestDB(t *testing.T) *sql.DB {
+	db := openTestDB(t, filepath.Join(t.TempDir(), "test.db"))
+	_, err := NewMigrator(db).Up(context.Background())
+	require.NoError(t, err)
+	return db
+}
+
+func openTestDB(t *testing.T, path string) *sql.DB {
+	db, err := Open(context.Background(), path)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestBugRepository(t *testing.T) {
+	repotest.RunBugRepositoryTests(t, func(t *testing.T) repository.BugRepositoryInterface {
+		return NewBugRepository(setupTestDB(t))
+	})
+}
+
+func TestUserRepository(t *testing.T) {
+	repotest.RunUserRepositoryTests(t, func(t *testing.T) repository.UserRepositoryInterface {
+		return NewUserRepository(setupTestDB(t))
+	})
+}
+
+func TestAPITokenRepository(t *testing.T) {
+	repotest.RunAPITokenRepositoryTests(t, func(t *testing.T) repository.APITokenRepositoryInterface {
+		return NewAPITokenRepository(setupTestDB(t))
+	})
+}
+
+func TestSigningKeyRepository(t *testing.T) {
+	repotest.RunSigningKeyRepositoryTests(t, func(t *testing.T) repository.SigningKeyRepositoryInterface {
+		return NewSigningKeyRepository(setupTestDB(t))
+	})
+}
+
+func TestRoleRepository(t *testing.T) {
+	repotest.RunRoleRepositoryTests(t, func(t *testing.T) repository.RoleRepositoryInterface {
+		return NewRoleRepository(setupTestDB(t))
+	})
+}
+
+func TestCustomFieldRepository(t *testing.T) {
+	repotest.RunCustomFieldRepositoryTests(t, func(t *testing.T) repository.CustomFieldRepositoryInterface {
+		return NewCustomFieldRepository(setupTestDB(t))
+	})
+}
+
+func TestBugTemplateRepository(t *testing.T) {
+	repotest.RunBugTemplateRepositoryTests(t, func(t *testing.T) repository.BugTemplateRepositoryInterface {
+		return NewBugTemplateRepository(setupTestDB(t))
+	})
+}
+
+func TestMilestoneRepository(t *testing.T) {
+	repotest.RunMilestoneRepositoryTests(t, func(t *testing.T) repository.MilestoneRepositoryInterface {
+		return NewMilestoneRepository(setupTestDB(t))
+	})
+}
+
+func TestMigrator(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	migrator := NewMigrator(db)
+
+	applied, err := migrator.Applied(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrator.migrations))
+
+	// Re-running is a no-op
+	again, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	pending, err := migrator.Pending(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestMigratorConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	ctx := context.Background()
+
+	// Two processes starting at once apply each migration exactly once
+	results := make(chan []Migration, 2)
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		db := openTestDB(t, path)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			applied, err := NewMigrator(db).Up(ctx)
+			assert.NoError(t, err)
+			results <- applied
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	total := 0
+	for applied := range results {
+		total += len(applied)
+	}
+	assert.Equal(t, len(NewMigrator(openTestDB(t, path)).migrations), total)
+}
+
+func TestOpenUsesWAL(t *testing.T) {
+	db := setupTestDB(t)
+
+	var mode string
+	require.NoError(t, db.QueryRow("PRAGMA journal_mode").Scan(&mode))
+	assert.Equal(t, "wal", mode)
+}
+
+func TestBackup(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+
+	bug := &models.Bug{Title: "Backed up crash", Priority: "high", ReportedBy: models.NewID()}
+	require.NoError(t, NewBugRepository(db).Create(ctx, bug))
+
+	path := filepath.Join(t.TempDir(), "backup.db")
+	require.NoError(t, Backup(ctx, db, path))
+	assert.Error(t, Backup(ctx, db, path), "an existing file is never overwritten")
+
+	// The snapshot is a complete database, search index included
+	restored := NewBugRepository(openTestDB(t, path))
+	found, err := restored.Search(ctx, models.BugSearch{Query: "crash"})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, bug.ID, found[0].ID)
+}
+
+func TestSearchRanksBestMatchesFirst(t *testing.T) {
+	db := setupTestDB(t)
+	bugs := NewBugRepository(db)
+	ctx := context.Background()
+
+	for _, title := range []string{"Crash", "Crash crash crash", "Crash after crash"} {
+		bug := &models.Bug{Title: title, Description: "Seen on " + title, Priority: "low", ReportedBy: models.NewID()}
+		require.NoError(t, bugs.Create(ctx, bug))
+	}
+
+	found, err := bugs.Search(ctx, models.BugSearch{Query: "crash", Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "Crash crash crash", found[0].Title)
+	assert.Equal(t, "Crash after crash", found[1].Title)
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := sqlutil.LoadMigrations(migrationFiles, "migrations")
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.Equal(t, "init", migrations[0].Name)
+}
+
+func TestReportRepository(t *testing.T) {
+	repotest.RunReportRepositoryTests(t, func(t *testing.T) (repository.BugRepositoryInterface, repository.ReportRepositoryInterface) {
+		db := setupTestDB(t)
+		return NewBugRepository(db), NewReportRepository(db)
+	})
+}
@@ -0,0 +1,185 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+	"bug-tracker/repository/sqlutil"
+)
+
+// Ensure UserRepository implements UserRepositoryInterface
+var _ repository.UserRepositoryInterface = (*UserRepository)(nil)
+
+const userColumns = "id, name, email, password, role, created_at, updated_at, failed_logins, locked_until, two_factor, oidc_issuer, oidc_subject, deactivated_at"
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	id := models.NewID()
+	now := time.Now()
+	issuer, subject := sqlutil.OIDCIdentity(user.OIDC)
+
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO users ("+userColumns+") VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, 0, NULL, ?8, ?9, ?10, ?11)",
+		id.Hex(), user.Name, user.Email, user.Password, user.Role, now.UTC(), now.UTC(),
+		sqlutil.TwoFactor(user.TwoFactor), issuer, subject, nullableTime(user.DeactivatedAt))
+	if isUniqueViolation(err, "users.email") {
+		return repository.ErrDuplicateEmail
+	}
+	if err != nil {
+		return err
+	}
+
+	user.ID = id
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	return nil
+}
+
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE email = ?1", email)
+	return scanOptionalUser(row)
+}
+
+func (r *UserRepository) FindByID(ctx context.Context, id models.ID) (*models.User, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?1", id.Hex())
+	return scanOptionalUser(row)
+}
+
+func (r *UserRepository) FindByOIDC(ctx context.Context, issuer, subject string) (*models.User, error) {
+	row := r.db.QueryRowContext(ctx,
+		"SELECT "+userColumns+" FROM users WHERE oidc_issuer = ?1 AND oidc_subject = ?2", issuer, subject)
+	return scanOptionalUser(row)
+}
+
+// Update replaces the stored user, inserting it if it doesn't exist
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	user.UpdatedAt = time.Now()
+	issuer, subject := sqlutil.OIDCIdentity(user.OIDC)
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO users (`+userColumns+`) VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			email = EXCLUDED.email,
+			password = EXCLUDED.password,
+			role = EXCLUDED.role,
+			created_at = EXCLUDED.created_at,
+			updated_at = EXCLUDED.updated_at,
+			failed_logins = EXCLUDED.failed_logins,
+			locked_until = EXCLUDED.locked_until,
+			two_factor = EXCLUDED.two_factor,
+			oidc_issuer = EXCLUDED.oidc_issuer,
+			oidc_subject = EXCLUDED.oidc_subject,
+			deactivated_at = EXCLUDED.deactivated_at`,
+		user.ID.Hex(), user.Name, user.Email, user.Password, user.Role, user.CreatedAt.UTC(), user.UpdatedAt.UTC(),
+		user.FailedLogins, nullableTime(user.LockedUntil), sqlutil.TwoFactor(user.TwoFactor), issuer, subject, nullableTime(user.DeactivatedAt))
+	if isUniqueViolation(err, "users.email") {
+		return repository.ErrDuplicateEmail
+	}
+	return err
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id models.ID) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = ?1", id.Hex())
+	return err
+}
+
+func (r *UserRepository) FindByRole(ctx context.Context, role string) ([]*models.User, error) {
+	return r.query(ctx, "SELECT "+userColumns+" FROM users WHERE role = ?1 ORDER BY created_at", role)
+}
+
+func (r *UserRepository) FindAll(ctx context.Context) ([]*models.User, error) {
+	return r.query(ctx, "SELECT "+userColumns+" FROM users ORDER BY created_at")
+}
+
+func (r *UserRepository) RecordLoginFailure(ctx context.Context, id models.ID) (int, error) {
+	var failures int
+	err := r.db.QueryRowContext(ctx,
+		"UPDATE users SET failed_logins = failed_logins + 1 WHERE id = ?1 RETURNING failed_logins",
+		id.Hex()).Scan(&failures)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return failures, err
+}
+
+func (r *UserRepository) SetLockout(ctx context.Context, id models.ID, failedLogins int, lockedUntil *time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE users SET failed_logins = ?2, locked_until = ?3 WHERE id = ?1",
+		id.Hex(), failedLogins, nullableTime(lockedUntil))
+	return err
+}
+
+func (r *UserRepository) SetTwoFactor(ctx context.Context, id models.ID, prev, next *models.TwoFactor) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE users SET two_factor = ?3 WHERE id = ?1 AND two_factor IS ?2",
+		id.Hex(), sqlutil.TwoFactor(prev), sqlutil.TwoFactor(next))
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+func (r *UserRepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.User, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func scanUser(row sqlutil.Scanner) (*models.User, error) {
+	var user models.User
+	var lockedUntil, deactivatedAt sql.NullTime
+	var oidcIssuer, oidcSubject sql.NullString
+	err := row.Scan(
+		sqlutil.ScanID(&user.ID), &user.Name, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt,
+		&user.FailedLogins, &lockedUntil, sqlutil.ScanTwoFactor(&user.TwoFactor),
+		&oidcIssuer, &oidcSubject, &deactivatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lockedUntil.Valid {
+		user.LockedUntil = &lockedUntil.Time
+	}
+	if oidcIssuer.Valid {
+		user.OIDC = &models.OIDCIdentity{Issuer: oidcIssuer.String, Subject: oidcSubject.String}
+	}
+	if deactivatedAt.Valid {
+		user.DeactivatedAt = &deactivatedAt.Time
+	}
+	return &user, nil
+}
+
+// scanOptionalUser returns nil when row is empty, like the Mongo repository
+func scanOptionalUser(row *sql.Row) (*models.User, error) {
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return user, err
+}
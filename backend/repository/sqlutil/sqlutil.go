@@ -0,0 +1,327 @@
+// Package sqlutil holds the helpers shared by the SQL repository
+// implementations: ID conversion, statement building and migration files.
+package sqlutil
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bug-tracker/models"
+)
+
+// Scanner is satisfied by *sql.Row and *sql.Rows
+type Scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// ScanID returns a destination for Scan that reads a hex ID column into id,
+// treating NULL as the zero ID
+func ScanID(id *models.ID) sql.Scanner {
+	return idScanner{id}
+}
+
+type idScanner struct {
+	id *models.ID
+}
+
+func (s idScanner) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		*s.id = models.NilID
+		return nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into an ID", src)
+	}
+
+	id, err := models.ParseID(strings.TrimSpace(text))
+	if err != nil {
+		return err
+	}
+	*s.id = id
+	return nil
+}
+
+// NullableID stores the zero ID as NULL
+func NullableID(id models.ID) driver.Value {
+	if id.IsZero() {
+		return nil
+	}
+	return id.Hex()
+}
+
+// NullableString stores the empty string as NULL
+func NullableString(s string) driver.Value {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// TwoFactor stores two-factor settings as a JSON column, nil as NULL
+func TwoFactor(tf *models.TwoFactor) driver.Valuer {
+	return twoFactorValue{tf}
+}
+
+type twoFactorValue struct {
+	tf *models.TwoFactor
+}
+
+func (v twoFactorValue) Value() (driver.Value, error) {
+	if v.tf == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v.tf)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// ScanTwoFactor returns a destination for Scan that reads a JSON column
+// into tf, treating NULL as nil
+func ScanTwoFactor(tf **models.TwoFactor) sql.Scanner {
+	return twoFactorScanner{tf}
+}
+
+type twoFactorScanner struct {
+	tf **models.TwoFactor
+}
+
+func (s twoFactorScanner) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*s.tf = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into two-factor settings", src)
+	}
+
+	var tf models.TwoFactor
+	if err := json.Unmarshal(data, &tf); err != nil {
+		return err
+	}
+	*s.tf = &tf
+	return nil
+}
+
+// OIDCIdentity returns the issuer and subject columns of identity, both
+// NULL when it is nil
+func OIDCIdentity(identity *models.OIDCIdentity) (issuer, subject driver.Value) {
+	if identity == nil {
+		return nil, nil
+	}
+	return identity.Issuer, identity.Subject
+}
+
+// Strings stores a list such as API token scopes as a JSON array
+func Strings(list []string) driver.Valuer {
+	return stringsValue(list)
+}
+
+type stringsValue []string
+
+func (v stringsValue) Value() (driver.Value, error) {
+	if v == nil {
+		v = stringsValue{}
+	}
+	data, err := json.Marshal([]string(v))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// ScanStrings returns a destination for Scan that reads a JSON array
+// column into list
+func ScanStrings(list *[]string) sql.Scanner {
+	return stringsScanner{list}
+}
+
+type stringsScanner struct {
+	list *[]string
+}
+
+func (s stringsScanner) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into a list", src)
+	}
+	return json.Unmarshal(data, s.list)
+}
+
+// FieldValues stores custom field values as a JSON object, none as NULL
+func FieldValues(values models.FieldValues) driver.Valuer {
+	return fieldValuesValue(values)
+}
+
+type fieldValuesValue models.FieldValues
+
+func (v fieldValuesValue) Value() (driver.Value, error) {
+	if len(v) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(map[string]interface{}(v))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// ScanFieldValues returns a destination for Scan that reads a JSON object
+// column into values, treating NULL and {} as nil
+func ScanFieldValues(values *models.FieldValues) sql.Scanner {
+	return fieldValuesScanner{values}
+}
+
+type fieldValuesScanner struct {
+	values *models.FieldValues
+}
+
+func (s fieldValuesScanner) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*s.values = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into custom field values", src)
+	}
+
+	var values models.FieldValues
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		values = nil
+	}
+	*s.values = values
+	return nil
+}
+
+// FieldPath returns the JSON path of a custom field for SQLite's JSON
+// functions. Keys are validated when fields are defined, but are quoted
+// anyway.
+func FieldPath(key string) string {
+	return `$."` + strings.ReplaceAll(key, `"`, "") + `"`
+}
+
+// Params collects the positional arguments of a statement as it is built.
+// Placeholders are Prefix followed by the argument's position, e.g. $1.
+type Params struct {
+	Prefix string
+	Args   []interface{}
+}
+
+// Bind adds arg and returns its placeholder
+func (p *Params) Bind(arg interface{}) string {
+	p.Args = append(p.Args, arg)
+	return p.Prefix + strconv.Itoa(len(p.Args))
+}
+
+// DateRange returns conditions restricting expr to the filter's inclusive
+// range. Bounds are bound in UTC so they compare correctly with timestamps
+// stored as text.
+func (p *Params) DateRange(expr string, filter models.ReportFilter) []string {
+	var conds []string
+	if filter.From != nil {
+		conds = append(conds, expr+" >= "+p.Bind(filter.From.UTC()))
+	}
+	if filter.To != nil {
+		conds = append(conds, expr+" <= "+p.Bind(filter.To.UTC()))
+	}
+	return conds
+}
+
+// Where joins conditions into a WHERE clause, or returns "" for none
+func Where(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+// Migration is one SQL file from a migrations directory. Files are named
+// NNNN_name.sql and applied in version order.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// LoadMigrations reads the *.sql files in dir of fsys, sorted by version
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	names, err := fs.Glob(fsys, path.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, name := range names {
+		base := strings.TrimSuffix(path.Base(name), ".sql")
+		number, label, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(number)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("migration file %s must be named NNNN_name.sql", name)
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: label, SQL: string(content)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Pending returns the migrations in all that are not in applied
+func Pending(all []Migration, applied []AppliedMigration) []Migration {
+	done := make(map[int]bool, len(applied))
+	for _, a := range applied {
+		done[a.Version] = true
+	}
+
+	var pending []Migration
+	for _, migration := range all {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
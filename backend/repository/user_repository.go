@@ -2,123 +2,33 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"bug-tracker/models"
-
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrDuplicateEmail is returned by Create when the email is already taken
+var ErrDuplicateEmail = errors.New("duplicate email")
+
 type UserRepositoryInterface interface {
 	Create(ctx context.Context, user *models.User) error
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
-	FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	// FindByOIDC finds the user linked to subject at the OIDC issuer
+	FindByOIDC(ctx context.Context, issuer, subject string) (*models.User, error)
+	FindByID(ctx context.Context, id models.ID) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
-	Delete(ctx context.Context, id primitive.ObjectID) error
+	Delete(ctx context.Context, id models.ID) error
 	FindByRole(ctx context.Context, role string) ([]*models.User, error)
-}
-
-type UserRepository struct {
-	db *mongo.Database
-}
-
-func NewUserRepository(db *mongo.Database) *UserRepository {
-	return &UserRepository{db: db}
-}
-
-func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
-	collection := r.db.Collection("users")
-
-	// Check for existing user with the same email
-	existingUser, err := r.FindByEmail(ctx, user.Email)
-	if err != nil {
-		return err
-	}
-	if existingUser != nil {
-		return mongo.ErrNoDocuments // Return an error to indicate duplicate email
-	}
-
-	// Set timestamps
-	user.CreatedAt = time.Now()
-	user.UpdatedAt = time.Now()
-
-	result, err := collection.InsertOne(ctx, user)
-	if err != nil {
-		return err
-	}
-
-	user.ID = result.InsertedID.(primitive.ObjectID)
-	return nil
-}
-
-func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
-	collection := r.db.Collection("users")
-
-	var user models.User
-	err := collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, nil
-		}
-		return nil, err
-	}
-
-	return &user, nil
-}
-
-func (r *UserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
-	collection := r.db.Collection("users")
-
-	var user models.User
-	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, nil
-		}
-		return nil, err
-	}
-
-	return &user, nil
-}
-
-func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
-	collection := r.db.Collection("users")
-
-	user.UpdatedAt = time.Now()
-
-	_, err := collection.ReplaceOne(
-		ctx,
-		bson.M{"_id": user.ID},
-		user,
-		options.Replace().SetUpsert(true),
-	)
-
-	return err
-}
-
-func (r *UserRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
-	collection := r.db.Collection("users")
-
-	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
-	return err
-}
-
-func (r *UserRepository) FindByRole(ctx context.Context, role string) ([]*models.User, error) {
-	collection := r.db.Collection("users")
-
-	cursor, err := collection.Find(ctx, bson.M{"role": role})
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-
-	var users []*models.User
-	if err = cursor.All(ctx, &users); err != nil {
-		return nil, err
-	}
-
-	return users, nil
+	FindAll(ctx context.Context) ([]*models.User, error)
+	// RecordLoginFailure atomically adds one to the user's failed logins
+	// and returns the new count, or 0 if the user doesn't exist
+	RecordLoginFailure(ctx context.Context, id models.ID) (int, error)
+	// SetLockout stores the user's failed login count and lock expiry. A
+	// nil lockedUntil leaves the account unlocked.
+	SetLockout(ctx context.Context, id models.ID, failedLogins int, lockedUntil *time.Time) error
+	// SetTwoFactor replaces the user's two-factor settings with next, nil
+	// to remove them, but only if the stored settings still equal prev. It
+	// reports whether they did, so a code can't be spent by two requests.
+	SetTwoFactor(ctx context.Context, id models.ID, prev, next *models.TwoFactor) (bool, error)
 }
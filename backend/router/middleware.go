@@ -0,0 +1,240 @@
+package router
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
+	"math"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"bug-tracker/logging"
+	"bug-tracker/metrics"
+	"bug-tracker/models"
+	"bug-tracker/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the correlation ID of a request
+const RequestIDHeader = "X-Request-ID"
+
+// probePaths are requested every few seconds by monitoring, so they are
+// logged at debug level and not traced
+var probePaths = map[string]bool{
+	"/healthz": true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// maxRequestIDLength bounds client-supplied IDs so they can't bloat logs
+const maxRequestIDLength = 128
+
+// RequestID accepts the caller's X-Request-ID, or generates one, echoes it in
+// the response and attaches a logger tagged with it, and the trace ID if
+// any, to the request context
+func RequestID(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+
+		requestLogger := logger.With("request_id", id)
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+			requestLogger = requestLogger.With("trace_id", span.TraceID().String())
+		}
+		setLogger(c, requestLogger)
+		c.Next()
+	}
+}
+
+// AccessLog logs one line per request once it has been handled
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.Float64("duration_ms", float64(time.Since(start))/float64(time.Millisecond)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case probePaths[c.Request.URL.Path]:
+			// A failing probe is expected while draining or degraded
+			level = slog.LevelDebug
+			if status >= http.StatusBadRequest {
+				level = slog.LevelWarn
+			}
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		}
+		// The logger picks up user_id if AuthMiddleware ran
+		logging.FromContext(c.Request.Context()).LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Metrics records the count, latency and in-flight number of requests by
+// matched route, so unknown paths can't explode the label set
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		done := m.StartRequest()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		done(c.Request.Method, route, c.Writer.Status())
+	}
+}
+
+// MetricsAuth requires "Authorization: Bearer <token>" when token is set
+func MetricsAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+		expected := []byte("Bearer " + token)
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), expected) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid metrics token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Recovery turns panics into 500 responses and logs them with a stack trace
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if err := recover(); err != nil {
+				logging.FromContext(c.Request.Context()).Error("panic while handling request",
+					"panic", err,
+					"stack", string(debug.Stack()),
+				)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			}
+		}()
+		c.Next()
+	}
+}
+
+// setUser records the authenticated user and tags the request logger with it
+func setUser(c *gin.Context, user *models.User) {
+	c.Set("user", user)
+	setLogger(c, logging.FromContext(c.Request.Context()).With("user_id", user.ID.Hex()))
+}
+
+func setLogger(c *gin.Context, logger *slog.Logger) {
+	c.Request = c.Request.WithContext(logging.NewContext(c.Request.Context(), logger))
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		// Printable ASCII only, so IDs can't forge log lines or headers
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return hex.EncodeToString([]byte(time.Now().Format(time.RFC3339Nano)))
+	}
+	return hex.EncodeToString(b)
+}
+
+// CORS answers preflight requests and allows browsers on origins to call
+// the API. Outside release mode any origin is allowed.
+func CORS(origins []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	return func(c *gin.Context) {
+		origin := c.Request.Header.Get("Origin")
+		if origin != "" && (allowed[origin] || gin.Mode() != gin.ReleaseMode) {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length, X-Request-ID, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining")
+		// Single sign-on binds its state to a cookie; everything else
+		// authenticates with tokens
+		credentials := "false"
+		if strings.HasPrefix(c.Request.URL.Path, "/api/auth/oidc/") {
+			credentials = "true"
+		}
+		c.Writer.Header().Set("Access-Control-Allow-Credentials", credentials)
+
+		// Handle preflight requests
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RateLimit answers 429 once a client IP exceeds limit on a route. Each IP
+// has a bucket per route and scope, so scopes stack on the same route.
+// Requests are let through if the store fails.
+func RateLimit(store ratelimit.Store, scope string, limit ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limit.Enabled() || probePaths[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		result, err := store.Take(c, scope+":"+route+":"+c.ClientIP(), limit)
+		if err != nil {
+			logging.FromContext(c).Warn("rate limit check failed", "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			return
+		}
+		c.Next()
+	}
+}
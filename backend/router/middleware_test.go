@@ -0,0 +1,423 @@
+package router
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-tracker/logging"
+	"bug-tracker/metrics"
+	"bug-tracker/models"
+	"bug-tracker/ratelimit"
+	"bug-tracker/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// stubAuth accepts the session token "good" and the enrollment token
+// "enroll" only; other methods are not used
+type stubAuth struct {
+	usecase.AuthUseCaseInterface
+	user *models.User
+}
+
+func (s stubAuth) ValidateToken(ctx context.Context, token string) (*models.User, error) {
+	if token != "good" {
+		return nil, errors.New("invalid token")
+	}
+	return s.user, nil
+}
+
+func (s stubAuth) ValidateEnrollmentToken(ctx context.Context, token string) (*models.User, error) {
+	if token != "enroll" {
+		return nil, usecase.ErrInvalidChallenge
+	}
+	return s.user, nil
+}
+
+// stubTokens accepts the API token "bt_read" granting bugs:read only
+type stubTokens struct {
+	usecase.TokenUseCaseInterface
+	user *models.User
+}
+
+func (s stubTokens) ValidateAPIToken(ctx context.Context, token string) (*models.User, *models.APIToken, error) {
+	if token != "bt_read" {
+		return nil, nil, usecase.ErrInvalidAPIToken
+	}
+	return s.user, &models.APIToken{Scopes: []string{models.ScopeBugsRead}}, nil
+}
+
+func newTestEngine(buf *bytes.Buffer, user *models.User) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.ContextWithFallback = true
+	engine.Use(RequestID(logging.New(buf, slog.LevelDebug)), AccessLog(), Recovery())
+
+	protected := engine.Group("/", AuthMiddleware(stubAuth{user: user}, stubTokens{user: user}, Scopes{}))
+	protected.GET("/hello", func(c *gin.Context) {
+		logging.FromContext(c).Info("handler ran")
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	protected.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	return engine
+}
+
+// logEntries decodes the JSON lines written to buf
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{name: "propagates the caller's ID", header: "trace-123", expected: "trace-123"},
+		{name: "generates an ID when missing", header: ""},
+		{name: "replaces IDs with control characters", header: "bad\nid"},
+		{name: "replaces overlong IDs", header: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			engine := newTestEngine(&buf, &models.User{ID: models.NewID()})
+
+			req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+			req.Header.Set("Authorization", "Bearer good")
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, id)
+			} else {
+				assert.Len(t, id, 32)
+			}
+
+			for _, entry := range logEntries(t, &buf) {
+				assert.Equal(t, id, entry["request_id"], entry["msg"])
+			}
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	user := &models.User{ID: models.NewID()}
+	engine := newTestEngine(&buf, user)
+
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.Header.Set("Authorization", "Bearer good")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	assert.NotContains(t, buf.String(), "Bearer good")
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "handler ran", entries[0]["msg"])
+	assert.Equal(t, user.ID.Hex(), entries[0]["user_id"])
+
+	access := entries[1]
+	assert.Equal(t, "request", access["msg"])
+	assert.Equal(t, "INFO", access["level"])
+	assert.Equal(t, "GET", access["method"])
+	assert.Equal(t, "/hello", access["path"])
+	assert.Equal(t, float64(http.StatusOK), access["status"])
+	assert.Equal(t, user.ID.Hex(), access["user_id"])
+}
+
+func TestAccessLogUnauthenticated(t *testing.T) {
+	var buf bytes.Buffer
+	engine := newTestEngine(&buf, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.Header.Set("Authorization", "Bearer bad")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, float64(http.StatusUnauthorized), entries[0]["status"])
+	assert.NotContains(t, entries[0], "user_id")
+}
+
+func TestRecovery(t *testing.T) {
+	var buf bytes.Buffer
+	engine := newTestEngine(&buf, &models.User{ID: models.NewID()})
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set("Authorization", "Bearer good")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "panic while handling request", entries[0]["msg"])
+	assert.Equal(t, "boom", entries[0]["panic"])
+	assert.Equal(t, "ERROR", entries[1]["level"])
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := metrics.New()
+	engine := gin.New()
+	engine.Use(Metrics(m))
+	engine.GET("/bugs/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	engine.GET("/metrics", MetricsAuth("s3cret"), gin.WrapH(m.Handler()))
+
+	for _, path := range []string{"/bugs/1", "/bugs/2", "/random/path"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, `bugtracker_http_requests_total{method="GET",route="/bugs/:id",status="204"} 2`)
+	assert.Contains(t, body, `bugtracker_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.NotContains(t, body, "/random/path")
+}
+
+func TestTraceContextPropagation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var buf bytes.Buffer
+	engine := gin.New()
+	engine.Use(otelgin.Middleware("test"), RequestID(logging.New(&buf, slog.LevelInfo)), AccessLog())
+	engine.GET("/hello", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entries[0]["trace_id"])
+}
+
+func TestAccessLogProbes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	engine := gin.New()
+	engine.Use(RequestID(logging.New(&buf, slog.LevelDebug)), AccessLog())
+	engine.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.GET("/readyz", func(c *gin.Context) { c.Status(http.StatusServiceUnavailable) })
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "DEBUG", entries[0]["level"])
+	assert.Equal(t, "WARN", entries[1]["level"])
+}
+
+func TestCORS(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          string
+		origin        string
+		method        string
+		expectedAllow string
+		expectedCode  int
+	}{
+		{name: "allows a listed origin", mode: gin.ReleaseMode, origin: "https://bugs.example.com", method: http.MethodGet, expectedAllow: "https://bugs.example.com", expectedCode: http.StatusOK},
+		{name: "refuses other origins in release mode", mode: gin.ReleaseMode, origin: "https://evil.example.com", method: http.MethodGet, expectedCode: http.StatusOK},
+		{name: "allows any origin outside release mode", mode: gin.DebugMode, origin: "http://localhost:9999", method: http.MethodGet, expectedAllow: "http://localhost:9999", expectedCode: http.StatusOK},
+		{name: "answers preflight requests", mode: gin.ReleaseMode, origin: "https://bugs.example.com", method: http.MethodOptions, expectedAllow: "https://bugs.example.com", expectedCode: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(tt.mode)
+			defer gin.SetMode(gin.TestMode)
+
+			engine := gin.New()
+			engine.Use(CORS([]string{"https://bugs.example.com/"}))
+			engine.GET("/hello", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tt.method, "/hello", nil)
+			req.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedAllow, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "false", w.Header().Get("Access-Control-Allow-Credentials"))
+		})
+	}
+
+	t.Run("allows credentials for single sign-on", func(t *testing.T) {
+		engine := gin.New()
+		engine.Use(CORS([]string{"https://bugs.example.com"}))
+
+		req := httptest.NewRequest(http.MethodOptions, "/api/auth/oidc/callback", nil)
+		req.Header.Set("Origin", "https://bugs.example.com")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	})
+}
+
+// failingStore fails every check
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store down")
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limit := ratelimit.Limit{Requests: 2, Per: time.Minute}
+
+	newEngine := func(store ratelimit.Store) *gin.Engine {
+		engine := gin.New()
+		engine.Use(RateLimit(store, "api", limit))
+		engine.GET("/a", func(c *gin.Context) { c.Status(http.StatusOK) })
+		engine.GET("/b", func(c *gin.Context) { c.Status(http.StatusOK) })
+		engine.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+		return engine
+	}
+	get := func(engine *gin.Engine, path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	engine := newEngine(ratelimit.NewMemoryStore())
+	for remaining := 1; remaining >= 0; remaining-- {
+		w := get(engine, "/a", "10.0.0.1")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, strconv.Itoa(remaining), w.Header().Get("X-RateLimit-Remaining"))
+	}
+
+	w := get(engine, "/a", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"error":"Too many requests"}`, w.Body.String())
+
+	// Buckets are per route and per client, and probes are never limited
+	assert.Equal(t, http.StatusOK, get(engine, "/b", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, get(engine, "/a", "10.0.0.2").Code)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, get(engine, "/healthz", "10.0.0.1").Code)
+	}
+
+	// A broken store lets requests through rather than taking the API down
+	engine = newEngine(failingStore{})
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, get(engine, "/a", "10.0.0.1").Code)
+	}
+}
+
+func TestEnrollmentAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &models.User{ID: models.NewID(), Role: "admin"}
+	engine := gin.New()
+	handler := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": c.MustGet("user").(*models.User).ID})
+	}
+	engine.POST("/enroll", EnrollmentAuth(stubAuth{user: user}), handler)
+	engine.GET("/session", AuthMiddleware(stubAuth{user: user}, stubTokens{user: user}, Scopes{}), handler)
+
+	tests := []struct {
+		method, path, token string
+		expected            int
+	}{
+		{method: http.MethodPost, path: "/enroll", token: "good", expected: http.StatusOK},
+		{method: http.MethodPost, path: "/enroll", token: "enroll", expected: http.StatusOK},
+		{method: http.MethodPost, path: "/enroll", token: "bad", expected: http.StatusUnauthorized},
+		{method: http.MethodPost, path: "/enroll", expected: http.StatusUnauthorized},
+		// Enrollment tokens open nothing else
+		{method: http.MethodGet, path: "/session", token: "enroll", expected: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, tt.expected, w.Code, "%s %s with %q", tt.method, tt.path, tt.token)
+	}
+}
+
+func TestAPITokenScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &models.User{ID: models.NewID(), Role: "admin"}
+	engine := gin.New()
+	handler := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": c.MustGet("user").(*models.User).ID})
+	}
+	auth, tokens := stubAuth{user: user}, stubTokens{user: user}
+	bugs := AuthMiddleware(auth, tokens, Scopes{Read: models.ScopeBugsRead, Write: models.ScopeBugsWrite})
+	engine.GET("/bugs", bugs, handler)
+	engine.POST("/bugs", bugs, handler)
+	engine.GET("/users", AuthMiddleware(auth, tokens, Only(models.ScopeAdmin)), handler)
+	engine.GET("/tokens", AuthMiddleware(auth, tokens, Scopes{}), handler)
+
+	tests := []struct {
+		method, path, token string
+		expected            int
+	}{
+		{method: http.MethodGet, path: "/bugs", token: "bt_read", expected: http.StatusOK},
+		{method: http.MethodPost, path: "/bugs", token: "bt_read", expected: http.StatusForbidden},
+		{method: http.MethodGet, path: "/users", token: "bt_read", expected: http.StatusForbidden},
+		// Routes without scopes take login sessions only
+		{method: http.MethodGet, path: "/tokens", token: "bt_read", expected: http.StatusForbidden},
+		{method: http.MethodGet, path: "/tokens", token: "good", expected: http.StatusOK},
+		{method: http.MethodPost, path: "/bugs", token: "good", expected: http.StatusOK},
+		{method: http.MethodGet, path: "/bugs", token: "bt_revoked", expected: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("Authorization", "Bearer "+tt.token)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, tt.expected, w.Code, "%s %s with %q", tt.method, tt.path, tt.token)
+	}
+}
@@ -2,84 +2,144 @@ package router
 
 import (
 	"bug-tracker/controller"
+	"bug-tracker/metrics"
+	"bug-tracker/models"
+	"bug-tracker/ratelimit"
+	"bug-tracker/tracing"
 	"bug-tracker/usecase"
-	"os"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Router struct {
-	authController *controller.AuthController
-	bugController  *controller.BugController
-	authUseCase    usecase.AuthUseCaseInterface
+	authController      *controller.AuthController
+	bugController       *controller.BugController
+	reportController    *controller.ReportController
+	importController    *controller.ImportController
+	healthController    *controller.HealthController
+	tokenController     *controller.TokenController
+	roleController      *controller.RoleController
+	fieldController     *controller.CustomFieldController
+	templateController  *controller.BugTemplateController
+	milestoneController *controller.MilestoneController
+	authUseCase         usecase.AuthUseCaseInterface
+	tokenUseCase        usecase.TokenUseCaseInterface
+	opts                Options
 }
 
-func NewRouter(authController *controller.AuthController, bugController *controller.BugController, authUseCase usecase.AuthUseCaseInterface) *Router {
+// Options configures the middleware around the routes
+type Options struct {
+	// Metrics is nil when metrics are disabled
+	Metrics      *metrics.Metrics
+	MetricsToken string
+	// CORSOrigins may call the API from a browser; outside release mode
+	// any origin may
+	CORSOrigins []string
+	// TrustedProxies may set X-Forwarded-For; with none the client IP is
+	// the connection's
+	TrustedProxies []string
+	// RateLimits holds the rate limit buckets. RateLimit applies to each
+	// API route and AuthRateLimit to login and registration.
+	RateLimits    ratelimit.Store
+	RateLimit     ratelimit.Limit
+	AuthRateLimit ratelimit.Limit
+}
+
+func NewRouter(authController *controller.AuthController, bugController *controller.BugController, reportController *controller.ReportController, importController *controller.ImportController, healthController *controller.HealthController, tokenController *controller.TokenController, roleController *controller.RoleController, fieldController *controller.CustomFieldController, templateController *controller.BugTemplateController, milestoneController *controller.MilestoneController, authUseCase usecase.AuthUseCaseInterface, tokenUseCase usecase.TokenUseCaseInterface, opts Options) *Router {
+	if opts.RateLimits == nil {
+		opts.RateLimits = ratelimit.NewMemoryStore()
+	}
 	return &Router{
-		authController: authController,
-		bugController:  bugController,
-		authUseCase:    authUseCase,
+		authController:      authController,
+		bugController:       bugController,
+		reportController:    reportController,
+		importController:    importController,
+		healthController:    healthController,
+		tokenController:     tokenController,
+		roleController:      roleController,
+		fieldController:     fieldController,
+		templateController:  templateController,
+		milestoneController: milestoneController,
+		authUseCase:         authUseCase,
+		tokenUseCase:        tokenUseCase,
+		opts:                opts,
 	}
 }
 
 func (r *Router) Setup() *gin.Engine {
-	router := gin.Default()
-
-	// CORS middleware
-	router.Use(func(c *gin.Context) {
-		origin := c.Request.Header.Get("Origin")
-		// Allow requests from these origins
-		allowedOrigins := []string{
-			"https://bug-tracker-frontend.vercel.app",
-			"https://bug-tracker-frontend-kenean-r.vercel.app", // Deployed frontend URL
-			"http://localhost:5174",                            // Local development ports
-			"http://localhost:5173",
-			"http://localhost:3000",
-		}
-
-		// Check if the origin is allowed
-		allowed := false
-		for _, allowedOrigin := range allowedOrigins {
-			if origin == allowedOrigin {
-				allowed = true
-				c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-				break
-			}
-		}
-
-		// If no allowed origin matches, allow any origin in development
-		if !allowed && (os.Getenv("GIN_MODE") != "release") {
-			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-		}
+	router := gin.New()
+	// Lets handlers reach the request logger through the gin context
+	router.ContextWithFallback = true
+	if err := router.SetTrustedProxies(r.opts.TrustedProxies); err != nil {
+		// config.Validate has checked them, so this is a programming error
+		panic(err)
+	}
+	router.Use(otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(func(req *http.Request) bool {
+		// Probes and scrapes would drown out the requests worth tracing
+		return !probePaths[req.URL.Path]
+	})))
+	router.Use(RequestID(slog.Default()), AccessLog())
+	if r.opts.Metrics != nil {
+		router.Use(Metrics(r.opts.Metrics))
+	}
+	router.Use(Recovery())
 
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "false")
+	router.Use(CORS(r.opts.CORSOrigins))
+	// After CORS, so browsers can read the 429s
+	router.Use(RateLimit(r.opts.RateLimits, "api", r.opts.RateLimit))
 
-		// Handle preflight requests
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	// Probes answer without authentication
+	router.GET("/healthz", r.healthController.Live)
+	router.GET("/readyz", r.healthController.Ready)
+	// Other services verify login tokens with these keys
+	router.GET("/.well-known/jwks.json", r.authController.JWKS)
 
-		c.Next()
-	})
+	if r.opts.Metrics != nil {
+		router.GET("/metrics", MetricsAuth(r.opts.MetricsToken), gin.WrapH(r.opts.Metrics.Handler()))
+	}
 
 	// Auth routes
 	auth := router.Group("/api/auth")
 	{
-		auth.POST("/register", r.authController.Register)
-		auth.POST("/login", r.authController.Login)
+		// Each login costs a bcrypt comparison, so these get a tighter limit
+		authLimit := RateLimit(r.opts.RateLimits, "auth", r.opts.AuthRateLimit)
+		auth.POST("/register", authLimit, r.authController.Register)
+		auth.POST("/login", authLimit, r.authController.Login)
+		auth.POST("/login/2fa", authLimit, r.authController.VerifyTwoFactor)
+		auth.GET("/oidc/login", authLimit, r.authController.BeginSSO)
+		auth.POST("/oidc/callback", authLimit, r.authController.CompleteSSO)
 		auth.GET("/developers", r.authController.GetDevelopers)
 	}
 
+	// Two-factor settings of the current user. Users whose role requires
+	// two factors enroll with the challenge their login returned.
+	twoFactor := router.Group("/api/auth/2fa")
+	{
+		enroll := EnrollmentAuth(r.authUseCase)
+		twoFactor.POST("/enroll", enroll, r.authController.BeginTwoFactor)
+		twoFactor.POST("/confirm", enroll, r.authController.ConfirmTwoFactor)
+
+		// Scripts have no business changing how their owner logs in
+		session := AuthMiddleware(r.authUseCase, r.tokenUseCase, Scopes{})
+		twoFactor.GET("", session, r.authController.TwoFactorStatus)
+		twoFactor.POST("/recovery-codes", session, r.authController.RegenerateRecoveryCodes)
+		twoFactor.POST("/disable", session, r.authController.DisableTwoFactor)
+	}
+
 	// Bug routes (protected)
 	bugs := router.Group("/api/bugs")
-	bugs.Use(AuthMiddleware(r.authUseCase))
+	bugs.Use(AuthMiddleware(r.authUseCase, r.tokenUseCase, Scopes{Read: models.ScopeBugsRead, Write: models.ScopeBugsWrite}))
 	{
 		bugs.POST("", r.bugController.CreateBug)
 		bugs.GET("", r.bugController.GetBugs)
+		bugs.GET("/search", r.bugController.SearchBugs)
+		bugs.GET("/export", r.bugController.ExportBugs)
+		bugs.POST("/import", r.importController.ImportBugs)
 		bugs.GET("/:id", r.bugController.GetBugByID)
 		bugs.PUT("/:id", r.bugController.UpdateBug)
 		bugs.DELETE("/:id", r.bugController.DeleteBug)
@@ -87,11 +147,154 @@ func (r *Router) Setup() *gin.Engine {
 		bugs.POST("/:id/assign", r.bugController.AssignBug)
 	}
 
+	// Report routes (protected)
+	reports := router.Group("/api/reports")
+	reports.Use(AuthMiddleware(r.authUseCase, r.tokenUseCase, Only(models.ScopeBugsRead)))
+	{
+		reports.GET("/summary", r.reportController.GetSummary)
+		reports.GET("/trend", r.reportController.GetTrend)
+		reports.GET("/resolution-time", r.reportController.GetResolutionTime)
+		reports.GET("/ageing", r.reportController.GetAgeing)
+		reports.GET("/throughput", r.reportController.GetDeveloperThroughput)
+	}
+
+	// User administration (admins)
+	users := router.Group("/api/users")
+	users.Use(AuthMiddleware(r.authUseCase, r.tokenUseCase, Only(models.ScopeAdmin)))
+	{
+		users.GET("/lockouts", r.authController.ListLockouts)
+		users.DELETE("/:id/lockout", r.authController.Unlock)
+		users.DELETE("/:id/2fa", r.authController.ResetTwoFactor)
+		users.PUT("/:id/role", r.authController.ChangeRole)
+		users.POST("/:id/deactivate", r.authController.Deactivate)
+		users.POST("/:id/reactivate", r.authController.Reactivate)
+	}
+
+	// Personal API tokens of the current user. Tokens can't mint more
+	// tokens, so a leaked one can be revoked for good.
+	tokens := router.Group("/api/users/me/tokens")
+	tokens.Use(AuthMiddleware(r.authUseCase, r.tokenUseCase, Scopes{}))
+	{
+		tokens.GET("", r.tokenController.ListTokens)
+		tokens.POST("", r.tokenController.CreateToken)
+		tokens.DELETE("/:id", r.tokenController.RevokeToken)
+	}
+
+	// Roles and the permissions they grant (admins)
+	roles := router.Group("/api/roles")
+	roles.Use(AuthMiddleware(r.authUseCase, r.tokenUseCase, Only(models.ScopeAdmin)))
+	{
+		roles.GET("", r.roleController.ListRoles)
+		roles.GET("/permissions", r.roleController.ListPermissions)
+		roles.PUT("/:name", r.roleController.SaveRole)
+		roles.DELETE("/:name", r.roleController.DeleteRole)
+	}
+
+	// Custom fields of bugs, which everyone may read and admins edit
+	fields := router.Group("/api/fields")
+	fields.Use(AuthMiddleware(r.authUseCase, r.tokenUseCase, Scopes{Read: models.ScopeBugsRead, Write: models.ScopeAdmin}))
+	{
+		fields.GET("", r.fieldController.ListFields)
+		fields.PUT("/:key", r.fieldController.SaveField)
+		fields.DELETE("/:key", r.fieldController.DeleteField)
+	}
+
+	// Templates bugs are reported from, which everyone may read
+	templates := router.Group("/api/templates")
+	templates.Use(AuthMiddleware(r.authUseCase, r.tokenUseCase, Scopes{Read: models.ScopeBugsRead, Write: models.ScopeBugsWrite}))
+	{
+		templates.GET("", r.templateController.ListTemplates)
+		templates.POST("", r.templateController.CreateTemplate)
+		templates.GET("/:id", r.templateController.GetTemplate)
+		templates.PUT("/:id", r.templateController.UpdateTemplate)
+		templates.DELETE("/:id", r.templateController.DeleteTemplate)
+	}
+
+	// Milestones, which everyone may read. Progress needs report.view and
+	// release notes bug.view.
+	milestones := router.Group("/api/milestones")
+	milestones.Use(AuthMiddleware(r.authUseCase, r.tokenUseCase, Scopes{Read: models.ScopeBugsRead, Write: models.ScopeBugsWrite}))
+	{
+		milestones.GET("", r.milestoneController.ListMilestones)
+		milestones.POST("", r.milestoneController.CreateMilestone)
+		milestones.GET("/:id", r.milestoneController.GetMilestone)
+		milestones.PUT("/:id", r.milestoneController.UpdateMilestone)
+		milestones.DELETE("/:id", r.milestoneController.DeleteMilestone)
+		milestones.GET("/:id/progress", r.milestoneController.GetProgress)
+		milestones.GET("/:id/release-notes", r.milestoneController.GetReleaseNotes)
+	}
+
 	return router
 }
 
-// AuthMiddleware validates the JWT token
-func AuthMiddleware(authUseCase usecase.AuthUseCaseInterface) gin.HandlerFunc {
+// Scopes are the API token scopes a route needs: Read for GET and HEAD
+// requests, Write for the rest. Login sessions may use any route, while API
+// tokens are refused where the scope is empty.
+type Scopes struct {
+	Read  string
+	Write string
+}
+
+// Only returns the same scope for reads and writes
+func Only(scope string) Scopes {
+	return Scopes{Read: scope, Write: scope}
+}
+
+// forMethod returns the scope a request with method needs
+func (s Scopes) forMethod(method string) string {
+	if method == http.MethodGet || method == http.MethodHead {
+		return s.Read
+	}
+	return s.Write
+}
+
+// AuthMiddleware validates the JWT token, or a personal API token granting
+// scopes
+func AuthMiddleware(authUseCase usecase.AuthUseCaseInterface, tokenUseCase usecase.TokenUseCaseInterface, scopes Scopes) gin.HandlerFunc {
+	return tokenAuth(func(c *gin.Context, token string) (*models.User, error) {
+		if !strings.HasPrefix(token, models.APITokenPrefix) {
+			return authUseCase.ValidateToken(c, token)
+		}
+
+		user, apiToken, err := tokenUseCase.ValidateAPIToken(c, token)
+		if err != nil {
+			return nil, err
+		}
+		scope := scopes.forMethod(c.Request.Method)
+		if scope == "" || !apiToken.Allows(scope) {
+			return nil, &missingScopeError{scope: scope}
+		}
+		return user, nil
+	})
+}
+
+// EnrollmentAuth accepts a session token, or the challenge a login returns
+// to users who must set up two-factor authentication before logging in
+func EnrollmentAuth(authUseCase usecase.AuthUseCaseInterface) gin.HandlerFunc {
+	return tokenAuth(func(c *gin.Context, token string) (*models.User, error) {
+		user, err := authUseCase.ValidateToken(c, token)
+		if err != nil {
+			return authUseCase.ValidateEnrollmentToken(c, token)
+		}
+		return user, nil
+	})
+}
+
+// missingScopeError refuses a valid API token that doesn't grant the scope
+// a route needs. An empty scope means the route takes no API tokens.
+type missingScopeError struct {
+	scope string
+}
+
+func (e *missingScopeError) Error() string {
+	if e.scope == "" {
+		return "API tokens can't be used here"
+	}
+	return "Token lacks the " + e.scope + " scope"
+}
+
+// tokenAuth sets the user validate finds for the request's bearer token
+func tokenAuth(validate func(c *gin.Context, token string) (*models.User, error)) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := c.GetHeader("Authorization")
 		if token == "" {
@@ -105,7 +308,13 @@ func AuthMiddleware(authUseCase usecase.AuthUseCaseInterface) gin.HandlerFunc {
 			token = token[7:]
 		}
 
-		user, err := authUseCase.ValidateToken(token)
+		user, err := validate(c, token)
+		var scopeErr *missingScopeError
+		if errors.As(err, &scopeErr) {
+			c.JSON(http.StatusForbidden, gin.H{"error": scopeErr.Error()})
+			c.Abort()
+			return
+		}
 		if err != nil {
 			c.JSON(401, gin.H{"error": "Invalid token"})
 			c.Abort()
@@ -113,7 +322,7 @@ func AuthMiddleware(authUseCase usecase.AuthUseCaseInterface) gin.HandlerFunc {
 		}
 
 		// Set user in context
-		c.Set("user", user)
+		setUser(c, user)
 		c.Next()
 	}
 }
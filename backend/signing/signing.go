@@ -0,0 +1,291 @@
+// Package signing signs and verifies login tokens with asymmetric keys
+// that rotate on a schedule.
+//
+// Keys live in storage so every server signs with the same key and
+// rotation survives restarts. Each key is published for PublishDelay
+// before it starts signing, signs for RotationInterval, and is published
+// for TokenTTL after its successor takes over, so every token it signed
+// can still be verified.
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"bug-tracker/logging"
+	"bug-tracker/models"
+	"bug-tracker/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms
+const (
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+// rsaKeyBits is the size of generated RSA keys
+const rsaKeyBits = 2048
+
+var (
+	ErrNoSigningKey = errors.New("no signing key is active")
+	// ErrUnknownKey is returned for tokens whose kid names no published key
+	ErrUnknownKey = errors.New("token signed with an unknown key")
+)
+
+// Options configure a KeySet
+type Options struct {
+	// Algorithm signs new keys: RS256 or EdDSA. Changing it rotates to a
+	// key of the new algorithm.
+	Algorithm string
+	// RotationInterval is how long each key signs tokens
+	RotationInterval time.Duration
+	// PublishDelay is how long new keys are published before they sign,
+	// so verifiers caching the key set, and other servers, have them first
+	PublishDelay time.Duration
+	// TokenTTL is the lifetime of the longest-lived token signed
+	TokenTTL time.Duration
+}
+
+// KeySet signs with the active key and verifies with any published one
+type KeySet struct {
+	repo repository.SigningKeyRepositoryInterface
+	opts Options
+	now  func() time.Time
+
+	mu sync.RWMutex
+	// keys is in activation order
+	keys []*key
+}
+
+type key struct {
+	id        string
+	algorithm string
+	activeAt  time.Time
+	private   crypto.Signer
+}
+
+// New creates a key set backed by repo. Call Refresh before use.
+func New(repo repository.SigningKeyRepositoryInterface, opts Options) *KeySet {
+	return &KeySet{repo: repo, opts: opts, now: time.Now}
+}
+
+// Run refreshes the key set every interval until ctx is done, so keys
+// rotate on schedule and keys other servers create are picked up.
+// Failures are logged and retried next time.
+func (s *KeySet) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Warn("failed to refresh signing keys", "error", err)
+		}
+	}
+}
+
+// Refresh loads the stored keys, creates the next key when rotation is
+// due and deletes keys no unexpired token can have been signed with.
+// Servers refreshing at the same moment may each create a key; the
+// extra keys are harmless and age out like the rest.
+func (s *KeySet) Refresh(ctx context.Context) error {
+	stored, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	logger := logging.FromContext(ctx)
+
+	if s.rotationDue(stored, now) {
+		activeAt := now
+		if len(stored) > 0 {
+			activeAt = now.Add(s.opts.PublishDelay)
+		}
+		created, err := s.generate(ctx, activeAt)
+		if err != nil {
+			return err
+		}
+		stored = append(stored, created)
+		logger.Info("signing key created", "kid", created.ID.Hex(), "algorithm", created.Algorithm, "active_at", created.ActiveAt)
+	}
+
+	keys := make([]*key, 0, len(stored))
+	for i, sk := range stored {
+		// A key is done once its successor has signed for a token lifetime
+		if i+1 < len(stored) && !now.Before(stored[i+1].ActiveAt.Add(s.opts.TokenTTL)) {
+			if err := s.repo.Delete(ctx, sk.ID); err != nil {
+				return err
+			}
+			logger.Info("signing key retired", "kid", sk.ID.Hex())
+			continue
+		}
+
+		k, err := parseKey(sk)
+		if err != nil {
+			return fmt.Errorf("signing key %s: %w", sk.ID.Hex(), err)
+		}
+		keys = append(keys, k)
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+	return nil
+}
+
+// rotationDue reports whether a new key must be created: there is none,
+// the newest uses another algorithm, or it has nearly signed for a whole
+// RotationInterval
+func (s *KeySet) rotationDue(stored []*models.SigningKey, now time.Time) bool {
+	if len(stored) == 0 {
+		return true
+	}
+	newest := stored[len(stored)-1]
+	return newest.Algorithm != s.opts.Algorithm ||
+		!now.Before(newest.ActiveAt.Add(s.opts.RotationInterval-s.opts.PublishDelay))
+}
+
+func (s *KeySet) generate(ctx context.Context, activeAt time.Time) (*models.SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch s.opts.Algorithm {
+	case RS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case EdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", s.opts.Algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	sk := &models.SigningKey{Algorithm: s.opts.Algorithm, PrivateKey: der, ActiveAt: activeAt}
+	if err := s.repo.Create(ctx, sk); err != nil {
+		return nil, err
+	}
+	return sk, nil
+}
+
+func parseKey(sk *models.SigningKey) (*key, error) {
+	parsed, err := x509.ParsePKCS8PrivateKey(sk.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &key{id: sk.ID.Hex(), algorithm: sk.Algorithm, activeAt: sk.ActiveAt}
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		if sk.Algorithm != RS256 {
+			return nil, fmt.Errorf("RSA key stored for %s", sk.Algorithm)
+		}
+		k.private = private
+	case ed25519.PrivateKey:
+		if sk.Algorithm != EdDSA {
+			return nil, fmt.Errorf("Ed25519 key stored for %s", sk.Algorithm)
+		}
+		k.private = private
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return k, nil
+}
+
+// Sign signs claims with the active key, naming it in the kid header
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	k := s.active(s.now())
+	if k == nil {
+		return "", ErrNoSigningKey
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(k.algorithm), claims)
+	token.Header["kid"] = k.id
+	return token.SignedString(k.private)
+}
+
+// active returns the newest key that has started signing at now
+func (s *KeySet) active(now time.Time) *key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if !now.Before(s.keys[i].activeAt) {
+			return s.keys[i]
+		}
+	}
+	return nil
+}
+
+// Parse verifies tokenString into claims. The token must name a
+// published key in its kid header and be signed with that key's
+// algorithm, so tokens can't pick how they are checked.
+func (s *KeySet) Parse(tokenString string, claims jwt.Claims, opts ...jwt.ParserOption) (*jwt.Token, error) {
+	opts = append(opts, jwt.WithValidMethods([]string{RS256, EdDSA}))
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		k := s.find(kid)
+		if k == nil {
+			return nil, ErrUnknownKey
+		}
+		if token.Method.Alg() != k.algorithm {
+			return nil, fmt.Errorf("token signed with %s by a %s key", token.Method.Alg(), k.algorithm)
+		}
+		return k.private.Public(), nil
+	}, opts...)
+}
+
+func (s *KeySet) find(kid string) *key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, k := range s.keys {
+		if k.id == kid {
+			return k
+		}
+	}
+	return nil
+}
+
+// PublicKeys returns the published keys for /.well-known/jwks.json
+func (s *KeySet) PublicKeys() models.JSONWebKeySet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := models.JSONWebKeySet{Keys: make([]models.JSONWebKey, 0, len(s.keys))}
+	for _, k := range s.keys {
+		jwk := models.JSONWebKey{Use: "sig", Algorithm: k.algorithm, KeyID: k.id}
+		switch public := k.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
@@ -0,0 +1,191 @@
+package signing
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"bug-tracker/repository/memory"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testOptions = Options{
+	Algorithm:        RS256,
+	RotationInterval: 30 * 24 * time.Hour,
+	PublishDelay:     time.Hour,
+	TokenTTL:         24 * time.Hour,
+}
+
+// clock is a settable time for key sets
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newTestKeySet(t *testing.T, repo *memory.SigningKeyRepository, opts Options, c *clock) *KeySet {
+	t.Helper()
+	s := New(repo, opts)
+	s.now = c.now
+	require.NoError(t, s.Refresh(context.Background()))
+	return s
+}
+
+func sign(t *testing.T, s *KeySet) string {
+	t.Helper()
+	token, err := s.Sign(jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	require.NoError(t, err)
+	return token
+}
+
+func kid(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	return parsed.Header["kid"].(string)
+}
+
+func TestSignAndParse(t *testing.T) {
+	for _, algorithm := range []string{RS256, EdDSA} {
+		opts := testOptions
+		opts.Algorithm = algorithm
+		s := newTestKeySet(t, memory.NewSigningKeyRepository(), opts, &clock{time.Now()})
+
+		token := sign(t, s)
+		claims := jwt.MapClaims{}
+		parsed, err := s.Parse(token, claims)
+		require.NoError(t, err, algorithm)
+		assert.Equal(t, algorithm, parsed.Method.Alg())
+		assert.Equal(t, "user-1", claims["sub"])
+
+		keys := s.PublicKeys().Keys
+		require.Len(t, keys, 1)
+		assert.Equal(t, kid(t, token), keys[0].KeyID)
+		assert.Equal(t, algorithm, keys[0].Algorithm)
+		assert.Equal(t, "sig", keys[0].Use)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	repo := memory.NewSigningKeyRepository()
+	c := &clock{time.Now()}
+	s := newTestKeySet(t, repo, testOptions, c)
+	first := sign(t, s)
+
+	// Not due yet
+	c.t = c.t.Add(testOptions.RotationInterval - testOptions.PublishDelay - time.Minute)
+	require.NoError(t, s.Refresh(context.Background()))
+	assert.Len(t, s.PublicKeys().Keys, 1)
+
+	// The next key is published before it signs
+	c.t = c.t.Add(time.Minute)
+	require.NoError(t, s.Refresh(context.Background()))
+	assert.Len(t, s.PublicKeys().Keys, 2)
+	assert.Equal(t, kid(t, first), kid(t, sign(t, s)))
+
+	// It takes over without a refresh
+	c.t = c.t.Add(testOptions.PublishDelay)
+	second := sign(t, s)
+	assert.NotEqual(t, kid(t, first), kid(t, second))
+
+	// The old key verifies until its tokens have expired
+	c.t = c.t.Add(testOptions.TokenTTL - time.Second)
+	require.NoError(t, s.Refresh(context.Background()))
+	_, err := s.Parse(first, jwt.MapClaims{})
+	assert.NoError(t, err)
+
+	c.t = c.t.Add(time.Second)
+	require.NoError(t, s.Refresh(context.Background()))
+	_, err = s.Parse(first, jwt.MapClaims{})
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	_, err = s.Parse(second, jwt.MapClaims{})
+	assert.NoError(t, err)
+	stored, err := repo.FindAll(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, stored, 1)
+}
+
+func TestAlgorithmChange(t *testing.T) {
+	repo := memory.NewSigningKeyRepository()
+	c := &clock{time.Now()}
+	rsaToken := sign(t, newTestKeySet(t, repo, testOptions, c))
+
+	opts := testOptions
+	opts.Algorithm = EdDSA
+	s := newTestKeySet(t, repo, opts, c)
+	keys := s.PublicKeys().Keys
+	require.Len(t, keys, 2)
+	assert.Equal(t, "OKP", keys[1].KeyType)
+
+	// The RSA key signs until the new key is published long enough
+	assert.Equal(t, kid(t, rsaToken), kid(t, sign(t, s)))
+	c.t = c.t.Add(opts.PublishDelay)
+	token := sign(t, s)
+	parsed, err := s.Parse(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, EdDSA, parsed.Method.Alg())
+	_, err = s.Parse(rsaToken, jwt.MapClaims{})
+	assert.NoError(t, err)
+}
+
+func TestSharedKeys(t *testing.T) {
+	repo := memory.NewSigningKeyRepository()
+	c := &clock{time.Now()}
+	a := newTestKeySet(t, repo, testOptions, c)
+	b := newTestKeySet(t, repo, testOptions, c)
+
+	// Servers sharing storage sign with the same key
+	assert.Equal(t, kid(t, sign(t, a)), kid(t, sign(t, b)))
+	_, err := b.Parse(sign(t, a), jwt.MapClaims{})
+	assert.NoError(t, err)
+}
+
+func TestParseRejects(t *testing.T) {
+	s := newTestKeySet(t, memory.NewSigningKeyRepository(), testOptions, &clock{time.Now()})
+	keyID := s.PublicKeys().Keys[0].KeyID
+	claims := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	publicDER, err := x509.MarshalPKIXPublicKey(s.find(keyID).private.Public())
+	require.NoError(t, err)
+
+	// HMAC keyed with the public key, the classic algorithm confusion
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	hmac.Header["kid"] = keyID
+	confused, err := hmac.SignedString(publicDER)
+	require.NoError(t, err)
+
+	unsigned := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+	unsigned.Header["kid"] = keyID
+	none, err := unsigned.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	// A valid signature by a key of another algorithm under this kid
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ed := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	ed.Header["kid"] = keyID
+	mismatched, err := ed.SignedString(edKey)
+	require.NoError(t, err)
+
+	noKid, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims).SignedString(edKey)
+	require.NoError(t, err)
+
+	for name, token := range map[string]string{
+		"HS256 with the public key": confused,
+		"alg none":                  none,
+		"algorithm mismatch":        mismatched,
+		"no kid":                    noKid,
+	} {
+		_, err := s.Parse(token, jwt.MapClaims{})
+		assert.Error(t, err, name)
+	}
+}
+
+func TestSignWithoutKeys(t *testing.T) {
+	s := New(memory.NewSigningKeyRepository(), testOptions)
+	_, err := s.Sign(jwt.MapClaims{})
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+}
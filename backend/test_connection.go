@@ -1,50 +0,0 @@
-package main
-
-import (
-	"context"
-	"log"
-	"os"
-	"time"
-
-	"github.com/joho/godotenv"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
-)
-
-func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found")
-	}
-
-	// Get MongoDB URI from environment
-	mongoURI := os.Getenv("MONGO_URI")
-	if mongoURI == "" {
-		log.Fatal("MONGO_URI environment variable is not set")
-	}
-
-	log.Println("Connecting to MongoDB Atlas...")
-	log.Println("URI:", mongoURI)
-
-	// Create client options
-	clientOptions := options.Client().ApplyURI(mongoURI).
-		SetServerSelectionTimeout(5 * time.Second).
-		SetConnectTimeout(10 * time.Second)
-
-	// Connect to MongoDB
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	client, err := mongo.Connect(ctx, clientOptions)
-	if err != nil {
-		log.Fatal("Failed to connect to MongoDB:", err)
-	}
-	defer client.Disconnect(ctx)
-
-	// Ping the database
-	if err := client.Ping(ctx, nil); err != nil {
-		log.Fatal("Failed to ping MongoDB:", err)
-	}
-
-	log.Println("Successfully connected to MongoDB Atlas!")
-}
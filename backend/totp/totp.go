@@ -0,0 +1,116 @@
+// Package totp implements the time-based one-time passwords of RFC 6238,
+// as generated by authenticator apps: HMAC-SHA1, six digits, a new code
+// every 30 seconds.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Period is how long each code is valid
+	Period = 30 * time.Second
+	// Digits is the length of a code
+	Digits = 6
+	// Skew is how many periods either side of now are accepted, to allow
+	// for clock drift and slow typing
+	Skew = 1
+	// secretSize is the secret length in bytes; RFC 4226 recommends 160 bits
+	secretSize = 20
+)
+
+// ErrInvalidSecret is returned for secrets that aren't base32
+var ErrInvalidSecret = errors.New("totp: secret is not base32")
+
+// encoding is the unpadded base32 authenticator apps expect
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random secret in base32
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// URI returns the otpauth:// URI that authenticator apps read from a QR
+// code. The issuer and account name label the entry in the app.
+func URI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	query := url.Values{
+		"secret":    {secret},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(Digits)},
+		"period":    {fmt.Sprint(int(Period.Seconds()))},
+	}
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Step returns the number of periods since the Unix epoch at t
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period.Seconds())
+}
+
+// Code returns the code for secret at t
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decode(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, Step(t)), nil
+}
+
+// Validate checks code against secret at t, within Skew periods. It
+// returns the step the code belongs to, so callers can refuse a code
+// that has already been used.
+func Validate(secret, code string, t time.Time) (step int64, ok bool, err error) {
+	key, err := decode(secret)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(code) != Digits {
+		return 0, false, nil
+	}
+
+	now := Step(t)
+	for step := now - Skew; step <= now+Skew; step++ {
+		if hmac.Equal([]byte(hotp(key, step)), []byte(code)) {
+			return step, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+func decode(secret string) ([]byte, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidSecret
+	}
+	return key, nil
+}
+
+// hotp is the HOTP value of RFC 4226 for counter step
+func hotp(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%1_000_000)
+}
@@ -0,0 +1,90 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 test key of RFC 6238, "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B, truncated to six digits
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{unix: 59, expected: "287082"},
+		{unix: 1111111109, expected: "081804"},
+		{unix: 1111111111, expected: "050471"},
+		{unix: 1234567890, expected: "005924"},
+		{unix: 2000000000, expected: "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, time.Unix(tt.unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, code, "at %d", tt.unix)
+	}
+
+	_, err := Code("not base32!", time.Now())
+	assert.ErrorIs(t, err, ErrInvalidSecret)
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, err := Code(rfcSecret, now)
+	require.NoError(t, err)
+
+	step, ok, err := Validate(rfcSecret, code, now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// A period either side is accepted and reports the code's own step
+	step, ok, _ = Validate(rfcSecret, code, now.Add(Period))
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+	_, ok, _ = Validate(rfcSecret, code, now.Add(-Period))
+	assert.True(t, ok)
+
+	// Two periods is too far
+	_, ok, _ = Validate(rfcSecret, code, now.Add(2*Period))
+	assert.False(t, ok)
+
+	for _, bad := range []string{"", "12345", "1234567", "000000"} {
+		_, ok, _ = Validate(rfcSecret, bad, now)
+		assert.False(t, ok, bad)
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	other, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+
+	_, err = Code(secret, time.Now())
+	assert.NoError(t, err)
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Bug Tracker", "ada@example.com", rfcSecret)
+
+	u, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Bug Tracker:ada@example.com", u.Path)
+	assert.Equal(t, rfcSecret, u.Query().Get("secret"))
+	assert.Equal(t, "Bug Tracker", u.Query().Get("issuer"))
+	assert.Equal(t, "6", u.Query().Get("digits"))
+	assert.Equal(t, "30", u.Query().Get("period"))
+}
@@ -0,0 +1,107 @@
+// Package tracing configures OpenTelemetry tracing: the exporter, W3C trace
+// context propagation and spans around repository calls.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"bug-tracker/repository/instrument"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName is reported unless OTEL_SERVICE_NAME overrides it
+const ServiceName = "bugtracker"
+
+// Setup installs the global tracer provider and W3C propagators. exporter
+// is "otlp" (configured by the standard OTEL_EXPORTER_OTLP_* variables),
+// "stdout" for local runs, or "none" to only propagate trace context.
+// The returned function flushes pending spans and must be called on exit.
+func Setup(ctx context.Context, exporter string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		spanExporter, err = otlptracehttp.New(ctx)
+	case "stdout", "console":
+		// stdout carries the JSON logs, so keep spans apart
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown OTEL_TRACES_EXPORTER %q; use otlp, stdout or none", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", exporter, err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", ServiceName)),
+		// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	// The sampler follows OTEL_TRACES_SAMPLER, sampling everything by default
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// StorageHook starts a span around each repository call made within a
+// trace. Calls outside one, such as from admin commands, aren't traced.
+func StorageHook(backend string) instrument.Hook {
+	tracer := otel.Tracer("bug-tracker/repository")
+	system := attribute.String("db.system", dbSystem(backend))
+
+	return func(ctx context.Context, repo, method string) (context.Context, func(error)) {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			return ctx, func(error) {}
+		}
+
+		ctx, span := tracer.Start(ctx, repo+"."+method,
+			trace.WithSpanKind(trace.SpanKindInternal),
+			trace.WithAttributes(system, attribute.String("bugtracker.repository", repo)),
+		)
+		return ctx, func(err error) {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}
+	}
+}
+
+// dbSystem maps a STORAGE name to the OpenTelemetry db.system value
+func dbSystem(backend string) string {
+	switch backend {
+	case "mongo":
+		return "mongodb"
+	case "postgres":
+		return "postgresql"
+	default:
+		return backend
+	}
+}
@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStorageHook(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	hook := StorageHook("mongo")
+
+	// Outside a trace nothing is recorded
+	_, done := hook(context.Background(), "bugs", "FindAll")
+	done(nil)
+	assert.Empty(t, recorder.Ended())
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	childCtx, done := hook(ctx, "bugs", "FindByID")
+	done(errors.New("connection reset"))
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "bugs.FindByID", span.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	assert.Equal(t, span.SpanContext().SpanID(), spanID(childCtx))
+	assert.Contains(t, span.Attributes(), attribute.String("db.system", "mongodb"))
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Equal(t, "connection reset", span.Status().Description)
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), "none")
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+	assert.ElementsMatch(t, []string{"traceparent", "tracestate", "baggage"}, otel.GetTextMapPropagator().Fields())
+
+	_, err = Setup(context.Background(), "zipkin")
+	assert.Error(t, err)
+}
+
+func spanID(ctx context.Context) trace.SpanID {
+	return trace.SpanContextFromContext(ctx).SpanID()
+}
@@ -5,40 +5,87 @@ import (
 	"errors"
 	"time"
 
+	"bug-tracker/authz"
+	"bug-tracker/logging"
 	"bug-tracker/models"
 	"bug-tracker/repository"
+	"bug-tracker/signing"
 
 	"github.com/golang-jwt/jwt/v5"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // AuthUseCaseInterface defines the interface for authentication operations
 type AuthUseCaseInterface interface {
 	Register(ctx context.Context, req models.RegisterRequest) (*models.UserResponse, error)
-	Login(ctx context.Context, req models.LoginRequest) (string, *models.UserResponse, error)
+	Login(ctx context.Context, req models.LoginRequest) (*models.LoginResult, error)
 	GetDevelopers(ctx context.Context) ([]models.UserResponse, error)
-	ValidateToken(tokenString string) (*models.User, error)
+	ValidateToken(ctx context.Context, tokenString string) (*models.User, error)
+	ResetPassword(ctx context.Context, email, password string) error
+	ListLockouts(ctx context.Context) ([]models.LockoutStatus, error)
+	Unlock(ctx context.Context, id models.ID) error
+	ChangeRole(ctx context.Context, id models.ID, role string) (*models.UserResponse, error)
+	Deactivate(ctx context.Context, id models.ID) error
+	Reactivate(ctx context.Context, id models.ID) error
+
+	VerifyTwoFactor(ctx context.Context, challenge, code string) (*models.LoginResult, error)
+	ValidateEnrollmentToken(ctx context.Context, tokenString string) (*models.User, error)
+	TwoFactorStatus(ctx context.Context, id models.ID) (*models.TwoFactorStatus, error)
+	BeginTwoFactor(ctx context.Context, id models.ID) (*models.TwoFactorEnrollment, error)
+	ConfirmTwoFactor(ctx context.Context, id models.ID, code string) (*models.TwoFactorConfirmation, error)
+	RegenerateRecoveryCodes(ctx context.Context, id models.ID, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, id models.ID, code string) error
+	ResetTwoFactor(ctx context.Context, id models.ID) error
+
+	BeginSSO(ctx context.Context) (*models.SSOAuthorization, error)
+	CompleteSSO(ctx context.Context, code, state, binding string) (*models.LoginResult, error)
+
+	// PublicKeys returns the keys that verify login tokens
+	PublicKeys() models.JSONWebKeySet
 }
 
 var (
 	ErrUserNotFound       = errors.New("user not found")
 	ErrInvalidPassword    = errors.New("invalid password")
 	ErrEmailAlreadyExists = errors.New("email already exists")
+	ErrPasswordTooShort   = errors.New("password must be at least 6 characters")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrUserDeactivated    = errors.New("account is deactivated")
 )
 
 type AuthUseCase struct {
 	userRepo  repository.UserRepositoryInterface
+	roles     *authz.Roles
 	jwtSecret []byte
+	// keys signs tokens; without it they are signed with HS256 and
+	// jwtSecret
+	keys *signing.KeySet
+	// tokenTTL is how long issued tokens stay valid
+	tokenTTL time.Duration
+	// users caches the users of validated tokens; nil when disabled
+	users     *userCache
+	lockout   LockoutPolicy
+	twoFactor TwoFactorPolicy
+	sso       SSOPolicy
 }
 
-func NewAuthUseCase(userRepo repository.UserRepositoryInterface, jwtSecret string) *AuthUseCase {
+func NewAuthUseCase(userRepo repository.UserRepositoryInterface, roles *authz.Roles, jwtSecret string, keys *signing.KeySet, tokenTTL, cacheTTL time.Duration, lockout LockoutPolicy, twoFactor TwoFactorPolicy, sso SSOPolicy) *AuthUseCase {
 	return &AuthUseCase{
 		userRepo:  userRepo,
+		roles:     roles,
 		jwtSecret: []byte(jwtSecret),
+		keys:      keys,
+		tokenTTL:  tokenTTL,
+		users:     newUserCache(cacheTTL),
+		lockout:   lockout,
+		twoFactor: twoFactor,
+		sso:       sso,
 	}
 }
 
-func (uc *AuthUseCase) Register(ctx context.Context, req models.RegisterRequest) (*models.UserResponse, error) {
+func (uc *AuthUseCase) Register(ctx context.Context, req models.RegisterRequest) (_ *models.UserResponse, err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.Register")
+	defer func() { endSpan(span, err) }()
+
 	// Check if user already exists
 	existingUser, err := uc.userRepo.FindByEmail(ctx, req.Email)
 	if err != nil {
@@ -63,102 +110,251 @@ func (uc *AuthUseCase) Register(ctx context.Context, req models.RegisterRequest)
 
 	// Save user
 	if err := uc.userRepo.Create(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrDuplicateEmail) {
+			return nil, ErrEmailAlreadyExists
+		}
 		return nil, err
 	}
 
+	logging.FromContext(ctx).Info("user registered", "user_id", user.ID.Hex(), "role", user.Role)
+
 	// Return user response
 	response := user.ToResponse()
 	return &response, nil
 }
 
-func (uc *AuthUseCase) Login(ctx context.Context, req models.LoginRequest) (string, *models.UserResponse, error) {
+func (uc *AuthUseCase) Login(ctx context.Context, req models.LoginRequest) (_ *models.LoginResult, err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.Login")
+	defer func() { endSpan(span, err) }()
+
 	// Find user by email
 	user, err := uc.userRepo.FindByEmail(ctx, req.Email)
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
 	if user == nil {
-		return "", nil, ErrUserNotFound
+		logging.FromContext(ctx).Warn("login failed", "reason", "unknown email")
+		return nil, ErrUserNotFound
+	}
+
+	// Refuse locked accounts before spending a bcrypt comparison on them
+	if user.Locked(time.Now()) {
+		logging.FromContext(ctx).Warn("login failed", "reason", "account locked", "user_id", user.ID.Hex())
+		return nil, &AccountLockedError{Until: *user.LockedUntil}
 	}
 
 	// Check password
 	if err := user.CheckPassword(req.Password); err != nil {
-		return "", nil, ErrInvalidPassword
+		logging.FromContext(ctx).Warn("login failed", "reason", "wrong password", "user_id", user.ID.Hex())
+		if err := uc.recordLoginFailure(ctx, user); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidPassword
+	}
+	// Only tell the password's owner the account is deactivated
+	if user.Deactivated() {
+		logging.FromContext(ctx).Warn("login failed", "reason", "account deactivated", "user_id", user.ID.Hex())
+		return nil, ErrUserDeactivated
+	}
+
+	// Failed logins are only forgiven once the second factor is checked
+	// too, or guessing codes could reset the lockout with the password
+	switch {
+	case user.TwoFactorEnabled():
+		challenge, err := uc.generateChallenge(user, purposeLogin, loginChallengeTTL)
+		if err != nil {
+			return nil, err
+		}
+		return &models.LoginResult{ChallengeToken: challenge, TwoFactorRequired: true}, nil
+	case uc.twoFactor.required(user.Role):
+		challenge, err := uc.generateChallenge(user, purposeEnroll, enrollChallengeTTL)
+		if err != nil {
+			return nil, err
+		}
+		logging.FromContext(ctx).Info("login awaiting two-factor enrollment", "user_id", user.ID.Hex())
+		return &models.LoginResult{ChallengeToken: challenge, EnrollmentRequired: true}, nil
+	}
+
+	return uc.completeLogin(ctx, user)
+}
+
+// completeLogin forgives earlier failed logins and issues a session token
+func (uc *AuthUseCase) completeLogin(ctx context.Context, user *models.User) (*models.LoginResult, error) {
+	if user.Deactivated() {
+		return nil, ErrUserDeactivated
+	}
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		if err := uc.userRepo.SetLockout(ctx, user.ID, 0, nil); err != nil {
+			return nil, err
+		}
 	}
 
 	// Generate JWT token
 	token, err := uc.generateToken(user)
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
 
+	logging.FromContext(ctx).Info("user logged in", "user_id", user.ID.Hex())
+
 	// Return token and user response
 	response := user.ToResponse()
-	return token, &response, nil
+	return &models.LoginResult{Token: token, User: &response}, nil
 }
 
 func (uc *AuthUseCase) generateToken(user *models.User) (string, error) {
-	// Create claims
-	claims := jwt.MapClaims{
-		"user_id": user.ID.Hex(),
-		"email":   user.Email,
-		"role":    user.Role,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(), // 24 hours
-	}
-
-	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Sign token
-	tokenString, err := token.SignedString(uc.jwtSecret)
+	tokenID, err := newTokenID()
 	if err != nil {
 		return "", err
 	}
-
-	return tokenString, nil
-}
-
-func (uc *AuthUseCase) ValidateToken(tokenString string) (*models.User, error) {
-	// Parse token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return uc.jwtSecret, nil
+	now := time.Now()
+	return uc.signToken(jwt.MapClaims{
+		"jti":     tokenID,
+		"user_id": user.ID.Hex(),
+		"email":   user.Email,
+		"role":    user.Role,
+		"iat":     now.Unix(),
+		"exp":     now.Add(uc.tokenTTL).Unix(),
 	})
+}
 
+// ValidateToken returns the user of a session token. Users are cached by
+// token ID for a short while, so most requests skip the lookup.
+func (uc *AuthUseCase) ValidateToken(ctx context.Context, tokenString string) (*models.User, error) {
+	claims, err := uc.parseToken(tokenString)
 	if err != nil {
 		return nil, err
 	}
+	// Login challenges only prove the password
+	if _, ok := claims["purpose"]; ok {
+		return nil, ErrInvalidToken
+	}
 
-	// Get claims
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return nil, errors.New("invalid token")
+	tokenID, _ := claims["jti"].(string)
+	if user := uc.users.get(tokenID); user != nil {
+		return user, nil
 	}
 
 	// Get user ID
-	userID, err := primitive.ObjectIDFromHex(claims["user_id"].(string))
+	userID, _ := claims["user_id"].(string)
+	id, err := models.ParseID(userID)
 	if err != nil {
-		return nil, err
+		return nil, ErrInvalidToken
 	}
 
 	// Get user from repository
-	user, err := uc.userRepo.FindByID(context.Background(), userID)
+	user, err := uc.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if user == nil || user.Deactivated() {
+		return nil, ErrInvalidToken
+	}
 
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	uc.users.put(tokenID, user, expiresAt.Time)
 	return user, nil
 }
 
-func (uc *AuthUseCase) GetDevelopers(ctx context.Context) ([]models.UserResponse, error) {
-	developers, err := uc.userRepo.FindByRole(ctx, "developer")
+// updateUser saves a changed user and forgets their cached tokens
+func (uc *AuthUseCase) updateUser(ctx context.Context, user *models.User) error {
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	uc.users.invalidate(user.ID)
+	return nil
+}
+
+// signToken signs claims with the active key, or the JWT secret when
+// there are no keys
+func (uc *AuthUseCase) signToken(claims jwt.MapClaims) (string, error) {
+	if uc.keys != nil {
+		return uc.keys.Sign(claims)
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(uc.jwtSecret)
+}
+
+// parseToken verifies a token signToken issued. Only the algorithms it
+// signs with are accepted, and the token must expire.
+func (uc *AuthUseCase) parseToken(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	var err error
+	if uc.keys != nil {
+		_, err = uc.keys.Parse(tokenString, claims, jwt.WithExpirationRequired())
+	} else {
+		_, err = jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+			return uc.jwtSecret, nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	}
 	if err != nil {
 		return nil, err
 	}
+	return claims, nil
+}
 
-	responses := make([]models.UserResponse, len(developers))
-	for i, dev := range developers {
-		responses[i] = dev.ToResponse()
+// PublicKeys returns the keys that verify login tokens, which are none
+// when they are signed with the JWT secret
+func (uc *AuthUseCase) PublicKeys() models.JSONWebKeySet {
+	if uc.keys == nil {
+		return models.JSONWebKeySet{Keys: []models.JSONWebKey{}}
+	}
+	return uc.keys.PublicKeys()
+}
+
+func (uc *AuthUseCase) GetDevelopers(ctx context.Context) (_ []models.UserResponse, err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.GetDevelopers")
+	defer func() { endSpan(span, err) }()
+
+	// Developers are the users bugs can be assigned to
+	responses := []models.UserResponse{}
+	for _, role := range uc.roles.Granting(models.PermBugAssignable) {
+		developers, err := uc.userRepo.FindByRole(ctx, role)
+		if err != nil {
+			return nil, err
+		}
+		for _, dev := range developers {
+			// Deactivated developers can't be assigned bugs
+			if dev.Deactivated() {
+				continue
+			}
+			responses = append(responses, dev.ToResponse())
+		}
 	}
 
 	return responses, nil
 }
+
+// ResetPassword replaces the password of the user with the given email
+func (uc *AuthUseCase) ResetPassword(ctx context.Context, email, password string) (err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.ResetPassword")
+	defer func() { endSpan(span, err) }()
+
+	if len(password) < 6 {
+		return ErrPasswordTooShort
+	}
+
+	user, err := uc.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	user.Password = password
+	if err := user.HashPassword(); err != nil {
+		return err
+	}
+	// A new password ends any lockout
+	user.FailedLogins = 0
+	user.LockedUntil = nil
+
+	if err := uc.updateUser(ctx, user); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("password reset", "user_id", user.ID.Hex())
+	return nil
+}
@@ -2,12 +2,18 @@ package usecase
 
 import (
 	"bug-tracker/models"
+	"bug-tracker/repository"
+	"bug-tracker/repository/memory"
+	"bug-tracker/signing"
 	"context"
 	"errors"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/stretchr/testify/require"
 )
 
 type MockUserRepository struct {
@@ -22,7 +28,7 @@ func NewMockUserRepository() *MockUserRepository {
 
 func (m *MockUserRepository) Create(ctx context.Context, user *models.User) error {
 	if _, exists := m.users[user.Email]; exists {
-		return errors.New("user already exists")
+		return repository.ErrDuplicateEmail
 	}
 	m.users[user.Email] = user
 	return nil
@@ -35,7 +41,16 @@ func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*mo
 	return nil, nil
 }
 
-func (m *MockUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+func (m *MockUserRepository) FindByOIDC(ctx context.Context, issuer, subject string) (*models.User, error) {
+	for _, user := range m.users {
+		if user.OIDC != nil && user.OIDC.Issuer == issuer && user.OIDC.Subject == subject {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockUserRepository) FindByID(ctx context.Context, id models.ID) (*models.User, error) {
 	for _, user := range m.users {
 		if user.ID == id {
 			return user, nil
@@ -52,7 +67,7 @@ func (m *MockUserRepository) Update(ctx context.Context, user *models.User) erro
 	return nil
 }
 
-func (m *MockUserRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+func (m *MockUserRepository) Delete(ctx context.Context, id models.ID) error {
 	for email, user := range m.users {
 		if user.ID == id {
 			delete(m.users, email)
@@ -75,9 +90,47 @@ func (m *MockUserRepository) FindByRole(ctx context.Context, role string) ([]*mo
 	return developers, nil
 }
 
+func (m *MockUserRepository) FindAll(ctx context.Context) ([]*models.User, error) {
+	users := make([]*models.User, 0, len(m.users))
+	for _, user := range m.users {
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (m *MockUserRepository) RecordLoginFailure(ctx context.Context, id models.ID) (int, error) {
+	for _, user := range m.users {
+		if user.ID == id {
+			user.FailedLogins++
+			return user.FailedLogins, nil
+		}
+	}
+	return 0, nil
+}
+
+func (m *MockUserRepository) SetLockout(ctx context.Context, id models.ID, failedLogins int, lockedUntil *time.Time) error {
+	for _, user := range m.users {
+		if user.ID == id {
+			user.FailedLogins = failedLogins
+			user.LockedUntil = lockedUntil
+		}
+	}
+	return nil
+}
+
+func (m *MockUserRepository) SetTwoFactor(ctx context.Context, id models.ID, prev, next *models.TwoFactor) (bool, error) {
+	for _, user := range m.users {
+		if user.ID == id && reflect.DeepEqual(user.TwoFactor, prev) {
+			user.TwoFactor = next
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func TestRegister(t *testing.T) {
 	mockRepo := NewMockUserRepository()
-	authUseCase := NewAuthUseCase(mockRepo, "test-secret")
+	authUseCase := NewAuthUseCase(mockRepo, nil, "test-secret", nil, time.Hour, 0, LockoutPolicy{}, TwoFactorPolicy{}, SSOPolicy{})
 
 	t.Run("successful registration", func(t *testing.T) {
 		req := models.RegisterRequest{
@@ -112,7 +165,7 @@ func TestRegister(t *testing.T) {
 
 func TestLogin(t *testing.T) {
 	mockRepo := NewMockUserRepository()
-	authUseCase := NewAuthUseCase(mockRepo, "test-secret")
+	authUseCase := NewAuthUseCase(mockRepo, nil, "test-secret", nil, time.Hour, 0, LockoutPolicy{}, TwoFactorPolicy{}, SSOPolicy{})
 
 	// Register a test user first
 	req := models.RegisterRequest{
@@ -128,11 +181,21 @@ func TestLogin(t *testing.T) {
 			Email:    "test@example.com",
 			Password: "password123",
 		}
-		token, response, err := authUseCase.Login(context.Background(), loginReq)
-		assert.NoError(t, err)
+		result, err := authUseCase.Login(context.Background(), loginReq)
+		require.NoError(t, err)
+		token := result.Token
 		assert.NotEmpty(t, token)
-		assert.NotNil(t, response)
-		assert.Equal(t, req.Email, response.Email)
+		require.NotNil(t, result.User)
+		assert.Equal(t, req.Email, result.User.Email)
+		assert.Empty(t, result.ChallengeToken)
+
+		// Tokens expire after the configured lifetime
+		claims := jwt.MapClaims{}
+		_, err = jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) { return []byte("test-secret"), nil })
+		require.NoError(t, err)
+		exp, err := claims.GetExpirationTime()
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), exp.Time, time.Minute)
 	})
 
 	t.Run("user not found", func(t *testing.T) {
@@ -140,11 +203,10 @@ func TestLogin(t *testing.T) {
 			Email:    "nonexistent@example.com",
 			Password: "password123",
 		}
-		token, response, err := authUseCase.Login(context.Background(), loginReq)
+		result, err := authUseCase.Login(context.Background(), loginReq)
 		assert.Error(t, err)
 		assert.Equal(t, ErrUserNotFound, err)
-		assert.Empty(t, token)
-		assert.Nil(t, response)
+		assert.Nil(t, result)
 	})
 
 	t.Run("invalid password", func(t *testing.T) {
@@ -152,17 +214,16 @@ func TestLogin(t *testing.T) {
 			Email:    "test@example.com",
 			Password: "wrongpassword",
 		}
-		token, response, err := authUseCase.Login(context.Background(), loginReq)
+		result, err := authUseCase.Login(context.Background(), loginReq)
 		assert.Error(t, err)
 		assert.Equal(t, ErrInvalidPassword, err)
-		assert.Empty(t, token)
-		assert.Nil(t, response)
+		assert.Nil(t, result)
 	})
 }
 
 func TestValidateToken(t *testing.T) {
 	mockRepo := NewMockUserRepository()
-	authUseCase := NewAuthUseCase(mockRepo, "test-secret")
+	authUseCase := NewAuthUseCase(mockRepo, nil, "test-secret", nil, time.Hour, 0, LockoutPolicy{}, TwoFactorPolicy{}, SSOPolicy{})
 
 	// Register and login a test user to get a valid token
 	req := models.RegisterRequest{
@@ -177,25 +238,75 @@ func TestValidateToken(t *testing.T) {
 		Email:    "test@example.com",
 		Password: "password123",
 	}
-	token, _, _ := authUseCase.Login(context.Background(), loginReq)
+	result, err := authUseCase.Login(context.Background(), loginReq)
+	require.NoError(t, err)
+	token := result.Token
 
 	t.Run("valid token", func(t *testing.T) {
-		user, err := authUseCase.ValidateToken(token)
+		user, err := authUseCase.ValidateToken(context.Background(), token)
 		assert.NoError(t, err)
 		assert.NotNil(t, user)
 		assert.Equal(t, "test@example.com", user.Email)
 	})
 
 	t.Run("invalid token", func(t *testing.T) {
-		user, err := authUseCase.ValidateToken("invalid.token.here")
+		user, err := authUseCase.ValidateToken(context.Background(), "invalid.token.here")
 		assert.Error(t, err)
 		assert.Nil(t, user)
 	})
 }
 
+func TestValidateTokenAlgorithms(t *testing.T) {
+	ctx := context.Background()
+	keys := signing.New(memory.NewSigningKeyRepository(), signing.Options{
+		Algorithm:        signing.EdDSA,
+		RotationInterval: 30 * 24 * time.Hour,
+		PublishDelay:     time.Hour,
+		TokenTTL:         time.Hour,
+	})
+	require.NoError(t, keys.Refresh(ctx))
+
+	mockRepo := NewMockUserRepository()
+	signed := NewAuthUseCase(mockRepo, nil, "test-secret", keys, time.Hour, 0, LockoutPolicy{}, TwoFactorPolicy{}, SSOPolicy{})
+	hmac := NewAuthUseCase(mockRepo, nil, "test-secret", nil, time.Hour, 0, LockoutPolicy{}, TwoFactorPolicy{}, SSOPolicy{})
+	registerForTwoFactor(t, signed, mockRepo, "test@example.com", "developer")
+
+	login := func(uc *AuthUseCase) string {
+		result, err := uc.Login(ctx, models.LoginRequest{Email: "test@example.com", Password: "password123"})
+		require.NoError(t, err)
+		return result.Token
+	}
+	signedToken, hmacToken := login(signed), login(hmac)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(signedToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", parsed.Method.Alg())
+	assert.Equal(t, keys.PublicKeys().Keys[0].KeyID, parsed.Header["kid"])
+	assert.Len(t, signed.PublicKeys().Keys, 1)
+	assert.Empty(t, hmac.PublicKeys().Keys)
+
+	_, err = signed.ValidateToken(context.Background(), signedToken)
+	assert.NoError(t, err)
+	_, err = hmac.ValidateToken(context.Background(), hmacToken)
+	assert.NoError(t, err)
+
+	// Each accepts only the algorithm it signs with, so a leaked JWT
+	// secret can't forge tokens once keys are in use
+	_, err = signed.ValidateToken(context.Background(), hmacToken)
+	assert.Error(t, err)
+	_, err = hmac.ValidateToken(context.Background(), signedToken)
+	assert.Error(t, err)
+
+	// Tokens must expire
+	forever, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": models.NilID.Hex()}).SignedString([]byte("test-secret"))
+	require.NoError(t, err)
+	_, err = hmac.ValidateToken(context.Background(), forever)
+	assert.Error(t, err)
+}
+
 func TestGetDevelopers(t *testing.T) {
 	mockRepo := NewMockUserRepository()
-	authUseCase := NewAuthUseCase(mockRepo, "test-secret")
+	authUseCase := NewAuthUseCase(mockRepo, nil, "test-secret", nil, time.Hour, 0, LockoutPolicy{}, TwoFactorPolicy{}, SSOPolicy{})
 
 	// Register some test developers
 	developers := []models.RegisterRequest{
@@ -232,3 +343,105 @@ func TestGetDevelopers(t *testing.T) {
 		assert.Nil(t, devs)
 	})
 }
+
+func TestResetPassword(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	authUseCase := NewAuthUseCase(mockRepo, nil, "test-secret", nil, time.Hour, 0, LockoutPolicy{}, TwoFactorPolicy{}, SSOPolicy{})
+
+	req := models.RegisterRequest{
+		Email:    "test@example.com",
+		Password: "password123",
+		Name:     "Test User",
+		Role:     "admin",
+	}
+	_, _ = authUseCase.Register(context.Background(), req)
+
+	t.Run("successful reset", func(t *testing.T) {
+		err := authUseCase.ResetPassword(context.Background(), "test@example.com", "new-password")
+		assert.NoError(t, err)
+
+		_, err = authUseCase.Login(context.Background(), models.LoginRequest{Email: "test@example.com", Password: "new-password"})
+		assert.NoError(t, err)
+
+		_, err = authUseCase.Login(context.Background(), models.LoginRequest{Email: "test@example.com", Password: "password123"})
+		assert.Equal(t, ErrInvalidPassword, err)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		err := authUseCase.ResetPassword(context.Background(), "nobody@example.com", "new-password")
+		assert.Equal(t, ErrUserNotFound, err)
+	})
+
+	t.Run("password too short", func(t *testing.T) {
+		err := authUseCase.ResetPassword(context.Background(), "test@example.com", "123")
+		assert.Equal(t, ErrPasswordTooShort, err)
+	})
+}
+
+func TestLoginLockout(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	policy := LockoutPolicy{Threshold: 3, Duration: time.Minute, MaxDuration: 10 * time.Minute}
+	authUseCase := NewAuthUseCase(mockRepo, nil, "test-secret", nil, time.Hour, 0, policy, TwoFactorPolicy{}, SSOPolicy{})
+
+	ctx := context.Background()
+	registered, err := authUseCase.Register(ctx, models.RegisterRequest{
+		Email:    "test@example.com",
+		Password: "password123",
+		Name:     "Test User",
+		Role:     "developer",
+	})
+	require.NoError(t, err)
+
+	login := func(password string) error {
+		_, err := authUseCase.Login(ctx, models.LoginRequest{Email: "test@example.com", Password: password})
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		assert.ErrorIs(t, login("wrongpassword"), ErrInvalidPassword)
+	}
+	// The third failure locks the account, and then even the right
+	// password is refused
+	assert.ErrorIs(t, login("wrongpassword"), ErrInvalidPassword)
+	err = login("password123")
+	var locked *AccountLockedError
+	require.ErrorAs(t, err, &locked)
+	assert.ErrorIs(t, err, ErrAccountLocked)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), locked.Until, 5*time.Second)
+
+	lockouts, err := authUseCase.ListLockouts(ctx)
+	require.NoError(t, err)
+	require.Len(t, lockouts, 1)
+	assert.Equal(t, 3, lockouts[0].FailedLogins)
+	assert.True(t, lockouts[0].Locked)
+
+	// Admins can clear the lock, after which logins work and reset the count
+	require.NoError(t, authUseCase.Unlock(ctx, registered.ID))
+	assert.ErrorIs(t, login("wrongpassword"), ErrInvalidPassword)
+	assert.NoError(t, login("password123"))
+
+	lockouts, err = authUseCase.ListLockouts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, lockouts)
+}
+
+func TestLockoutPolicy(t *testing.T) {
+	policy := LockoutPolicy{Threshold: 5, Duration: time.Minute, MaxDuration: 10 * time.Minute}
+
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 4, expected: 0},
+		{failures: 5, expected: time.Minute},
+		{failures: 6, expected: 2 * time.Minute},
+		{failures: 8, expected: 8 * time.Minute},
+		{failures: 9, expected: 10 * time.Minute},
+		{failures: 100, expected: 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, policy.lockFor(tt.failures), "after %d failures", tt.failures)
+	}
+
+	assert.Zero(t, LockoutPolicy{}.lockFor(100), "a zero threshold disables lockout")
+}
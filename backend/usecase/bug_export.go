@@ -0,0 +1,336 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"bug-tracker/models"
+)
+
+var (
+	ErrInvalidExportFormat = errors.New("export format must be csv or ndjson")
+	ErrInvalidExportColumn = errors.New("unknown export column")
+)
+
+// exportFlushEvery is how many rows are buffered before flushing to the client
+const exportFlushEvery = 100
+
+var exportColumnValues = map[string]func(*models.BugResponse) string{
+	"id":             func(b *models.BugResponse) string { return b.ID.Hex() },
+	"title":          func(b *models.BugResponse) string { return b.Title },
+	"description":    func(b *models.BugResponse) string { return b.Description },
+	"status":         func(b *models.BugResponse) string { return b.Status },
+	"priority":       func(b *models.BugResponse) string { return b.Priority },
+	"reporter_id":    func(b *models.BugResponse) string { return b.ReportedBy.ID.Hex() },
+	"reporter_name":  func(b *models.BugResponse) string { return b.ReportedBy.Name },
+	"reporter_email": func(b *models.BugResponse) string { return b.ReportedBy.Email },
+	"assignee_id": func(b *models.BugResponse) string {
+		if b.AssignedTo == nil {
+			return ""
+		}
+		return b.AssignedTo.ID.Hex()
+	},
+	"assignee_name": func(b *models.BugResponse) string {
+		if b.AssignedTo == nil {
+			return ""
+		}
+		return b.AssignedTo.Name
+	},
+	"assignee_email": func(b *models.BugResponse) string {
+		if b.AssignedTo == nil {
+			return ""
+		}
+		return b.AssignedTo.Email
+	},
+	"created_at": func(b *models.BugResponse) string { return b.CreatedAt.UTC().Format(time.RFC3339) },
+	"updated_at": func(b *models.BugResponse) string { return b.UpdatedAt.UTC().Format(time.RFC3339) },
+	"resolved_at": func(b *models.BugResponse) string {
+		if b.ResolvedAt == nil {
+			return ""
+		}
+		return b.ResolvedAt.UTC().Format(time.RFC3339)
+	},
+	"labels":           func(b *models.BugResponse) string { return strings.Join(b.Labels, ", ") },
+	"affected_version": func(b *models.BugResponse) string { return milestoneName(b.AffectedVersion) },
+	"fix_version":      func(b *models.BugResponse) string { return milestoneName(b.FixVersion) },
+}
+
+// exportColumnValue returns the value of column, a key of
+// exportColumnValues or a custom field key with FieldPrefix
+func exportColumnValue(column string, b *models.BugResponse) string {
+	if key, ok := strings.CutPrefix(column, models.FieldPrefix); ok {
+		return exportFieldValue(b.Fields[key])
+	}
+	return exportColumnValues[column](b)
+}
+
+// exportFieldValue formats a custom field value as the bug response holds
+// it, with multi-select options listed like labels
+func exportFieldValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		return strings.Join(v, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func milestoneName(ref *models.MilestoneRef) string {
+	if ref == nil {
+		return ""
+	}
+	return ref.Name
+}
+
+// NormalizeExportOptions validates opts and fills in the default columns.
+// Custom field columns are only checked for their form here;
+// CheckExportColumns checks that the fields exist.
+func NormalizeExportOptions(opts *models.ExportOptions) error {
+	if opts.Format == "" {
+		opts.Format = "csv"
+	}
+	if opts.Format != "csv" && opts.Format != "ndjson" {
+		return ErrInvalidExportFormat
+	}
+
+	if len(opts.Columns) == 0 {
+		opts.Columns = models.DefaultExportColumns
+	}
+	for _, column := range opts.Columns {
+		if key, ok := strings.CutPrefix(column, models.FieldPrefix); ok && key != "" {
+			continue
+		}
+		if _, ok := exportColumnValues[column]; !ok {
+			return ErrInvalidExportColumn
+		}
+	}
+
+	return nil
+}
+
+// CheckExportColumns checks custom field columns against the field
+// definitions
+func (uc *BugUseCase) CheckExportColumns(ctx context.Context, columns []string) error {
+	var fields []*models.CustomField
+	for _, column := range columns {
+		key, ok := strings.CutPrefix(column, models.FieldPrefix)
+		if !ok {
+			continue
+		}
+		if fields == nil {
+			var err error
+			if fields, err = uc.fieldRepo.FindAll(ctx); err != nil {
+				return err
+			}
+		}
+		if findField(fields, key) == nil {
+			return fmt.Errorf("%w %q", ErrInvalidExportColumn, column)
+		}
+	}
+	return nil
+}
+
+// ExportBugs writes every bug matching filter to w in the requested format.
+// Rows are written as they are read from the repository, so once the first
+// row is out a failure leaves a truncated export rather than an error page.
+func (uc *BugUseCase) ExportBugs(ctx context.Context, filter models.BugFilter, opts models.ExportOptions, w io.Writer) (err error) {
+	ctx, span := tracer.Start(ctx, "BugUseCase.ExportBugs")
+	defer func() { endSpan(span, err) }()
+
+	if err := NormalizeExportOptions(&opts); err != nil {
+		return err
+	}
+	if err := uc.CheckExportColumns(ctx, opts.Columns); err != nil {
+		return err
+	}
+	if err := uc.NormalizeFilter(ctx, &filter); err != nil {
+		return err
+	}
+
+	var enc rowEncoder
+	if opts.Format == "csv" {
+		if opts.BOM {
+			if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+				return err
+			}
+		}
+		enc = &csvRowEncoder{w: csv.NewWriter(w), columns: opts.Columns}
+	} else {
+		enc = &ndjsonRowEncoder{enc: json.NewEncoder(w), columns: opts.Columns}
+	}
+
+	if err := enc.header(); err != nil {
+		return err
+	}
+
+	// Reporters and assignees repeat heavily across bugs, so resolve each once
+	users := make(map[models.ID]*models.User)
+	lookup := func(id models.ID) (*models.User, error) {
+		if user, ok := users[id]; ok {
+			return user, nil
+		}
+		user, err := uc.userRepo.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		users[id] = user
+		return user, nil
+	}
+	milestones := make(map[models.ID]*models.MilestoneRef)
+	milestoneRef := func(id models.ID) (*models.MilestoneRef, error) {
+		if ref, ok := milestones[id]; ok {
+			return ref, nil
+		}
+		ref, err := uc.milestoneRef(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		milestones[id] = ref
+		return ref, nil
+	}
+
+	rows := 0
+	err = uc.bugRepo.Stream(ctx, filter, func(bug *models.Bug) error {
+		response := exportBugResponse(bug)
+
+		reporter, err := lookup(bug.ReportedBy)
+		if err != nil {
+			return err
+		}
+		if reporter != nil {
+			response.ReportedBy = reporter.ToResponse()
+		}
+
+		if !bug.AssignedTo.IsZero() {
+			assignee, err := lookup(bug.AssignedTo)
+			if err != nil {
+				return err
+			}
+			if assignee != nil {
+				assigneeResponse := assignee.ToResponse()
+				response.AssignedTo = &assigneeResponse
+			}
+		}
+
+		if response.AffectedVersion, err = milestoneRef(bug.AffectedVersion); err != nil {
+			return err
+		}
+		if response.FixVersion, err = milestoneRef(bug.FixVersion); err != nil {
+			return err
+		}
+
+		if err := enc.row(response); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushEvery == 0 {
+			return enc.flush(w)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return enc.flush(w)
+}
+
+func exportBugResponse(bug *models.Bug) *models.BugResponse {
+	return &models.BugResponse{
+		ID:          bug.ID,
+		Title:       bug.Title,
+		Description: bug.Description,
+		Status:      bug.Status,
+		Priority:    bug.Priority,
+		ReportedBy:  models.UserResponse{ID: bug.ReportedBy},
+		CreatedAt:   bug.CreatedAt,
+		UpdatedAt:   bug.UpdatedAt,
+		ResolvedAt:  bug.ResolvedAt,
+		Fields:      bug.Fields,
+		Labels:      bug.Labels,
+	}
+}
+
+type rowEncoder interface {
+	header() error
+	row(bug *models.BugResponse) error
+	flush(w io.Writer) error
+}
+
+type csvRowEncoder struct {
+	w       *csv.Writer
+	columns []string
+}
+
+func (e *csvRowEncoder) header() error {
+	return e.w.Write(e.columns)
+}
+
+func (e *csvRowEncoder) row(bug *models.BugResponse) error {
+	record := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		record[i] = escapeSpreadsheetFormula(exportColumnValue(column, bug))
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvRowEncoder) flush(w io.Writer) error {
+	e.w.Flush()
+	if err := e.w.Error(); err != nil {
+		return err
+	}
+	flushWriter(w)
+	return nil
+}
+
+type ndjsonRowEncoder struct {
+	enc     *json.Encoder
+	columns []string
+}
+
+func (e *ndjsonRowEncoder) header() error {
+	return nil
+}
+
+func (e *ndjsonRowEncoder) row(bug *models.BugResponse) error {
+	record := make(map[string]string, len(e.columns))
+	for _, column := range e.columns {
+		record[column] = exportColumnValue(column, bug)
+	}
+	return e.enc.Encode(record)
+}
+
+func (e *ndjsonRowEncoder) flush(w io.Writer) error {
+	flushWriter(w)
+	return nil
+}
+
+// flushWriter pushes buffered bytes to the client when w is an HTTP response
+func flushWriter(w io.Writer) {
+	if f, ok := w.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+}
+
+// escapeSpreadsheetFormula stops spreadsheet applications from evaluating
+// user-supplied text such as "=HYPERLINK(...)" as a formula.
+func escapeSpreadsheetFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"bug-tracker/models"
+	"bug-tracker/repository/memory"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupExportUseCase(t *testing.T) (*BugUseCase, *models.User, *models.User) {
+	mockBugRepo := NewMockBugRepository()
+	mockUserRepo := NewMockUserRepository()
+
+	reporter := &models.User{ID: models.NewID(), Name: "Reporter, Jr.", Email: "reporter@example.com", Role: "manager"}
+	developer := &models.User{ID: models.NewID(), Name: "Dev", Email: "dev@example.com", Role: "developer"}
+	require.NoError(t, mockUserRepo.Create(context.Background(), reporter))
+	require.NoError(t, mockUserRepo.Create(context.Background(), developer))
+
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	bugs := []*models.Bug{
+		{Title: `Crash on "save"`, Description: "line one\nline two", Status: "open", Priority: "high", ReportedBy: reporter.ID, AssignedTo: developer.ID, CreatedAt: created, UpdatedAt: created},
+		{Title: "=HYPERLINK(\"http://evil\")", Status: "resolved", Priority: "low", ReportedBy: reporter.ID, CreatedAt: created, UpdatedAt: created},
+	}
+	for _, bug := range bugs {
+		require.NoError(t, mockBugRepo.Create(context.Background(), bug))
+	}
+
+	return NewBugUseCase(mockBugRepo, mockUserRepo, memory.NewCustomFieldRepository(), memory.NewBugTemplateRepository(), memory.NewMilestoneRepository(), nil), reporter, developer
+}
+
+func TestExportBugsCSV(t *testing.T) {
+	bugUseCase, _, _ := setupExportUseCase(t)
+
+	var buf bytes.Buffer
+	err := bugUseCase.ExportBugs(context.Background(), models.BugFilter{Status: "open"}, models.ExportOptions{
+		Format:  "csv",
+		Columns: []string{"title", "description", "reporter_name", "assignee_email", "created_at"},
+	}, &buf)
+	assert.NoError(t, err)
+
+	expected := "title,description,reporter_name,assignee_email,created_at\n" +
+		"\"Crash on \"\"save\"\"\",\"line one\nline two\",\"Reporter, Jr.\",dev@example.com,2024-03-01T12:00:00Z\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestExportBugsEscapesFormulas(t *testing.T) {
+	bugUseCase, _, _ := setupExportUseCase(t)
+
+	var buf bytes.Buffer
+	err := bugUseCase.ExportBugs(context.Background(), models.BugFilter{Status: "resolved"}, models.ExportOptions{
+		Columns: []string{"title", "assignee_name"},
+		BOM:     true,
+	}, &buf)
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(buf.String(), "\uFEFF"))
+	assert.Contains(t, buf.String(), `"'=HYPERLINK(""http://evil"")",`)
+}
+
+func TestExportBugsNDJSON(t *testing.T) {
+	bugUseCase, _, developer := setupExportUseCase(t)
+
+	var buf bytes.Buffer
+	err := bugUseCase.ExportBugs(context.Background(), models.BugFilter{}, models.ExportOptions{
+		Format:  "ndjson",
+		Columns: []string{"title", "assignee_id"},
+	}, &buf)
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var rows []map[string]string
+	for _, line := range lines {
+		var row map[string]string
+		require.NoError(t, json.Unmarshal([]byte(line), &row))
+		rows = append(rows, row)
+	}
+	assert.ElementsMatch(t, []map[string]string{
+		{"title": `Crash on "save"`, "assignee_id": developer.ID.Hex()},
+		{"title": "=HYPERLINK(\"http://evil\")", "assignee_id": ""},
+	}, rows)
+}
+
+func TestExportBugsCustomFields(t *testing.T) {
+	ctx := context.Background()
+	mockBugRepo := NewMockBugRepository()
+	mockUserRepo := NewMockUserRepository()
+	fieldRepo := memory.NewCustomFieldRepository()
+
+	reporter := &models.User{ID: models.NewID(), Name: "Reporter", Role: "manager"}
+	require.NoError(t, mockUserRepo.Create(ctx, reporter))
+	for _, field := range []*models.CustomField{
+		{Key: "environment", Name: "Environment", Type: models.FieldEnum, Options: []string{"staging", "production"}},
+		{Key: "browsers", Name: "Browsers", Type: models.FieldMultiSelect, Options: []string{"Chrome", "Firefox"}},
+		{Key: "estimate", Name: "Estimate", Type: models.FieldNumber},
+	} {
+		require.NoError(t, fieldRepo.Save(ctx, field))
+	}
+	require.NoError(t, mockBugRepo.Create(ctx, &models.Bug{
+		Title:      "Crash",
+		ReportedBy: reporter.ID,
+		Fields: models.FieldValues{
+			"environment": "production",
+			"browsers":    []string{"Chrome", "Firefox"},
+			"estimate":    2.5,
+		},
+	}))
+	require.NoError(t, mockBugRepo.Create(ctx, &models.Bug{Title: "No fields", ReportedBy: reporter.ID}))
+	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo, fieldRepo, memory.NewBugTemplateRepository(), memory.NewMilestoneRepository(), nil)
+
+	var buf bytes.Buffer
+	err := bugUseCase.ExportBugs(ctx, models.BugFilter{}, models.ExportOptions{
+		Columns: []string{"title", "fields.environment", "fields.browsers", "fields.estimate"},
+	}, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, "title,fields.environment,fields.browsers,fields.estimate\n"+
+		"Crash,production,\"Chrome, Firefox\",2.5\n"+
+		"No fields,,,\n", buf.String())
+
+	// Fields must be defined, and removed ones can't be exported
+	buf.Reset()
+	err = bugUseCase.ExportBugs(ctx, models.BugFilter{}, models.ExportOptions{Columns: []string{"title", "fields.severity"}}, &buf)
+	assert.ErrorIs(t, err, ErrInvalidExportColumn)
+	assert.Empty(t, buf.String())
+
+	err = bugUseCase.ExportBugs(ctx, models.BugFilter{}, models.ExportOptions{Columns: []string{"fields."}}, &buf)
+	assert.Equal(t, ErrInvalidExportColumn, err)
+}
+
+func TestExportBugsInvalidOptions(t *testing.T) {
+	bugUseCase, _, _ := setupExportUseCase(t)
+
+	var buf bytes.Buffer
+	err := bugUseCase.ExportBugs(context.Background(), models.BugFilter{}, models.ExportOptions{Format: "xlsx"}, &buf)
+	assert.Equal(t, ErrInvalidExportFormat, err)
+
+	err = bugUseCase.ExportBugs(context.Background(), models.BugFilter{}, models.ExportOptions{Columns: []string{"password"}}, &buf)
+	assert.Equal(t, ErrInvalidExportColumn, err)
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,249 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"bug-tracker/logging"
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// BugTemplateUseCaseInterface defines the interface for editing bug templates
+type BugTemplateUseCaseInterface interface {
+	ListTemplates(ctx context.Context) ([]*models.BugTemplate, error)
+	GetTemplate(ctx context.Context, id models.ID) (*models.BugTemplate, error)
+	CreateTemplate(ctx context.Context, req models.SaveBugTemplateRequest) (*models.BugTemplate, error)
+	UpdateTemplate(ctx context.Context, id models.ID, req models.SaveBugTemplateRequest) (*models.BugTemplate, error)
+	DeleteTemplate(ctx context.Context, id models.ID) error
+}
+
+var (
+	ErrTemplateNotFound = errors.New("bug template not found")
+	// ErrInvalidTemplate is returned for required sections the template's
+	// description has no heading for
+	ErrInvalidTemplate = errors.New("invalid bug template")
+	ErrInvalidLabel    = errors.New("labels are 1 to 50 characters, at most 20 per bug")
+	// ErrMissingSections is returned for bugs reported from a template that
+	// leave required sections empty
+	ErrMissingSections = errors.New("required sections are empty")
+	// ErrIncompleteBug is returned for bugs without a description or
+	// priority their template could fill in
+	ErrIncompleteBug = errors.New("description and priority are required")
+)
+
+const (
+	maxLabelLength = 50
+	maxLabels      = 20
+)
+
+// sectionHeading matches a Markdown ATX heading, capturing its text
+var sectionHeading = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*\s*$`)
+
+type BugTemplateUseCase struct {
+	templateRepo repository.BugTemplateRepositoryInterface
+}
+
+func NewBugTemplateUseCase(templateRepo repository.BugTemplateRepositoryInterface) *BugTemplateUseCase {
+	return &BugTemplateUseCase{templateRepo: templateRepo}
+}
+
+// ListTemplates returns every template ordered by name
+func (uc *BugTemplateUseCase) ListTemplates(ctx context.Context) (_ []*models.BugTemplate, err error) {
+	ctx, span := tracer.Start(ctx, "BugTemplateUseCase.ListTemplates")
+	defer func() { endSpan(span, err) }()
+
+	templates, err := uc.templateRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if templates == nil {
+		templates = []*models.BugTemplate{}
+	}
+	return templates, nil
+}
+
+func (uc *BugTemplateUseCase) GetTemplate(ctx context.Context, id models.ID) (_ *models.BugTemplate, err error) {
+	ctx, span := tracer.Start(ctx, "BugTemplateUseCase.GetTemplate")
+	defer func() { endSpan(span, err) }()
+
+	template, err := uc.templateRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, ErrTemplateNotFound
+	}
+	return template, nil
+}
+
+func (uc *BugTemplateUseCase) CreateTemplate(ctx context.Context, req models.SaveBugTemplateRequest) (_ *models.BugTemplate, err error) {
+	ctx, span := tracer.Start(ctx, "BugTemplateUseCase.CreateTemplate")
+	defer func() { endSpan(span, err) }()
+
+	template := &models.BugTemplate{}
+	if err := fillTemplate(template, req); err != nil {
+		return nil, err
+	}
+	if err := uc.templateRepo.Create(ctx, template); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("bug template created", "template_id", template.ID.Hex(), "name", template.Name)
+	return template, nil
+}
+
+func (uc *BugTemplateUseCase) UpdateTemplate(ctx context.Context, id models.ID, req models.SaveBugTemplateRequest) (_ *models.BugTemplate, err error) {
+	ctx, span := tracer.Start(ctx, "BugTemplateUseCase.UpdateTemplate")
+	defer func() { endSpan(span, err) }()
+
+	template, err := uc.templateRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, ErrTemplateNotFound
+	}
+	if err := fillTemplate(template, req); err != nil {
+		return nil, err
+	}
+	if err := uc.templateRepo.Update(ctx, template); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("bug template updated", "template_id", id.Hex())
+	return template, nil
+}
+
+// DeleteTemplate deletes a template. Bugs reported from it are unaffected.
+func (uc *BugTemplateUseCase) DeleteTemplate(ctx context.Context, id models.ID) (err error) {
+	ctx, span := tracer.Start(ctx, "BugTemplateUseCase.DeleteTemplate")
+	defer func() { endSpan(span, err) }()
+
+	template, err := uc.templateRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if template == nil {
+		return ErrTemplateNotFound
+	}
+	if err := uc.templateRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("bug template deleted", "template_id", id.Hex())
+	return nil
+}
+
+// fillTemplate validates req and copies it to template
+func fillTemplate(template *models.BugTemplate, req models.SaveBugTemplateRequest) error {
+	sections := parseSections(req.Description)
+	required := []string{}
+	for _, name := range req.RequiredSections {
+		name = strings.TrimSpace(name)
+		if _, ok := sections[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("%w: the description has no %q section", ErrInvalidTemplate, name)
+		}
+		required = append(required, name)
+	}
+	labels, err := normalizeLabels(req.Labels)
+	if err != nil {
+		return err
+	}
+
+	template.Name = strings.TrimSpace(req.Name)
+	template.TitlePrefix = strings.TrimSpace(req.TitlePrefix)
+	template.Description = req.Description
+	template.RequiredSections = required
+	template.Priority = req.Priority
+	template.Labels = labels
+	return nil
+}
+
+// parseSections splits a Markdown description into the text under each
+// heading, keyed by the lowercased heading
+func parseSections(description string) map[string]string {
+	sections := make(map[string]string)
+	var heading string
+	var body []string
+	flush := func() {
+		if heading != "" {
+			sections[heading] = strings.TrimSpace(strings.Join(body, "\n"))
+		}
+	}
+	for _, line := range strings.Split(description, "\n") {
+		if m := sectionHeading.FindStringSubmatch(strings.TrimRight(line, "\r")); m != nil {
+			flush()
+			heading, body = strings.ToLower(m[1]), nil
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+	return sections
+}
+
+// missingSections returns the required sections of template that
+// description leaves empty or as the template had them
+func missingSections(template *models.BugTemplate, description string) []string {
+	skeleton := parseSections(template.Description)
+	filled := parseSections(description)
+	var missing []string
+	for _, name := range template.RequiredSections {
+		key := strings.ToLower(name)
+		if text := filled[key]; text == "" || text == skeleton[key] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// normalizeLabels trims labels and drops blanks and duplicates
+func normalizeLabels(labels []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool)
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" || seen[label] {
+			continue
+		}
+		if utf8.RuneCountInString(label) > maxLabelLength {
+			return nil, ErrInvalidLabel
+		}
+		seen[label] = true
+		normalized = append(normalized, label)
+	}
+	if len(normalized) > maxLabels {
+		return nil, ErrInvalidLabel
+	}
+	return normalized, nil
+}
+
+// applyTemplate fills in what req leaves out from the template it names
+// and checks the description fills the template's required sections
+func (uc *BugUseCase) applyTemplate(ctx context.Context, req *models.CreateBugRequest) error {
+	template, err := uc.templateRepo.FindByID(ctx, req.TemplateID)
+	if err != nil {
+		return err
+	}
+	if template == nil {
+		return ErrTemplateNotFound
+	}
+
+	if template.TitlePrefix != "" && !strings.HasPrefix(req.Title, template.TitlePrefix) {
+		req.Title = template.TitlePrefix + " " + req.Title
+	}
+	if strings.TrimSpace(req.Description) == "" {
+		req.Description = template.Description
+	}
+	if req.Priority == "" {
+		req.Priority = template.Priority
+	}
+	req.Labels = append(append([]string{}, template.Labels...), req.Labels...)
+
+	if missing := missingSections(template, req.Description); len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingSections, strings.Join(missing, ", "))
+	}
+	return nil
+}
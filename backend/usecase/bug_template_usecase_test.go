@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"bug-tracker/models"
+	"bug-tracker/repository/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const crashSkeleton = `## Steps to reproduce
+1.
+
+## Expected
+
+## Actual
+
+## Notes
+`
+
+func newTestTemplateUseCases(t *testing.T) (*BugTemplateUseCase, *BugUseCase, *models.User) {
+	t.Helper()
+	templateRepo := memory.NewBugTemplateRepository()
+	userRepo := memory.NewUserRepository()
+	reporter := &models.User{Name: "Re Porter", Email: "reporter@example.com", Role: models.RoleDeveloper}
+	require.NoError(t, userRepo.Create(context.Background(), reporter))
+
+	bugs := NewBugUseCase(memory.NewBugRepository(), userRepo, memory.NewCustomFieldRepository(), templateRepo, memory.NewMilestoneRepository(), nil)
+	return NewBugTemplateUseCase(templateRepo), bugs, reporter
+}
+
+func TestSaveTemplate(t *testing.T) {
+	ctx := context.Background()
+	uc, _, _ := newTestTemplateUseCases(t)
+
+	template, err := uc.CreateTemplate(ctx, models.SaveBugTemplateRequest{
+		Name:             " Crash ",
+		TitlePrefix:      "[Crash]",
+		Description:      crashSkeleton,
+		RequiredSections: []string{"Steps to reproduce", "expected"},
+		Priority:         "high",
+		Labels:           []string{"crash", " crash", ""},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Crash", template.Name)
+	assert.Equal(t, []string{"crash"}, template.Labels)
+
+	_, err = uc.CreateTemplate(ctx, models.SaveBugTemplateRequest{
+		Name:             "Crash",
+		Description:      crashSkeleton,
+		RequiredSections: []string{"Stack trace"},
+	})
+	assert.True(t, errors.Is(err, ErrInvalidTemplate))
+	assert.Contains(t, err.Error(), "Stack trace")
+
+	updated, err := uc.UpdateTemplate(ctx, template.ID, models.SaveBugTemplateRequest{Name: "Crash report", Description: crashSkeleton})
+	require.NoError(t, err)
+	assert.Equal(t, "Crash report", updated.Name)
+	assert.Empty(t, updated.RequiredSections)
+
+	_, err = uc.UpdateTemplate(ctx, models.NewID(), models.SaveBugTemplateRequest{Name: "Crash", Description: crashSkeleton})
+	assert.Equal(t, ErrTemplateNotFound, err)
+
+	require.NoError(t, uc.DeleteTemplate(ctx, template.ID))
+	assert.Equal(t, ErrTemplateNotFound, uc.DeleteTemplate(ctx, template.ID))
+	templates, err := uc.ListTemplates(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, templates)
+}
+
+func TestCreateBugFromTemplate(t *testing.T) {
+	ctx := context.Background()
+	templates, bugs, reporter := newTestTemplateUseCases(t)
+	template, err := templates.CreateTemplate(ctx, models.SaveBugTemplateRequest{
+		Name:             "Crash",
+		TitlePrefix:      "[Crash]",
+		Description:      crashSkeleton,
+		RequiredSections: []string{"Steps to reproduce", "Expected", "Actual"},
+		Priority:         "high",
+		Labels:           []string{"crash"},
+	})
+	require.NoError(t, err)
+
+	t.Run("fills in defaults", func(t *testing.T) {
+		bug, err := bugs.CreateBug(ctx, models.CreateBugRequest{
+			TemplateID:  template.ID,
+			Title:       "App closes on save",
+			Description: "## Steps to reproduce\n1. Save\n\n## Expected\nSaved\n\n## Actual\nThe app closes\n",
+			Labels:      []string{"editor", "crash"},
+		}, reporter.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "[Crash] App closes on save", bug.Title)
+		assert.Equal(t, "high", bug.Priority)
+		assert.Equal(t, []string{"crash", "editor"}, bug.Labels)
+	})
+
+	t.Run("request overrides defaults", func(t *testing.T) {
+		bug, err := bugs.CreateBug(ctx, models.CreateBugRequest{
+			TemplateID:  template.ID,
+			Title:       "[Crash] Closes on export",
+			Description: "### steps to reproduce\n1. Export\n### expected\nA file\n### actual\nNothing",
+			Priority:    "critical",
+		}, reporter.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "[Crash] Closes on export", bug.Title)
+		assert.Equal(t, "critical", bug.Priority)
+	})
+
+	t.Run("required sections must be filled in", func(t *testing.T) {
+		_, err := bugs.CreateBug(ctx, models.CreateBugRequest{
+			TemplateID:  template.ID,
+			Title:       "Crash",
+			Description: "## Steps to reproduce\n1.\n\n## Expected\nNo crash\n",
+		}, reporter.ID)
+		assert.True(t, errors.Is(err, ErrMissingSections))
+		assert.Contains(t, err.Error(), "Steps to reproduce, Actual")
+
+		// An empty description gets the bare skeleton
+		_, err = bugs.CreateBug(ctx, models.CreateBugRequest{TemplateID: template.ID, Title: "Crash"}, reporter.ID)
+		assert.True(t, errors.Is(err, ErrMissingSections))
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		_, err := bugs.CreateBug(ctx, models.CreateBugRequest{
+			TemplateID: models.NewID(), Title: "Crash", Description: "It crashed", Priority: "low",
+		}, reporter.ID)
+		assert.Equal(t, ErrTemplateNotFound, err)
+	})
+
+	t.Run("without a template", func(t *testing.T) {
+		_, err := bugs.CreateBug(ctx, models.CreateBugRequest{Title: "Crash", Description: "It crashed"}, reporter.ID)
+		assert.Equal(t, ErrIncompleteBug, err)
+
+		labels := make([]string, maxLabels+1)
+		for i := range labels {
+			labels[i] = string(rune('a' + i))
+		}
+		_, err = bugs.CreateBug(ctx, models.CreateBugRequest{
+			Title: "Crash", Description: "It crashed", Priority: "low", Labels: labels,
+		}, reporter.ID)
+		assert.Equal(t, ErrInvalidLabel, err)
+	})
+}
+
+func TestParseSections(t *testing.T) {
+	sections := parseSections("Intro\n# Summary #\nShort\n\n## Steps\r\n1. Open\n2. Close\n##Not a heading\n## Empty\n")
+	assert.Equal(t, map[string]string{
+		"summary": "Short",
+		"steps":   "1. Open\n2. Close\n##Not a heading",
+		"empty":   "",
+	}, sections)
+}
@@ -1,60 +1,118 @@
 package usecase
 
 import (
+	"bug-tracker/authz"
+	"bug-tracker/logging"
 	"bug-tracker/models"
 	"bug-tracker/repository"
 	"context"
 	"errors"
-
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"strings"
+	"time"
 )
 
 var (
 	ErrBugNotFound  = errors.New("bug not found")
 	ErrUnauthorized = errors.New("unauthorized action")
+	// ErrNotAssignable is returned for assignees whose role doesn't let
+	// them be assigned bugs
+	ErrNotAssignable = errors.New("user can't be assigned bugs")
 )
 
 // BugUseCaseInterface defines the interface for bug use cases
 type BugUseCaseInterface interface {
-	CreateBug(ctx context.Context, req models.CreateBugRequest, reporterID primitive.ObjectID) (*models.BugResponse, error)
-	GetBugByID(ctx context.Context, id primitive.ObjectID) (*models.BugResponse, error)
+	CreateBug(ctx context.Context, req models.CreateBugRequest, reporterID models.ID) (*models.BugResponse, error)
+	GetBugByID(ctx context.Context, id models.ID) (*models.BugResponse, error)
 	GetAllBugs(ctx context.Context) ([]*models.BugResponse, error)
-	GetBugsByDeveloper(ctx context.Context, developerID primitive.ObjectID) ([]*models.BugResponse, error)
-	UpdateBugStatus(ctx context.Context, bugID primitive.ObjectID, status string, userID primitive.ObjectID) (*models.BugResponse, error)
-	AssignBug(ctx context.Context, bugID, developerID primitive.ObjectID) (*models.BugResponse, error)
-	UpdateBug(ctx context.Context, id primitive.ObjectID, req models.UpdateBugRequest, user *models.User) (*models.BugResponse, error)
-	DeleteBug(ctx context.Context, id primitive.ObjectID) error
+	ListBugs(ctx context.Context, filter models.BugFilter) ([]*models.BugResponse, error)
+	NormalizeFilter(ctx context.Context, filter *models.BugFilter) error
+	GetBugsByDeveloper(ctx context.Context, developerID models.ID) ([]*models.BugResponse, error)
+	UpdateBugStatus(ctx context.Context, bugID models.ID, status string, user *models.User) (*models.BugResponse, error)
+	AssignBug(ctx context.Context, bugID, developerID models.ID) (*models.BugResponse, error)
+	UpdateBug(ctx context.Context, id models.ID, req models.UpdateBugRequest, user *models.User) (*models.BugResponse, error)
+	DeleteBug(ctx context.Context, id models.ID) error
+	CheckExportColumns(ctx context.Context, columns []string) error
+	ExportBugs(ctx context.Context, filter models.BugFilter, opts models.ExportOptions, w io.Writer) error
+	SearchBugs(ctx context.Context, search models.BugSearch) ([]*models.BugResponse, error)
 }
 
+const (
+	// DefaultSearchLimit is the number of results a search returns when the
+	// caller doesn't ask for a number
+	DefaultSearchLimit = 20
+	// MaxSearchLimit caps the number of results a single search returns
+	MaxSearchLimit = 100
+)
+
 type BugUseCase struct {
-	bugRepo  repository.BugRepositoryInterface
-	userRepo repository.UserRepositoryInterface
+	bugRepo       repository.BugRepositoryInterface
+	userRepo      repository.UserRepositoryInterface
+	fieldRepo     repository.CustomFieldRepositoryInterface
+	templateRepo  repository.BugTemplateRepositoryInterface
+	milestoneRepo repository.MilestoneRepositoryInterface
+	roles         *authz.Roles
 }
 
-func NewBugUseCase(bugRepo repository.BugRepositoryInterface, userRepo repository.UserRepositoryInterface) *BugUseCase {
+func NewBugUseCase(bugRepo repository.BugRepositoryInterface, userRepo repository.UserRepositoryInterface, fieldRepo repository.CustomFieldRepositoryInterface, templateRepo repository.BugTemplateRepositoryInterface, milestoneRepo repository.MilestoneRepositoryInterface, roles *authz.Roles) *BugUseCase {
 	return &BugUseCase{
-		bugRepo:  bugRepo,
-		userRepo: userRepo,
+		bugRepo:       bugRepo,
+		userRepo:      userRepo,
+		fieldRepo:     fieldRepo,
+		templateRepo:  templateRepo,
+		milestoneRepo: milestoneRepo,
+		roles:         roles,
 	}
 }
 
-func (uc *BugUseCase) CreateBug(ctx context.Context, req models.CreateBugRequest, reporterID primitive.ObjectID) (*models.BugResponse, error) {
+func (uc *BugUseCase) CreateBug(ctx context.Context, req models.CreateBugRequest, reporterID models.ID) (_ *models.BugResponse, err error) {
+	ctx, span := tracer.Start(ctx, "BugUseCase.CreateBug")
+	defer func() { endSpan(span, err) }()
+
+	if !req.TemplateID.IsZero() {
+		if err := uc.applyTemplate(ctx, &req); err != nil {
+			return nil, err
+		}
+	}
+	if strings.TrimSpace(req.Description) == "" || req.Priority == "" {
+		return nil, ErrIncompleteBug
+	}
+	labels, err := normalizeLabels(req.Labels)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := uc.applyFieldValues(ctx, nil, req.Fields, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.checkMilestones(ctx, req.AffectedVersion, req.FixVersion); err != nil {
+		return nil, err
+	}
+
 	bug := &models.Bug{
-		Title:       req.Title,
-		Description: req.Description,
-		Priority:    req.Priority,
-		ReportedBy:  reporterID,
-		Status:      "open",
+		Title:           req.Title,
+		Description:     req.Description,
+		Priority:        req.Priority,
+		ReportedBy:      reporterID,
+		Status:          "open",
+		Fields:          fields,
+		Labels:          labels,
+		AffectedVersion: req.AffectedVersion,
+		FixVersion:      req.FixVersion,
 	}
 
 	if err := uc.bugRepo.Create(ctx, bug); err != nil {
 		return nil, err
 	}
+	logging.FromContext(ctx).Info("bug created", "bug_id", bug.ID.Hex(), "priority", bug.Priority)
 
 	return uc.getBugResponse(ctx, bug)
 }
 
-func (uc *BugUseCase) GetAllBugs(ctx context.Context) ([]*models.BugResponse, error) {
+func (uc *BugUseCase) GetAllBugs(ctx context.Context) (_ []*models.BugResponse, err error) {
+	ctx, span := tracer.Start(ctx, "BugUseCase.GetAllBugs")
+	defer func() { endSpan(span, err) }()
+
 	bugs, err := uc.bugRepo.FindAll(ctx)
 	if err != nil {
 		return nil, err
@@ -72,7 +130,35 @@ func (uc *BugUseCase) GetAllBugs(ctx context.Context) ([]*models.BugResponse, er
 	return responses, nil
 }
 
-func (uc *BugUseCase) GetBugsByDeveloper(ctx context.Context, developerID primitive.ObjectID) ([]*models.BugResponse, error) {
+// ListBugs returns the bugs matching filter in the order it asks for
+func (uc *BugUseCase) ListBugs(ctx context.Context, filter models.BugFilter) (_ []*models.BugResponse, err error) {
+	ctx, span := tracer.Start(ctx, "BugUseCase.ListBugs")
+	defer func() { endSpan(span, err) }()
+
+	if err := uc.NormalizeFilter(ctx, &filter); err != nil {
+		return nil, err
+	}
+
+	responses := []*models.BugResponse{}
+	err = uc.bugRepo.Stream(ctx, filter, func(bug *models.Bug) error {
+		response, err := uc.getBugResponse(ctx, bug)
+		if err != nil {
+			return err
+		}
+		responses = append(responses, response)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return responses, nil
+}
+
+func (uc *BugUseCase) GetBugsByDeveloper(ctx context.Context, developerID models.ID) (_ []*models.BugResponse, err error) {
+	ctx, span := tracer.Start(ctx, "BugUseCase.GetBugsByDeveloper")
+	defer func() { endSpan(span, err) }()
+
 	bugs, err := uc.bugRepo.FindByAssignee(ctx, developerID)
 	if err != nil {
 		return nil, err
@@ -90,7 +176,10 @@ func (uc *BugUseCase) GetBugsByDeveloper(ctx context.Context, developerID primit
 	return responses, nil
 }
 
-func (uc *BugUseCase) UpdateBugStatus(ctx context.Context, bugID primitive.ObjectID, status string, userID primitive.ObjectID) (*models.BugResponse, error) {
+func (uc *BugUseCase) UpdateBugStatus(ctx context.Context, bugID models.ID, status string, user *models.User) (_ *models.BugResponse, err error) {
+	ctx, span := tracer.Start(ctx, "BugUseCase.UpdateBugStatus")
+	defer func() { endSpan(span, err) }()
+
 	bug, err := uc.bugRepo.FindByID(ctx, bugID)
 	if err != nil {
 		return nil, err
@@ -99,20 +188,30 @@ func (uc *BugUseCase) UpdateBugStatus(ctx context.Context, bugID primitive.Objec
 		return nil, ErrBugNotFound
 	}
 
-	// Only assigned developer can update status
-	if bug.AssignedTo != userID {
+	// Assignees may change the status of their bugs
+	if bug.AssignedTo != user.ID && !uc.roles.Can(user.Role, models.PermBugStatusChange) {
 		return nil, ErrUnauthorized
 	}
 
 	if err := uc.bugRepo.UpdateStatus(ctx, bugID, status); err != nil {
 		return nil, err
 	}
+	logging.FromContext(ctx).Info("bug status changed", "bug_id", bugID.Hex(), "from", bug.Status, "to", status)
 
 	bug.Status = status
+	if status == "resolved" {
+		now := time.Now()
+		bug.ResolvedAt = &now
+	} else {
+		bug.ResolvedAt = nil
+	}
 	return uc.getBugResponse(ctx, bug)
 }
 
-func (uc *BugUseCase) AssignBug(ctx context.Context, bugID, developerID primitive.ObjectID) (*models.BugResponse, error) {
+func (uc *BugUseCase) AssignBug(ctx context.Context, bugID, developerID models.ID) (_ *models.BugResponse, err error) {
+	ctx, span := tracer.Start(ctx, "BugUseCase.AssignBug")
+	defer func() { endSpan(span, err) }()
+
 	// Find the bug
 	bug, err := uc.bugRepo.FindByID(ctx, bugID)
 	if err != nil {
@@ -130,8 +229,8 @@ func (uc *BugUseCase) AssignBug(ctx context.Context, bugID, developerID primitiv
 	if developer == nil {
 		return nil, errors.New("user not found")
 	}
-	if developer.Role != "developer" {
-		return nil, errors.New("invalid developer role")
+	if !uc.roles.Can(developer.Role, models.PermBugAssignable) {
+		return nil, ErrNotAssignable
 	}
 
 	// Assign the bug to the developer
@@ -140,6 +239,7 @@ func (uc *BugUseCase) AssignBug(ctx context.Context, bugID, developerID primitiv
 	if err != nil {
 		return nil, err
 	}
+	logging.FromContext(ctx).Info("bug assigned", "bug_id", bugID.Hex(), "developer_id", developerID.Hex())
 
 	// Get the updated bug response
 	response, err := uc.GetBugByID(ctx, bugID)
@@ -150,7 +250,10 @@ func (uc *BugUseCase) AssignBug(ctx context.Context, bugID, developerID primitiv
 	return response, nil
 }
 
-func (uc *BugUseCase) GetBugByID(ctx context.Context, id primitive.ObjectID) (*models.BugResponse, error) {
+func (uc *BugUseCase) GetBugByID(ctx context.Context, id models.ID) (_ *models.BugResponse, err error) {
+	ctx, span := tracer.Start(ctx, "BugUseCase.GetBugByID")
+	defer func() { endSpan(span, err) }()
+
 	bug, err := uc.bugRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -162,7 +265,10 @@ func (uc *BugUseCase) GetBugByID(ctx context.Context, id primitive.ObjectID) (*m
 	return uc.getBugResponse(ctx, bug)
 }
 
-func (uc *BugUseCase) UpdateBug(ctx context.Context, id primitive.ObjectID, req models.UpdateBugRequest, user *models.User) (*models.BugResponse, error) {
+func (uc *BugUseCase) UpdateBug(ctx context.Context, id models.ID, req models.UpdateBugRequest, user *models.User) (_ *models.BugResponse, err error) {
+	ctx, span := tracer.Start(ctx, "BugUseCase.UpdateBug")
+	defer func() { endSpan(span, err) }()
+
 	bug, err := uc.bugRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -171,9 +277,8 @@ func (uc *BugUseCase) UpdateBug(ctx context.Context, id primitive.ObjectID, req
 		return nil, ErrBugNotFound
 	}
 
-	// Check permissions
-	if user.Role != "admin" && user.Role != "manager" &&
-		bug.ReportedBy != user.ID && bug.AssignedTo != user.ID {
+	// Reporters and assignees may edit their bugs
+	if bug.ReportedBy != user.ID && bug.AssignedTo != user.ID && !uc.roles.Can(user.Role, models.PermBugEdit) {
 		return nil, ErrUnauthorized
 	}
 
@@ -187,6 +292,26 @@ func (uc *BugUseCase) UpdateBug(ctx context.Context, id primitive.ObjectID, req
 	if req.Priority != "" {
 		bug.Priority = req.Priority
 	}
+	if req.Labels != nil {
+		if bug.Labels, err = normalizeLabels(*req.Labels); err != nil {
+			return nil, err
+		}
+	}
+	if bug.Fields, err = uc.applyFieldValues(ctx, bug.Fields, req.Fields, false); err != nil {
+		return nil, err
+	}
+	if req.AffectedVersion != nil {
+		if err := uc.checkMilestones(ctx, *req.AffectedVersion); err != nil {
+			return nil, err
+		}
+		bug.AffectedVersion = *req.AffectedVersion
+	}
+	if req.FixVersion != nil {
+		if err := uc.checkMilestones(ctx, *req.FixVersion); err != nil {
+			return nil, err
+		}
+		bug.FixVersion = *req.FixVersion
+	}
 
 	if err := uc.bugRepo.Update(ctx, bug); err != nil {
 		return nil, err
@@ -195,7 +320,10 @@ func (uc *BugUseCase) UpdateBug(ctx context.Context, id primitive.ObjectID, req
 	return uc.getBugResponse(ctx, bug)
 }
 
-func (uc *BugUseCase) DeleteBug(ctx context.Context, id primitive.ObjectID) error {
+func (uc *BugUseCase) DeleteBug(ctx context.Context, id models.ID) (err error) {
+	ctx, span := tracer.Start(ctx, "BugUseCase.DeleteBug")
+	defer func() { endSpan(span, err) }()
+
 	bug, err := uc.bugRepo.FindByID(ctx, id)
 	if err != nil {
 		return err
@@ -204,10 +332,47 @@ func (uc *BugUseCase) DeleteBug(ctx context.Context, id primitive.ObjectID) erro
 		return ErrBugNotFound
 	}
 
-	return uc.bugRepo.Delete(ctx, id)
+	if err := uc.bugRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("bug deleted", "bug_id", id.Hex())
+	return nil
 }
 
-func (uc *BugUseCase) getBugResponse(ctx context.Context, bug *models.Bug) (*models.BugResponse, error) {
+// SearchBugs returns the bugs whose title or description contains every word
+// of the query, best matches first
+func (uc *BugUseCase) SearchBugs(ctx context.Context, search models.BugSearch) (_ []*models.BugResponse, err error) {
+	ctx, span := tracer.Start(ctx, "BugUseCase.SearchBugs")
+	defer func() { endSpan(span, err) }()
+
+	if search.Limit <= 0 {
+		search.Limit = DefaultSearchLimit
+	}
+	if search.Limit > MaxSearchLimit {
+		search.Limit = MaxSearchLimit
+	}
+
+	bugs, err := uc.bugRepo.Search(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*models.BugResponse, len(bugs))
+	for i, bug := range bugs {
+		response, err := uc.getBugResponse(ctx, bug)
+		if err != nil {
+			return nil, err
+		}
+		responses[i] = response
+	}
+
+	return responses, nil
+}
+
+func (uc *BugUseCase) getBugResponse(ctx context.Context, bug *models.Bug) (_ *models.BugResponse, err error) {
+	ctx, span := tracer.Start(ctx, "BugUseCase.getBugResponse")
+	defer func() { endSpan(span, err) }()
+
 	reporter, err := uc.userRepo.FindByID(ctx, bug.ReportedBy)
 	if err != nil {
 		return nil, err
@@ -222,6 +387,9 @@ func (uc *BugUseCase) getBugResponse(ctx context.Context, bug *models.Bug) (*mod
 		ReportedBy:  reporter.ToResponse(),
 		CreatedAt:   bug.CreatedAt,
 		UpdatedAt:   bug.UpdatedAt,
+		ResolvedAt:  bug.ResolvedAt,
+		Fields:      bug.Fields,
+		Labels:      bug.Labels,
 	}
 
 	if !bug.AssignedTo.IsZero() {
@@ -233,5 +401,43 @@ func (uc *BugUseCase) getBugResponse(ctx context.Context, bug *models.Bug) (*mod
 		response.AssignedTo = &assigneeResponse
 	}
 
+	if response.AffectedVersion, err = uc.milestoneRef(ctx, bug.AffectedVersion); err != nil {
+		return nil, err
+	}
+	if response.FixVersion, err = uc.milestoneRef(ctx, bug.FixVersion); err != nil {
+		return nil, err
+	}
+
 	return response, nil
 }
+
+// checkMilestones returns ErrMilestoneNotFound unless every set ID names a
+// milestone
+func (uc *BugUseCase) checkMilestones(ctx context.Context, ids ...models.ID) error {
+	for _, id := range ids {
+		if id.IsZero() {
+			continue
+		}
+		milestone, err := uc.milestoneRepo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if milestone == nil {
+			return ErrMilestoneNotFound
+		}
+	}
+	return nil
+}
+
+// milestoneRef names the milestone with id, or returns nil when id is unset
+// or the milestone is gone
+func (uc *BugUseCase) milestoneRef(ctx context.Context, id models.ID) (*models.MilestoneRef, error) {
+	if id.IsZero() {
+		return nil, nil
+	}
+	milestone, err := uc.milestoneRepo.FindByID(ctx, id)
+	if err != nil || milestone == nil {
+		return nil, err
+	}
+	return &models.MilestoneRef{ID: milestone.ID, Name: milestone.Name}, nil
+}
@@ -2,35 +2,37 @@ package usecase
 
 import (
 	"bug-tracker/models"
+	"bug-tracker/repository/memory"
 	"context"
 	"errors"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type MockBugRepository struct {
-	bugs map[primitive.ObjectID]*models.Bug
+	bugs       map[models.ID]*models.Bug
+	lastSearch models.BugSearch
 }
 
 func NewMockBugRepository() *MockBugRepository {
 	return &MockBugRepository{
-		bugs: make(map[primitive.ObjectID]*models.Bug),
+		bugs: make(map[models.ID]*models.Bug),
 	}
 }
 
 func (m *MockBugRepository) Create(ctx context.Context, bug *models.Bug) error {
 	if bug.ID.IsZero() {
-		bug.ID = primitive.NewObjectID()
+		bug.ID = models.NewID()
 	}
 	m.bugs[bug.ID] = bug
 	return nil
 }
 
-func (m *MockBugRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Bug, error) {
+func (m *MockBugRepository) FindByID(ctx context.Context, id models.ID) (*models.Bug, error) {
 	if bug, exists := m.bugs[id]; exists {
 		return bug, nil
 	}
@@ -49,7 +51,7 @@ func (m *MockBugRepository) FindAll(ctx context.Context) ([]*models.Bug, error)
 	return bugs, nil
 }
 
-func (m *MockBugRepository) FindByAssignee(ctx context.Context, assigneeID primitive.ObjectID) ([]*models.Bug, error) {
+func (m *MockBugRepository) FindByAssignee(ctx context.Context, assigneeID models.ID) ([]*models.Bug, error) {
 	var bugs []*models.Bug
 	for _, bug := range m.bugs {
 		if bug.AssignedTo == assigneeID {
@@ -59,7 +61,7 @@ func (m *MockBugRepository) FindByAssignee(ctx context.Context, assigneeID primi
 	return bugs, nil
 }
 
-func (m *MockBugRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, status string) error {
+func (m *MockBugRepository) UpdateStatus(ctx context.Context, id models.ID, status string) error {
 	bug, exists := m.bugs[id]
 	if !exists {
 		return errors.New("bug not found")
@@ -68,7 +70,7 @@ func (m *MockBugRepository) UpdateStatus(ctx context.Context, id primitive.Objec
 	return nil
 }
 
-func (m *MockBugRepository) AssignToDeveloper(ctx context.Context, bugID, developerID primitive.ObjectID) error {
+func (m *MockBugRepository) AssignToDeveloper(ctx context.Context, bugID, developerID models.ID) error {
 	bug, exists := m.bugs[bugID]
 	if !exists {
 		return errors.New("bug not found")
@@ -85,7 +87,7 @@ func (m *MockBugRepository) Update(ctx context.Context, bug *models.Bug) error {
 	return nil
 }
 
-func (m *MockBugRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+func (m *MockBugRepository) Delete(ctx context.Context, id models.ID) error {
 	if _, exists := m.bugs[id]; !exists {
 		return errors.New("bug not found")
 	}
@@ -93,13 +95,80 @@ func (m *MockBugRepository) Delete(ctx context.Context, id primitive.ObjectID) e
 	return nil
 }
 
+func (m *MockBugRepository) Stream(ctx context.Context, filter models.BugFilter, fn func(*models.Bug) error) error {
+	bugs, _ := m.FindAll(ctx)
+	for _, bug := range bugs {
+		if filter.Status != "" && bug.Status != filter.Status {
+			continue
+		}
+		if filter.Priority != "" && bug.Priority != filter.Priority {
+			continue
+		}
+		if filter.AssignedTo != nil && bug.AssignedTo != *filter.AssignedTo {
+			continue
+		}
+		if err := fn(bug); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockBugRepository) FindByExternalID(ctx context.Context, externalID string) (*models.Bug, error) {
+	for _, bug := range m.bugs {
+		if bug.ExternalID == externalID {
+			return bug, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockBugRepository) CreateIfNotExists(ctx context.Context, bug *models.Bug) (bool, error) {
+	if existing, _ := m.FindByExternalID(ctx, bug.ExternalID); existing != nil {
+		bug.ID = existing.ID
+		return false, nil
+	}
+	return true, m.Create(ctx, bug)
+}
+
+func (m *MockBugRepository) RemoveField(ctx context.Context, key string) error {
+	for _, bug := range m.bugs {
+		delete(bug.Fields, key)
+	}
+	return nil
+}
+
+func (m *MockBugRepository) ClearMilestone(ctx context.Context, id models.ID) error {
+	for _, bug := range m.bugs {
+		if bug.AffectedVersion == id {
+			bug.AffectedVersion = models.NilID
+		}
+		if bug.FixVersion == id {
+			bug.FixVersion = models.NilID
+		}
+	}
+	return nil
+}
+
+// Search matches titles containing the query and records the search
+func (m *MockBugRepository) Search(ctx context.Context, search models.BugSearch) ([]*models.Bug, error) {
+	m.lastSearch = search
+	bugs := []*models.Bug{}
+	for _, bug := range m.bugs {
+		if strings.Contains(strings.ToLower(bug.Title), strings.ToLower(search.Query)) {
+			bugs = append(bugs, bug)
+		}
+	}
+	return bugs, nil
+}
+
 func TestCreateBug(t *testing.T) {
 	mockBugRepo := NewMockBugRepository()
 	mockUserRepo := NewMockUserRepository()
-	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo)
+	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo, memory.NewCustomFieldRepository(), memory.NewBugTemplateRepository(), memory.NewMilestoneRepository(), nil)
 
 	// Create a test reporter
-	reporterID := primitive.NewObjectID()
+	reporterID := models.NewID()
 	reporter := &models.User{
 		ID:    reporterID,
 		Name:  "Test Reporter",
@@ -129,11 +198,11 @@ func TestCreateBug(t *testing.T) {
 func TestGetBugByID(t *testing.T) {
 	mockBugRepo := NewMockBugRepository()
 	mockUserRepo := NewMockUserRepository()
-	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo)
+	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo, memory.NewCustomFieldRepository(), memory.NewBugTemplateRepository(), memory.NewMilestoneRepository(), nil)
 
 	// Create a test bug
-	bugID := primitive.NewObjectID()
-	reporterID := primitive.NewObjectID()
+	bugID := models.NewID()
+	reporterID := models.NewID()
 	bug := &models.Bug{
 		ID:          bugID,
 		Title:       "Test Bug",
@@ -168,7 +237,7 @@ func TestGetBugByID(t *testing.T) {
 	})
 
 	t.Run("bug not found", func(t *testing.T) {
-		response, err := bugUseCase.GetBugByID(context.Background(), primitive.NewObjectID())
+		response, err := bugUseCase.GetBugByID(context.Background(), models.NewID())
 		assert.Error(t, err)
 		assert.Equal(t, ErrBugNotFound, err)
 		assert.Nil(t, response)
@@ -178,10 +247,10 @@ func TestGetBugByID(t *testing.T) {
 func TestGetAllBugs(t *testing.T) {
 	mockBugRepo := NewMockBugRepository()
 	mockUserRepo := NewMockUserRepository()
-	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo)
+	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo, memory.NewCustomFieldRepository(), memory.NewBugTemplateRepository(), memory.NewMilestoneRepository(), nil)
 
 	// Create test bugs
-	reporterID := primitive.NewObjectID()
+	reporterID := models.NewID()
 	reporter := &models.User{
 		ID:    reporterID,
 		Name:  "Test Reporter",
@@ -190,8 +259,8 @@ func TestGetAllBugs(t *testing.T) {
 	}
 	_ = mockUserRepo.Create(context.Background(), reporter)
 
-	bug1ID := primitive.NewObjectID()
-	bug2ID := primitive.NewObjectID()
+	bug1ID := models.NewID()
+	bug2ID := models.NewID()
 	bugs := []*models.Bug{
 		{
 			ID:          bug1ID,
@@ -243,12 +312,12 @@ func TestGetAllBugs(t *testing.T) {
 func TestUpdateBugStatus(t *testing.T) {
 	mockBugRepo := NewMockBugRepository()
 	mockUserRepo := NewMockUserRepository()
-	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo)
+	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo, memory.NewCustomFieldRepository(), memory.NewBugTemplateRepository(), memory.NewMilestoneRepository(), nil)
 
 	// Create a test bug
-	bugID := primitive.NewObjectID()
-	reporterID := primitive.NewObjectID()
-	developerID := primitive.NewObjectID()
+	bugID := models.NewID()
+	reporterID := models.NewID()
+	developerID := models.NewID()
 	bug := &models.Bug{
 		ID:          bugID,
 		Title:       "Test Bug",
@@ -279,21 +348,28 @@ func TestUpdateBugStatus(t *testing.T) {
 	_ = mockUserRepo.Create(context.Background(), developer)
 
 	t.Run("successful status update", func(t *testing.T) {
-		response, err := bugUseCase.UpdateBugStatus(context.Background(), bugID, "in-progress", developerID)
+		response, err := bugUseCase.UpdateBugStatus(context.Background(), bugID, "in-progress", developer)
 		assert.NoError(t, err)
 		assert.NotNil(t, response)
 		assert.Equal(t, "in-progress", response.Status)
 	})
 
 	t.Run("unauthorized update", func(t *testing.T) {
-		response, err := bugUseCase.UpdateBugStatus(context.Background(), bugID, "in-progress", reporterID)
+		response, err := bugUseCase.UpdateBugStatus(context.Background(), bugID, "in-progress", reporter)
 		assert.Error(t, err)
 		assert.Equal(t, ErrUnauthorized, err)
 		assert.Nil(t, response)
 	})
 
+	t.Run("manager may change any status", func(t *testing.T) {
+		manager := &models.User{ID: models.NewID(), Role: "manager"}
+		response, err := bugUseCase.UpdateBugStatus(context.Background(), bugID, "resolved", manager)
+		assert.NoError(t, err)
+		assert.Equal(t, "resolved", response.Status)
+	})
+
 	t.Run("bug not found", func(t *testing.T) {
-		response, err := bugUseCase.UpdateBugStatus(context.Background(), primitive.NewObjectID(), "in-progress", developerID)
+		response, err := bugUseCase.UpdateBugStatus(context.Background(), models.NewID(), "in-progress", developer)
 		assert.Error(t, err)
 		assert.Equal(t, ErrBugNotFound, err)
 		assert.Nil(t, response)
@@ -303,13 +379,13 @@ func TestUpdateBugStatus(t *testing.T) {
 func TestAssignBug(t *testing.T) {
 	mockBugRepo := NewMockBugRepository()
 	mockUserRepo := NewMockUserRepository()
-	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo)
+	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo, memory.NewCustomFieldRepository(), memory.NewBugTemplateRepository(), memory.NewMilestoneRepository(), nil)
 
 	// Create a test bug
-	bugID := primitive.NewObjectID()
-	reporterID := primitive.NewObjectID()
-	developerID := primitive.NewObjectID()
-	invalidDeveloperID := primitive.NewObjectID()
+	bugID := models.NewID()
+	reporterID := models.NewID()
+	developerID := models.NewID()
+	invalidDeveloperID := models.NewID()
 	bug := &models.Bug{
 		ID:          bugID,
 		Title:       "Test Bug",
@@ -353,7 +429,7 @@ func TestAssignBug(t *testing.T) {
 	})
 
 	t.Run("bug not found", func(t *testing.T) {
-		response, err := bugUseCase.AssignBug(context.Background(), primitive.NewObjectID(), developer.ID)
+		response, err := bugUseCase.AssignBug(context.Background(), models.NewID(), developer.ID)
 		assert.Error(t, err)
 		assert.Equal(t, ErrBugNotFound, err)
 		assert.Nil(t, response)
@@ -363,11 +439,11 @@ func TestAssignBug(t *testing.T) {
 func TestUpdateBug(t *testing.T) {
 	mockBugRepo := NewMockBugRepository()
 	mockUserRepo := NewMockUserRepository()
-	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo)
+	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo, memory.NewCustomFieldRepository(), memory.NewBugTemplateRepository(), memory.NewMilestoneRepository(), nil)
 
 	// Create a test bug
-	bugID := primitive.NewObjectID()
-	reporterID := primitive.NewObjectID()
+	bugID := models.NewID()
+	reporterID := models.NewID()
 	bug := &models.Bug{
 		ID:          bugID,
 		Title:       "Test Bug",
@@ -388,7 +464,7 @@ func TestUpdateBug(t *testing.T) {
 		Role:  "developer",
 	}
 	manager := &models.User{
-		ID:    primitive.NewObjectID(),
+		ID:    models.NewID(),
 		Name:  "Test Manager",
 		Email: "manager@example.com",
 		Role:  "manager",
@@ -432,7 +508,7 @@ func TestUpdateBug(t *testing.T) {
 		}
 
 		unauthorizedUser := &models.User{
-			ID:    primitive.NewObjectID(),
+			ID:    models.NewID(),
 			Name:  "Unauthorized User",
 			Email: "unauthorized@example.com",
 			Role:  "developer",
@@ -449,7 +525,7 @@ func TestUpdateBug(t *testing.T) {
 			Title: "Non-existent Bug Update",
 		}
 
-		response, err := bugUseCase.UpdateBug(context.Background(), primitive.NewObjectID(), req, reporter)
+		response, err := bugUseCase.UpdateBug(context.Background(), models.NewID(), req, reporter)
 		assert.Error(t, err)
 		assert.Equal(t, ErrBugNotFound, err)
 		assert.Nil(t, response)
@@ -459,11 +535,11 @@ func TestUpdateBug(t *testing.T) {
 func TestDeleteBug(t *testing.T) {
 	mockBugRepo := NewMockBugRepository()
 	mockUserRepo := NewMockUserRepository()
-	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo)
+	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo, memory.NewCustomFieldRepository(), memory.NewBugTemplateRepository(), memory.NewMilestoneRepository(), nil)
 
 	// Create a test bug
-	bugID := primitive.NewObjectID()
-	reporterID := primitive.NewObjectID()
+	bugID := models.NewID()
+	reporterID := models.NewID()
 	bug := &models.Bug{
 		ID:          bugID,
 		Title:       "Test Bug",
@@ -487,8 +563,42 @@ func TestDeleteBug(t *testing.T) {
 	})
 
 	t.Run("bug not found", func(t *testing.T) {
-		err := bugUseCase.DeleteBug(context.Background(), primitive.NewObjectID())
+		err := bugUseCase.DeleteBug(context.Background(), models.NewID())
 		assert.Error(t, err)
 		assert.Equal(t, ErrBugNotFound, err)
 	})
 }
+
+func TestSearchBugs(t *testing.T) {
+	mockBugRepo := NewMockBugRepository()
+	mockUserRepo := NewMockUserRepository()
+	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo, memory.NewCustomFieldRepository(), memory.NewBugTemplateRepository(), memory.NewMilestoneRepository(), nil)
+
+	reporter := &models.User{Name: "Test Reporter", Email: "reporter@example.com", Role: "manager"}
+	_ = mockUserRepo.Create(context.Background(), reporter)
+	for _, title := range []string{"Login crash", "Slow export"} {
+		_ = mockBugRepo.Create(context.Background(), &models.Bug{Title: title, Priority: "high", ReportedBy: reporter.ID})
+	}
+
+	t.Run("returns matching bugs", func(t *testing.T) {
+		responses, err := bugUseCase.SearchBugs(context.Background(), models.BugSearch{Query: "crash"})
+		assert.NoError(t, err)
+		assert.Len(t, responses, 1)
+		assert.Equal(t, "Login crash", responses[0].Title)
+		assert.Equal(t, reporter.ID, responses[0].ReportedBy.ID)
+	})
+
+	t.Run("limits results", func(t *testing.T) {
+		_, err := bugUseCase.SearchBugs(context.Background(), models.BugSearch{Query: "crash"})
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultSearchLimit, mockBugRepo.lastSearch.Limit)
+
+		_, err = bugUseCase.SearchBugs(context.Background(), models.BugSearch{Query: "crash", Limit: 5})
+		assert.NoError(t, err)
+		assert.Equal(t, 5, mockBugRepo.lastSearch.Limit)
+
+		_, err = bugUseCase.SearchBugs(context.Background(), models.BugSearch{Query: "crash", Limit: 1000})
+		assert.NoError(t, err)
+		assert.Equal(t, MaxSearchLimit, mockBugRepo.lastSearch.Limit)
+	})
+}
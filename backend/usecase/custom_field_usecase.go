@@ -0,0 +1,378 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"bug-tracker/logging"
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// CustomFieldUseCaseInterface defines the interface for editing custom fields
+type CustomFieldUseCaseInterface interface {
+	ListFields(ctx context.Context) ([]*models.CustomField, error)
+	SaveField(ctx context.Context, key string, req models.SaveCustomFieldRequest) (*models.CustomField, error)
+	DeleteField(ctx context.Context, key string) error
+}
+
+var (
+	ErrFieldNotFound   = errors.New("custom field not found")
+	ErrInvalidFieldKey = errors.New("custom field keys are 1 to 32 lowercase letters, digits or '_', starting with a letter")
+	ErrBlankFieldName  = errors.New("custom field names can't be blank")
+	// ErrFieldTypeChange is returned for changing the type of a field, which
+	// would leave bugs holding values of the old type
+	ErrFieldTypeChange = errors.New("the type of a custom field can't be changed")
+	// ErrInvalidFieldOptions is returned when enum and multi-select fields
+	// don't list unique, non-blank options, or other fields list any
+	ErrInvalidFieldOptions = errors.New("enum and multi_select fields need unique, non-blank options and other types none")
+	ErrInvalidFieldValue   = errors.New("invalid custom field value")
+	ErrInvalidFilter       = errors.New("invalid filter")
+	ErrInvalidSort         = errors.New("invalid sort")
+)
+
+var fieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// maxTextFieldLength caps text field values, counted in characters
+const maxTextFieldLength = 1000
+
+type CustomFieldUseCase struct {
+	fieldRepo repository.CustomFieldRepositoryInterface
+	bugRepo   repository.BugRepositoryInterface
+}
+
+func NewCustomFieldUseCase(fieldRepo repository.CustomFieldRepositoryInterface, bugRepo repository.BugRepositoryInterface) *CustomFieldUseCase {
+	return &CustomFieldUseCase{
+		fieldRepo: fieldRepo,
+		bugRepo:   bugRepo,
+	}
+}
+
+// ListFields returns every custom field ordered by key
+func (uc *CustomFieldUseCase) ListFields(ctx context.Context) (_ []*models.CustomField, err error) {
+	ctx, span := tracer.Start(ctx, "CustomFieldUseCase.ListFields")
+	defer func() { endSpan(span, err) }()
+
+	return uc.fieldRepo.FindAll(ctx)
+}
+
+// SaveField defines the custom field key, or changes its definition.
+// Making a field required only affects bugs reported afterwards.
+func (uc *CustomFieldUseCase) SaveField(ctx context.Context, key string, req models.SaveCustomFieldRequest) (_ *models.CustomField, err error) {
+	ctx, span := tracer.Start(ctx, "CustomFieldUseCase.SaveField")
+	defer func() { endSpan(span, err) }()
+
+	if !fieldKeyPattern.MatchString(key) {
+		return nil, ErrInvalidFieldKey
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrBlankFieldName
+	}
+
+	hasOptions := req.Type == models.FieldEnum || req.Type == models.FieldMultiSelect
+	if hasOptions != (len(req.Options) > 0) {
+		return nil, ErrInvalidFieldOptions
+	}
+	seen := make(map[string]bool)
+	for _, option := range req.Options {
+		if strings.TrimSpace(option) == "" || seen[option] {
+			return nil, ErrInvalidFieldOptions
+		}
+		seen[option] = true
+	}
+
+	fields, err := uc.fieldRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range fields {
+		if existing.Key == key && existing.Type != req.Type {
+			return nil, ErrFieldTypeChange
+		}
+	}
+
+	field := &models.CustomField{
+		Key:      key,
+		Name:     name,
+		Type:     req.Type,
+		Options:  req.Options,
+		Required: req.Required,
+	}
+	if err := uc.fieldRepo.Save(ctx, field); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("custom field saved", "field", key, "type", field.Type)
+	return field, nil
+}
+
+// DeleteField deletes a custom field along with its values on every bug
+func (uc *CustomFieldUseCase) DeleteField(ctx context.Context, key string) (err error) {
+	ctx, span := tracer.Start(ctx, "CustomFieldUseCase.DeleteField")
+	defer func() { endSpan(span, err) }()
+
+	fields, err := uc.fieldRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	if findField(fields, key) == nil {
+		return ErrFieldNotFound
+	}
+
+	// Values go first, so a failure leaves the field defined and the
+	// delete can simply be retried
+	if err := uc.bugRepo.RemoveField(ctx, key); err != nil {
+		return err
+	}
+	if err := uc.fieldRepo.Delete(ctx, key); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("custom field deleted", "field", key)
+	return nil
+}
+
+func findField(fields []*models.CustomField, key string) *models.CustomField {
+	for _, field := range fields {
+		if field.Key == key {
+			return field
+		}
+	}
+	return nil
+}
+
+// applyFieldValues validates values against the custom field definitions and
+// returns current with them applied. A null, empty string or empty list
+// clears a field. Required fields must be set when creating and can't be
+// cleared afterwards.
+func (uc *BugUseCase) applyFieldValues(ctx context.Context, current models.FieldValues, values map[string]interface{}, creating bool) (models.FieldValues, error) {
+	if len(values) == 0 && !creating {
+		return current, nil
+	}
+
+	fields, err := uc.fieldRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := current.Clone()
+	if result == nil {
+		result = models.FieldValues{}
+	}
+	for key, value := range values {
+		field := findField(fields, key)
+		if field == nil {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidFieldValue, key)
+		}
+		converted, err := uc.fieldValue(ctx, field, value)
+		if err != nil {
+			return nil, err
+		}
+		if converted == nil {
+			if field.Required {
+				return nil, fmt.Errorf("%w: %q is required", ErrInvalidFieldValue, key)
+			}
+			delete(result, key)
+			continue
+		}
+		result[key] = converted
+	}
+
+	if creating {
+		for _, field := range fields {
+			if _, ok := result[field.Key]; field.Required && !ok {
+				return nil, fmt.Errorf("%w: %q is required", ErrInvalidFieldValue, field.Key)
+			}
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// fieldValue converts a value decoded from JSON to the type field holds,
+// returning nil for values that clear the field
+func (uc *BugUseCase) fieldValue(ctx context.Context, field *models.CustomField, value interface{}) (interface{}, error) {
+	if value == nil || value == "" {
+		return nil, nil
+	}
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %q %s", ErrInvalidFieldValue, field.Key, fmt.Sprintf(format, args...))
+	}
+
+	if field.Type == models.FieldMultiSelect {
+		var list []interface{}
+		switch v := value.(type) {
+		case []interface{}:
+			list = v
+		case []string:
+			for _, s := range v {
+				list = append(list, s)
+			}
+		default:
+			return nil, invalid("must be a list of options")
+		}
+		selected := []string{}
+		seen := make(map[string]bool)
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok || !hasOption(field, s) {
+				return nil, invalid("must be a list of %s", strings.Join(field.Options, ", "))
+			}
+			if !seen[s] {
+				seen[s] = true
+				selected = append(selected, s)
+			}
+		}
+		if len(selected) == 0 {
+			return nil, nil
+		}
+		return selected, nil
+	}
+
+	if field.Type == models.FieldNumber {
+		n, ok := value.(float64)
+		if !ok {
+			return nil, invalid("must be a number")
+		}
+		return n, nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return nil, invalid("must be a string")
+	}
+	switch field.Type {
+	case models.FieldText:
+		if utf8.RuneCountInString(s) > maxTextFieldLength {
+			return nil, invalid("must be at most %d characters", maxTextFieldLength)
+		}
+	case models.FieldEnum:
+		if !hasOption(field, s) {
+			return nil, invalid("must be one of %s", strings.Join(field.Options, ", "))
+		}
+	case models.FieldDate:
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			return nil, invalid("must be a date written YYYY-MM-DD")
+		}
+	case models.FieldUser:
+		id, err := models.ParseID(s)
+		if err != nil {
+			return nil, invalid("must be a user ID")
+		}
+		user, err := uc.userRepo.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, invalid("names no existing user")
+		}
+		return id.Hex(), nil
+	}
+	return s, nil
+}
+
+func hasOption(field *models.CustomField, value string) bool {
+	for _, option := range field.Options {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeFilter checks the custom fields and sort key filter names against
+// the field definitions, and converts filter values given as strings to the
+// types the fields hold
+func (uc *BugUseCase) NormalizeFilter(ctx context.Context, filter *models.BugFilter) error {
+	sortField, customSort := strings.CutPrefix(filter.Sort.Key, models.FieldPrefix)
+	if !customSort && filter.Sort.Key != "" && !validSortColumn(filter.Sort.Key) {
+		return fmt.Errorf("%w %q", ErrInvalidSort, filter.Sort.Key)
+	}
+	if len(filter.Fields) == 0 && !customSort {
+		return nil
+	}
+
+	fields, err := uc.fieldRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i, f := range filter.Fields {
+		field := findField(fields, f.Key)
+		if field == nil {
+			return fmt.Errorf("%w: unknown field %q", ErrInvalidFilter, f.Key)
+		}
+		value, err := filterValue(field, f.Value)
+		if err != nil {
+			return err
+		}
+		filter.Fields[i].Value = value
+	}
+
+	if customSort {
+		// Lists have no natural order
+		field := findField(fields, sortField)
+		if field == nil || field.Type == models.FieldMultiSelect {
+			return fmt.Errorf("%w %q", ErrInvalidSort, filter.Sort.Key)
+		}
+	}
+	return nil
+}
+
+// filterValue converts a filter value to the type field holds. Multi-select
+// filters give a single option, which matching bugs include.
+func filterValue(field *models.CustomField, value interface{}) (interface{}, error) {
+	invalid := fmt.Errorf("%w: bad value for %q", ErrInvalidFilter, field.Key)
+	if field.Type == models.FieldNumber {
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case string:
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, invalid
+			}
+			return n, nil
+		}
+		return nil, invalid
+	}
+
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return nil, invalid
+	}
+	switch field.Type {
+	case models.FieldEnum, models.FieldMultiSelect:
+		if !hasOption(field, s) {
+			return nil, invalid
+		}
+	case models.FieldDate:
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			return nil, invalid
+		}
+	case models.FieldUser:
+		id, err := models.ParseID(s)
+		if err != nil {
+			return nil, invalid
+		}
+		return id.Hex(), nil
+	}
+	return s, nil
+}
+
+func validSortColumn(key string) bool {
+	for _, column := range models.SortColumns {
+		if column == key {
+			return true
+		}
+	}
+	return false
+}
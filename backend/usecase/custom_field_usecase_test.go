@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"bug-tracker/models"
+	"bug-tracker/repository/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type customFieldFixture struct {
+	fields   *CustomFieldUseCase
+	bugs     *BugUseCase
+	bugRepo  *memory.BugRepository
+	reporter *models.User
+}
+
+func newCustomFieldFixture(t *testing.T) *customFieldFixture {
+	t.Helper()
+	ctx := context.Background()
+	fieldRepo := memory.NewCustomFieldRepository()
+	bugRepo := memory.NewBugRepository()
+	userRepo := memory.NewUserRepository()
+
+	reporter := &models.User{Name: "Re Porter", Email: "reporter@example.com", Role: models.RoleDeveloper}
+	require.NoError(t, userRepo.Create(ctx, reporter))
+
+	f := &customFieldFixture{
+		fields:   NewCustomFieldUseCase(fieldRepo, bugRepo),
+		bugs:     NewBugUseCase(bugRepo, userRepo, fieldRepo, memory.NewBugTemplateRepository(), memory.NewMilestoneRepository(), nil),
+		bugRepo:  bugRepo,
+		reporter: reporter,
+	}
+	for key, req := range map[string]models.SaveCustomFieldRequest{
+		"environment": {Name: "Environment", Type: models.FieldEnum, Options: []string{"prod", "staging"}, Required: true},
+		"severity":    {Name: "Severity", Type: models.FieldNumber},
+		"seen_on":     {Name: "Seen on", Type: models.FieldDate},
+		"customer":    {Name: "Customer contact", Type: models.FieldUser},
+		"browsers":    {Name: "Browsers", Type: models.FieldMultiSelect, Options: []string{"firefox", "safari", "chrome"}},
+		"notes":       {Name: "Notes", Type: models.FieldText},
+	} {
+		_, err := f.fields.SaveField(ctx, key, req)
+		require.NoError(t, err)
+	}
+	return f
+}
+
+func (f *customFieldFixture) create(t *testing.T, fields map[string]interface{}) (*models.BugResponse, error) {
+	t.Helper()
+	return f.bugs.CreateBug(context.Background(), models.CreateBugRequest{
+		Title: "Crash", Description: "It crashed", Priority: "high", Fields: fields,
+	}, f.reporter.ID)
+}
+
+func TestSaveField(t *testing.T) {
+	ctx := context.Background()
+	f := newCustomFieldFixture(t)
+
+	t.Run("edits definition", func(t *testing.T) {
+		field, err := f.fields.SaveField(ctx, "environment", models.SaveCustomFieldRequest{
+			Name: "Env", Type: models.FieldEnum, Options: []string{"prod", "staging", "dev"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Env", field.Name)
+		assert.False(t, field.Required)
+	})
+
+	t.Run("type can't change", func(t *testing.T) {
+		_, err := f.fields.SaveField(ctx, "severity", models.SaveCustomFieldRequest{Name: "Severity", Type: models.FieldText})
+		assert.Equal(t, ErrFieldTypeChange, err)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := f.fields.SaveField(ctx, "Found In", models.SaveCustomFieldRequest{Name: "Found in", Type: models.FieldText})
+		assert.Equal(t, ErrInvalidFieldKey, err)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		for _, req := range []models.SaveCustomFieldRequest{
+			{Name: "Platform", Type: models.FieldEnum},
+			{Name: "Platform", Type: models.FieldEnum, Options: []string{"ios", "ios"}},
+			{Name: "Platform", Type: models.FieldMultiSelect, Options: []string{" "}},
+			{Name: "Platform", Type: models.FieldText, Options: []string{"ios"}},
+		} {
+			_, err := f.fields.SaveField(ctx, "platform", req)
+			assert.Equal(t, ErrInvalidFieldOptions, err, req)
+		}
+	})
+
+	t.Run("blank name", func(t *testing.T) {
+		_, err := f.fields.SaveField(ctx, "platform", models.SaveCustomFieldRequest{Name: "  ", Type: models.FieldText})
+		assert.Equal(t, ErrBlankFieldName, err)
+	})
+}
+
+func TestCustomFieldValues(t *testing.T) {
+	ctx := context.Background()
+	f := newCustomFieldFixture(t)
+
+	bug, err := f.create(t, map[string]interface{}{
+		"environment": "prod",
+		"severity":    float64(3),
+		"seen_on":     "2024-05-01",
+		"customer":    f.reporter.ID.Hex(),
+		"browsers":    []interface{}{"safari", "firefox", "safari"},
+		"notes":       "",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.FieldValues{
+		"environment": "prod",
+		"severity":    float64(3),
+		"seen_on":     "2024-05-01",
+		"customer":    f.reporter.ID.Hex(),
+		"browsers":    []string{"safari", "firefox"},
+	}, bug.Fields)
+
+	t.Run("invalid values", func(t *testing.T) {
+		for _, fields := range []map[string]interface{}{
+			{"environment": "moon"},
+			{"environment": "prod", "severity": "high"},
+			{"environment": "prod", "seen_on": "May 1st"},
+			{"environment": "prod", "customer": models.NewID().Hex()},
+			{"environment": "prod", "browsers": []interface{}{"lynx"}},
+			{"environment": "prod", "browsers": "firefox"},
+			{"environment": "prod", "color": "red"},
+		} {
+			_, err := f.create(t, fields)
+			assert.True(t, errors.Is(err, ErrInvalidFieldValue), fields)
+		}
+	})
+
+	t.Run("required on create", func(t *testing.T) {
+		_, err := f.create(t, nil)
+		assert.True(t, errors.Is(err, ErrInvalidFieldValue))
+		assert.Contains(t, err.Error(), `"environment" is required`)
+	})
+
+	t.Run("update sets and clears values", func(t *testing.T) {
+		updated, err := f.bugs.UpdateBug(ctx, bug.ID, models.UpdateBugRequest{
+			Fields: map[string]interface{}{"severity": nil, "browsers": []interface{}{}, "environment": "staging"},
+		}, f.reporter)
+		require.NoError(t, err)
+		assert.Equal(t, models.FieldValues{
+			"environment": "staging",
+			"seen_on":     "2024-05-01",
+			"customer":    f.reporter.ID.Hex(),
+		}, updated.Fields)
+
+		_, err = f.bugs.UpdateBug(ctx, bug.ID, models.UpdateBugRequest{
+			Fields: map[string]interface{}{"environment": nil},
+		}, f.reporter)
+		assert.True(t, errors.Is(err, ErrInvalidFieldValue))
+	})
+
+	t.Run("delete removes values", func(t *testing.T) {
+		require.NoError(t, f.fields.DeleteField(ctx, "seen_on"))
+		assert.Equal(t, ErrFieldNotFound, f.fields.DeleteField(ctx, "seen_on"))
+
+		stored, err := f.bugRepo.FindByID(ctx, bug.ID)
+		require.NoError(t, err)
+		assert.NotContains(t, stored.Fields, "seen_on")
+	})
+}
+
+func TestListBugsByCustomField(t *testing.T) {
+	ctx := context.Background()
+	f := newCustomFieldFixture(t)
+
+	for _, fields := range []map[string]interface{}{
+		{"environment": "prod", "severity": float64(1)},
+		{"environment": "staging", "severity": float64(10)},
+		{"environment": "prod", "severity": float64(2), "browsers": []interface{}{"chrome"}},
+	} {
+		_, err := f.create(t, fields)
+		require.NoError(t, err)
+	}
+
+	severities := func(filter models.BugFilter) []interface{} {
+		bugs, err := f.bugs.ListBugs(ctx, filter)
+		require.NoError(t, err)
+		var values []interface{}
+		for _, bug := range bugs {
+			values = append(values, bug.Fields["severity"])
+		}
+		return values
+	}
+
+	// Filter values arrive as query strings
+	assert.Equal(t, []interface{}{float64(2), float64(1)}, severities(models.BugFilter{
+		Fields: []models.FieldFilter{{Key: "environment", Value: "prod"}},
+		Sort:   models.BugSort{Key: "fields.severity", Desc: true},
+	}))
+	assert.Equal(t, []interface{}{float64(10)}, severities(models.BugFilter{
+		Fields: []models.FieldFilter{{Key: "severity", Value: "10"}},
+	}))
+	assert.Equal(t, []interface{}{float64(2)}, severities(models.BugFilter{
+		Fields: []models.FieldFilter{{Key: "browsers", Value: "chrome"}},
+	}))
+
+	for _, filter := range []models.BugFilter{
+		{Fields: []models.FieldFilter{{Key: "color", Value: "red"}}},
+		{Fields: []models.FieldFilter{{Key: "severity", Value: "high"}}},
+		{Fields: []models.FieldFilter{{Key: "environment", Value: "moon"}}},
+	} {
+		_, err := f.bugs.ListBugs(ctx, filter)
+		assert.True(t, errors.Is(err, ErrInvalidFilter), filter)
+	}
+	for _, key := range []string{"priority", "fields.browsers", "fields.color"} {
+		_, err := f.bugs.ListBugs(ctx, models.BugFilter{Sort: models.BugSort{Key: key}})
+		assert.True(t, errors.Is(err, ErrInvalidSort), key)
+	}
+}
@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"bug-tracker/models"
+)
+
+var (
+	ErrInvalidImportFormat = errors.New("import format must be csv, github or jira")
+	ErrInvalidImportFile   = errors.New("import file could not be read")
+	ErrInvalidImportField  = errors.New("unknown field in column mapping")
+)
+
+// importFields are the bug fields a generic CSV column mapping may target
+var importFields = []string{
+	"external_id", "title", "description", "status", "priority",
+	"reporter", "assignee", "created_at", "resolved_at",
+}
+
+// jiraColumns maps bug fields to the column headers of a Jira CSV export
+var jiraColumns = map[string]string{
+	"external_id": "Issue key",
+	"title":       "Summary",
+	"description": "Description",
+	"status":      "Status",
+	"priority":    "Priority",
+	"reporter":    "Reporter",
+	"assignee":    "Assignee",
+	"created_at":  "Created",
+	"resolved_at": "Resolved",
+}
+
+var importTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2/Jan/06 3:04 PM", // Jira
+	"1/2/2006 15:04",   // spreadsheet round-trips
+	"1/2/2006",
+}
+
+// readImportRecords parses r according to opts.Format
+func readImportRecords(r io.Reader, opts models.ImportOptions) ([]models.ImportRecord, error) {
+	switch opts.Format {
+	case "csv":
+		mapping := make(map[string]string, len(importFields))
+		for _, field := range importFields {
+			mapping[field] = field
+		}
+		for field, column := range opts.Mapping {
+			if _, ok := mapping[field]; !ok {
+				return nil, ErrInvalidImportField
+			}
+			mapping[field] = column
+		}
+		return readCSVRecords(r, mapping, "csv")
+	case "jira":
+		return readCSVRecords(r, jiraColumns, "jira")
+	case "github":
+		return readGitHubRecords(r)
+	default:
+		return nil, ErrInvalidImportFormat
+	}
+}
+
+func readCSVRecords(r io.Reader, mapping map[string]string, source string) ([]models.ImportRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, ErrInvalidImportFile
+	}
+
+	// Exports may repeat headers (e.g. Jira's multiple "Labels" columns);
+	// the first occurrence wins.
+	columnIndex := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF"))
+		if _, exists := columnIndex[name]; !exists {
+			columnIndex[name] = i
+		}
+	}
+	if _, ok := columnIndex[mapping["title"]]; !ok {
+		return nil, fmt.Errorf("%w: missing title column %q", ErrInvalidImportFile, mapping["title"])
+	}
+
+	var records []models.ImportRecord
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			records = append(records, models.ImportRecord{Line: line, Err: err})
+			continue
+		}
+
+		value := func(field string) string {
+			i, ok := columnIndex[mapping[field]]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		record := models.ImportRecord{
+			Line:        line,
+			Title:       value("title"),
+			Description: value("description"),
+			Status:      value("status"),
+			Priority:    value("priority"),
+			Reporter:    value("reporter"),
+			Assignee:    value("assignee"),
+		}
+		if record.CreatedAt, err = parseImportTime(value("created_at")); err != nil {
+			record.Err = fmt.Errorf("invalid created_at: %w", err)
+		}
+		if record.ResolvedAt, err = parseImportTime(value("resolved_at")); err != nil {
+			record.Err = fmt.Errorf("invalid resolved_at: %w", err)
+		}
+
+		if id := value("external_id"); id != "" {
+			record.ExternalID = source + ":" + id
+		} else {
+			record.ExternalID = source + ":" + contentHash(record)
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// githubIssue is the subset of a GitHub Issues API/export object we read
+type githubIssue struct {
+	Number      int        `json:"number"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	State       string     `json:"state"`
+	HTMLURL     string     `json:"html_url"`
+	CreatedAt   *time.Time `json:"created_at"`
+	ClosedAt    *time.Time `json:"closed_at"`
+	PullRequest *struct{}  `json:"pull_request"`
+	User        *struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Assignee *struct {
+		Login string `json:"login"`
+	} `json:"assignee"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+}
+
+func readGitHubRecords(r io.Reader) ([]models.ImportRecord, error) {
+	var issues []githubIssue
+	if err := json.NewDecoder(r).Decode(&issues); err != nil {
+		return nil, ErrInvalidImportFile
+	}
+
+	records := make([]models.ImportRecord, 0, len(issues))
+	for i, issue := range issues {
+		// The issues API also returns pull requests; they aren't bugs
+		if issue.PullRequest != nil {
+			continue
+		}
+
+		record := models.ImportRecord{
+			Line:        i + 1,
+			Title:       issue.Title,
+			Description: issue.Body,
+			Status:      issue.State,
+			CreatedAt:   issue.CreatedAt,
+			ResolvedAt:  issue.ClosedAt,
+		}
+		if issue.HTMLURL != "" {
+			record.ExternalID = "github:" + issue.HTMLURL
+		} else {
+			record.ExternalID = "github:#" + strconv.Itoa(issue.Number)
+		}
+		if issue.User != nil {
+			record.Reporter = issue.User.Login
+		}
+		if issue.Assignee != nil {
+			record.Assignee = issue.Assignee.Login
+		}
+		for _, label := range issue.Labels {
+			record.Labels = append(record.Labels, label.Name)
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func parseImportTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range importTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("unrecognized date %q", value)
+}
+
+// contentHash identifies a CSV row without an ID column so re-imports of the
+// same file are recognized as duplicates.
+func contentHash(record models.ImportRecord) string {
+	created := ""
+	if record.CreatedAt != nil {
+		created = record.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		record.Title, record.Description, record.Reporter, created,
+	}, "\x00")))
+	return hex.EncodeToString(sum[:12])
+}
@@ -0,0 +1,288 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"bug-tracker/authz"
+	"bug-tracker/logging"
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// ImportUseCaseInterface defines the interface for bulk bug imports
+type ImportUseCaseInterface interface {
+	ImportBugs(ctx context.Context, r io.Reader, opts models.ImportOptions, importer *models.User) (*models.ImportReport, error)
+}
+
+// importStatuses maps status names used by other trackers to ours
+var importStatuses = map[string]string{
+	"open":        "open",
+	"new":         "open",
+	"to do":       "open",
+	"todo":        "open",
+	"backlog":     "open",
+	"reopened":    "open",
+	"in-progress": "in-progress",
+	"in progress": "in-progress",
+	"in review":   "in-progress",
+	"resolved":    "resolved",
+	"closed":      "resolved",
+	"done":        "resolved",
+	"fixed":       "resolved",
+}
+
+// importPriorities maps priority names used by other trackers to ours
+var importPriorities = map[string]string{
+	"low":      "low",
+	"lowest":   "low",
+	"trivial":  "low",
+	"minor":    "low",
+	"p3":       "low",
+	"medium":   "medium",
+	"normal":   "medium",
+	"p2":       "medium",
+	"high":     "high",
+	"major":    "high",
+	"p1":       "high",
+	"critical": "critical",
+	"highest":  "critical",
+	"blocker":  "critical",
+	"urgent":   "critical",
+	"p0":       "critical",
+}
+
+type ImportUseCase struct {
+	bugRepo  repository.BugRepositoryInterface
+	userRepo repository.UserRepositoryInterface
+	roles    *authz.Roles
+}
+
+func NewImportUseCase(bugRepo repository.BugRepositoryInterface, userRepo repository.UserRepositoryInterface, roles *authz.Roles) *ImportUseCase {
+	return &ImportUseCase{
+		bugRepo:  bugRepo,
+		userRepo: userRepo,
+		roles:    roles,
+	}
+}
+
+// ImportBugs reads bugs from r and creates the ones not imported before.
+// Bugs are matched to earlier imports by their source ID, so re-running an
+// import is safe. With opts.DryRun nothing is written and the report shows
+// what would happen.
+func (uc *ImportUseCase) ImportBugs(ctx context.Context, r io.Reader, opts models.ImportOptions, importer *models.User) (*models.ImportReport, error) {
+	records, err := readImportRecords(r, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ImportReport{
+		DryRun: opts.DryRun,
+		Total:  len(records),
+		Rows:   make([]models.ImportRowResult, 0, len(records)),
+	}
+	users := &importUserResolver{userRepo: uc.userRepo, userMap: opts.UserMap, cache: make(map[string]*models.User)}
+	seen := make(map[string]bool)
+
+	for _, record := range records {
+		result := models.ImportRowResult{
+			Line:       record.Line,
+			ExternalID: record.ExternalID,
+			Title:      record.Title,
+		}
+
+		bug, err := uc.buildBug(ctx, record, importer, users, &result)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case bug == nil:
+			result.Action = "error"
+		case seen[record.ExternalID]:
+			result.Action = "skip"
+			result.Warnings = append(result.Warnings, "duplicate of an earlier row in this file")
+		default:
+			seen[record.ExternalID] = true
+			result.Action, err = uc.importBug(ctx, bug, opts.DryRun)
+			if err != nil {
+				return nil, err
+			}
+			if !bug.ID.IsZero() {
+				id := bug.ID
+				result.BugID = &id
+			}
+		}
+
+		switch result.Action {
+		case "create":
+			report.Created++
+		case "skip":
+			report.Skipped++
+		default:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	logging.FromContext(ctx).Info("bugs imported",
+		"format", opts.Format,
+		"dry_run", opts.DryRun,
+		"created", report.Created,
+		"skipped", report.Skipped,
+		"failed", report.Failed,
+	)
+	return report, nil
+}
+
+// buildBug validates record and converts it to a bug, recording problems on
+// result. It returns a nil bug when the record can't be imported.
+func (uc *ImportUseCase) buildBug(ctx context.Context, record models.ImportRecord, importer *models.User, users *importUserResolver, result *models.ImportRowResult) (*models.Bug, error) {
+	if record.Err != nil {
+		result.Errors = append(result.Errors, record.Err.Error())
+	}
+	if record.Title == "" {
+		result.Errors = append(result.Errors, "title is required")
+	}
+
+	status := "open"
+	if record.Status != "" {
+		var ok bool
+		if status, ok = importStatuses[strings.ToLower(record.Status)]; !ok {
+			result.Errors = append(result.Errors, fmt.Sprintf("unknown status %q", record.Status))
+		}
+	}
+
+	priority := "medium"
+	if record.Priority != "" {
+		var ok bool
+		if priority, ok = importPriorities[strings.ToLower(record.Priority)]; !ok {
+			result.Errors = append(result.Errors, fmt.Sprintf("unknown priority %q", record.Priority))
+		}
+	} else if p, ok := priorityFromLabels(record.Labels); ok {
+		priority = p
+	}
+
+	if len(result.Errors) > 0 {
+		return nil, nil
+	}
+
+	bug := &models.Bug{
+		Title:       record.Title,
+		Description: record.Description,
+		Status:      status,
+		Priority:    priority,
+		ReportedBy:  importer.ID,
+		ExternalID:  record.ExternalID,
+	}
+	if record.CreatedAt != nil {
+		bug.CreatedAt = *record.CreatedAt
+	}
+	if status == "resolved" {
+		bug.ResolvedAt = record.ResolvedAt
+		if bug.ResolvedAt == nil {
+			bug.ResolvedAt = record.CreatedAt
+		}
+	}
+
+	if record.Reporter != "" {
+		reporter, err := users.resolve(ctx, record.Reporter)
+		if err != nil {
+			return nil, err
+		}
+		if reporter != nil {
+			bug.ReportedBy = reporter.ID
+		} else {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("reporter %q not found; reported by importer", record.Reporter))
+		}
+	}
+
+	if record.Assignee != "" {
+		assignee, err := users.resolve(ctx, record.Assignee)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case assignee == nil:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("assignee %q not found; left unassigned", record.Assignee))
+		case !uc.roles.Can(assignee.Role, models.PermBugAssignable):
+			result.Warnings = append(result.Warnings, fmt.Sprintf("assignee %q can't be assigned bugs; left unassigned", record.Assignee))
+		default:
+			bug.AssignedTo = assignee.ID
+		}
+	}
+
+	return bug, nil
+}
+
+// importBug creates bug unless it was imported before, returning the action taken
+func (uc *ImportUseCase) importBug(ctx context.Context, bug *models.Bug, dryRun bool) (string, error) {
+	if dryRun {
+		existing, err := uc.bugRepo.FindByExternalID(ctx, bug.ExternalID)
+		if err != nil {
+			return "", err
+		}
+		if existing != nil {
+			bug.ID = existing.ID
+			return "skip", nil
+		}
+		return "create", nil
+	}
+
+	if bug.CreatedAt.IsZero() {
+		bug.CreatedAt = time.Now()
+	}
+	created, err := uc.bugRepo.CreateIfNotExists(ctx, bug)
+	if err != nil {
+		return "", err
+	}
+	if !created {
+		return "skip", nil
+	}
+	return "create", nil
+}
+
+// priorityFromLabels picks up priorities encoded as labels such as
+// "priority: high" or "P1", which is how GitHub projects usually track them.
+func priorityFromLabels(labels []string) (string, bool) {
+	for _, label := range labels {
+		name := strings.ToLower(strings.TrimSpace(label))
+		name = strings.TrimPrefix(name, "priority:")
+		name = strings.TrimPrefix(name, "priority/")
+		if priority, ok := importPriorities[strings.TrimSpace(name)]; ok {
+			return priority, true
+		}
+	}
+	return "", false
+}
+
+// importUserResolver maps source user identities to existing users by email
+type importUserResolver struct {
+	userRepo repository.UserRepositoryInterface
+	userMap  map[string]string
+	cache    map[string]*models.User
+}
+
+func (r *importUserResolver) resolve(ctx context.Context, identity string) (*models.User, error) {
+	email := identity
+	if mapped, ok := r.userMap[identity]; ok {
+		email = mapped
+	}
+	email = strings.TrimSpace(email)
+	if !strings.Contains(email, "@") {
+		return nil, nil
+	}
+
+	if user, ok := r.cache[email]; ok {
+		return user, nil
+	}
+	user, err := r.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	r.cache[email] = user
+	return user, nil
+}
@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"bug-tracker/models"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupImportUseCase(t *testing.T) (*ImportUseCase, *MockBugRepository, *models.User, *models.User) {
+	mockBugRepo := NewMockBugRepository()
+	mockUserRepo := NewMockUserRepository()
+
+	importer := &models.User{ID: models.NewID(), Name: "Admin", Email: "admin@example.com", Role: "admin"}
+	developer := &models.User{ID: models.NewID(), Name: "Dev", Email: "dev@example.com", Role: "developer"}
+	require.NoError(t, mockUserRepo.Create(context.Background(), importer))
+	require.NoError(t, mockUserRepo.Create(context.Background(), developer))
+
+	return NewImportUseCase(mockBugRepo, mockUserRepo, nil), mockBugRepo, importer, developer
+}
+
+func TestImportCSV(t *testing.T) {
+	importUseCase, mockBugRepo, importer, developer := setupImportUseCase(t)
+
+	csvData := "Key,Name,Details,State,Severity,Owner,Opened\n" +
+		"1,Login broken,Cannot log in,in progress,High,dev@example.com,2024-01-02\n" +
+		"2,Typo on page,,Closed,Trivial,,2024-01-03\n" +
+		"3,,No title,open,low,,\n" +
+		"4,Bad priority,,open,whenever,,\n"
+	opts := models.ImportOptions{
+		Format: "csv",
+		Mapping: map[string]string{
+			"external_id": "Key",
+			"title":       "Name",
+			"description": "Details",
+			"status":      "State",
+			"priority":    "Severity",
+			"assignee":    "Owner",
+			"created_at":  "Opened",
+		},
+		DryRun: true,
+	}
+
+	t.Run("dry run reports without writing", func(t *testing.T) {
+		report, err := importUseCase.ImportBugs(context.Background(), strings.NewReader(csvData), opts, importer)
+		assert.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 4, report.Total)
+		assert.Equal(t, 2, report.Created)
+		assert.Equal(t, 2, report.Failed)
+		assert.Equal(t, []string{"title is required"}, report.Rows[2].Errors)
+		assert.Equal(t, []string{`unknown priority "whenever"`}, report.Rows[3].Errors)
+		assert.Empty(t, mockBugRepo.bugs)
+	})
+
+	t.Run("import maps fields and users", func(t *testing.T) {
+		opts := opts
+		opts.DryRun = false
+
+		report, err := importUseCase.ImportBugs(context.Background(), strings.NewReader(csvData), opts, importer)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Created)
+		require.Len(t, mockBugRepo.bugs, 2)
+
+		bug, _ := mockBugRepo.FindByExternalID(context.Background(), "csv:1")
+		require.NotNil(t, bug)
+		assert.Equal(t, "in-progress", bug.Status)
+		assert.Equal(t, "high", bug.Priority)
+		assert.Equal(t, developer.ID, bug.AssignedTo)
+		assert.Equal(t, importer.ID, bug.ReportedBy)
+		assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), bug.CreatedAt)
+
+		bug, _ = mockBugRepo.FindByExternalID(context.Background(), "csv:2")
+		require.NotNil(t, bug)
+		assert.Equal(t, "resolved", bug.Status)
+		assert.Equal(t, "low", bug.Priority)
+		assert.NotNil(t, bug.ResolvedAt)
+	})
+
+	t.Run("re-running is idempotent", func(t *testing.T) {
+		opts := opts
+		opts.DryRun = false
+
+		report, err := importUseCase.ImportBugs(context.Background(), strings.NewReader(csvData), opts, importer)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, report.Created)
+		assert.Equal(t, 2, report.Skipped)
+		assert.Len(t, mockBugRepo.bugs, 2)
+	})
+}
+
+func TestImportCSVWithoutIDColumn(t *testing.T) {
+	importUseCase, mockBugRepo, importer, _ := setupImportUseCase(t)
+
+	csvData := "title,description\nSame bug,Same text\nSame bug,Same text\nOther bug,Text\n"
+	opts := models.ImportOptions{Format: "csv"}
+
+	report, err := importUseCase.ImportBugs(context.Background(), strings.NewReader(csvData), opts, importer)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Len(t, mockBugRepo.bugs, 2)
+
+	report, err = importUseCase.ImportBugs(context.Background(), strings.NewReader(csvData), opts, importer)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Created)
+	assert.Len(t, mockBugRepo.bugs, 2)
+}
+
+func TestImportGitHub(t *testing.T) {
+	importUseCase, mockBugRepo, importer, developer := setupImportUseCase(t)
+
+	issues := `[
+		{"number": 1, "title": "Crash", "body": "Stack trace", "state": "closed",
+		 "html_url": "https://github.com/acme/app/issues/1",
+		 "created_at": "2024-01-01T10:00:00Z", "closed_at": "2024-01-02T10:00:00Z",
+		 "user": {"login": "reporter"}, "assignee": {"login": "octodev"},
+		 "labels": [{"name": "bug"}, {"name": "priority: critical"}]},
+		{"number": 2, "title": "Add feature", "state": "open", "pull_request": {}}
+	]`
+	opts := models.ImportOptions{
+		Format:  "github",
+		UserMap: map[string]string{"octodev": developer.Email},
+	}
+
+	report, err := importUseCase.ImportBugs(context.Background(), strings.NewReader(issues), opts, importer)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Total)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, []string{`reporter "reporter" not found; reported by importer`}, report.Rows[0].Warnings)
+
+	bug, _ := mockBugRepo.FindByExternalID(context.Background(), "github:https://github.com/acme/app/issues/1")
+	require.NotNil(t, bug)
+	assert.Equal(t, "resolved", bug.Status)
+	assert.Equal(t, "critical", bug.Priority)
+	assert.Equal(t, developer.ID, bug.AssignedTo)
+	assert.Equal(t, time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), *bug.ResolvedAt)
+}
+
+func TestImportJira(t *testing.T) {
+	importUseCase, mockBugRepo, importer, developer := setupImportUseCase(t)
+
+	jiraCSV := "Summary,Issue key,Issue id,Status,Priority,Assignee,Reporter,Created,Resolved,Description\n" +
+		"Checkout fails,SHOP-7,10007,Done,Highest,jdev,admin@example.com,15/Jan/24 3:04 PM,16/Jan/24 9:00 AM,Payment error\n"
+	opts := models.ImportOptions{
+		Format:  "jira",
+		UserMap: map[string]string{"jdev": developer.Email},
+	}
+
+	report, err := importUseCase.ImportBugs(context.Background(), strings.NewReader(jiraCSV), opts, importer)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+
+	bug, _ := mockBugRepo.FindByExternalID(context.Background(), "jira:SHOP-7")
+	require.NotNil(t, bug)
+	assert.Equal(t, "Checkout fails", bug.Title)
+	assert.Equal(t, "Payment error", bug.Description)
+	assert.Equal(t, "resolved", bug.Status)
+	assert.Equal(t, "critical", bug.Priority)
+	assert.Equal(t, importer.ID, bug.ReportedBy)
+	assert.Equal(t, developer.ID, bug.AssignedTo)
+	assert.Equal(t, time.Date(2024, 1, 15, 15, 4, 0, 0, time.UTC), bug.CreatedAt)
+}
+
+func TestImportInvalidInput(t *testing.T) {
+	importUseCase, _, importer, _ := setupImportUseCase(t)
+
+	_, err := importUseCase.ImportBugs(context.Background(), strings.NewReader(""), models.ImportOptions{Format: "trello"}, importer)
+	assert.Equal(t, ErrInvalidImportFormat, err)
+
+	_, err = importUseCase.ImportBugs(context.Background(), strings.NewReader("not json"), models.ImportOptions{Format: "github"}, importer)
+	assert.Equal(t, ErrInvalidImportFile, err)
+
+	_, err = importUseCase.ImportBugs(context.Background(), strings.NewReader("name\nx\n"), models.ImportOptions{Format: "csv"}, importer)
+	assert.ErrorIs(t, err, ErrInvalidImportFile)
+
+	_, err = importUseCase.ImportBugs(context.Background(), strings.NewReader("title\nx\n"), models.ImportOptions{
+		Format:  "csv",
+		Mapping: map[string]string{"severity": "x"},
+	}, importer)
+	assert.Equal(t, ErrInvalidImportField, err)
+}
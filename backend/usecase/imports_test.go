@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"go/build"
+	"strings"
+	"testing"
+)
+
+// TestStorageIndependence keeps the domain, use case and HTTP layers free of
+// the MongoDB driver, so they work with every storage backend. Only the
+// packages under repository and the wiring in app may depend on it.
+func TestStorageIndependence(t *testing.T) {
+	for _, dir := range []string{"../models", "../usecase", "../controller", "../router"} {
+		pkg, err := build.ImportDir(dir, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		imports := append(append([]string{}, pkg.Imports...), pkg.TestImports...)
+		path, chain := findImport(t, imports, "go.mongodb.org/mongo-driver", map[string]bool{})
+		if path == "" {
+			continue
+		}
+		if len(chain) == 0 {
+			t.Errorf("%s imports %s", dir, path)
+		} else {
+			t.Errorf("%s depends on %s through %s", dir, path, strings.Join(chain, " -> "))
+		}
+	}
+}
+
+// findImport searches the module's packages reachable from imports for one
+// starting with prefix, returning it and the chain of imports leading to it
+func findImport(t *testing.T, imports []string, prefix string, seen map[string]bool) (string, []string) {
+	for _, path := range imports {
+		if strings.HasPrefix(path, prefix) {
+			return path, nil
+		}
+		// Third-party packages are leaves; only follow this module's own
+		if seen[path] || !strings.HasPrefix(path, "bug-tracker/") {
+			continue
+		}
+		seen[path] = true
+
+		pkg, err := build.ImportDir("../"+strings.TrimPrefix(path, "bug-tracker/"), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found, chain := findImport(t, pkg.Imports, prefix, seen); found != "" {
+			return found, append([]string{path}, chain...)
+		}
+	}
+	return "", nil
+}
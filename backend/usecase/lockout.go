@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"bug-tracker/logging"
+	"bug-tracker/models"
+)
+
+// ErrAccountLocked is matched by AccountLockedError
+var ErrAccountLocked = errors.New("account locked after too many failed logins")
+
+// AccountLockedError is returned by Login while an account is locked
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return ErrAccountLocked.Error()
+}
+
+func (e *AccountLockedError) Is(target error) bool {
+	return target == ErrAccountLocked
+}
+
+// LockoutPolicy locks an account after Threshold consecutive wrong
+// passwords. The first lock lasts Duration and each further failure
+// doubles it, up to MaxDuration if that is longer. A zero Threshold
+// disables lockout.
+type LockoutPolicy struct {
+	Threshold   int
+	Duration    time.Duration
+	MaxDuration time.Duration
+}
+
+// lockFor returns how long to lock an account after failures consecutive
+// wrong passwords, or 0 to leave it unlocked
+func (p LockoutPolicy) lockFor(failures int) time.Duration {
+	if p.Threshold <= 0 || failures < p.Threshold {
+		return 0
+	}
+	d := p.Duration
+	for i := p.Threshold; i < failures && d < p.MaxDuration; i++ {
+		d *= 2
+	}
+	if d > p.MaxDuration && p.MaxDuration > p.Duration {
+		d = p.MaxDuration
+	}
+	return d
+}
+
+// recordLoginFailure counts a wrong password for user and locks the
+// account once the policy says so
+func (uc *AuthUseCase) recordLoginFailure(ctx context.Context, user *models.User) error {
+	failures, err := uc.userRepo.RecordLoginFailure(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	lockFor := uc.lockout.lockFor(failures)
+	if lockFor == 0 {
+		return nil
+	}
+	until := time.Now().Add(lockFor)
+	if err := uc.userRepo.SetLockout(ctx, user.ID, failures, &until); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Warn("account locked", "user_id", user.ID.Hex(), "failed_logins", failures, "until", until)
+	return nil
+}
+
+// ListLockouts returns the users with failed logins since their last
+// successful one, including those currently locked
+func (uc *AuthUseCase) ListLockouts(ctx context.Context) (_ []models.LockoutStatus, err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.ListLockouts")
+	defer func() { endSpan(span, err) }()
+
+	users, err := uc.userRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	lockouts := []models.LockoutStatus{}
+	for _, user := range users {
+		if user.FailedLogins == 0 && user.LockedUntil == nil {
+			continue
+		}
+		lockouts = append(lockouts, user.LockoutStatus(now))
+	}
+	return lockouts, nil
+}
+
+// Unlock clears the failed logins and any lock on the user's account
+func (uc *AuthUseCase) Unlock(ctx context.Context, id models.ID) (err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.Unlock")
+	defer func() { endSpan(span, err) }()
+
+	user, err := uc.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	if err := uc.userRepo.SetLockout(ctx, id, 0, nil); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("account unlocked", "user_id", id.Hex())
+	return nil
+}
@@ -0,0 +1,370 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"bug-tracker/logging"
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// MilestoneUseCaseInterface defines the interface for milestone use cases
+type MilestoneUseCaseInterface interface {
+	ListMilestones(ctx context.Context, state string) ([]*models.Milestone, error)
+	GetMilestone(ctx context.Context, id models.ID) (*models.Milestone, error)
+	CreateMilestone(ctx context.Context, req models.SaveMilestoneRequest) (*models.Milestone, error)
+	UpdateMilestone(ctx context.Context, id models.ID, req models.SaveMilestoneRequest) (*models.Milestone, error)
+	DeleteMilestone(ctx context.Context, id models.ID) error
+	GetProgress(ctx context.Context, id models.ID) (*models.MilestoneProgress, error)
+	ReleaseNotes(ctx context.Context, id models.ID) (string, error)
+}
+
+var (
+	ErrMilestoneNotFound  = errors.New("milestone not found")
+	ErrDuplicateMilestone = errors.New("a milestone with this name already exists")
+	ErrInvalidDueDate     = errors.New("due dates are written YYYY-MM-DD")
+	ErrInvalidState       = errors.New("milestone state must be open or closed")
+)
+
+// maxBurndownDays caps the days a burndown covers, counting back from its
+// last day
+const maxBurndownDays = 366
+
+// bugStatuses are the statuses progress counts bugs by, in workflow order
+var bugStatuses = []string{"open", "in-progress", "resolved"}
+
+// releaseNotePriorities orders the sections of release notes
+var releaseNotePriorities = []string{"critical", "high", "medium", "low"}
+
+// markdownEscaper keeps titles from being read as Markdown syntax
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "\r", "", "\n", " ",
+)
+
+type MilestoneUseCase struct {
+	milestoneRepo repository.MilestoneRepositoryInterface
+	bugRepo       repository.BugRepositoryInterface
+	now           func() time.Time
+}
+
+func NewMilestoneUseCase(milestoneRepo repository.MilestoneRepositoryInterface, bugRepo repository.BugRepositoryInterface) *MilestoneUseCase {
+	return &MilestoneUseCase{
+		milestoneRepo: milestoneRepo,
+		bugRepo:       bugRepo,
+		now:           time.Now,
+	}
+}
+
+// ListMilestones returns the milestones in state, or every milestone when
+// state is empty, ordered by due date
+func (uc *MilestoneUseCase) ListMilestones(ctx context.Context, state string) (_ []*models.Milestone, err error) {
+	ctx, span := tracer.Start(ctx, "MilestoneUseCase.ListMilestones")
+	defer func() { endSpan(span, err) }()
+
+	if state != "" && state != models.MilestoneOpen && state != models.MilestoneClosed {
+		return nil, ErrInvalidState
+	}
+
+	all, err := uc.milestoneRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	milestones := []*models.Milestone{}
+	for _, milestone := range all {
+		if state == "" || milestone.State == state {
+			milestones = append(milestones, milestone)
+		}
+	}
+	return milestones, nil
+}
+
+func (uc *MilestoneUseCase) GetMilestone(ctx context.Context, id models.ID) (_ *models.Milestone, err error) {
+	ctx, span := tracer.Start(ctx, "MilestoneUseCase.GetMilestone")
+	defer func() { endSpan(span, err) }()
+
+	return uc.findMilestone(ctx, id)
+}
+
+func (uc *MilestoneUseCase) CreateMilestone(ctx context.Context, req models.SaveMilestoneRequest) (_ *models.Milestone, err error) {
+	ctx, span := tracer.Start(ctx, "MilestoneUseCase.CreateMilestone")
+	defer func() { endSpan(span, err) }()
+
+	milestone := &models.Milestone{State: models.MilestoneOpen}
+	if err := uc.fillMilestone(ctx, milestone, req); err != nil {
+		return nil, err
+	}
+	if err := uc.milestoneRepo.Create(ctx, milestone); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("milestone created", "milestone_id", milestone.ID.Hex(), "name", milestone.Name)
+	return milestone, nil
+}
+
+// UpdateMilestone replaces the details of a milestone. Closing it records
+// when; reopening it clears that.
+func (uc *MilestoneUseCase) UpdateMilestone(ctx context.Context, id models.ID, req models.SaveMilestoneRequest) (_ *models.Milestone, err error) {
+	ctx, span := tracer.Start(ctx, "MilestoneUseCase.UpdateMilestone")
+	defer func() { endSpan(span, err) }()
+
+	milestone, err := uc.findMilestone(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.fillMilestone(ctx, milestone, req); err != nil {
+		return nil, err
+	}
+	if err := uc.milestoneRepo.Update(ctx, milestone); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("milestone updated", "milestone_id", id.Hex(), "state", milestone.State)
+	return milestone, nil
+}
+
+// DeleteMilestone deletes a milestone and clears it from the bugs naming it
+// as their affected or fix version
+func (uc *MilestoneUseCase) DeleteMilestone(ctx context.Context, id models.ID) (err error) {
+	ctx, span := tracer.Start(ctx, "MilestoneUseCase.DeleteMilestone")
+	defer func() { endSpan(span, err) }()
+
+	if _, err := uc.findMilestone(ctx, id); err != nil {
+		return err
+	}
+	// Bugs are cleared first, so a failure never leaves them naming a
+	// milestone that is gone
+	if err := uc.bugRepo.ClearMilestone(ctx, id); err != nil {
+		return err
+	}
+	if err := uc.milestoneRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("milestone deleted", "milestone_id", id.Hex())
+	return nil
+}
+
+// GetProgress counts the bugs to be fixed in a milestone and charts how many
+// were still open at the end of each day since it was created. The
+// burndown follows the bugs' current fix version; bugs moved to another
+// milestone drop out of its history too.
+func (uc *MilestoneUseCase) GetProgress(ctx context.Context, id models.ID) (_ *models.MilestoneProgress, err error) {
+	ctx, span := tracer.Start(ctx, "MilestoneUseCase.GetProgress")
+	defer func() { endSpan(span, err) }()
+
+	milestone, err := uc.findMilestone(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var bugs []*models.Bug
+	counts := make(map[string]int)
+	err = uc.bugRepo.Stream(ctx, models.BugFilter{FixVersion: &id}, func(bug *models.Bug) error {
+		bugs = append(bugs, bug)
+		counts[bug.Status]++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &models.MilestoneProgress{
+		Milestone: milestone,
+		Total:     len(bugs),
+		Closed:    counts["resolved"],
+		ByStatus:  make([]models.CountBucket, len(bugStatuses)),
+		Burndown:  uc.burndown(milestone, bugs),
+	}
+	progress.Open = progress.Total - progress.Closed
+	if progress.Total > 0 {
+		progress.PercentComplete = math.Round(float64(progress.Closed)/float64(progress.Total)*1000) / 10
+	}
+	for i, status := range bugStatuses {
+		progress.ByStatus[i] = models.CountBucket{Key: status, Count: counts[status]}
+	}
+	return progress, nil
+}
+
+// ReleaseNotes lists the resolved bugs of a milestone in Markdown, grouped
+// by priority
+func (uc *MilestoneUseCase) ReleaseNotes(ctx context.Context, id models.ID) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "MilestoneUseCase.ReleaseNotes")
+	defer func() { endSpan(span, err) }()
+
+	milestone, err := uc.findMilestone(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	byPriority := make(map[string][]*models.Bug)
+	filter := models.BugFilter{Status: "resolved", FixVersion: &id, Sort: models.BugSort{Key: "title"}}
+	err = uc.bugRepo.Stream(ctx, filter, func(bug *models.Bug) error {
+		// Imported bugs may carry priorities of their own, listed last
+		priority := bug.Priority
+		if !containsString(releaseNotePriorities, priority) {
+			priority = ""
+		}
+		byPriority[priority] = append(byPriority[priority], bug)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", markdownEscaper.Replace(milestone.Name))
+	switch {
+	case milestone.ClosedAt != nil:
+		fmt.Fprintf(&b, "Released %s.\n\n", milestone.ClosedAt.UTC().Format(time.DateOnly))
+	case milestone.DueDate != "":
+		fmt.Fprintf(&b, "Due %s.\n\n", milestone.DueDate)
+	}
+	if description := strings.TrimSpace(milestone.Description); description != "" {
+		b.WriteString(description + "\n\n")
+	}
+
+	b.WriteString("## Fixed\n")
+	if len(byPriority) == 0 {
+		b.WriteString("\nNo bugs were fixed in this milestone.\n")
+		return b.String(), nil
+	}
+	section := func(heading string, bugs []*models.Bug) {
+		if len(bugs) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", heading)
+		for _, bug := range bugs {
+			fmt.Fprintf(&b, "- %s (%s)\n", markdownEscaper.Replace(bug.Title), bug.ID.Hex())
+		}
+	}
+	for _, priority := range releaseNotePriorities {
+		section(strings.ToUpper(priority[:1])+priority[1:], byPriority[priority])
+	}
+	section("Other", byPriority[""])
+	return b.String(), nil
+}
+
+func (uc *MilestoneUseCase) findMilestone(ctx context.Context, id models.ID) (*models.Milestone, error) {
+	milestone, err := uc.milestoneRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if milestone == nil {
+		return nil, ErrMilestoneNotFound
+	}
+	return milestone, nil
+}
+
+// fillMilestone validates req and copies it to milestone, recording when it
+// was closed
+func (uc *MilestoneUseCase) fillMilestone(ctx context.Context, milestone *models.Milestone, req models.SaveMilestoneRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if req.DueDate != "" {
+		if _, err := time.Parse(time.DateOnly, req.DueDate); err != nil {
+			return ErrInvalidDueDate
+		}
+	}
+	if req.State != "" && req.State != models.MilestoneOpen && req.State != models.MilestoneClosed {
+		return ErrInvalidState
+	}
+
+	milestones, err := uc.milestoneRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, other := range milestones {
+		if other.ID != milestone.ID && strings.EqualFold(other.Name, name) {
+			return ErrDuplicateMilestone
+		}
+	}
+
+	milestone.Name = name
+	milestone.Description = req.Description
+	milestone.DueDate = req.DueDate
+	if req.State != "" && req.State != milestone.State {
+		milestone.State = req.State
+		milestone.ClosedAt = nil
+		if req.State == models.MilestoneClosed {
+			now := uc.now()
+			milestone.ClosedAt = &now
+		}
+	}
+	return nil
+}
+
+// burndown returns the number of bugs left open at the end of each day from
+// the milestone's creation until it was closed, or until today
+func (uc *MilestoneUseCase) burndown(milestone *models.Milestone, bugs []*models.Bug) []models.BurndownPoint {
+	end := uc.now()
+	if milestone.ClosedAt != nil {
+		end = *milestone.ClosedAt
+	}
+	first, last := startOfDay(milestone.CreatedAt), startOfDay(end)
+	if last.Before(first) {
+		last = first
+	}
+	if days := int(last.Sub(first).Hours()/24) + 1; days > maxBurndownDays {
+		first = last.AddDate(0, 0, 1-maxBurndownDays)
+	}
+
+	var due time.Time
+	if milestone.DueDate != "" {
+		due, _ = time.Parse(time.DateOnly, milestone.DueDate)
+	}
+
+	remaining := func(cutoff time.Time) int {
+		count := 0
+		for _, bug := range bugs {
+			resolvedAt := bugResolvedAt(bug)
+			if bug.CreatedAt.Before(cutoff) && (resolvedAt == nil || !resolvedAt.Before(cutoff)) {
+				count++
+			}
+		}
+		return count
+	}
+	start := remaining(first.AddDate(0, 0, 1))
+
+	points := []models.BurndownPoint{}
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		point := models.BurndownPoint{Date: day.Format(time.DateOnly), Remaining: remaining(day.AddDate(0, 0, 1))}
+		if !due.IsZero() {
+			// Burn down evenly from the first day's count to none on the
+			// due date
+			ideal := 0.0
+			if span := due.Sub(first).Hours() / 24; span > 0 {
+				ideal = math.Max(0, float64(start)*(1-day.Sub(first).Hours()/24/span))
+				ideal = math.Round(ideal*100) / 100
+			}
+			point.Ideal = &ideal
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// bugResolvedAt returns when bug was resolved, falling back to its last
+// update for resolved bugs that predate resolution tracking
+func bugResolvedAt(bug *models.Bug) *time.Time {
+	if bug.ResolvedAt != nil || bug.Status != "resolved" {
+		return bug.ResolvedAt
+	}
+	return &bug.UpdatedAt
+}
+
+// startOfDay truncates t to midnight UTC
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,300 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type milestoneFixture struct {
+	milestones    *MilestoneUseCase
+	bugs          *BugUseCase
+	milestoneRepo *memory.MilestoneRepository
+	bugRepo       *memory.BugRepository
+	reporter      *models.User
+}
+
+func newMilestoneFixture(t *testing.T, now time.Time) *milestoneFixture {
+	t.Helper()
+	milestoneRepo := memory.NewMilestoneRepository()
+	bugRepo := memory.NewBugRepository()
+	userRepo := memory.NewUserRepository()
+	reporter := &models.User{Name: "Re Porter", Email: "reporter@example.com", Role: models.RoleManager}
+	require.NoError(t, userRepo.Create(context.Background(), reporter))
+
+	milestones := NewMilestoneUseCase(milestoneRepo, bugRepo)
+	milestones.now = func() time.Time { return now }
+	return &milestoneFixture{
+		milestones:    milestones,
+		bugs:          NewBugUseCase(bugRepo, userRepo, memory.NewCustomFieldRepository(), memory.NewBugTemplateRepository(), milestoneRepo, nil),
+		milestoneRepo: milestoneRepo,
+		bugRepo:       bugRepo,
+		reporter:      reporter,
+	}
+}
+
+// createdAt moves the creation of a milestone back in time
+func (f *milestoneFixture) createdAt(t *testing.T, milestone *models.Milestone, at time.Time) {
+	t.Helper()
+	milestone.CreatedAt = at
+	require.NoError(t, f.milestoneRepo.Update(context.Background(), milestone))
+}
+
+// addBug stores bug with the timestamps and status it was given
+func (f *milestoneFixture) addBug(t *testing.T, bug models.Bug) *models.Bug {
+	t.Helper()
+	bug.ExternalID = models.NewID().Hex()
+	bug.ReportedBy = f.reporter.ID
+	if bug.UpdatedAt.IsZero() {
+		bug.UpdatedAt = bug.CreatedAt
+	}
+	_, err := f.bugRepo.CreateIfNotExists(context.Background(), &bug)
+	require.NoError(t, err)
+	return &bug
+}
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestSaveMilestone(t *testing.T) {
+	ctx := context.Background()
+	now := date("2024-05-04T12:00:00Z")
+	f := newMilestoneFixture(t, now)
+
+	v2, err := f.milestones.CreateMilestone(ctx, models.SaveMilestoneRequest{Name: " 2.0 ", DueDate: "2024-06-01"})
+	require.NoError(t, err)
+	assert.Equal(t, "2.0", v2.Name)
+	assert.Equal(t, models.MilestoneOpen, v2.State)
+	assert.Nil(t, v2.ClosedAt)
+	_, err = f.milestones.CreateMilestone(ctx, models.SaveMilestoneRequest{Name: "1.0", DueDate: "2024-05-01"})
+	require.NoError(t, err)
+
+	t.Run("names are unique", func(t *testing.T) {
+		_, err := f.milestones.CreateMilestone(ctx, models.SaveMilestoneRequest{Name: "2.0"})
+		assert.Equal(t, ErrDuplicateMilestone, err)
+
+		// A milestone keeps its own name
+		_, err = f.milestones.UpdateMilestone(ctx, v2.ID, models.SaveMilestoneRequest{Name: "2.0", DueDate: "2024-06-01"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("due dates", func(t *testing.T) {
+		_, err := f.milestones.CreateMilestone(ctx, models.SaveMilestoneRequest{Name: "3.0", DueDate: "June 1st"})
+		assert.Equal(t, ErrInvalidDueDate, err)
+	})
+
+	t.Run("closing records when", func(t *testing.T) {
+		closed, err := f.milestones.UpdateMilestone(ctx, v2.ID, models.SaveMilestoneRequest{Name: "2.0", State: models.MilestoneClosed})
+		require.NoError(t, err)
+		assert.Equal(t, models.MilestoneClosed, closed.State)
+		require.NotNil(t, closed.ClosedAt)
+		assert.Equal(t, now, *closed.ClosedAt)
+		assert.Empty(t, closed.DueDate)
+
+		// Leaving the state out keeps it
+		renamed, err := f.milestones.UpdateMilestone(ctx, v2.ID, models.SaveMilestoneRequest{Name: "2.0.0"})
+		require.NoError(t, err)
+		assert.Equal(t, models.MilestoneClosed, renamed.State)
+		assert.NotNil(t, renamed.ClosedAt)
+
+		open, err := f.milestones.ListMilestones(ctx, models.MilestoneOpen)
+		require.NoError(t, err)
+		require.Len(t, open, 1)
+		assert.Equal(t, "1.0", open[0].Name)
+
+		reopened, err := f.milestones.UpdateMilestone(ctx, v2.ID, models.SaveMilestoneRequest{Name: "2.0", State: models.MilestoneOpen})
+		require.NoError(t, err)
+		assert.Nil(t, reopened.ClosedAt)
+	})
+
+	t.Run("list by state", func(t *testing.T) {
+		all, err := f.milestones.ListMilestones(ctx, "")
+		require.NoError(t, err)
+		assert.Len(t, all, 2)
+
+		closed, err := f.milestones.ListMilestones(ctx, models.MilestoneClosed)
+		require.NoError(t, err)
+		assert.NotNil(t, closed)
+		assert.Empty(t, closed)
+
+		_, err = f.milestones.ListMilestones(ctx, "released")
+		assert.Equal(t, ErrInvalidState, err)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := f.milestones.GetMilestone(ctx, models.NewID())
+		assert.Equal(t, ErrMilestoneNotFound, err)
+		_, err = f.milestones.UpdateMilestone(ctx, models.NewID(), models.SaveMilestoneRequest{Name: "4.0"})
+		assert.Equal(t, ErrMilestoneNotFound, err)
+		assert.Equal(t, ErrMilestoneNotFound, f.milestones.DeleteMilestone(ctx, models.NewID()))
+	})
+}
+
+func TestBugVersions(t *testing.T) {
+	ctx := context.Background()
+	f := newMilestoneFixture(t, time.Now())
+	v1, err := f.milestones.CreateMilestone(ctx, models.SaveMilestoneRequest{Name: "1.0"})
+	require.NoError(t, err)
+	v2, err := f.milestones.CreateMilestone(ctx, models.SaveMilestoneRequest{Name: "2.0"})
+	require.NoError(t, err)
+
+	_, err = f.bugs.CreateBug(ctx, models.CreateBugRequest{
+		Title: "Crash", Description: "It crashed", Priority: "high", FixVersion: models.NewID(),
+	}, f.reporter.ID)
+	assert.Equal(t, ErrMilestoneNotFound, err)
+
+	bug, err := f.bugs.CreateBug(ctx, models.CreateBugRequest{
+		Title: "Crash", Description: "It crashed", Priority: "high", AffectedVersion: v1.ID, FixVersion: v2.ID,
+	}, f.reporter.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &models.MilestoneRef{ID: v1.ID, Name: "1.0"}, bug.AffectedVersion)
+	assert.Equal(t, &models.MilestoneRef{ID: v2.ID, Name: "2.0"}, bug.FixVersion)
+
+	// Leaving a version out keeps it, an empty one clears it
+	updated, err := f.bugs.UpdateBug(ctx, bug.ID, models.UpdateBugRequest{AffectedVersion: &models.NilID}, f.reporter)
+	require.NoError(t, err)
+	assert.Nil(t, updated.AffectedVersion)
+	assert.NotNil(t, updated.FixVersion)
+
+	unknown := models.NewID()
+	_, err = f.bugs.UpdateBug(ctx, bug.ID, models.UpdateBugRequest{FixVersion: &unknown}, f.reporter)
+	assert.Equal(t, ErrMilestoneNotFound, err)
+
+	bugs, err := f.bugs.ListBugs(ctx, models.BugFilter{FixVersion: &v2.ID})
+	require.NoError(t, err)
+	assert.Len(t, bugs, 1)
+
+	// Deleting a milestone clears it from its bugs
+	require.NoError(t, f.milestones.DeleteMilestone(ctx, v2.ID))
+	found, err := f.bugs.GetBugByID(ctx, bug.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found.FixVersion)
+	stored, err := f.bugRepo.FindByID(ctx, bug.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.FixVersion.IsZero())
+}
+
+func TestMilestoneProgress(t *testing.T) {
+	ctx := context.Background()
+	f := newMilestoneFixture(t, date("2024-05-04T12:00:00Z"))
+	milestone, err := f.milestones.CreateMilestone(ctx, models.SaveMilestoneRequest{Name: "2.0", DueDate: "2024-05-05"})
+	require.NoError(t, err)
+	f.createdAt(t, milestone, date("2024-05-01T09:00:00Z"))
+
+	resolvedAt := date("2024-05-02T10:00:00Z")
+	f.addBug(t, models.Bug{Title: "A", Status: "resolved", FixVersion: milestone.ID,
+		CreatedAt: date("2024-04-30T09:00:00Z"), ResolvedAt: &resolvedAt})
+	f.addBug(t, models.Bug{Title: "B", Status: "open", FixVersion: milestone.ID,
+		CreatedAt: date("2024-05-01T10:00:00Z")})
+	f.addBug(t, models.Bug{Title: "C", Status: "in-progress", FixVersion: milestone.ID,
+		CreatedAt: date("2024-05-03T08:00:00Z")})
+	// Resolved before resolution times were tracked
+	f.addBug(t, models.Bug{Title: "D", Status: "resolved", FixVersion: milestone.ID,
+		CreatedAt: date("2024-04-20T09:00:00Z"), UpdatedAt: date("2024-05-03T12:00:00Z")})
+	f.addBug(t, models.Bug{Title: "Elsewhere", Status: "open", CreatedAt: date("2024-05-01T10:00:00Z")})
+
+	progress, err := f.milestones.GetProgress(ctx, milestone.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, progress.Total)
+	assert.Equal(t, 2, progress.Open)
+	assert.Equal(t, 2, progress.Closed)
+	assert.Equal(t, 50.0, progress.PercentComplete)
+	assert.Equal(t, []models.CountBucket{
+		{Key: "open", Count: 1}, {Key: "in-progress", Count: 1}, {Key: "resolved", Count: 2},
+	}, progress.ByStatus)
+
+	ideal := func(v float64) *float64 { return &v }
+	assert.Equal(t, []models.BurndownPoint{
+		{Date: "2024-05-01", Remaining: 3, Ideal: ideal(3)},
+		{Date: "2024-05-02", Remaining: 2, Ideal: ideal(2.25)},
+		{Date: "2024-05-03", Remaining: 2, Ideal: ideal(1.5)},
+		{Date: "2024-05-04", Remaining: 2, Ideal: ideal(0.75)},
+	}, progress.Burndown)
+
+	t.Run("closed milestones stop on the day they closed", func(t *testing.T) {
+		_, err := f.milestones.UpdateMilestone(ctx, milestone.ID, models.SaveMilestoneRequest{Name: "2.0", State: models.MilestoneClosed})
+		require.NoError(t, err)
+		closedAt := date("2024-05-02T18:00:00Z")
+		stored, err := f.milestoneRepo.FindByID(ctx, milestone.ID)
+		require.NoError(t, err)
+		stored.ClosedAt = &closedAt
+		require.NoError(t, f.milestoneRepo.Update(ctx, stored))
+
+		progress, err := f.milestones.GetProgress(ctx, milestone.ID)
+		require.NoError(t, err)
+		require.Len(t, progress.Burndown, 2)
+		assert.Nil(t, progress.Burndown[0].Ideal)
+	})
+
+	t.Run("empty milestone", func(t *testing.T) {
+		empty, err := f.milestones.CreateMilestone(ctx, models.SaveMilestoneRequest{Name: "3.0"})
+		require.NoError(t, err)
+		f.createdAt(t, empty, date("2024-05-04T08:00:00Z"))
+		progress, err := f.milestones.GetProgress(ctx, empty.ID)
+		require.NoError(t, err)
+		assert.Zero(t, progress.Total)
+		assert.Zero(t, progress.PercentComplete)
+		assert.Equal(t, []models.BurndownPoint{{Date: "2024-05-04", Remaining: 0}}, progress.Burndown)
+	})
+}
+
+func TestReleaseNotes(t *testing.T) {
+	ctx := context.Background()
+	f := newMilestoneFixture(t, date("2024-05-04T12:00:00Z"))
+	milestone, err := f.milestones.CreateMilestone(ctx, models.SaveMilestoneRequest{
+		Name: "2.0", Description: "The spring release.", DueDate: "2024-05-05",
+	})
+	require.NoError(t, err)
+
+	notes, err := f.milestones.ReleaseNotes(ctx, milestone.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "# 2.0\n\nDue 2024-05-05.\n\nThe spring release.\n\n## Fixed\n\nNo bugs were fixed in this milestone.\n", notes)
+
+	created := date("2024-05-01T09:00:00Z")
+	save := f.addBug(t, models.Bug{Title: "Crash on *save*", Priority: "high", Status: "resolved", FixVersion: milestone.ID, CreatedAt: created})
+	login := f.addBug(t, models.Bug{Title: "Login fails", Priority: "critical", Status: "resolved", FixVersion: milestone.ID, CreatedAt: created})
+	export := f.addBug(t, models.Bug{Title: "Export is slow", Priority: "high", Status: "resolved", FixVersion: milestone.ID, CreatedAt: created})
+	imported := f.addBug(t, models.Bug{Title: "Imported", Priority: "blocker", Status: "resolved", FixVersion: milestone.ID, CreatedAt: created})
+	f.addBug(t, models.Bug{Title: "Still open", Priority: "high", Status: "open", FixVersion: milestone.ID, CreatedAt: created})
+	f.addBug(t, models.Bug{Title: "Other release", Priority: "high", Status: "resolved", CreatedAt: created})
+
+	_, err = f.milestones.UpdateMilestone(ctx, milestone.ID, models.SaveMilestoneRequest{
+		Name: "2.0", Description: "The spring release.", State: models.MilestoneClosed,
+	})
+	require.NoError(t, err)
+
+	notes, err = f.milestones.ReleaseNotes(ctx, milestone.ID)
+	require.NoError(t, err)
+	assert.Equal(t, `# 2.0
+
+Released 2024-05-04.
+
+The spring release.
+
+## Fixed
+
+### Critical
+
+- Login fails (`+login.ID.Hex()+`)
+
+### High
+
+- Crash on \*save\* (`+save.ID.Hex()+`)
+- Export is slow (`+export.ID.Hex()+`)
+
+### Other
+
+- Imported (`+imported.ID.Hex()+`)
+`, notes)
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// ReportUseCaseInterface defines the interface for bug statistics reports
+type ReportUseCaseInterface interface {
+	GetSummary(ctx context.Context, filter models.ReportFilter) (*models.SummaryReport, error)
+	GetTrend(ctx context.Context, filter models.ReportFilter, interval string) ([]models.TrendPoint, error)
+	GetResolutionTime(ctx context.Context, filter models.ReportFilter) (*models.ResolutionReport, error)
+	GetAgeing(ctx context.Context, filter models.ReportFilter) ([]models.AgeingBucket, error)
+	GetDeveloperThroughput(ctx context.Context, filter models.ReportFilter) ([]models.DeveloperThroughputResponse, error)
+}
+
+type ReportUseCase struct {
+	reportRepo repository.ReportRepositoryInterface
+	userRepo   repository.UserRepositoryInterface
+}
+
+func NewReportUseCase(reportRepo repository.ReportRepositoryInterface, userRepo repository.UserRepositoryInterface) *ReportUseCase {
+	return &ReportUseCase{
+		reportRepo: reportRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (uc *ReportUseCase) GetSummary(ctx context.Context, filter models.ReportFilter) (*models.SummaryReport, error) {
+	byStatus, err := uc.reportRepo.CountByStatus(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	byPriority, err := uc.reportRepo.CountByPriority(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	assigneeBuckets, err := uc.reportRepo.CountByAssignee(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	byAssignee := make([]models.AssigneeCount, 0, len(assigneeBuckets))
+	for _, bucket := range assigneeBuckets {
+		count := models.AssigneeCount{Count: bucket.Count}
+		if !bucket.AssigneeID.IsZero() {
+			assignee, err := uc.userRepo.FindByID(ctx, bucket.AssigneeID)
+			if err != nil {
+				return nil, err
+			}
+			// Bugs assigned to since-deleted users are reported as unassigned
+			if assignee != nil {
+				response := assignee.ToResponse()
+				count.Assignee = &response
+			}
+		}
+		byAssignee = append(byAssignee, count)
+	}
+
+	return &models.SummaryReport{
+		ByStatus:   byStatus,
+		ByPriority: byPriority,
+		ByAssignee: byAssignee,
+	}, nil
+}
+
+func (uc *ReportUseCase) GetTrend(ctx context.Context, filter models.ReportFilter, interval string) ([]models.TrendPoint, error) {
+	return uc.reportRepo.Trend(ctx, filter, interval)
+}
+
+func (uc *ReportUseCase) GetResolutionTime(ctx context.Context, filter models.ReportFilter) (*models.ResolutionReport, error) {
+	return uc.reportRepo.ResolutionTime(ctx, filter)
+}
+
+func (uc *ReportUseCase) GetAgeing(ctx context.Context, filter models.ReportFilter) ([]models.AgeingBucket, error) {
+	return uc.reportRepo.Ageing(ctx, filter, time.Now())
+}
+
+func (uc *ReportUseCase) GetDeveloperThroughput(ctx context.Context, filter models.ReportFilter) ([]models.DeveloperThroughputResponse, error) {
+	results, err := uc.reportRepo.DeveloperThroughput(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.DeveloperThroughputResponse, 0, len(results))
+	for _, result := range results {
+		developer, err := uc.userRepo.FindByID(ctx, result.DeveloperID)
+		if err != nil {
+			return nil, err
+		}
+		if developer == nil {
+			continue
+		}
+		responses = append(responses, models.DeveloperThroughputResponse{
+			Developer:           developer.ToResponse(),
+			DeveloperThroughput: result,
+		})
+	}
+
+	return responses, nil
+}
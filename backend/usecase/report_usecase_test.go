@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"bug-tracker/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type MockReportRepository struct {
+	byStatus   []models.CountBucket
+	byPriority []models.CountBucket
+	byAssignee []models.AssigneeBucket
+	throughput []models.DeveloperThroughput
+	ageingNow  time.Time
+	err        error
+}
+
+func (m *MockReportRepository) CountByStatus(ctx context.Context, filter models.ReportFilter) ([]models.CountBucket, error) {
+	return m.byStatus, m.err
+}
+
+func (m *MockReportRepository) CountByPriority(ctx context.Context, filter models.ReportFilter) ([]models.CountBucket, error) {
+	return m.byPriority, m.err
+}
+
+func (m *MockReportRepository) CountByAssignee(ctx context.Context, filter models.ReportFilter) ([]models.AssigneeBucket, error) {
+	return m.byAssignee, m.err
+}
+
+func (m *MockReportRepository) Trend(ctx context.Context, filter models.ReportFilter, interval string) ([]models.TrendPoint, error) {
+	return []models.TrendPoint{{Period: interval}}, m.err
+}
+
+func (m *MockReportRepository) ResolutionTime(ctx context.Context, filter models.ReportFilter) (*models.ResolutionReport, error) {
+	return &models.ResolutionReport{}, m.err
+}
+
+func (m *MockReportRepository) Ageing(ctx context.Context, filter models.ReportFilter, now time.Time) ([]models.AgeingBucket, error) {
+	m.ageingNow = now
+	return []models.AgeingBucket{}, m.err
+}
+
+func (m *MockReportRepository) DeveloperThroughput(ctx context.Context, filter models.ReportFilter) ([]models.DeveloperThroughput, error) {
+	return m.throughput, m.err
+}
+
+func TestGetSummary(t *testing.T) {
+	mockUserRepo := NewMockUserRepository()
+	developer := &models.User{
+		ID:    models.NewID(),
+		Name:  "Dev",
+		Email: "dev@example.com",
+		Role:  "developer",
+	}
+	_ = mockUserRepo.Create(context.Background(), developer)
+
+	t.Run("resolves assignee names", func(t *testing.T) {
+		mockReportRepo := &MockReportRepository{
+			byStatus:   []models.CountBucket{{Key: "open", Count: 2}, {Key: "resolved", Count: 1}},
+			byPriority: []models.CountBucket{{Key: "high", Count: 3}},
+			byAssignee: []models.AssigneeBucket{
+				{AssigneeID: developer.ID, Count: 2},
+				{Count: 1},
+			},
+		}
+		reportUseCase := NewReportUseCase(mockReportRepo, mockUserRepo)
+
+		report, err := reportUseCase.GetSummary(context.Background(), models.ReportFilter{})
+		assert.NoError(t, err)
+		assert.Len(t, report.ByStatus, 2)
+		assert.Len(t, report.ByPriority, 1)
+		assert.Len(t, report.ByAssignee, 2)
+		assert.Equal(t, "Dev", report.ByAssignee[0].Assignee.Name)
+		assert.Equal(t, 2, report.ByAssignee[0].Count)
+		assert.Nil(t, report.ByAssignee[1].Assignee)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockReportRepo := &MockReportRepository{err: errors.New("database error")}
+		reportUseCase := NewReportUseCase(mockReportRepo, mockUserRepo)
+
+		report, err := reportUseCase.GetSummary(context.Background(), models.ReportFilter{})
+		assert.Error(t, err)
+		assert.Nil(t, report)
+	})
+}
+
+func TestGetDeveloperThroughput(t *testing.T) {
+	mockUserRepo := NewMockUserRepository()
+	developer := &models.User{
+		ID:    models.NewID(),
+		Name:  "Dev",
+		Email: "dev@example.com",
+		Role:  "developer",
+	}
+	_ = mockUserRepo.Create(context.Background(), developer)
+
+	mockReportRepo := &MockReportRepository{
+		throughput: []models.DeveloperThroughput{
+			{DeveloperID: developer.ID, Assigned: 5, Resolved: 3, MeanHours: 10},
+		},
+	}
+	reportUseCase := NewReportUseCase(mockReportRepo, mockUserRepo)
+
+	results, err := reportUseCase.GetDeveloperThroughput(context.Background(), models.ReportFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, developer.Email, results[0].Developer.Email)
+	assert.Equal(t, 3, results[0].Resolved)
+	assert.Equal(t, 5, results[0].Assigned)
+}
+
+func TestGetAgeing(t *testing.T) {
+	mockReportRepo := &MockReportRepository{}
+	reportUseCase := NewReportUseCase(mockReportRepo, NewMockUserRepository())
+
+	before := time.Now()
+	_, err := reportUseCase.GetAgeing(context.Background(), models.ReportFilter{})
+	assert.NoError(t, err)
+	assert.False(t, mockReportRepo.ageingNow.Before(before))
+}
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"bug-tracker/authz"
+	"bug-tracker/logging"
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// RoleUseCaseInterface defines the interface for editing roles
+type RoleUseCaseInterface interface {
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	SaveRole(ctx context.Context, name string, permissions []string) (*models.Role, error)
+	DeleteRole(ctx context.Context, name string) error
+}
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrInvalidRoleName   = errors.New("role names are 1 to 32 lowercase letters, digits, '-' or '_'")
+	ErrUnknownPermission = errors.New("unknown permission")
+	// ErrBuiltinRole is returned for deleting a built-in role, or taking
+	// role.manage from admin
+	ErrBuiltinRole = errors.New("built-in roles can't be deleted and admin must keep role.manage")
+	ErrRoleInUse   = errors.New("role is given to users")
+)
+
+type RoleUseCase struct {
+	roleRepo repository.RoleRepositoryInterface
+	userRepo repository.UserRepositoryInterface
+	roles    *authz.Roles
+}
+
+func NewRoleUseCase(roleRepo repository.RoleRepositoryInterface, userRepo repository.UserRepositoryInterface, roles *authz.Roles) *RoleUseCase {
+	return &RoleUseCase{
+		roleRepo: roleRepo,
+		userRepo: userRepo,
+		roles:    roles,
+	}
+}
+
+// ListRoles returns every role ordered by name
+func (uc *RoleUseCase) ListRoles(ctx context.Context) (_ []models.Role, err error) {
+	ctx, span := tracer.Start(ctx, "RoleUseCase.ListRoles")
+	defer func() { endSpan(span, err) }()
+
+	// Show changes made on other servers too
+	if err := uc.roles.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return uc.roles.List(), nil
+}
+
+// SaveRole sets the permissions of the role, creating it if needed
+func (uc *RoleUseCase) SaveRole(ctx context.Context, name string, permissions []string) (_ *models.Role, err error) {
+	ctx, span := tracer.Start(ctx, "RoleUseCase.SaveRole")
+	defer func() { endSpan(span, err) }()
+
+	if !models.ValidRoleName(name) {
+		return nil, ErrInvalidRoleName
+	}
+	granted := []string{}
+	seen := make(map[string]bool)
+	for _, p := range permissions {
+		if !models.ValidPermission(p) {
+			return nil, fmt.Errorf("%w %q", ErrUnknownPermission, p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			granted = append(granted, p)
+		}
+	}
+	if name == models.RoleAdmin && !seen[models.PermRoleManage] {
+		return nil, ErrBuiltinRole
+	}
+
+	role := &models.Role{Name: name, Permissions: granted}
+	if err := uc.roleRepo.Save(ctx, role); err != nil {
+		return nil, err
+	}
+	if err := uc.roles.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("role saved", "role", name, "permissions", granted)
+	return role, nil
+}
+
+// DeleteRole deletes a role no user has
+func (uc *RoleUseCase) DeleteRole(ctx context.Context, name string) (err error) {
+	ctx, span := tracer.Start(ctx, "RoleUseCase.DeleteRole")
+	defer func() { endSpan(span, err) }()
+
+	if models.BuiltinRole(name) {
+		return ErrBuiltinRole
+	}
+	if err := uc.roles.Refresh(ctx); err != nil {
+		return err
+	}
+	if !uc.roles.Exists(name) {
+		return ErrRoleNotFound
+	}
+	users, err := uc.userRepo.FindByRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		return ErrRoleInUse
+	}
+
+	if err := uc.roleRepo.Delete(ctx, name); err != nil {
+		return err
+	}
+	if err := uc.roles.Refresh(ctx); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("role deleted", "role", name)
+	return nil
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"bug-tracker/authz"
+	"bug-tracker/models"
+	"bug-tracker/repository/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRoleUseCase(t *testing.T) (*RoleUseCase, *authz.Roles, *memory.UserRepository) {
+	t.Helper()
+	roleRepo := memory.NewRoleRepository()
+	userRepo := memory.NewUserRepository()
+	roles := authz.New(roleRepo)
+	require.NoError(t, roles.Refresh(context.Background()))
+	return NewRoleUseCase(roleRepo, userRepo, roles), roles, userRepo
+}
+
+func TestSaveRole(t *testing.T) {
+	ctx := context.Background()
+	uc, roles, _ := newTestRoleUseCase(t)
+
+	t.Run("creates role", func(t *testing.T) {
+		role, err := uc.SaveRole(ctx, "triager", []string{models.PermBugView, models.PermBugAssign, models.PermBugView})
+		require.NoError(t, err)
+		assert.Equal(t, []string{models.PermBugView, models.PermBugAssign}, role.Permissions)
+		assert.True(t, roles.Can("triager", models.PermBugAssign))
+	})
+
+	t.Run("edits built-in role", func(t *testing.T) {
+		_, err := uc.SaveRole(ctx, models.RoleDeveloper, []string{models.PermBugCreate, models.PermBugAssignable, models.PermBugView})
+		require.NoError(t, err)
+		assert.True(t, roles.Can(models.RoleDeveloper, models.PermBugView))
+	})
+
+	t.Run("unknown permission", func(t *testing.T) {
+		_, err := uc.SaveRole(ctx, "triager", []string{"bug.fly"})
+		assert.True(t, errors.Is(err, ErrUnknownPermission))
+		assert.Contains(t, err.Error(), "bug.fly")
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		_, err := uc.SaveRole(ctx, "Bug Triager", []string{models.PermBugView})
+		assert.Equal(t, ErrInvalidRoleName, err)
+	})
+
+	t.Run("admin keeps role.manage", func(t *testing.T) {
+		_, err := uc.SaveRole(ctx, models.RoleAdmin, []string{models.PermUserManage})
+		assert.Equal(t, ErrBuiltinRole, err)
+		assert.True(t, roles.Can(models.RoleAdmin, models.PermRoleManage))
+	})
+}
+
+func TestDeleteRole(t *testing.T) {
+	ctx := context.Background()
+	uc, roles, userRepo := newTestRoleUseCase(t)
+	_, err := uc.SaveRole(ctx, "triager", []string{models.PermBugView})
+	require.NoError(t, err)
+	_, err = uc.SaveRole(ctx, "auditor", []string{models.PermReportView})
+	require.NoError(t, err)
+	require.NoError(t, userRepo.Create(ctx, &models.User{Name: "Tri Ager", Email: "triager@example.com", Role: "triager"}))
+
+	assert.Equal(t, ErrBuiltinRole, uc.DeleteRole(ctx, models.RoleManager))
+	assert.Equal(t, ErrRoleNotFound, uc.DeleteRole(ctx, "owner"))
+	assert.Equal(t, ErrRoleInUse, uc.DeleteRole(ctx, "triager"))
+
+	require.NoError(t, uc.DeleteRole(ctx, "auditor"))
+	assert.False(t, roles.Exists("auditor"))
+
+	list, err := uc.ListRoles(ctx)
+	require.NoError(t, err)
+	var names []string
+	for _, role := range list {
+		names = append(names, role.Name)
+	}
+	assert.Equal(t, []string{models.RoleAdmin, models.RoleDeveloper, models.RoleManager, "triager"}, names)
+}
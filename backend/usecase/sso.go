@@ -0,0 +1,298 @@
+package usecase
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"bug-tracker/authz"
+	"bug-tracker/logging"
+	"bug-tracker/models"
+	"bug-tracker/oidc"
+	"bug-tracker/repository"
+)
+
+var (
+	ErrSSODisabled     = errors.New("single sign-on is not configured")
+	ErrInvalidSSOState = errors.New("invalid or expired single sign-on state")
+	// ErrSSOFailed is returned when the provider refuses the code or its
+	// ID token doesn't verify
+	ErrSSOFailed  = errors.New("single sign-on failed")
+	ErrSSONoEmail = errors.New("the identity provider didn't share an email address")
+	ErrSSONoRole  = errors.New("no role is mapped to this account")
+	// ErrSSOAccountConflict is returned when the email belongs to an account
+	// that can't be linked: it is linked to another identity, or the
+	// provider hasn't verified the email
+	ErrSSOAccountConflict = errors.New("an account with this email exists and can't be linked")
+)
+
+// SSOStateTTL is how long users have to sign in at the provider
+const SSOStateTTL = 10 * time.Minute
+
+// SSOPolicy configures OpenID Connect single sign-on. It is disabled
+// without a Provider.
+type SSOPolicy struct {
+	Provider *oidc.Provider
+	// RoleClaim names the ID token claim whose values map to roles, such
+	// as groups
+	RoleClaim string
+	// Roles maps values of RoleClaim to roles
+	Roles map[string]string
+	// DefaultRole is given to new users no value maps to. Without one they
+	// are refused.
+	DefaultRole string
+}
+
+// role returns the most privileged role claims map to: the one granting
+// the most permissions, and of those the first by name. Roles that no
+// longer exist are passed over.
+func (p SSOPolicy) role(claims *oidc.Claims, roles *authz.Roles) (string, bool) {
+	best, bestRank := "", -1
+	for _, value := range claims.Strings(p.RoleClaim) {
+		role, ok := p.Roles[value]
+		if !ok || !roles.Exists(role) {
+			continue
+		}
+		if rank := len(roles.Permissions(role)); rank > bestRank || rank == bestRank && role < best {
+			best, bestRank = role, rank
+		}
+	}
+	return best, best != ""
+}
+
+// ssoState is what a single sign-on carries through the provider. It is
+// sealed, since the PKCE verifier must stay secret. Binding ties it to the
+// browser that began the sign-on.
+type ssoState struct {
+	Verifier  string `json:"v"`
+	Nonce     string `json:"n"`
+	Binding   string `json:"b"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// BeginSSO starts a single sign-on at the identity provider
+func (uc *AuthUseCase) BeginSSO(ctx context.Context) (_ *models.SSOAuthorization, err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.BeginSSO")
+	defer func() { endSpan(span, err) }()
+
+	if uc.sso.Provider == nil {
+		return nil, ErrSSODisabled
+	}
+
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := oidc.NewNonce()
+	if err != nil {
+		return nil, err
+	}
+	binding, err := oidc.NewNonce()
+	if err != nil {
+		return nil, err
+	}
+	state, err := uc.sealSSOState(ssoState{
+		Verifier:  verifier,
+		Nonce:     nonce,
+		Binding:   binding,
+		ExpiresAt: time.Now().Add(SSOStateTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := uc.sso.Provider.AuthCodeURL(ctx, state, nonce, oidc.S256(verifier))
+	if err != nil {
+		return nil, err
+	}
+	return &models.SSOAuthorization{AuthorizationURL: authURL, State: state, Binding: binding}, nil
+}
+
+// CompleteSSO logs in with the code and state the provider returned, and
+// the binding BeginSSO gave the browser. A state only completes with its
+// own binding, so nobody can log a victim into the attacker's account.
+// Unknown users are created and users with the same verified email are
+// linked. The provider is trusted to have checked any second factor.
+func (uc *AuthUseCase) CompleteSSO(ctx context.Context, code, state, binding string) (_ *models.LoginResult, err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.CompleteSSO")
+	defer func() { endSpan(span, err) }()
+
+	if uc.sso.Provider == nil {
+		return nil, ErrSSODisabled
+	}
+
+	flow, err := uc.openSSOState(state)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(flow.Binding), []byte(binding)) != 1 {
+		logging.FromContext(ctx).Warn("single sign-on refused", "reason", "state not bound to this browser")
+		return nil, ErrInvalidSSOState
+	}
+
+	claims, err := uc.sso.Provider.Exchange(ctx, code, flow.Verifier, flow.Nonce)
+	var providerErr *oidc.ProviderError
+	if errors.Is(err, oidc.ErrInvalidIDToken) || errors.As(err, &providerErr) {
+		logging.FromContext(ctx).Warn("single sign-on failed", "error", err)
+		return nil, ErrSSOFailed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := uc.ssoUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	return uc.completeLogin(ctx, user)
+}
+
+// ssoUser finds, links or creates the user claims describe. Mapped roles
+// are applied on every login so the provider stays in charge of them.
+func (uc *AuthUseCase) ssoUser(ctx context.Context, claims *oidc.Claims) (*models.User, error) {
+	identity := &models.OIDCIdentity{Issuer: claims.Issuer, Subject: claims.Subject}
+	role, mapped := uc.sso.role(claims, uc.roles)
+	logger := logging.FromContext(ctx)
+
+	user, err := uc.userRepo.FindByOIDC(ctx, identity.Issuer, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		if mapped && user.Role != role {
+			logger.Info("role changed by identity provider", "user_id", user.ID.Hex(), "from", user.Role, "to", role)
+			user.Role = role
+			if err := uc.updateUser(ctx, user); err != nil {
+				return nil, err
+			}
+		}
+		return user, nil
+	}
+
+	if claims.Email == "" {
+		return nil, ErrSSONoEmail
+	}
+	user, err = uc.userRepo.FindByEmail(ctx, claims.Email)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil {
+		// Linking an unverified email would hand the account to whoever
+		// typed it in at the provider
+		if user.OIDC != nil || !claims.EmailVerified {
+			logger.Warn("single sign-on refused", "reason", "account can't be linked", "user_id", user.ID.Hex())
+			return nil, ErrSSOAccountConflict
+		}
+		user.OIDC = identity
+		if mapped {
+			user.Role = role
+		}
+		if err := uc.updateUser(ctx, user); err != nil {
+			return nil, err
+		}
+		logger.Info("account linked to identity provider", "user_id", user.ID.Hex())
+		return user, nil
+	}
+
+	if !mapped {
+		if uc.sso.DefaultRole == "" {
+			logger.Warn("single sign-on refused", "reason", "no role mapped")
+			return nil, ErrSSONoRole
+		}
+		role = uc.sso.DefaultRole
+	}
+	// Provisioned users log in through the provider only
+	password, err := oidc.NewVerifier()
+	if err != nil {
+		return nil, err
+	}
+	user = &models.User{
+		Name:     ssoName(claims),
+		Email:    claims.Email,
+		Password: password,
+		Role:     role,
+		OIDC:     identity,
+	}
+	if err := user.HashPassword(); err != nil {
+		return nil, err
+	}
+	if err := uc.userRepo.Create(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrDuplicateEmail) {
+			return nil, ErrSSOAccountConflict
+		}
+		return nil, err
+	}
+	logger.Info("user provisioned by identity provider", "user_id", user.ID.Hex(), "role", user.Role)
+	return user, nil
+}
+
+// ssoName picks the display name for a provisioned user
+func ssoName(claims *oidc.Claims) string {
+	switch {
+	case claims.Name != "":
+		return claims.Name
+	case claims.PreferredUsername != "":
+		return claims.PreferredUsername
+	default:
+		return claims.Email
+	}
+}
+
+// sealSSOState encrypts state so only this server can read it
+func (uc *AuthUseCase) sealSSOState(state ssoState) (string, error) {
+	plaintext, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	aead, err := uc.ssoCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+func (uc *AuthUseCase) openSSOState(sealed string) (*ssoState, error) {
+	data, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, ErrInvalidSSOState
+	}
+	aead, err := uc.ssoCipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, ErrInvalidSSOState
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrInvalidSSOState
+	}
+
+	var state ssoState
+	if err := json.Unmarshal(plaintext, &state); err != nil || time.Now().Unix() > state.ExpiresAt {
+		return nil, ErrInvalidSSOState
+	}
+	return &state, nil
+}
+
+// ssoCipher derives the state key from the JWT secret, so no other
+// setting has to be kept secret
+func (uc *AuthUseCase) ssoCipher() (cipher.AEAD, error) {
+	key := sha256.Sum256(append([]byte("bug-tracker sso state\x00"), uc.jwtSecret...))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bug-tracker/authz"
+	"bug-tracker/models"
+	"bug-tracker/oidc"
+	"bug-tracker/oidc/oidctest"
+	"bug-tracker/repository/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSSOUseCase(t *testing.T, mockRepo *MockUserRepository, defaultRole string) (*AuthUseCase, *oidctest.Server) {
+	t.Helper()
+	return newSSOUseCaseWithRoles(t, mockRepo, nil, map[string]string{"bt-admins": "admin", "bt-managers": "manager"}, defaultRole)
+}
+
+func newSSOUseCaseWithRoles(t *testing.T, mockRepo *MockUserRepository, roles *authz.Roles, mapping map[string]string, defaultRole string) (*AuthUseCase, *oidctest.Server) {
+	t.Helper()
+	server := oidctest.NewServer(t, "bug-tracker", "client-secret")
+	policy := SSOPolicy{
+		Provider: oidc.NewProvider(oidc.Config{
+			Issuer:       server.URL,
+			ClientID:     "bug-tracker",
+			ClientSecret: "client-secret",
+			RedirectURL:  "https://app.example.com/sso/callback",
+			Scopes:       []string{"openid", "email", "profile"},
+		}),
+		RoleClaim:   "groups",
+		Roles:       mapping,
+		DefaultRole: defaultRole,
+	}
+	return NewAuthUseCase(mockRepo, roles, "test-secret", nil, time.Hour, 0, LockoutPolicy{}, TwoFactorPolicy{}, policy), server
+}
+
+// ssoLogin runs a single sign-on for a user with claims
+func ssoLogin(t *testing.T, uc *AuthUseCase, server *oidctest.Server, claims map[string]interface{}) (*models.LoginResult, error) {
+	t.Helper()
+	ctx := context.Background()
+	authorization, err := uc.BeginSSO(ctx)
+	require.NoError(t, err)
+
+	code, state := server.Authorize(t, authorization.AuthorizationURL, claims)
+	require.Equal(t, authorization.State, state)
+	return uc.CompleteSSO(ctx, code, state, authorization.Binding)
+}
+
+func TestSSOProvisionsUsers(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	uc, server := newSSOUseCase(t, mockRepo, "developer")
+
+	result, err := ssoLogin(t, uc, server, map[string]interface{}{
+		"email": "ada@example.com",
+		"name":  "Ada Lovelace",
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Token)
+	assert.Equal(t, "developer", result.User.Role)
+
+	user := mockRepo.users["ada@example.com"]
+	require.NotNil(t, user)
+	assert.Equal(t, "Ada Lovelace", user.Name)
+	assert.Equal(t, &models.OIDCIdentity{Issuer: server.URL, Subject: "user-1"}, user.OIDC)
+	// There is no password to log in with
+	assert.NotEmpty(t, user.Password)
+
+	// Roles follow the provider's groups, the most privileged winning
+	result, err = ssoLogin(t, uc, server, map[string]interface{}{
+		"email":  "ada@example.com",
+		"groups": []string{"bt-managers", "bt-admins", "staff"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "admin", result.User.Role)
+	assert.Len(t, mockRepo.users, 1)
+}
+
+func TestSSOMapsCustomRoles(t *testing.T) {
+	ctx := context.Background()
+	roleRepo := memory.NewRoleRepository()
+	require.NoError(t, roleRepo.Save(ctx, &models.Role{
+		Name:        "triage",
+		Permissions: []string{models.PermBugView, models.PermBugCreate, models.PermBugAssign},
+	}))
+	roles := authz.New(roleRepo)
+	require.NoError(t, roles.Refresh(ctx))
+
+	mockRepo := NewMockUserRepository()
+	uc, server := newSSOUseCaseWithRoles(t, mockRepo, roles, map[string]string{
+		"bt-developers": "developer",
+		"bt-triage":     "triage",
+		"bt-retired":    "retired",
+	}, "")
+
+	// The role granting the most permissions wins
+	result, err := ssoLogin(t, uc, server, map[string]interface{}{
+		"email":  "ada@example.com",
+		"groups": []string{"bt-developers", "bt-triage"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "triage", result.User.Role)
+
+	// Mapped roles that were deleted are passed over
+	_, err = ssoLogin(t, uc, server, map[string]interface{}{
+		"sub":    "user-2",
+		"email":  "grace@example.com",
+		"groups": "bt-retired",
+	})
+	assert.ErrorIs(t, err, ErrSSONoRole)
+}
+
+func TestSSOLinksVerifiedEmail(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	uc, server := newSSOUseCase(t, mockRepo, "developer")
+	local := registerForTwoFactor(t, uc, mockRepo, "manager@example.com", "manager")
+
+	// An unverified email could be anyone's
+	_, err := ssoLogin(t, uc, server, map[string]interface{}{"email": "manager@example.com"})
+	assert.ErrorIs(t, err, ErrSSOAccountConflict)
+	assert.Nil(t, local.OIDC)
+
+	result, err := ssoLogin(t, uc, server, map[string]interface{}{
+		"email":          "manager@example.com",
+		"email_verified": true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, local.ID, result.User.ID)
+	// The default role is only for new users
+	assert.Equal(t, "manager", result.User.Role)
+	require.NotNil(t, local.OIDC)
+
+	// Another identity can't take over the linked account
+	_, err = ssoLogin(t, uc, server, map[string]interface{}{
+		"sub":            "user-2",
+		"email":          "manager@example.com",
+		"email_verified": true,
+	})
+	assert.ErrorIs(t, err, ErrSSOAccountConflict)
+
+	// The password still works for linked accounts
+	_, err = uc.Login(context.Background(), models.LoginRequest{Email: "manager@example.com", Password: "password123"})
+	assert.NoError(t, err)
+}
+
+func TestSSORefusals(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	uc, server := newSSOUseCase(t, mockRepo, "")
+	ctx := context.Background()
+
+	// Without a default role, unmapped users are refused
+	_, err := ssoLogin(t, uc, server, map[string]interface{}{"email": "ada@example.com", "groups": "staff"})
+	assert.ErrorIs(t, err, ErrSSONoRole)
+	_, err = ssoLogin(t, uc, server, map[string]interface{}{"email": "ada@example.com", "groups": "bt-managers"})
+	assert.NoError(t, err)
+
+	_, err = ssoLogin(t, uc, server, map[string]interface{}{"sub": "user-2", "groups": "bt-admins"})
+	assert.ErrorIs(t, err, ErrSSONoEmail)
+
+	_, err = ssoLogin(t, uc, server, map[string]interface{}{"aud": "other-client"})
+	assert.ErrorIs(t, err, ErrSSOFailed)
+
+	authorization, err := uc.BeginSSO(ctx)
+	require.NoError(t, err)
+	code, state := server.Authorize(t, authorization.AuthorizationURL, map[string]interface{}{"email": "ada@example.com"})
+
+	binding := authorization.Binding
+	require.NotEmpty(t, binding)
+
+	// The state is sealed and can't be made up or altered
+	_, err = uc.CompleteSSO(ctx, code, "made-up", binding)
+	assert.ErrorIs(t, err, ErrInvalidSSOState)
+	_, err = uc.CompleteSSO(ctx, code, state[:len(state)-2]+"AA", binding)
+	assert.ErrorIs(t, err, ErrInvalidSSOState)
+	other := NewAuthUseCase(mockRepo, nil, "other-secret", nil, time.Hour, 0, LockoutPolicy{}, TwoFactorPolicy{}, uc.sso)
+	_, err = other.CompleteSSO(ctx, code, state, binding)
+	assert.ErrorIs(t, err, ErrInvalidSSOState)
+
+	// Another browser can't complete it, whether it has a sign-on of its
+	// own or none
+	another, err := uc.BeginSSO(ctx)
+	require.NoError(t, err)
+	_, err = uc.CompleteSSO(ctx, code, state, another.Binding)
+	assert.ErrorIs(t, err, ErrInvalidSSOState)
+	_, err = uc.CompleteSSO(ctx, code, state, "")
+	assert.ErrorIs(t, err, ErrInvalidSSOState)
+
+	_, err = uc.CompleteSSO(ctx, code, state, binding)
+	require.NoError(t, err)
+	// Codes work once
+	_, err = uc.CompleteSSO(ctx, code, state, binding)
+	assert.ErrorIs(t, err, ErrSSOFailed)
+
+	expired, err := uc.sealSSOState(ssoState{Verifier: "v", Nonce: "n", Binding: "b", ExpiresAt: time.Now().Add(-time.Second).Unix()})
+	require.NoError(t, err)
+	_, err = uc.CompleteSSO(ctx, code, expired, "b")
+	assert.ErrorIs(t, err, ErrInvalidSSOState)
+}
+
+func TestSSODisabled(t *testing.T) {
+	uc := NewAuthUseCase(NewMockUserRepository(), nil, "test-secret", nil, time.Hour, 0, LockoutPolicy{}, TwoFactorPolicy{}, SSOPolicy{})
+
+	_, err := uc.BeginSSO(context.Background())
+	assert.ErrorIs(t, err, ErrSSODisabled)
+	_, err = uc.CompleteSSO(context.Background(), "code", "state", "binding")
+	assert.ErrorIs(t, err, ErrSSODisabled)
+}
@@ -0,0 +1,193 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"bug-tracker/authz"
+	"bug-tracker/logging"
+	"bug-tracker/models"
+	"bug-tracker/repository"
+)
+
+// TokenUseCaseInterface defines the interface for personal API tokens
+type TokenUseCaseInterface interface {
+	CreateToken(ctx context.Context, user *models.User, req models.CreateAPITokenRequest) (*models.CreatedAPIToken, error)
+	ListTokens(ctx context.Context, userID models.ID) ([]*models.APIToken, error)
+	RevokeToken(ctx context.Context, userID, id models.ID) error
+	// ValidateAPIToken returns the token's owner and the token, whose
+	// scopes the caller must check
+	ValidateAPIToken(ctx context.Context, token string) (*models.User, *models.APIToken, error)
+}
+
+var (
+	ErrInvalidAPIToken   = errors.New("invalid API token")
+	ErrAPITokenNotFound  = errors.New("API token not found")
+	ErrExpiryInPast      = errors.New("expiry must be in the future")
+	ErrScopeNotPermitted = errors.New("only user managers may create admin tokens")
+)
+
+const (
+	// apiTokenBytes is the randomness in a token, 160 bits
+	apiTokenBytes = 20
+	// apiTokenPrefixLength is how much of a token is kept to tell it apart
+	apiTokenPrefixLength = len(models.APITokenPrefix) + 8
+	// lastUsedInterval limits how often a token's last use is written, so
+	// busy scripts don't turn every request into a write
+	lastUsedInterval = time.Minute
+)
+
+type TokenUseCase struct {
+	tokenRepo repository.APITokenRepositoryInterface
+	userRepo  repository.UserRepositoryInterface
+	roles     *authz.Roles
+}
+
+func NewTokenUseCase(tokenRepo repository.APITokenRepositoryInterface, userRepo repository.UserRepositoryInterface, roles *authz.Roles) *TokenUseCase {
+	return &TokenUseCase{
+		tokenRepo: tokenRepo,
+		userRepo:  userRepo,
+		roles:     roles,
+	}
+}
+
+func (uc *TokenUseCase) CreateToken(ctx context.Context, user *models.User, req models.CreateAPITokenRequest) (_ *models.CreatedAPIToken, err error) {
+	ctx, span := tracer.Start(ctx, "TokenUseCase.CreateToken")
+	defer func() { endSpan(span, err) }()
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrExpiryInPast
+	}
+	for _, scope := range req.Scopes {
+		// Admin tokens reach the user administration routes
+		if scope == models.ScopeAdmin && !uc.roles.Can(user.Role, models.PermUserManage) {
+			return nil, ErrScopeNotPermitted
+		}
+	}
+
+	secret, err := newAPIToken()
+	if err != nil {
+		return nil, err
+	}
+	token := &models.APIToken{
+		UserID:    user.ID,
+		Name:      req.Name,
+		Prefix:    secret[:apiTokenPrefixLength],
+		Hash:      hashAPIToken(secret),
+		Scopes:    dedupe(req.Scopes),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := uc.tokenRepo.Create(ctx, token); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).Info("API token created", "token_id", token.ID.Hex(), "scopes", token.Scopes)
+	return &models.CreatedAPIToken{APIToken: *token, Token: secret}, nil
+}
+
+func (uc *TokenUseCase) ListTokens(ctx context.Context, userID models.ID) (_ []*models.APIToken, err error) {
+	ctx, span := tracer.Start(ctx, "TokenUseCase.ListTokens")
+	defer func() { endSpan(span, err) }()
+
+	tokens, err := uc.tokenRepo.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tokens == nil {
+		tokens = []*models.APIToken{}
+	}
+	return tokens, nil
+}
+
+// RevokeToken deletes one of the user's tokens. Other users' tokens are
+// reported as not found.
+func (uc *TokenUseCase) RevokeToken(ctx context.Context, userID, id models.ID) (err error) {
+	ctx, span := tracer.Start(ctx, "TokenUseCase.RevokeToken")
+	defer func() { endSpan(span, err) }()
+
+	token, err := uc.tokenRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if token == nil || token.UserID != userID {
+		return ErrAPITokenNotFound
+	}
+
+	if err := uc.tokenRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("API token revoked", "token_id", id.Hex())
+	return nil
+}
+
+func (uc *TokenUseCase) ValidateAPIToken(ctx context.Context, secret string) (_ *models.User, _ *models.APIToken, err error) {
+	ctx, span := tracer.Start(ctx, "TokenUseCase.ValidateAPIToken")
+	defer func() { endSpan(span, err) }()
+
+	if !strings.HasPrefix(secret, models.APITokenPrefix) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+	// The hash is looked up by equality, so comparing it leaks nothing
+	// about the secret
+	token, err := uc.tokenRepo.FindByHash(ctx, hashAPIToken(secret))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	if token == nil || token.Expired(now) {
+		return nil, nil, ErrInvalidAPIToken
+	}
+
+	user, err := uc.userRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil || user.Deactivated() {
+		return nil, nil, ErrInvalidAPIToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedInterval {
+		// The request may go ahead even if the timestamp can't be saved
+		if err := uc.tokenRepo.SetLastUsed(ctx, token.ID, now); err != nil {
+			logging.FromContext(ctx).Warn("recording API token use failed", "token_id", token.ID.Hex(), "error", err)
+		} else {
+			token.LastUsedAt = &now
+		}
+	}
+	return user, token, nil
+}
+
+// newAPIToken returns a random token such as "bt_abcd..."
+func newAPIToken() (string, error) {
+	raw := make([]byte, apiTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return models.APITokenPrefix + recoveryEncoding.EncodeToString(raw), nil
+}
+
+// hashAPIToken hashes a token for storage. Tokens are random enough that a
+// fast hash is safe, and it has to be fast since every request checks one.
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// dedupe returns values without repeats, keeping their order
+func dedupe(values []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-tracker/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAPITokenRepository is a mock implementation of APITokenRepositoryInterface
+type MockAPITokenRepository struct {
+	tokens map[models.ID]*models.APIToken
+	// lastUsedWrites counts SetLastUsed calls
+	lastUsedWrites int
+}
+
+func NewMockAPITokenRepository() *MockAPITokenRepository {
+	return &MockAPITokenRepository{
+		tokens: make(map[models.ID]*models.APIToken),
+	}
+}
+
+func (m *MockAPITokenRepository) Create(ctx context.Context, token *models.APIToken) error {
+	token.ID = models.NewID()
+	token.CreatedAt = time.Now()
+	m.tokens[token.ID] = token
+	return nil
+}
+
+func (m *MockAPITokenRepository) FindByID(ctx context.Context, id models.ID) (*models.APIToken, error) {
+	return m.tokens[id], nil
+}
+
+func (m *MockAPITokenRepository) FindByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	for _, token := range m.tokens {
+		if token.Hash == hash {
+			return token, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockAPITokenRepository) FindByUser(ctx context.Context, userID models.ID) ([]*models.APIToken, error) {
+	var tokens []*models.APIToken
+	for _, token := range m.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (m *MockAPITokenRepository) Delete(ctx context.Context, id models.ID) error {
+	delete(m.tokens, id)
+	return nil
+}
+
+func (m *MockAPITokenRepository) SetLastUsed(ctx context.Context, id models.ID, at time.Time) error {
+	m.lastUsedWrites++
+	if token, ok := m.tokens[id]; ok {
+		token.LastUsedAt = &at
+		return nil
+	}
+	return errors.New("token not found")
+}
+
+func newTokenUseCase(t *testing.T) (*TokenUseCase, *MockAPITokenRepository, *models.User, *models.User) {
+	t.Helper()
+	users := NewMockUserRepository()
+	admin := &models.User{ID: models.NewID(), Email: "admin@example.com", Role: "admin"}
+	developer := &models.User{ID: models.NewID(), Email: "dev@example.com", Role: "developer"}
+	users.users[admin.Email] = admin
+	users.users[developer.Email] = developer
+
+	tokens := NewMockAPITokenRepository()
+	return NewTokenUseCase(tokens, users, nil), tokens, admin, developer
+}
+
+func TestCreateToken(t *testing.T) {
+	uc, tokens, admin, developer := newTokenUseCase(t)
+	ctx := context.Background()
+
+	created, err := uc.CreateToken(ctx, developer, models.CreateAPITokenRequest{
+		Name:   "CI",
+		Scopes: []string{models.ScopeBugsRead, models.ScopeBugsRead, models.ScopeBugsWrite},
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Token, models.APITokenPrefix))
+	assert.True(t, strings.HasPrefix(created.Token, created.Prefix))
+	assert.Equal(t, []string{models.ScopeBugsRead, models.ScopeBugsWrite}, created.Scopes)
+	assert.Equal(t, developer.ID, created.UserID)
+
+	// Only the hash is stored
+	stored := tokens.tokens[created.ID]
+	assert.NotEqual(t, created.Token, stored.Hash)
+	assert.NotContains(t, stored.Hash, created.Token)
+
+	_, err = uc.CreateToken(ctx, developer, models.CreateAPITokenRequest{Name: "Root", Scopes: []string{models.ScopeAdmin}})
+	assert.ErrorIs(t, err, ErrScopeNotPermitted)
+	_, err = uc.CreateToken(ctx, admin, models.CreateAPITokenRequest{Name: "Root", Scopes: []string{models.ScopeAdmin}})
+	assert.NoError(t, err)
+
+	past := time.Now().Add(-time.Minute)
+	_, err = uc.CreateToken(ctx, developer, models.CreateAPITokenRequest{Name: "Old", Scopes: []string{models.ScopeBugsRead}, ExpiresAt: &past})
+	assert.ErrorIs(t, err, ErrExpiryInPast)
+
+	list, err := uc.ListTokens(ctx, developer.ID)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestValidateAPIToken(t *testing.T) {
+	uc, tokens, _, developer := newTokenUseCase(t)
+	ctx := context.Background()
+
+	created, err := uc.CreateToken(ctx, developer, models.CreateAPITokenRequest{Name: "CI", Scopes: []string{models.ScopeBugsRead}})
+	require.NoError(t, err)
+
+	user, token, err := uc.ValidateAPIToken(ctx, created.Token)
+	require.NoError(t, err)
+	assert.Equal(t, developer.ID, user.ID)
+	assert.True(t, token.Allows(models.ScopeBugsRead))
+	assert.False(t, token.Allows(models.ScopeBugsWrite))
+	require.NotNil(t, token.LastUsedAt)
+
+	// Last use is written at most once a minute
+	_, _, err = uc.ValidateAPIToken(ctx, created.Token)
+	require.NoError(t, err)
+	assert.Equal(t, 1, tokens.lastUsedWrites)
+
+	for _, bad := range []string{"", "good", created.Token + "x", models.APITokenPrefix + "unknown"} {
+		_, _, err = uc.ValidateAPIToken(ctx, bad)
+		assert.ErrorIs(t, err, ErrInvalidAPIToken, bad)
+	}
+
+	expired := time.Now().Add(-time.Second)
+	tokens.tokens[created.ID].ExpiresAt = &expired
+	_, _, err = uc.ValidateAPIToken(ctx, created.Token)
+	assert.ErrorIs(t, err, ErrInvalidAPIToken)
+}
+
+func TestRevokeToken(t *testing.T) {
+	uc, _, admin, developer := newTokenUseCase(t)
+	ctx := context.Background()
+
+	created, err := uc.CreateToken(ctx, developer, models.CreateAPITokenRequest{Name: "CI", Scopes: []string{models.ScopeBugsRead}})
+	require.NoError(t, err)
+
+	// Not even admins revoke other users' tokens here
+	assert.ErrorIs(t, uc.RevokeToken(ctx, admin.ID, created.ID), ErrAPITokenNotFound)
+	assert.ErrorIs(t, uc.RevokeToken(ctx, developer.ID, models.NewID()), ErrAPITokenNotFound)
+
+	require.NoError(t, uc.RevokeToken(ctx, developer.ID, created.ID))
+	_, _, err = uc.ValidateAPIToken(ctx, created.Token)
+	assert.ErrorIs(t, err, ErrInvalidAPIToken)
+}
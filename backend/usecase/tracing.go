@@ -0,0 +1,20 @@
+package usecase
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts a span for each use case method, so traces show how much of
+// a request is spent here rather than in the HTTP layer or storage
+var tracer = otel.Tracer("bug-tracker/usecase")
+
+// endSpan marks span as failed when err is set and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
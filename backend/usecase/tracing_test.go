@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"bug-tracker/models"
+	"bug-tracker/repository/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestUseCaseSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	mockBugRepo := NewMockBugRepository()
+	mockUserRepo := NewMockUserRepository()
+	bugUseCase := NewBugUseCase(mockBugRepo, mockUserRepo, memory.NewCustomFieldRepository(), memory.NewBugTemplateRepository(), memory.NewMilestoneRepository(), nil)
+
+	reporter := &models.User{ID: models.NewID(), Name: "Reporter", Role: "developer"}
+	_ = mockUserRepo.Create(context.Background(), reporter)
+	_ = mockBugRepo.Create(context.Background(), &models.Bug{Title: "Bug", ReportedBy: reporter.ID})
+
+	_, err := bugUseCase.GetAllBugs(context.Background())
+	require.NoError(t, err)
+	_, err = bugUseCase.GetBugByID(context.Background(), models.NewID())
+	assert.ErrorIs(t, err, ErrBugNotFound)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	// Children end first
+	assert.Equal(t, "BugUseCase.getBugResponse", spans[0].Name())
+	assert.Equal(t, "BugUseCase.GetAllBugs", spans[1].Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+
+	assert.Equal(t, "BugUseCase.GetBugByID", spans[2].Name())
+	assert.Equal(t, codes.Error, spans[2].Status().Code)
+	assert.Equal(t, ErrBugNotFound.Error(), spans[2].Status().Description)
+}
@@ -0,0 +1,430 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"bug-tracker/logging"
+	"bug-tracker/models"
+	"bug-tracker/totp"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidCode         = errors.New("invalid two-factor code")
+	ErrInvalidChallenge    = errors.New("invalid or expired login challenge")
+	ErrTwoFactorEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotPending = errors.New("two-factor enrollment has not been started")
+	ErrTwoFactorRequired   = errors.New("two-factor authentication is required for this role")
+	// ErrTwoFactorChanged is returned when another request changed the
+	// settings first
+	ErrTwoFactorChanged = errors.New("two-factor settings changed; try again")
+)
+
+// Challenge token purposes. Session tokens have none, and ValidateToken
+// refuses tokens that do.
+const (
+	purposeLogin  = "2fa_login"
+	purposeEnroll = "2fa_enroll"
+)
+
+const (
+	// loginChallengeTTL is how long a login challenge can be answered
+	loginChallengeTTL = 5 * time.Minute
+	// enrollChallengeTTL leaves time to install an authenticator app
+	enrollChallengeTTL = 15 * time.Minute
+	// ChallengeTTL is the lifetime of the longest-lived login challenge
+	ChallengeTTL = enrollChallengeTTL
+	// recoveryCodeCount is how many recovery codes enrollment hands out
+	recoveryCodeCount = 10
+)
+
+// TwoFactorPolicy configures TOTP two-factor authentication
+type TwoFactorPolicy struct {
+	// Issuer labels accounts in authenticator apps
+	Issuer string
+	// RequiredRoles must set up two-factor authentication before they
+	// can log in, and can't turn it off
+	RequiredRoles []string
+}
+
+func (p TwoFactorPolicy) required(role string) bool {
+	return slices.Contains(p.RequiredRoles, role)
+}
+
+// VerifyTwoFactor completes a login that returned a challenge, given a
+// TOTP code or an unused recovery code. Wrong codes count towards the
+// account lockout like wrong passwords.
+func (uc *AuthUseCase) VerifyTwoFactor(ctx context.Context, challenge, code string) (_ *models.LoginResult, err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.VerifyTwoFactor")
+	defer func() { endSpan(span, err) }()
+
+	id, err := uc.parseChallenge(challenge, purposeLogin)
+	if err != nil {
+		return nil, err
+	}
+	user, err := uc.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Two-factor authentication may have been reset since the password
+	if user == nil || !user.TwoFactorEnabled() {
+		return nil, ErrInvalidChallenge
+	}
+	if user.Locked(time.Now()) {
+		return nil, &AccountLockedError{Until: *user.LockedUntil}
+	}
+
+	if err := uc.verifyCode(ctx, user, code, nil); err != nil {
+		return nil, err
+	}
+	return uc.completeLogin(ctx, user)
+}
+
+// ValidateEnrollmentToken returns the user a login challenge asked to set
+// up two-factor authentication. The token is good for nothing else.
+func (uc *AuthUseCase) ValidateEnrollmentToken(ctx context.Context, tokenString string) (*models.User, error) {
+	id, err := uc.parseChallenge(tokenString, purposeEnroll)
+	if err != nil {
+		return nil, err
+	}
+	user, err := uc.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.Deactivated() {
+		return nil, ErrInvalidChallenge
+	}
+	return user, nil
+}
+
+// TwoFactorStatus reports the user's two-factor settings
+func (uc *AuthUseCase) TwoFactorStatus(ctx context.Context, id models.ID) (_ *models.TwoFactorStatus, err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.TwoFactorStatus")
+	defer func() { endSpan(span, err) }()
+
+	user, err := uc.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.TwoFactorStatus{Required: uc.twoFactor.required(user.Role)}
+	if tf := user.TwoFactor; tf != nil {
+		status.Enabled = tf.Enabled
+		status.Pending = !tf.Enabled
+		status.RecoveryCodesLeft = len(tf.RecoveryCodes)
+	}
+	return status, nil
+}
+
+// BeginTwoFactor generates a new secret for the user to add to an
+// authenticator app. It takes effect once ConfirmTwoFactor checks a code
+// from the app; beginning again replaces a pending secret.
+func (uc *AuthUseCase) BeginTwoFactor(ctx context.Context, id models.ID) (_ *models.TwoFactorEnrollment, err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.BeginTwoFactor")
+	defer func() { endSpan(span, err) }()
+
+	user, err := uc.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	ok, err := uc.userRepo.SetTwoFactor(ctx, id, user.TwoFactor, &models.TwoFactor{Secret: secret})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTwoFactorChanged
+	}
+
+	return &models.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.URI(uc.twoFactor.Issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication once code shows the
+// app has the pending secret. It returns the recovery codes, which are
+// not stored, and a session token.
+func (uc *AuthUseCase) ConfirmTwoFactor(ctx context.Context, id models.ID, code string) (_ *models.TwoFactorConfirmation, err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.ConfirmTwoFactor")
+	defer func() { endSpan(span, err) }()
+
+	user, err := uc.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case user.TwoFactorEnabled():
+		return nil, ErrTwoFactorEnabled
+	case user.TwoFactor == nil:
+		return nil, ErrTwoFactorNotPending
+	case user.Locked(time.Now()):
+		return nil, &AccountLockedError{Until: *user.LockedUntil}
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = uc.verifyCode(ctx, user, code, func(tf *models.TwoFactor) *models.TwoFactor {
+		tf.Enabled = true
+		tf.RecoveryCodes = hashes
+		return tf
+	})
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("two-factor authentication enabled", "user_id", id.Hex())
+
+	result, err := uc.completeLogin(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TwoFactorConfirmation{RecoveryCodes: codes, Token: result.Token, User: *result.User}, nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, given a
+// current code
+func (uc *AuthUseCase) RegenerateRecoveryCodes(ctx context.Context, id models.ID, code string) (_ []string, err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.RegenerateRecoveryCodes")
+	defer func() { endSpan(span, err) }()
+
+	user, err := uc.findEnabledUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = uc.verifyCode(ctx, user, code, func(tf *models.TwoFactor) *models.TwoFactor {
+		tf.RecoveryCodes = hashes
+		return tf
+	})
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("recovery codes regenerated", "user_id", id.Hex())
+	return codes, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off, given a current
+// code. Roles that require it can't.
+func (uc *AuthUseCase) DisableTwoFactor(ctx context.Context, id models.ID, code string) (err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.DisableTwoFactor")
+	defer func() { endSpan(span, err) }()
+
+	user, err := uc.findEnabledUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if uc.twoFactor.required(user.Role) {
+		return ErrTwoFactorRequired
+	}
+
+	err = uc.verifyCode(ctx, user, code, func(*models.TwoFactor) *models.TwoFactor { return nil })
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Warn("two-factor authentication disabled", "user_id", id.Hex())
+	return nil
+}
+
+// ResetTwoFactor removes a user's two-factor settings, for users who lost
+// their authenticator and recovery codes. Users whose role requires two
+// factors must enroll again at their next login.
+func (uc *AuthUseCase) ResetTwoFactor(ctx context.Context, id models.ID) (err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.ResetTwoFactor")
+	defer func() { endSpan(span, err) }()
+
+	user, err := uc.findUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.TwoFactor == nil {
+		return nil
+	}
+
+	ok, err := uc.userRepo.SetTwoFactor(ctx, id, user.TwoFactor, nil)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTwoFactorChanged
+	}
+	logging.FromContext(ctx).Warn("two-factor authentication reset", "user_id", id.Hex())
+	return nil
+}
+
+func (uc *AuthUseCase) findUser(ctx context.Context, id models.ID) (*models.User, error) {
+	user, err := uc.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// findEnabledUser finds a user with two-factor authentication enabled and
+// an unlocked account, ready for verifyCode
+func (uc *AuthUseCase) findEnabledUser(ctx context.Context, id models.ID) (*models.User, error) {
+	user, err := uc.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if user.Locked(time.Now()) {
+		return nil, &AccountLockedError{Until: *user.LockedUntil}
+	}
+	return user, nil
+}
+
+// verifyCode checks code against the user's settings and spends it, so it
+// can't be used again, storing update's changes with it when update isn't
+// nil. Wrong codes count as failed logins.
+func (uc *AuthUseCase) verifyCode(ctx context.Context, user *models.User, code string, update func(*models.TwoFactor) *models.TwoFactor) error {
+	prev := user.TwoFactor
+	next, ok, err := spendCode(prev, code, time.Now())
+	if err != nil {
+		return err
+	}
+	if ok {
+		if update != nil {
+			next = update(next)
+		}
+		// Losing the race means another request spent the code first
+		ok, err = uc.userRepo.SetTwoFactor(ctx, user.ID, prev, next)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !ok {
+		logging.FromContext(ctx).Warn("two-factor code rejected", "user_id", user.ID.Hex())
+		if err := uc.recordLoginFailure(ctx, user); err != nil {
+			return err
+		}
+		return ErrInvalidCode
+	}
+	user.TwoFactor = next
+	return nil
+}
+
+// spendCode returns tf updated to refuse code from now on, and whether
+// code was a valid TOTP code or unused recovery code at all
+func spendCode(tf *models.TwoFactor, code string, now time.Time) (*models.TwoFactor, bool, error) {
+	code = normalizeCode(code)
+	next := *tf
+	next.RecoveryCodes = slices.Clone(tf.RecoveryCodes)
+
+	if isDigits(code) {
+		step, ok, err := totp.Validate(tf.Secret, code, now)
+		// A step at or before the last one is a replayed code
+		if err != nil || !ok || step <= tf.LastStep {
+			return nil, false, err
+		}
+		next.LastStep = step
+		return &next, true, nil
+	}
+
+	hash := hashRecoveryCode(code)
+	for i, stored := range tf.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) == 1 {
+			next.RecoveryCodes = slices.Delete(next.RecoveryCodes, i, i+1)
+			return &next, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// recoveryEncoding spells recovery codes in lower-case base32, which
+// avoids easily confused characters such as 0 and O
+var recoveryEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// newRecoveryCodes returns recoveryCodeCount codes such as "abcde-fghij"
+// and the hashes to store
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		// 50 random bits
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := recoveryEncoding.EncodeToString(raw)[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes a normalized recovery code. Codes are random
+// enough that a fast hash is safe.
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeCode drops the spaces and dashes people type into codes
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// generateChallenge signs a short-lived token that only proves the user
+// passed the password step
+func (uc *AuthUseCase) generateChallenge(user *models.User, purpose string, ttl time.Duration) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": user.ID.Hex(),
+		"purpose": purpose,
+		"exp":     time.Now().Add(ttl).Unix(),
+	}
+	return uc.signToken(claims)
+}
+
+// parseChallenge returns the user ID of a valid challenge for purpose
+func (uc *AuthUseCase) parseChallenge(tokenString, purpose string) (models.ID, error) {
+	claims, err := uc.parseToken(tokenString)
+	if err != nil || claims["purpose"] != purpose {
+		return models.NilID, ErrInvalidChallenge
+	}
+	userID, _ := claims["user_id"].(string)
+	id, err := models.ParseID(userID)
+	if err != nil {
+		return models.NilID, ErrInvalidChallenge
+	}
+	return id, nil
+}
@@ -0,0 +1,248 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bug-tracker/models"
+	"bug-tracker/totp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registerForTwoFactor registers a user with password "password123"
+func registerForTwoFactor(t *testing.T, uc *AuthUseCase, mockRepo *MockUserRepository, email, role string) *models.User {
+	t.Helper()
+	_, err := uc.Register(context.Background(), models.RegisterRequest{
+		Email:    email,
+		Password: "password123",
+		Name:     "Test User",
+		Role:     role,
+	})
+	require.NoError(t, err)
+	// The mock doesn't assign IDs
+	user := mockRepo.users[email]
+	user.ID = models.NewID()
+	return user
+}
+
+// enroll sets up two-factor authentication for user and returns the
+// secret, the recovery codes and the time of the code it confirmed with
+func enroll(t *testing.T, uc *AuthUseCase, user *models.User) (string, []string, time.Time) {
+	t.Helper()
+	ctx := context.Background()
+
+	enrollment, err := uc.BeginTwoFactor(ctx, user.ID)
+	require.NoError(t, err)
+
+	now := time.Now()
+	code, err := totp.Code(enrollment.Secret, now)
+	require.NoError(t, err)
+	confirmation, err := uc.ConfirmTwoFactor(ctx, user.ID, code)
+	require.NoError(t, err)
+	return enrollment.Secret, confirmation.RecoveryCodes, now
+}
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	authUseCase := NewAuthUseCase(mockRepo, nil, "test-secret", nil, time.Hour, 0, LockoutPolicy{}, TwoFactorPolicy{Issuer: "Bug Tracker"}, SSOPolicy{})
+	ctx := context.Background()
+	user := registerForTwoFactor(t, authUseCase, mockRepo, "admin@example.com", "admin")
+
+	_, err := authUseCase.ConfirmTwoFactor(ctx, user.ID, "123456")
+	assert.ErrorIs(t, err, ErrTwoFactorNotPending)
+
+	enrollment, err := authUseCase.BeginTwoFactor(ctx, user.ID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, enrollment.Secret)
+	assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/Bug%20Tracker:admin@example.com?"))
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+	status, err := authUseCase.TwoFactorStatus(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TwoFactorStatus{Pending: true}, *status)
+
+	// Logins don't ask for a code until enrollment is confirmed
+	result, err := authUseCase.Login(ctx, models.LoginRequest{Email: "admin@example.com", Password: "password123"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Token)
+
+	_, err = authUseCase.ConfirmTwoFactor(ctx, user.ID, "not-a-code")
+	assert.ErrorIs(t, err, ErrInvalidCode)
+	assert.Equal(t, 1, user.FailedLogins)
+
+	now := time.Now()
+	code, err := totp.Code(enrollment.Secret, now)
+	require.NoError(t, err)
+	confirmation, err := authUseCase.ConfirmTwoFactor(ctx, user.ID, code)
+	require.NoError(t, err)
+	assert.Len(t, confirmation.RecoveryCodes, recoveryCodeCount)
+	assert.NotEmpty(t, confirmation.Token)
+	assert.Equal(t, "admin@example.com", confirmation.User.Email)
+	// Only hashes are stored
+	assert.NotContains(t, user.TwoFactor.RecoveryCodes, normalizeCode(confirmation.RecoveryCodes[0]))
+
+	_, err = authUseCase.BeginTwoFactor(ctx, user.ID)
+	assert.ErrorIs(t, err, ErrTwoFactorEnabled)
+	_, err = authUseCase.ConfirmTwoFactor(ctx, user.ID, code)
+	assert.ErrorIs(t, err, ErrTwoFactorEnabled)
+
+	status, err = authUseCase.TwoFactorStatus(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TwoFactorStatus{Enabled: true, RecoveryCodesLeft: recoveryCodeCount}, *status)
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	authUseCase := NewAuthUseCase(mockRepo, nil, "test-secret", nil, time.Hour, 0, LockoutPolicy{}, TwoFactorPolicy{Issuer: "Bug Tracker"}, SSOPolicy{})
+	ctx := context.Background()
+	user := registerForTwoFactor(t, authUseCase, mockRepo, "admin@example.com", "admin")
+	secret, recoveryCodes, enrolledAt := enroll(t, authUseCase, user)
+
+	login := func() string {
+		t.Helper()
+		result, err := authUseCase.Login(ctx, models.LoginRequest{Email: "admin@example.com", Password: "password123"})
+		require.NoError(t, err)
+		assert.True(t, result.TwoFactorRequired)
+		assert.Empty(t, result.Token)
+		assert.Nil(t, result.User)
+		require.NotEmpty(t, result.ChallengeToken)
+		return result.ChallengeToken
+	}
+
+	challenge := login()
+
+	// A challenge is not a session, nor an enrollment token
+	_, err := authUseCase.ValidateToken(context.Background(), challenge)
+	assert.Error(t, err)
+	_, err = authUseCase.ValidateEnrollmentToken(context.Background(), challenge)
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+
+	// The code used to confirm enrollment can't be replayed
+	used, err := totp.Code(secret, enrolledAt)
+	require.NoError(t, err)
+	_, err = authUseCase.VerifyTwoFactor(ctx, challenge, used)
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	_, err = authUseCase.VerifyTwoFactor(ctx, "not.a.challenge", "123456")
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+
+	next, err := totp.Code(secret, enrolledAt.Add(totp.Period))
+	require.NoError(t, err)
+	result, err := authUseCase.VerifyTwoFactor(ctx, challenge, next)
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Token)
+	assert.Equal(t, "admin@example.com", result.User.Email)
+	// The session forgives the wrong code
+	assert.Zero(t, user.FailedLogins)
+
+	validated, err := authUseCase.ValidateToken(context.Background(), result.Token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, validated.ID)
+
+	// Recovery codes work once each, however they are typed
+	recovery := strings.ToUpper(recoveryCodes[0])
+	_, err = authUseCase.VerifyTwoFactor(ctx, login(), recovery)
+	require.NoError(t, err)
+	_, err = authUseCase.VerifyTwoFactor(ctx, login(), recoveryCodes[0])
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	status, err := authUseCase.TwoFactorStatus(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, recoveryCodeCount-1, status.RecoveryCodesLeft)
+
+	// Regenerating replaces every old code
+	fresh, err := authUseCase.RegenerateRecoveryCodes(ctx, user.ID, recoveryCodes[1])
+	require.NoError(t, err)
+	assert.Len(t, fresh, recoveryCodeCount)
+	_, err = authUseCase.VerifyTwoFactor(ctx, login(), recoveryCodes[2])
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	assert.ErrorIs(t, authUseCase.DisableTwoFactor(ctx, user.ID, "wrong-code"), ErrInvalidCode)
+	require.NoError(t, authUseCase.DisableTwoFactor(ctx, user.ID, fresh[0]))
+	assert.Nil(t, user.TwoFactor)
+
+	result, err = authUseCase.Login(ctx, models.LoginRequest{Email: "admin@example.com", Password: "password123"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Token)
+
+	_, err = authUseCase.RegenerateRecoveryCodes(ctx, user.ID, fresh[1])
+	assert.ErrorIs(t, err, ErrTwoFactorNotEnabled)
+}
+
+func TestTwoFactorRequiredRoles(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	policy := TwoFactorPolicy{Issuer: "Bug Tracker", RequiredRoles: []string{"admin", "manager"}}
+	authUseCase := NewAuthUseCase(mockRepo, nil, "test-secret", nil, time.Hour, 0, LockoutPolicy{}, policy, SSOPolicy{})
+	ctx := context.Background()
+	admin := registerForTwoFactor(t, authUseCase, mockRepo, "admin@example.com", "admin")
+	registerForTwoFactor(t, authUseCase, mockRepo, "dev@example.com", "developer")
+
+	result, err := authUseCase.Login(ctx, models.LoginRequest{Email: "dev@example.com", Password: "password123"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.Token)
+	_, err = authUseCase.ValidateEnrollmentToken(context.Background(), result.Token)
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+
+	// Admins must enroll before they get a session
+	result, err = authUseCase.Login(ctx, models.LoginRequest{Email: "admin@example.com", Password: "password123"})
+	require.NoError(t, err)
+	assert.True(t, result.EnrollmentRequired)
+	assert.Empty(t, result.Token)
+
+	_, err = authUseCase.ValidateToken(context.Background(), result.ChallengeToken)
+	assert.Error(t, err)
+	enrolling, err := authUseCase.ValidateEnrollmentToken(context.Background(), result.ChallengeToken)
+	require.NoError(t, err)
+	assert.Equal(t, admin.ID, enrolling.ID)
+
+	status, err := authUseCase.TwoFactorStatus(ctx, admin.ID)
+	require.NoError(t, err)
+	assert.True(t, status.Required)
+
+	_, recoveryCodes, _ := enroll(t, authUseCase, admin)
+
+	result, err = authUseCase.Login(ctx, models.LoginRequest{Email: "admin@example.com", Password: "password123"})
+	require.NoError(t, err)
+	assert.True(t, result.TwoFactorRequired)
+
+	assert.ErrorIs(t, authUseCase.DisableTwoFactor(ctx, admin.ID, recoveryCodes[0]), ErrTwoFactorRequired)
+
+	// After an admin reset the user has to enroll again
+	require.NoError(t, authUseCase.ResetTwoFactor(ctx, admin.ID))
+	result, err = authUseCase.Login(ctx, models.LoginRequest{Email: "admin@example.com", Password: "password123"})
+	require.NoError(t, err)
+	assert.True(t, result.EnrollmentRequired)
+}
+
+func TestTwoFactorCodeGuessingLocksAccount(t *testing.T) {
+	mockRepo := NewMockUserRepository()
+	lockout := LockoutPolicy{Threshold: 2, Duration: time.Minute}
+	authUseCase := NewAuthUseCase(mockRepo, nil, "test-secret", nil, time.Hour, 0, lockout, TwoFactorPolicy{Issuer: "Bug Tracker"}, SSOPolicy{})
+	ctx := context.Background()
+	user := registerForTwoFactor(t, authUseCase, mockRepo, "admin@example.com", "admin")
+	enroll(t, authUseCase, user)
+
+	login := func() string {
+		t.Helper()
+		result, err := authUseCase.Login(ctx, models.LoginRequest{Email: "admin@example.com", Password: "password123"})
+		require.NoError(t, err)
+		return result.ChallengeToken
+	}
+
+	_, err := authUseCase.VerifyTwoFactor(ctx, login(), "000000")
+	assert.ErrorIs(t, err, ErrInvalidCode)
+	// The right password alone doesn't forgive the wrong code
+	_, err = authUseCase.VerifyTwoFactor(ctx, login(), "000001")
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	challenge, err := authUseCase.generateChallenge(user, purposeLogin, time.Minute)
+	require.NoError(t, err)
+	_, err = authUseCase.VerifyTwoFactor(ctx, challenge, "000002")
+	assert.ErrorIs(t, err, ErrAccountLocked)
+	_, err = authUseCase.Login(ctx, models.LoginRequest{Email: "admin@example.com", Password: "password123"})
+	assert.ErrorIs(t, err, ErrAccountLocked)
+}
@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"bug-tracker/logging"
+	"bug-tracker/models"
+)
+
+// ChangeRole gives the user another role. Their sessions pick it up at
+// once on this server.
+func (uc *AuthUseCase) ChangeRole(ctx context.Context, id models.ID, role string) (_ *models.UserResponse, err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.ChangeRole")
+	defer func() { endSpan(span, err) }()
+
+	if !uc.roles.Exists(role) {
+		return nil, ErrRoleNotFound
+	}
+	user, err := uc.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Role != role {
+		from := user.Role
+		user.Role = role
+		if err := uc.updateUser(ctx, user); err != nil {
+			return nil, err
+		}
+		logging.FromContext(ctx).Info("role changed", "user_id", id.Hex(), "from", from, "to", role)
+	}
+
+	response := user.ToResponse()
+	return &response, nil
+}
+
+// Deactivate stops the user from logging in and ends their sessions and
+// API tokens. The account and its bugs are kept.
+func (uc *AuthUseCase) Deactivate(ctx context.Context, id models.ID) (err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.Deactivate")
+	defer func() { endSpan(span, err) }()
+
+	user, err := uc.findUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.Deactivated() {
+		return nil
+	}
+
+	now := time.Now()
+	user.DeactivatedAt = &now
+	if err := uc.updateUser(ctx, user); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("account deactivated", "user_id", id.Hex())
+	return nil
+}
+
+// Reactivate lets a deactivated user log in again
+func (uc *AuthUseCase) Reactivate(ctx context.Context, id models.ID) (err error) {
+	ctx, span := tracer.Start(ctx, "AuthUseCase.Reactivate")
+	defer func() { endSpan(span, err) }()
+
+	user, err := uc.findUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !user.Deactivated() {
+		return nil
+	}
+
+	user.DeactivatedAt = nil
+	if err := uc.updateUser(ctx, user); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("account reactivated", "user_id", id.Hex())
+	return nil
+}
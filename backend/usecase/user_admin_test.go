@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bug-tracker/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingUserRepository counts user lookups and honours cancellation
+type countingUserRepository struct {
+	*MockUserRepository
+	lookups int
+}
+
+func (r *countingUserRepository) FindByID(ctx context.Context, id models.ID) (*models.User, error) {
+	r.lookups++
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.MockUserRepository.FindByID(ctx, id)
+}
+
+func loginToken(t *testing.T, uc *AuthUseCase, email string) string {
+	t.Helper()
+	result, err := uc.Login(context.Background(), models.LoginRequest{Email: email, Password: "password123"})
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Token)
+	return result.Token
+}
+
+func TestValidateTokenCache(t *testing.T) {
+	ctx := context.Background()
+	repo := &countingUserRepository{MockUserRepository: NewMockUserRepository()}
+	uc := NewAuthUseCase(repo, nil, "test-secret", nil, time.Hour, time.Minute, LockoutPolicy{}, TwoFactorPolicy{}, SSOPolicy{})
+	user := registerForTwoFactor(t, uc, repo.MockUserRepository, "dev@example.com", "developer")
+	token := loginToken(t, uc, "dev@example.com")
+
+	for i := 0; i < 3; i++ {
+		validated, err := uc.ValidateToken(ctx, token)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, validated.ID)
+	}
+	assert.Equal(t, 1, repo.lookups)
+
+	// Each login is cached on its own
+	_, err := uc.ValidateToken(ctx, loginToken(t, uc, "dev@example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.lookups)
+
+	// Callers get copies
+	validated, err := uc.ValidateToken(ctx, token)
+	require.NoError(t, err)
+	validated.Role = "admin"
+	validated, err = uc.ValidateToken(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, "developer", validated.Role)
+
+	// Entries expire
+	uc.users.now = func() time.Time { return time.Now().Add(time.Minute) }
+	_, err = uc.ValidateToken(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, 3, repo.lookups)
+}
+
+func TestValidateTokenContext(t *testing.T) {
+	repo := &countingUserRepository{MockUserRepository: NewMockUserRepository()}
+	uc := NewAuthUseCase(repo, nil, "test-secret", nil, time.Hour, time.Minute, LockoutPolicy{}, TwoFactorPolicy{}, SSOPolicy{})
+	registerForTwoFactor(t, uc, repo.MockUserRepository, "dev@example.com", "developer")
+	token := loginToken(t, uc, "dev@example.com")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := uc.ValidateToken(ctx, token)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// Failures aren't cached
+	_, err = uc.ValidateToken(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, repo.lookups)
+}
+
+func TestChangeRole(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := NewMockUserRepository()
+	uc := NewAuthUseCase(mockRepo, nil, "test-secret", nil, time.Hour, time.Minute, LockoutPolicy{}, TwoFactorPolicy{}, SSOPolicy{})
+	user := registerForTwoFactor(t, uc, mockRepo, "dev@example.com", "developer")
+	token := loginToken(t, uc, "dev@example.com")
+	_, err := uc.ValidateToken(ctx, token)
+	require.NoError(t, err)
+
+	response, err := uc.ChangeRole(ctx, user.ID, "manager")
+	require.NoError(t, err)
+	assert.Equal(t, "manager", response.Role)
+
+	// The cached user is dropped, so the session has the new role at once
+	validated, err := uc.ValidateToken(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, "manager", validated.Role)
+
+	_, err = uc.ChangeRole(ctx, models.NewID(), "admin")
+	assert.Error(t, err)
+}
+
+func TestDeactivate(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := NewMockUserRepository()
+	uc := NewAuthUseCase(mockRepo, nil, "test-secret", nil, time.Hour, time.Minute, LockoutPolicy{}, TwoFactorPolicy{}, SSOPolicy{})
+	user := registerForTwoFactor(t, uc, mockRepo, "dev@example.com", "developer")
+	token := loginToken(t, uc, "dev@example.com")
+	_, err := uc.ValidateToken(ctx, token)
+	require.NoError(t, err)
+
+	require.NoError(t, uc.Deactivate(ctx, user.ID))
+	assert.True(t, user.Deactivated())
+
+	// Sessions end at once and no new ones start
+	_, err = uc.ValidateToken(ctx, token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = uc.Login(ctx, models.LoginRequest{Email: "dev@example.com", Password: "password123"})
+	assert.ErrorIs(t, err, ErrUserDeactivated)
+	// A wrong password still says nothing about the account
+	_, err = uc.Login(ctx, models.LoginRequest{Email: "dev@example.com", Password: "wrong"})
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+
+	developers, err := uc.GetDevelopers(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, developers)
+
+	require.NoError(t, uc.Reactivate(ctx, user.ID))
+	assert.False(t, user.Deactivated())
+	_, err = uc.ValidateToken(ctx, token)
+	assert.NoError(t, err)
+}
+
+func TestUserCacheLimit(t *testing.T) {
+	cache := newUserCache(time.Minute)
+	expires := time.Now().Add(time.Hour)
+	user := &models.User{ID: models.NewID()}
+	for i := 0; i < userCacheSize+1; i++ {
+		cache.put(models.NewID().Hex(), user, expires)
+	}
+	assert.LessOrEqual(t, len(cache.entries), userCacheSize)
+
+	assert.Nil(t, newUserCache(0))
+	var disabled *userCache
+	disabled.put("token", user, expires)
+	assert.Nil(t, disabled.get("token"))
+}
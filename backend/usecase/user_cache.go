@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+
+	"bug-tracker/models"
+)
+
+const (
+	// tokenIDBytes is the size of the random ID in each session token
+	tokenIDBytes = 16
+	// userCacheSize caps the validated tokens remembered at once
+	userCacheSize = 10000
+)
+
+// newTokenID returns a random ID for the jti claim of a session token
+func newTokenID() (string, error) {
+	raw := make([]byte, tokenIDBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// userCache remembers the user each validated session token belongs to,
+// so requests don't look the user up every time. Entries last ttl at
+// most; changes to a user drop theirs at once on this server, while other
+// servers see them when their entries expire.
+type userCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedUser
+}
+
+type cachedUser struct {
+	user      models.User
+	expiresAt time.Time
+}
+
+// newUserCache returns a cache keeping users for ttl, or nil when ttl is
+// zero. A nil cache remembers nothing.
+func newUserCache(ttl time.Duration) *userCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &userCache{ttl: ttl, now: time.Now, entries: map[string]cachedUser{}}
+}
+
+// get returns a copy of the user cached for tokenID, or nil
+func (c *userCache) get(tokenID string) *models.User {
+	if c == nil || tokenID == "" {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[tokenID]
+	if !ok {
+		return nil
+	}
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.entries, tokenID)
+		return nil
+	}
+	user := entry.user
+	return &user
+}
+
+// put caches user for tokenID until the token expires or ttl passes
+func (c *userCache) put(tokenID string, user *models.User, tokenExpiry time.Time) {
+	if c == nil || tokenID == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	expiresAt := now.Add(c.ttl)
+	if tokenExpiry.Before(expiresAt) {
+		expiresAt = tokenExpiry
+	}
+	if len(c.entries) >= userCacheSize {
+		c.purge(now)
+	}
+	c.entries[tokenID] = cachedUser{user: *user, expiresAt: expiresAt}
+}
+
+// purge drops expired entries, or every entry if none has expired
+func (c *userCache) purge(now time.Time) {
+	for id, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, id)
+		}
+	}
+	if len(c.entries) >= userCacheSize {
+		c.entries = map[string]cachedUser{}
+	}
+}
+
+// invalidate drops every entry of the user, after their account changed
+func (c *userCache) invalidate(userID models.ID) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, entry := range c.entries {
+		if entry.user.ID == userID {
+			delete(c.entries, id)
+		}
+	}
+}